@@ -159,6 +159,34 @@ func MigrateDB() error {
 		&models.EmployeeWorkDay{},
 		&models.AttendanceLog{},
 		&models.Device{},
+		&models.PayrollPeriod{},
+		&models.PayrollPeriodEvent{},
+		&models.LeaveType{},
+		&models.LeaveRequest{},
+		&models.LeaveAccrualPolicy{},
+		&models.LeaveSeniorityBonus{},
+		&models.LeaveBalanceEntry{},
+		&models.Document{},
+		&models.DeviceIdentity{},
+		&models.EmploymentAssignment{},
+		&models.Department{},
+		&models.Team{},
+		&models.OrgAssignment{},
+		&models.UserCompany{},
+		&models.RefreshToken{},
+		&models.PasswordHistory{},
+		&models.PasswordResetToken{},
+		&models.LoginAttempt{},
+		&models.LoginThrottle{},
+		&models.TwoFactor{},
+		&models.RecoveryCode{},
+		&models.ServiceAccount{},
+		&models.APIKey{},
+		&models.OIDCLoginState{},
+		&models.ExternalIdentity{},
+		&models.PunchCorrectionRequest{},
+		&models.AuditLog{},
+		&models.RawAttendanceVersion{},
 	)
 	if err != nil {
 		log.Printf("Database migration failed: %v", err)
@@ -173,32 +201,35 @@ func InitializeViewDB() error {
 	user_daily_checkin_checkout := `CREATE OR REPLACE VIEW user_daily_checkin_checkout AS 
 	WITH DailyPunchData AS (
             SELECT 
-                attendance_logs.user_id AS user_id,
+                attendance_logs.employee_id AS employee_id,
                 CAST(attendance_logs.timestamp AS DATE) AS date,
                 MIN(CASE WHEN (attendance_logs.system_punch = 'IN') THEN attendance_logs.timestamp END) AS checkin,
                 MAX(CASE WHEN (attendance_logs.system_punch = 'OUT') THEN attendance_logs.timestamp END) AS last_out_punch,
                 MAX(attendance_logs.timestamp) AS last_punch_of_day,
                 MAX(CASE WHEN (attendance_logs.system_punch = 'IN') THEN attendance_logs.timestamp END) AS last_in_punch
             FROM attendance_logs 
-            GROUP BY attendance_logs.user_id, CAST(attendance_logs.timestamp AS DATE)
+            WHERE attendance_logs.employee_id IS NOT NULL
+                AND attendance_logs.voided_at IS NULL AND attendance_logs.deleted_at IS NULL
+            GROUP BY attendance_logs.employee_id, CAST(attendance_logs.timestamp AS DATE)
         ), 
         NextDayPunch AS (
             SELECT 
-                c.user_id AS user_id,
+                c.employee_id AS employee_id,
                 c.date AS date,
                 MIN(n.timestamp) AS next_out_punch
             FROM DailyPunchData c 
             LEFT JOIN attendance_logs n 
-                ON ((c.user_id = n.user_id) 
+                ON ((c.employee_id = n.employee_id) 
                 AND (CAST(n.timestamp AS DATE) = (c.date + INTERVAL 1 DAY)) 
-                AND (n.system_punch = 'OUT'))
-            GROUP BY c.user_id, c.date
+                AND (n.system_punch = 'OUT')
+                AND (n.voided_at IS NULL) AND (n.deleted_at IS NULL))
+            GROUP BY c.employee_id, c.date
        ) 
 
 
 
         SELECT 
-            d.user_id AS user_id,
+            d.employee_id AS employee_id,
             d.date AS date,
             d.checkin AS checkin,
             (CASE 
@@ -210,8 +241,8 @@ func InitializeViewDB() error {
             END) AS checkout
         FROM DailyPunchData d 
         LEFT JOIN NextDayPunch nd 
-            ON ((d.user_id = nd.user_id) AND (d.date = nd.date))
-        ORDER BY d.user_id, d.date`
+            ON ((d.employee_id = nd.employee_id) AND (d.date = nd.date))
+        ORDER BY d.employee_id, d.date`
 
 	if err := dbInstance.db.Exec(user_daily_checkin_checkout).Error; err != nil {
 		return fmt.Errorf("failed to create user_daily_checkin_checkout view: %w", err)
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/services"
+)
+
+// APIKeyHandler handles HTTP requests for service accounts and their API keys.
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new instance of APIKeyHandler.
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateServiceAccount creates a service account with its permissions, optional company and
+// optional IP allow-list.
+func (h *APIKeyHandler) CreateServiceAccount(c *gin.Context) {
+	var request struct {
+		Name        string   `json:"name" binding:"required"`
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions" binding:"required"`
+		CompanyID   *uint    `json:"company_id"`
+		AllowedIPs  []string `json:"allowed_ips"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	account := models.ServiceAccount{
+		Name:        request.Name,
+		Description: request.Description,
+		Permissions: strings.Join(request.Permissions, ","),
+		CompanyID:   request.CompanyID,
+		AllowedIPs:  strings.Join(request.AllowedIPs, ","),
+		CreatedBy:   currentUserID(c),
+	}
+	if err := h.apiKeyService.CreateServiceAccount(c.Request.Context(), &account); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": account.ID, "data": account, "message": "Service account created successfully"})
+}
+
+// GetServiceAccount retrieves a service account by its ID.
+func (h *APIKeyHandler) GetServiceAccount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service account ID"})
+		return
+	}
+
+	account, err := h.apiKeyService.GetServiceAccountByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if account == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service account not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": account})
+}
+
+// ListServiceAccounts retrieves every service account.
+func (h *APIKeyHandler) ListServiceAccounts(c *gin.Context) {
+	accounts, err := h.apiKeyService.ListServiceAccounts(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": accounts})
+}
+
+// DeleteServiceAccount deletes a service account and revokes its keys.
+func (h *APIKeyHandler) DeleteServiceAccount(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service account ID"})
+		return
+	}
+
+	found, err := h.apiKeyService.DeleteServiceAccount(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service account not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service account deleted successfully", "id": id})
+}
+
+// CreateAPIKey issues an API key for a service account. The key is in the response only: it
+// cannot be retrieved afterwards.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service account ID"})
+		return
+	}
+	var request struct {
+		Name      string     `json:"name"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	key, apiKey, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), uint(id), request.Name, request.ExpiresAt, currentUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if apiKey == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service account not found"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"id":      apiKey.ID,
+		"key":     key,
+		"data":    apiKey,
+		"message": "API key created, store it now: it will not be shown again",
+	})
+}
+
+// ListAPIKeys retrieves the keys of a service account, with their prefix and last use.
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service account ID"})
+		return
+	}
+
+	keys, err := h.apiKeyService.ListAPIKeys(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// RevokeAPIKey revokes a key of a service account.
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service account ID"})
+		return
+	}
+	keyID, err := strconv.Atoi(c.Param("keyId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	revoked, err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), uint(id), uint(keyID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !revoked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Active API key not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully", "id": keyID})
+}
@@ -103,6 +103,12 @@ func (h *AttendanceHandler) ListAttendanceLogs(c *gin.Context) {
 			filters["company_id"] = companyIDInt
 		}
 	}
+	if c.Query("unmatched") == "true" {
+		filters["unmatched"] = true
+	}
+	if scope := currentOrgScope(c); scope != nil {
+		filters["org_scope"] = scope
+	}
 
 	// Get attendance logs with filters and pagination
 	attendanceLogs, total, err := h.attendanceService.GetAllAttendanceLogs(c.Request.Context(), page, limit, filters, search)
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/services"
+)
+
+// AuditHandler handles HTTP requests reading the audit trail. Audit logs cannot be changed through
+// the API.
+type AuditHandler struct {
+	auditService services.AuditService
+}
+
+// NewAuditHandler creates a new instance of AuditHandler.
+func NewAuditHandler(auditService services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// ListAuditLogs retrieves audit logs filtered by entity_type, entity_id, actor_user_id,
+// actor_service_account_id, action and company_id, and by the from and to dates (YYYY-MM-DD, both
+// included).
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	filters := map[string]interface{}{}
+	for _, key := range []string{"entity_id", "actor_user_id", "actor_service_account_id", "company_id"} {
+		if value := c.Query(key); value != "" {
+			valueInt, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key})
+				return
+			}
+			filters[key] = valueInt
+		}
+	}
+	for _, key := range []string{"entity_type", "action"} {
+		if value := c.Query(key); value != "" {
+			filters[key] = value
+		}
+	}
+	for _, key := range []string{"from", "to"} {
+		if value := c.Query(key); value != "" {
+			date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key + " date, expected YYYY-MM-DD"})
+				return
+			}
+			if key == "to" {
+				date = date.AddDate(0, 0, 1)
+			}
+			filters[key] = date
+		}
+	}
+
+	auditLogs, total, err := h.auditService.ListAuditLogs(c.Request.Context(), page, limit, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  auditLogs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetAuditLogByID retrieves an audit log by its ID.
+func (h *AuditHandler) GetAuditLogByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid audit log ID"})
+		return
+	}
+
+	auditLog, err := h.auditService.GetAuditLogByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if auditLog == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Audit log not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": auditLog})
+}
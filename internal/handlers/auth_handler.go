@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/services"
+	"point-system-api/internal/types"
+	"point-system-api/pkg/utils"
+)
+
+// AuthHandler handles HTTP requests for logging in and out, refreshing tokens and managing passwords.
+type AuthHandler struct {
+	authService services.AuthService
+	userService services.UserService
+}
+
+// NewAuthHandler creates a new instance of AuthHandler.
+func NewAuthHandler(authService services.AuthService, userService services.UserService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+		userService: userService,
+	}
+}
+
+// Login authenticates a user and returns an access token and a refresh token, or a pre-auth token
+// to complete the login with a second factor.
+func (h *AuthHandler) Login(c *gin.Context) {
+	var credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&credentials); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+
+	result, err := h.authService.Login(c.Request.Context(), credentials.Username, credentials.Password, clientInfo(c))
+	if throttledLogin(c, err) {
+		return
+	}
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log in"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// VerifyTwoFactor completes a login with the pre-auth token and a TOTP or recovery code, and
+// returns the token pair.
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var request types.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	pair, err := h.authService.VerifyTwoFactor(c.Request.Context(), request.PreAuthToken, request.Code, clientInfo(c))
+	if throttledLogin(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// throttledLogin answers 429 with a Retry-After header when err refuses a throttled login.
+func throttledLogin(c *gin.Context, err error) bool {
+	var throttled *services.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	retryAfter := int(math.Ceil(time.Until(throttled.RetryAt).Seconds()))
+	c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, try again later"})
+	return true
+}
+
+// GetTwoFactorStatus tells whether the authenticated user has a second factor and must have one.
+func (h *AuthHandler) GetTwoFactorStatus(c *gin.Context) {
+	status, err := h.authService.GetTwoFactorStatus(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// EnrollTwoFactor starts the enrollment of a TOTP second factor for the authenticated user and
+// returns its secret and provisioning URI. It is also reachable with the pre-auth token of a
+// login requiring the enrollment.
+func (h *AuthHandler) EnrollTwoFactor(c *gin.Context) {
+	enrollment, err := h.authService.EnrollTwoFactor(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": enrollment, "message": "Enter a code of the authenticator app to activate two-factor authentication"})
+}
+
+// ActivateTwoFactor enables the enrolled second factor with a code of it and returns the recovery
+// codes, shown only once. With the pre-auth token of a login, it also returns the token pair.
+func (h *AuthHandler) ActivateTwoFactor(c *gin.Context) {
+	var request types.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	completeLogin := c.GetString("tokenPurpose") == utils.TokenPurposeTwoFactorEnrollment
+	activation, err := h.authService.ActivateTwoFactor(c.Request.Context(), currentUserID(c), request.Code, completeLogin, clientInfo(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": activation, "message": "Two-factor authentication enabled"})
+}
+
+// DisableTwoFactor removes the second factor of the authenticated user, who enters a TOTP or
+// recovery code.
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var request types.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.authService.DisableTwoFactor(c.Request.Context(), currentUserID(c), request.Code); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the authenticated user, who enters a TOTP
+// code, and returns the new ones.
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var request types.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), currentUserID(c), request.Code)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": codes, "message": "Recovery codes regenerated"})
+}
+
+// ResetTwoFactor removes the second factor of a user who lost it, ending their sessions.
+func (h *AuthHandler) ResetTwoFactor(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	found, err := h.authService.ResetTwoFactor(c.Request.Context(), uint(userID))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset successfully"})
+}
+
+// Refresh exchanges a refresh token for a new token pair.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var request types.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	pair, err := h.authService.Refresh(c.Request.Context(), request.RefreshToken, clientInfo(c))
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+// Logout revokes a refresh token. The access tokens already issued expire on their own.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var request types.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.authService.Logout(c.Request.Context(), request.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// ChangePassword changes the password of the authenticated user, who must give the current one.
+// Their sessions end, so they log in again with the new password.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var request types.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.userService.ChangePassword(c.Request.Context(), currentUserID(c), request.CurrentPassword, request.NewPassword); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+// ForgotPassword sends a password reset token to a user. The answer is the same whether the
+// user exists or not.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var request types.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.userService.RequestPasswordReset(c.Request.Context(), request.Username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send password reset"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the user exists, a password reset code has been sent"})
+}
+
+// ResetPassword sets a new password with a reset token.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var request types.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.userService.ResetPassword(c.Request.Context(), request.Token, request.NewPassword); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
+
+// UnlockUser lifts the lockout of a user after failed logins.
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.authService.UnlockUser(c.Request.Context(), uint(userID)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked successfully"})
+}
+
+// ListLoginHistory retrieves the last logins of a user, with their IP address and user agent.
+// The limit query parameter defaults to 50.
+func (h *AuthHandler) ListLoginHistory(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	attempts, err := h.authService.ListLoginHistory(c.Request.Context(), uint(userID), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": attempts})
+}
+
+// clientInfo returns the address and user agent of the client of a request. Logins are throttled
+// and recorded by this address, so it is the remote address of the connection unless the request
+// came through one of the trusted proxies of the router.
+func clientInfo(c *gin.Context) types.ClientInfo {
+	return types.ClientInfo{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/services"
+)
+
+// DeviceIdentityHandler handles HTTP requests for the mapping of device user IDs to employees.
+type DeviceIdentityHandler struct {
+	identityService services.DeviceIdentityService
+}
+
+// NewDeviceIdentityHandler creates a new instance of DeviceIdentityHandler.
+func NewDeviceIdentityHandler(identityService services.DeviceIdentityService) *DeviceIdentityHandler {
+	return &DeviceIdentityHandler{
+		identityService: identityService,
+	}
+}
+
+// CreateDeviceIdentity maps a device user ID to an employee.
+func (h *DeviceIdentityHandler) CreateDeviceIdentity(c *gin.Context) {
+	var identity models.DeviceIdentity
+	if err := c.ShouldBindJSON(&identity); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.identityService.CreateDeviceIdentity(c.Request.Context(), &identity); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_DEVICE_IDENTITY")
+	c.JSON(http.StatusCreated, gin.H{"data": identity, "message": "Device identity created successfully"})
+}
+
+// ListDeviceIdentities retrieves mappings, optionally filtered by device_id, company_id,
+// employee_id and device_user_id.
+func (h *DeviceIdentityHandler) ListDeviceIdentities(c *gin.Context) {
+	filters := map[string]interface{}{}
+	for _, key := range []string{"device_id", "company_id", "employee_id"} {
+		if value := c.Query(key); value != "" {
+			valueInt, err := strconv.Atoi(value)
+			if err == nil {
+				filters[key] = valueInt
+			}
+		}
+	}
+	if deviceUserID := c.Query("device_user_id"); deviceUserID != "" {
+		filters["device_user_id"] = deviceUserID
+	}
+
+	identities, err := h.identityService.ListDeviceIdentities(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": identities})
+}
+
+// GetDeviceIdentityByID retrieves a mapping by its ID.
+func (h *DeviceIdentityHandler) GetDeviceIdentityByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device identity ID"})
+		return
+	}
+
+	identity, err := h.identityService.GetDeviceIdentityByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if identity == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device identity not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": identity})
+}
+
+// UpdateDeviceIdentity updates a mapping and relinks the punches it affects.
+func (h *DeviceIdentityHandler) UpdateDeviceIdentity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device identity ID"})
+		return
+	}
+
+	var identity models.DeviceIdentity
+	if err := c.ShouldBindJSON(&identity); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+	identity.ID = uint(id)
+
+	if err := h.identityService.UpdateDeviceIdentity(c.Request.Context(), &identity); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_DEVICE_IDENTITY")
+	c.JSON(http.StatusOK, gin.H{"data": identity, "message": "Device identity updated successfully"})
+}
+
+// DeleteDeviceIdentity deletes a mapping and unlinks the punches only it covered.
+func (h *DeviceIdentityHandler) DeleteDeviceIdentity(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid device identity ID"})
+		return
+	}
+
+	if err := h.identityService.DeleteDeviceIdentity(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("DELETE_DEVICE_IDENTITY")
+	c.JSON(http.StatusOK, gin.H{"message": "Device identity deleted successfully"})
+}
+
+// SyncDeviceIdentities maps every employee's registration number on the devices of their
+// company and links every punch that can be linked.
+func (h *DeviceIdentityHandler) SyncDeviceIdentities(c *gin.Context) {
+	changed, err := h.identityService.SyncFromRegistrationNumbers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("SYNC_DEVICE_IDENTITIES")
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"relinked_punches": changed}, "message": "Device identities synchronized successfully"})
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/services"
+	"point-system-api/internal/storage"
+	"point-system-api/pkg/utils"
+)
+
+// downloadLinkTTL is how long a generated download link stays valid.
+const downloadLinkTTL = 15 * time.Minute
+
+// DocumentHandler handles HTTP requests for justification documents.
+type DocumentHandler struct {
+	documentService services.DocumentService
+}
+
+// NewDocumentHandler creates a new instance of DocumentHandler.
+func NewDocumentHandler(documentService services.DocumentService) *DocumentHandler {
+	return &DocumentHandler{
+		documentService: documentService,
+	}
+}
+
+// UploadDocument handles a multipart upload with the file, owner_type, owner_id and an optional description.
+func (h *DocumentHandler) UploadDocument(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required"})
+		return
+	}
+	ownerID, err := strconv.Atoi(c.PostForm("owner_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid owner ID"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to read the uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	document := models.Document{
+		OwnerType:   c.PostForm("owner_type"),
+		OwnerID:     uint(ownerID),
+		FileName:    fileHeader.Filename,
+		Description: c.PostForm("description"),
+	}
+	if userID := currentUserID(c); userID != 0 {
+		document.UploadedBy = &userID
+	}
+
+	if err := h.documentService.UploadDocument(c.Request.Context(), &document, file); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_DOCUMENT")
+	c.JSON(http.StatusCreated, gin.H{"data": document, "message": "Document uploaded successfully"})
+}
+
+// ListDocuments retrieves documents filtered by owner_type, owner_id or status.
+func (h *DocumentHandler) ListDocuments(c *gin.Context) {
+	filters := map[string]interface{}{}
+	for _, key := range []string{"owner_type", "status"} {
+		if value := c.Query(key); value != "" {
+			filters[key] = value
+		}
+	}
+	if ownerID := c.Query("owner_id"); ownerID != "" {
+		ownerIDInt, err := strconv.Atoi(ownerID)
+		if err == nil {
+			filters["owner_id"] = ownerIDInt
+		}
+	}
+
+	documents, err := h.documentService.ListDocuments(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": documents})
+}
+
+// GetDocumentByID retrieves the metadata of a document.
+func (h *DocumentHandler) GetDocumentByID(c *gin.Context) {
+	document, ok := h.loadDocument(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": document})
+}
+
+// GetDownloadLink returns a signed link to download a document without an Authorization header,
+// so it can be used directly by a browser.
+func (h *DocumentHandler) GetDownloadLink(c *gin.Context) {
+	document, ok := h.loadDocument(c)
+	if !ok {
+		return
+	}
+
+	expires := time.Now().Add(downloadLinkTTL)
+	url := fmt.Sprintf("/documents/%d/download?expires=%d&signature=%s",
+		document.ID, expires.Unix(), utils.SignDownload(document.ID, expires))
+
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"url": url, "expires_at": expires.Format(time.RFC3339)}})
+}
+
+// DownloadDocument streams a document when the link signature is valid and not expired.
+func (h *DocumentHandler) DownloadDocument(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil || !utils.VerifyDownload(uint(id), expires, c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired download link"})
+		return
+	}
+
+	document, ok := h.loadDocument(c)
+	if !ok {
+		return
+	}
+	content, err := h.documentService.OpenDocument(c.Request.Context(), document)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Document file not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer content.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", document.FileName))
+	c.DataFromReader(http.StatusOK, document.Size, document.ContentType, content, nil)
+}
+
+// ReviewDocument approves or rejects a pending document.
+func (h *DocumentHandler) ReviewDocument(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return
+	}
+
+	var payload struct {
+		Approve *bool  `json:"approve" binding:"required"`
+		Comment string `json:"comment"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	document, err := h.documentService.ReviewDocument(c.Request.Context(), uint(id), currentUserID(c), *payload.Approve, payload.Comment)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_DOCUMENT")
+	c.JSON(http.StatusOK, gin.H{"data": document, "message": "Document " + document.Status})
+}
+
+// DeleteDocument deletes a document that has not been approved.
+func (h *DocumentHandler) DeleteDocument(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return
+	}
+
+	if err := h.documentService.DeleteDocument(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("DELETE_DOCUMENT")
+	c.JSON(http.StatusOK, gin.H{"message": "Document deleted successfully"})
+}
+
+// loadDocument fetches the document of the :id parameter, writing the error response when it fails.
+func (h *DocumentHandler) loadDocument(c *gin.Context) (*models.Document, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return nil, false
+	}
+
+	document, err := h.documentService.GetDocumentByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if document == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return nil, false
+	}
+	return document, true
+}
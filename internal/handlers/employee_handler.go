@@ -26,15 +26,16 @@ func NewEmployeeHandler(employeeService services.EmployeeService) *EmployeeHandl
 // CreateEmployee handles the creation of a new employee.
 func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
 	var request struct {
-		RegistrationNumber string `json:"RegistrationNumber"`
-		Qualification      string `json:"Qualification"`
-		CompanyID          uint   `json:"CompanyID"`
-		StartHour          string `json:"StartHour"`
-		EndHour            string `json:"EndHour"`
-		FirstName          string `json:"firstName"`
-		LastName           string `json:"lastName"`
-		Username           string `json:"username"`
-		Password           string `json:"password"`
+		RegistrationNumber string          `json:"RegistrationNumber"`
+		Qualification      string          `json:"Qualification"`
+		CompanyID          uint            `json:"CompanyID"`
+		StartHour          string          `json:"StartHour"`
+		EndHour            string          `json:"EndHour"`
+		HireDate           *types.DateOnly `json:"HireDate"`
+		FirstName          string          `json:"firstName"`
+		LastName           string          `json:"lastName"`
+		Username           string          `json:"username"`
+		Password           string          `json:"password"`
 	}
 
 	// Bind the JSON payload into the request struct
@@ -50,6 +51,7 @@ func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
 		CompanyID:          request.CompanyID,
 		StartHour:          request.StartHour,
 		EndHour:            request.EndHour,
+		HireDate:           request.HireDate,
 	}
 
 	user := models.User{
@@ -62,7 +64,7 @@ func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
 	// Call the service to create the employee
 	employee_response, err := h.employeeService.CreateEmployee(c.Request.Context(), employee, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -75,6 +77,7 @@ func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
 		CompanyID:          employee.CompanyID,
 		StartHour:          employee.StartHour,
 		EndHour:            employee.EndHour,
+		HireDate:           employee.HireDate,
 		CreatedAt:          employee.CreatedAt.Format("2006-01-02T15:04:05"),
 		UpdatedAt:          employee.UpdatedAt.Format("2006-01-02T15:04:05"),
 		FirstName:          user.FirstName,
@@ -131,15 +134,16 @@ func (h *EmployeeHandler) GetEmployeesByCompanyID(c *gin.Context) {
 // UpdateEmployee handles updating an existing employee.
 func (h *EmployeeHandler) UpdateEmployee(c *gin.Context) {
 	var request struct {
-		RegistrationNumber string  `json:"RegistrationNumber"`
-		Qualification      string  `json:"Qualification"`
-		CompanyID          uint    `json:"CompanyID"`
-		StartHour          string  `json:"StartHour"`
-		EndHour            string  `json:"EndHour"`
-		FirstName          string  `json:"firstName"`
-		LastName           string  `json:"lastName"`
-		Username           string  `json:"username"`
-		Password           *string `json:"password"`
+		RegistrationNumber string          `json:"RegistrationNumber"`
+		Qualification      string          `json:"Qualification"`
+		CompanyID          uint            `json:"CompanyID"`
+		StartHour          string          `json:"StartHour"`
+		EndHour            string          `json:"EndHour"`
+		HireDate           *types.DateOnly `json:"HireDate"`
+		FirstName          string          `json:"firstName"`
+		LastName           string          `json:"lastName"`
+		Username           string          `json:"username"`
+		Password           *string         `json:"password"`
 	}
 
 	// Bind the JSON payload into the request struct
@@ -164,6 +168,7 @@ func (h *EmployeeHandler) UpdateEmployee(c *gin.Context) {
 		CompanyID:          request.CompanyID,
 		StartHour:          request.StartHour,
 		EndHour:            request.EndHour,
+		HireDate:           request.HireDate,
 	}
 
 	var userUpdates *models.User
@@ -184,7 +189,7 @@ func (h *EmployeeHandler) UpdateEmployee(c *gin.Context) {
 	// Call the service to update the employee
 	success, err := h.employeeService.UpdateEmployee(c.Request.Context(), employee, userUpdates)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -202,6 +207,7 @@ func (h *EmployeeHandler) UpdateEmployee(c *gin.Context) {
 		CompanyID:          employee.CompanyID,
 		StartHour:          employee.StartHour,
 		EndHour:            employee.EndHour,
+		HireDate:           employee.HireDate,
 		CreatedAt:          employee.CreatedAt.String(),
 		UpdatedAt:          employee.UpdatedAt.String(),
 		FirstName:          userUpdates.FirstName,
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/services"
+	"point-system-api/internal/types"
+)
+
+// maxImportFileSize bounds the size of an uploaded import file.
+const maxImportFileSize = 20 << 20
+
+// EmployeeImportHandler handles bulk employee imports.
+type EmployeeImportHandler struct {
+	importService services.EmployeeImportService
+}
+
+// NewEmployeeImportHandler creates a new instance of EmployeeImportHandler.
+func NewEmployeeImportHandler(importService services.EmployeeImportService) *EmployeeImportHandler {
+	return &EmployeeImportHandler{
+		importService: importService,
+	}
+}
+
+// ImportEmployees handles a multipart upload of a CSV or XLSX file with the form fields:
+//   - mapping: JSON object mapping import fields to column headers
+//   - mode: all_or_nothing (default) or skip_invalid
+//   - dry_run: validate only, true unless explicitly set to false
+//   - company_id: company of the rows without a company column
+//   - default_password: password of the rows without a password column
+func (h *EmployeeImportHandler) ImportEmployees(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required"})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The import file is too large"})
+		return
+	}
+
+	opts := types.EmployeeImportOptions{
+		Mode:            c.PostForm("mode"),
+		DryRun:          c.DefaultPostForm("dry_run", "true") != "false",
+		DefaultPassword: c.PostForm("default_password"),
+	}
+	if mapping := c.PostForm("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &opts.Mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping " + err.Error()})
+			return
+		}
+	}
+	if companyID := c.PostForm("company_id"); companyID != "" {
+		companyIDInt, err := strconv.Atoi(companyID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+			return
+		}
+		opts.CompanyID = uint(companyIDInt)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to read the uploaded file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to read the uploaded file"})
+		return
+	}
+
+	report, err := h.importService.ImportEmployees(c.Request.Context(), fileHeader.Filename, data, opts)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if report.Committed {
+		manager.broadcast <- []byte("CREATE_EMPLOYEE")
+	}
+	status := http.StatusOK
+	if !report.DryRun && report.Mode == types.ImportModeAllOrNothing && report.InvalidRows > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, gin.H{"data": report})
+}
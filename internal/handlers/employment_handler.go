@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/services"
+	"point-system-api/internal/types"
+)
+
+// EmploymentHandler handles HTTP requests for the employment history of employees.
+type EmploymentHandler struct {
+	employmentService services.EmploymentService
+}
+
+// NewEmploymentHandler creates a new instance of EmploymentHandler.
+func NewEmploymentHandler(employmentService services.EmploymentService) *EmploymentHandler {
+	return &EmploymentHandler{
+		employmentService: employmentService,
+	}
+}
+
+// GetEmploymentHistory retrieves the hire, termination and assignments of an employee.
+func (h *EmploymentHandler) GetEmploymentHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+		return
+	}
+
+	history, err := h.employmentService.GetEmploymentHistory(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
+// TransferEmployee moves an employee to another company and/or position from a date onwards.
+func (h *EmploymentHandler) TransferEmployee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+		return
+	}
+
+	var request types.TransferRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	assignment, err := h.employmentService.TransferEmployee(c.Request.Context(), uint(id), request, currentUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("TRANSFER_EMPLOYEE")
+	c.JSON(http.StatusCreated, gin.H{"data": assignment, "message": "Employee transferred successfully"})
+}
+
+// TerminateEmployee ends the employment of an employee.
+func (h *EmploymentHandler) TerminateEmployee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+		return
+	}
+
+	var request types.TerminationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	employee, err := h.employmentService.TerminateEmployee(c.Request.Context(), uint(id), request)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("TERMINATE_EMPLOYEE")
+	c.JSON(http.StatusOK, gin.H{"data": employee, "message": "Employee terminated successfully"})
+}
+
+// ReinstateEmployee cancels the termination of an employee.
+func (h *EmploymentHandler) ReinstateEmployee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+		return
+	}
+
+	employee, err := h.employmentService.ReinstateEmployee(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("REINSTATE_EMPLOYEE")
+	c.JSON(http.StatusOK, gin.H{"data": employee, "message": "Employee reinstated successfully"})
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/services"
+)
+
+// LeaveBalanceHandler handles HTTP requests for accrual policies and leave balances.
+type LeaveBalanceHandler struct {
+	leaveBalanceService services.LeaveBalanceService
+}
+
+// NewLeaveBalanceHandler creates a new instance of LeaveBalanceHandler.
+func NewLeaveBalanceHandler(leaveBalanceService services.LeaveBalanceService) *LeaveBalanceHandler {
+	return &LeaveBalanceHandler{
+		leaveBalanceService: leaveBalanceService,
+	}
+}
+
+// GetAccrualPolicy retrieves the accrual policy of a leave type.
+func (h *LeaveBalanceHandler) GetAccrualPolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave type ID"})
+		return
+	}
+
+	policy, err := h.leaveBalanceService.GetAccrualPolicy(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if policy == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Accrual policy not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+// SaveAccrualPolicy creates or replaces the accrual policy of a leave type.
+func (h *LeaveBalanceHandler) SaveAccrualPolicy(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave type ID"})
+		return
+	}
+
+	var policy models.LeaveAccrualPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+	policy.LeaveTypeID = uint(id)
+
+	if err := h.leaveBalanceService.SaveAccrualPolicy(c.Request.Context(), &policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_ACCRUAL_POLICY")
+	c.JSON(http.StatusOK, gin.H{"data": policy, "message": "Accrual policy saved successfully"})
+}
+
+// GetLeaveBalances retrieves the leave balances of an employee, at today or at the as_of date.
+func (h *LeaveBalanceHandler) GetLeaveBalances(c *gin.Context) {
+	employeeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+		return
+	}
+	asOf, ok := asOfParam(c)
+	if !ok {
+		return
+	}
+
+	balances, err := h.leaveBalanceService.GetBalances(c.Request.Context(), uint(employeeID), asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": balances, "as_of": asOf.Format("2006-01-02")})
+}
+
+// GetLeaveLedger retrieves every movement of an employee's balance of a leave type.
+func (h *LeaveBalanceHandler) GetLeaveLedger(c *gin.Context) {
+	employeeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+		return
+	}
+	leaveTypeID, err := strconv.Atoi(c.Param("leaveTypeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave type ID"})
+		return
+	}
+	asOf, ok := asOfParam(c)
+	if !ok {
+		return
+	}
+
+	ledger, err := h.leaveBalanceService.GetLedger(c.Request.Context(), uint(employeeID), uint(leaveTypeID), asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": ledger, "as_of": asOf.Format("2006-01-02")})
+}
+
+// AdjustLeaveBalance records a manual adjustment of an employee's leave balance.
+func (h *LeaveBalanceHandler) AdjustLeaveBalance(c *gin.Context) {
+	employeeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+		return
+	}
+	leaveTypeID, err := strconv.Atoi(c.Param("leaveTypeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave type ID"})
+		return
+	}
+
+	var payload struct {
+		Days   float64 `json:"days" binding:"required"`
+		Reason string  `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	entry, err := h.leaveBalanceService.AdjustBalance(c.Request.Context(), uint(employeeID), uint(leaveTypeID), payload.Days, payload.Reason, currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_LEAVE_BALANCE")
+	c.JSON(http.StatusCreated, gin.H{"data": entry, "message": "Leave balance adjusted successfully"})
+}
+
+// RunYearEndRollover closes a year's leave balances. The year defaults to the previous one.
+func (h *LeaveBalanceHandler) RunYearEndRollover(c *gin.Context) {
+	year := time.Now().Year() - 1
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		year = parsed
+	}
+
+	created, err := h.leaveBalanceService.RunYearEndRollover(c.Request.Context(), year)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_LEAVE_BALANCE")
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"year": year, "created": created}, "message": "Year-end rollover completed"})
+}
+
+// asOfParam parses the optional as_of query parameter, defaulting to today.
+// It writes a 400 response and returns false when the date is malformed.
+func asOfParam(c *gin.Context) (time.Time, bool) {
+	value := c.Query("as_of")
+	if value == "" {
+		return time.Now(), true
+	}
+	asOf, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as_of date, expected YYYY-MM-DD"})
+		return time.Time{}, false
+	}
+	return asOf, true
+}
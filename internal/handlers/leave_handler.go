@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/services"
+)
+
+// LeaveHandler handles HTTP requests for leave types and leave requests.
+type LeaveHandler struct {
+	leaveService services.LeaveService
+}
+
+// NewLeaveHandler creates a new instance of LeaveHandler.
+func NewLeaveHandler(leaveService services.LeaveService) *LeaveHandler {
+	return &LeaveHandler{
+		leaveService: leaveService,
+	}
+}
+
+// CreateLeaveType handles the creation of a leave type.
+func (h *LeaveHandler) CreateLeaveType(c *gin.Context) {
+	var leaveType models.LeaveType
+	if err := c.ShouldBindJSON(&leaveType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.leaveService.CreateLeaveType(c.Request.Context(), &leaveType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_LEAVE_TYPE")
+	c.JSON(http.StatusCreated, gin.H{"data": leaveType, "message": "Leave type created successfully"})
+}
+
+// ListLeaveTypes retrieves leave types, optionally filtered by company_id.
+func (h *LeaveHandler) ListLeaveTypes(c *gin.Context) {
+	filters := map[string]interface{}{}
+	if companyID := c.Query("company_id"); companyID != "" {
+		companyIDInt, err := strconv.Atoi(companyID)
+		if err == nil {
+			filters["company_id"] = companyIDInt
+		}
+	}
+
+	leaveTypes, err := h.leaveService.ListLeaveTypes(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": leaveTypes})
+}
+
+// GetLeaveTypeByID retrieves a leave type by its ID.
+func (h *LeaveHandler) GetLeaveTypeByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave type ID"})
+		return
+	}
+
+	leaveType, err := h.leaveService.GetLeaveTypeByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if leaveType == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leave type not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": leaveType})
+}
+
+// UpdateLeaveType handles updating a leave type by its ID.
+func (h *LeaveHandler) UpdateLeaveType(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave type ID"})
+		return
+	}
+
+	var leaveType models.LeaveType
+	if err := c.ShouldBindJSON(&leaveType); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	leaveType.ID = uint(id)
+	if err := h.leaveService.UpdateLeaveType(c.Request.Context(), &leaveType); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_LEAVE_TYPE")
+	c.JSON(http.StatusOK, gin.H{"data": leaveType, "message": "Leave type updated successfully"})
+}
+
+// DeleteLeaveType handles deleting a leave type by its ID.
+func (h *LeaveHandler) DeleteLeaveType(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave type ID"})
+		return
+	}
+
+	if err := h.leaveService.DeleteLeaveType(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("DELETE_LEAVE_TYPE")
+	c.JSON(http.StatusOK, gin.H{"message": "Leave type deleted successfully"})
+}
+
+// CreateLeaveRequest handles the submission of a leave request.
+func (h *LeaveHandler) CreateLeaveRequest(c *gin.Context) {
+	var request models.LeaveRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.leaveService.CreateLeaveRequest(c.Request.Context(), &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_LEAVE_REQUEST")
+	c.JSON(http.StatusCreated, gin.H{"data": request, "message": "Leave request submitted successfully"})
+}
+
+// ListLeaveRequests retrieves leave requests filtered by employee_id, company_id, leave_type_id or status.
+func (h *LeaveHandler) ListLeaveRequests(c *gin.Context) {
+	filters := map[string]interface{}{}
+	for _, key := range []string{"employee_id", "company_id", "leave_type_id"} {
+		if value := c.Query(key); value != "" {
+			valueInt, err := strconv.Atoi(value)
+			if err == nil {
+				filters[key] = valueInt
+			}
+		}
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+
+	requests, err := h.leaveService.ListLeaveRequests(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+// GetLeaveRequestByID retrieves a leave request by its ID.
+func (h *LeaveHandler) GetLeaveRequestByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave request ID"})
+		return
+	}
+
+	request, err := h.leaveService.GetLeaveRequestByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if request == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Leave request not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+// ApproveLeaveRequest approves a pending leave request.
+func (h *LeaveHandler) ApproveLeaveRequest(c *gin.Context) {
+	h.reviewLeaveRequest(c, true)
+}
+
+// RejectLeaveRequest rejects a pending leave request.
+func (h *LeaveHandler) RejectLeaveRequest(c *gin.Context) {
+	h.reviewLeaveRequest(c, false)
+}
+
+func (h *LeaveHandler) reviewLeaveRequest(c *gin.Context, approve bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave request ID"})
+		return
+	}
+
+	var payload struct {
+		Comment string `json:"comment"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+			return
+		}
+	}
+
+	var request *models.LeaveRequest
+	if approve {
+		request, err = h.leaveService.ApproveLeaveRequest(c.Request.Context(), uint(id), currentUserID(c), payload.Comment)
+	} else {
+		request, err = h.leaveService.RejectLeaveRequest(c.Request.Context(), uint(id), currentUserID(c), payload.Comment)
+	}
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_LEAVE_REQUEST")
+	c.JSON(http.StatusOK, gin.H{"data": request, "message": "Leave request " + request.Status})
+}
+
+// CancelLeaveRequest cancels a pending or approved leave request.
+func (h *LeaveHandler) CancelLeaveRequest(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid leave request ID"})
+		return
+	}
+
+	request, err := h.leaveService.CancelLeaveRequest(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_LEAVE_REQUEST")
+	c.JSON(http.StatusOK, gin.H{"data": request, "message": "Leave request cancelled"})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/services"
+	"point-system-api/internal/types"
+)
+
+// ManualPunchHandler handles HTTP requests for the punches entered and corrected by hand.
+type ManualPunchHandler struct {
+	manualPunchService services.ManualPunchService
+}
+
+// NewManualPunchHandler creates a new instance of ManualPunchHandler.
+func NewManualPunchHandler(manualPunchService services.ManualPunchService) *ManualPunchHandler {
+	return &ManualPunchHandler{manualPunchService: manualPunchService}
+}
+
+// AddPunch enters a punch by hand for an employee.
+func (h *ManualPunchHandler) AddPunch(c *gin.Context) {
+	var request types.ManualPunchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	change, err := h.manualPunchService.AddPunch(c.Request.Context(), request, currentUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_ATTENDANCELOG")
+	c.JSON(http.StatusCreated, gin.H{"data": change, "message": "Punch added successfully"})
+}
+
+// UpdatePunch moves a punch entered by hand to another time. Device punches are adjusted instead.
+func (h *ManualPunchHandler) UpdatePunch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	var edit types.PunchEdit
+	if err := c.ShouldBindJSON(&edit); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	change, err := h.manualPunchService.UpdatePunch(c.Request.Context(), uint(id), edit)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_ATTENDANCELOG")
+	c.JSON(http.StatusOK, gin.H{"data": change, "message": "Punch updated successfully"})
+}
+
+// AdjustPunch voids a punch and enters the corrected one in its place.
+func (h *ManualPunchHandler) AdjustPunch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	var edit types.PunchEdit
+	if err := c.ShouldBindJSON(&edit); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	change, err := h.manualPunchService.AdjustPunch(c.Request.Context(), uint(id), edit, currentUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_ATTENDANCELOG")
+	c.JSON(http.StatusOK, gin.H{"data": change, "message": "Punch adjusted successfully"})
+}
+
+// VoidPunch voids a punch, which stays stored but no longer counts.
+func (h *ManualPunchHandler) VoidPunch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	var void types.PunchVoid
+	if err := c.ShouldBindJSON(&void); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	change, err := h.manualPunchService.VoidPunch(c.Request.Context(), uint(id), void, currentUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_ATTENDANCELOG")
+	c.JSON(http.StatusOK, gin.H{"data": change, "message": "Punch voided successfully"})
+}
+
+// DeletePunch deletes a punch entered by hand. Device punches are voided instead.
+func (h *ManualPunchHandler) DeletePunch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	change, err := h.manualPunchService.DeletePunch(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("DELETE_ATTENDANCELOG")
+	c.JSON(http.StatusOK, gin.H{"data": change, "message": "Punch deleted successfully"})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/services"
+	"point-system-api/internal/types"
+)
+
+// OIDCHandler handles HTTP requests for single sign-on with an OpenID Connect identity provider.
+type OIDCHandler struct {
+	oidcService services.OIDCService
+}
+
+// NewOIDCHandler creates a new instance of OIDCHandler.
+func NewOIDCHandler(oidcService services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+// StartLogin returns the authorization URL of the identity provider to send the user to.
+func (h *OIDCHandler) StartLogin(c *gin.Context) {
+	authorization, err := h.oidcService.StartLogin(c.Request.Context())
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": authorization})
+}
+
+// CompleteLogin completes a login with the code and state the identity provider returned, and
+// returns an access token and a refresh token, or a pre-auth token as the password login does.
+func (h *OIDCHandler) CompleteLogin(c *gin.Context) {
+	var request types.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	result, err := h.oidcService.CompleteLogin(c.Request.Context(), request.Code, request.State, clientInfo(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/rbac"
+	"point-system-api/internal/services"
+	"point-system-api/internal/types"
+)
+
+// OrganizationHandler handles HTTP requests for departments, teams and reporting lines.
+type OrganizationHandler struct {
+	organizationService services.OrganizationService
+}
+
+// NewOrganizationHandler creates a new instance of OrganizationHandler.
+func NewOrganizationHandler(organizationService services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+	}
+}
+
+// CreateDepartment creates a department in a company.
+func (h *OrganizationHandler) CreateDepartment(c *gin.Context) {
+	var department models.Department
+	if err := c.ShouldBindJSON(&department); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.organizationService.CreateDepartment(c.Request.Context(), &department); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_DEPARTMENT")
+	c.JSON(http.StatusCreated, gin.H{"data": department, "message": "Department created successfully"})
+}
+
+// ListDepartments retrieves departments, optionally filtered by company_id and manager_id.
+func (h *OrganizationHandler) ListDepartments(c *gin.Context) {
+	filters := map[string]interface{}{}
+	for _, key := range []string{"company_id", "manager_id"} {
+		if value := c.Query(key); value != "" {
+			valueInt, err := strconv.Atoi(value)
+			if err == nil {
+				filters[key] = valueInt
+			}
+		}
+	}
+
+	departments, err := h.organizationService.ListDepartments(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": departments})
+}
+
+// GetDepartmentByID retrieves a department by its ID.
+func (h *OrganizationHandler) GetDepartmentByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid department ID"})
+		return
+	}
+
+	department, err := h.organizationService.GetDepartmentByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if department == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Department not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": department})
+}
+
+// UpdateDepartment renames a department or changes its manager.
+func (h *OrganizationHandler) UpdateDepartment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid department ID"})
+		return
+	}
+
+	var department models.Department
+	if err := c.ShouldBindJSON(&department); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+	department.ID = uint(id)
+
+	if err := h.organizationService.UpdateDepartment(c.Request.Context(), &department); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_DEPARTMENT")
+	c.JSON(http.StatusOK, gin.H{"data": department, "message": "Department updated successfully"})
+}
+
+// DeleteDepartment deletes a department without teams nor members.
+func (h *OrganizationHandler) DeleteDepartment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid department ID"})
+		return
+	}
+
+	if err := h.organizationService.DeleteDepartment(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("DELETE_DEPARTMENT")
+	c.JSON(http.StatusOK, gin.H{"message": "Department deleted successfully"})
+}
+
+// CreateTeam creates a team in a department.
+func (h *OrganizationHandler) CreateTeam(c *gin.Context) {
+	var team models.Team
+	if err := c.ShouldBindJSON(&team); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.organizationService.CreateTeam(c.Request.Context(), &team); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_TEAM")
+	c.JSON(http.StatusCreated, gin.H{"data": team, "message": "Team created successfully"})
+}
+
+// ListTeams retrieves teams, optionally filtered by department_id and manager_id.
+func (h *OrganizationHandler) ListTeams(c *gin.Context) {
+	filters := map[string]interface{}{}
+	for _, key := range []string{"department_id", "manager_id"} {
+		if value := c.Query(key); value != "" {
+			valueInt, err := strconv.Atoi(value)
+			if err == nil {
+				filters[key] = valueInt
+			}
+		}
+	}
+
+	teams, err := h.organizationService.ListTeams(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": teams})
+}
+
+// GetTeamByID retrieves a team by its ID.
+func (h *OrganizationHandler) GetTeamByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	team, err := h.organizationService.GetTeamByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if team == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": team})
+}
+
+// UpdateTeam renames a team or changes its manager.
+func (h *OrganizationHandler) UpdateTeam(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	var team models.Team
+	if err := c.ShouldBindJSON(&team); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+	team.ID = uint(id)
+
+	if err := h.organizationService.UpdateTeam(c.Request.Context(), &team); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_TEAM")
+	c.JSON(http.StatusOK, gin.H{"data": team, "message": "Team updated successfully"})
+}
+
+// DeleteTeam deletes a team without members.
+func (h *OrganizationHandler) DeleteTeam(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return
+	}
+
+	if err := h.organizationService.DeleteTeam(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("DELETE_TEAM")
+	c.JSON(http.StatusOK, gin.H{"message": "Team deleted successfully"})
+}
+
+// ListOrgAssignments retrieves the department and team history of an employee.
+func (h *OrganizationHandler) ListOrgAssignments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+		return
+	}
+
+	assignments, err := h.organizationService.ListOrgAssignments(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": assignments})
+}
+
+// AssignEmployee moves an employee to a department and team from a date onwards.
+func (h *OrganizationHandler) AssignEmployee(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+		return
+	}
+
+	var request types.OrgAssignmentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	assignment, err := h.organizationService.AssignEmployee(c.Request.Context(), uint(id), request.DepartmentID, request.TeamID, request.EffectiveFrom.ToTime())
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("ASSIGN_EMPLOYEE")
+	c.JSON(http.StatusCreated, gin.H{"data": assignment, "message": "Employee assigned successfully"})
+}
+
+// EndOrgAssignment removes an employee from their department.
+func (h *OrganizationHandler) EndOrgAssignment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+		return
+	}
+
+	var request types.EndOrgAssignmentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	assignment, err := h.organizationService.EndOrgAssignment(c.Request.Context(), uint(id), request.Date.ToTime())
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("END_ORG_ASSIGNMENT")
+	c.JSON(http.StatusOK, gin.H{"data": assignment, "message": "Assignment ended successfully"})
+}
+
+// GetReportingTree retrieves the reporting lines, optionally for a company_id, below a manager_id
+// and on a date (defaults to today). Managers get their own subtree by default.
+func (h *OrganizationHandler) GetReportingTree(c *gin.Context) {
+	var companyID, managerID uint
+	if value := c.Query("company_id"); value != "" {
+		valueInt, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+			return
+		}
+		companyID = uint(valueInt)
+	}
+	if value := c.Query("manager_id"); value != "" {
+		valueInt, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid manager ID"})
+			return
+		}
+		managerID = uint(valueInt)
+	} else if role, _ := c.Get("userRole"); role == rbac.RoleManager {
+		managerID = currentUserID(c)
+	}
+	date := time.Now()
+	if value := c.Query("date"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	tree, err := h.organizationService.GetReportingTree(c.Request.Context(), companyID, managerID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tree})
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/services"
+	"point-system-api/internal/types"
+)
+
+// PayrollPeriodHandler handles HTTP requests for payroll period-related operations.
+type PayrollPeriodHandler struct {
+	periodService services.PayrollPeriodService
+}
+
+// NewPayrollPeriodHandler creates a new instance of PayrollPeriodHandler.
+func NewPayrollPeriodHandler(periodService services.PayrollPeriodService) *PayrollPeriodHandler {
+	return &PayrollPeriodHandler{
+		periodService: periodService,
+	}
+}
+
+// CreatePayrollPeriod handles the creation of a payroll period.
+// Either start_date/end_date or year/month (a monthly period) must be given.
+func (h *PayrollPeriodHandler) CreatePayrollPeriod(c *gin.Context) {
+	var request struct {
+		CompanyID uint            `json:"company_id" binding:"required"`
+		StartDate *types.DateOnly `json:"start_date"`
+		EndDate   *types.DateOnly `json:"end_date"`
+		Year      int             `json:"year"`
+		Month     int             `json:"month"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	period := models.PayrollPeriod{CompanyID: request.CompanyID}
+	switch {
+	case request.StartDate != nil && request.EndDate != nil:
+		period.StartDate = *request.StartDate
+		period.EndDate = *request.EndDate
+	case request.Year > 0 && request.Month >= 1 && request.Month <= 12:
+		start := time.Date(request.Year, time.Month(request.Month), 1, 0, 0, 0, 0, time.UTC)
+		period.StartDate = types.DateOnly(start)
+		period.EndDate = types.DateOnly(start.AddDate(0, 1, -1))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Either start_date and end_date or year and month are required"})
+		return
+	}
+
+	if err := h.periodService.CreatePayrollPeriod(c.Request.Context(), &period, currentUserID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_PAYROLL_PERIOD")
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    period,
+		"message": "Payroll period created successfully",
+	})
+}
+
+// GetPayrollPeriodByID retrieves a payroll period by its ID.
+func (h *PayrollPeriodHandler) GetPayrollPeriodByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payroll period ID"})
+		return
+	}
+
+	period, err := h.periodService.GetPayrollPeriodByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if period == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payroll period not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": period})
+}
+
+// ListPayrollPeriods retrieves payroll periods, optionally filtered by company_id and status.
+func (h *PayrollPeriodHandler) ListPayrollPeriods(c *gin.Context) {
+	filters := map[string]interface{}{}
+	if companyID := c.Query("company_id"); companyID != "" {
+		companyIDInt, err := strconv.Atoi(companyID)
+		if err == nil {
+			filters["company_id"] = companyIDInt
+		}
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+
+	periods, err := h.periodService.ListPayrollPeriods(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": periods})
+}
+
+// ClosePayrollPeriod closes a payroll period so that its attendance can no longer be edited.
+func (h *PayrollPeriodHandler) ClosePayrollPeriod(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payroll period ID"})
+		return
+	}
+
+	period, err := h.periodService.ClosePayrollPeriod(c.Request.Context(), uint(id), currentUserID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CLOSE_PAYROLL_PERIOD")
+	c.JSON(http.StatusOK, gin.H{
+		"data":    period,
+		"message": "Payroll period closed successfully",
+	})
+}
+
+// ReopenPayrollPeriod reopens a closed payroll period. A reason is mandatory.
+func (h *PayrollPeriodHandler) ReopenPayrollPeriod(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payroll period ID"})
+		return
+	}
+
+	var request struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required to reopen a payroll period"})
+		return
+	}
+
+	period, err := h.periodService.ReopenPayrollPeriod(c.Request.Context(), uint(id), currentUserID(c), request.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("REOPEN_PAYROLL_PERIOD")
+	c.JSON(http.StatusOK, gin.H{
+		"data":    period,
+		"message": "Payroll period reopened successfully",
+	})
+}
+
+// ListPayrollPeriodEvents retrieves the audit trail of a payroll period.
+func (h *PayrollPeriodHandler) ListPayrollPeriodEvents(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payroll period ID"})
+		return
+	}
+
+	events, err := h.periodService.ListPayrollPeriodEvents(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": events})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/services"
+)
+
+// PunchCorrectionHandler handles the review of the punch correction requests of employees.
+type PunchCorrectionHandler struct {
+	punchCorrectionService services.PunchCorrectionService
+}
+
+// NewPunchCorrectionHandler creates a new instance of PunchCorrectionHandler.
+func NewPunchCorrectionHandler(punchCorrectionService services.PunchCorrectionService) *PunchCorrectionHandler {
+	return &PunchCorrectionHandler{punchCorrectionService: punchCorrectionService}
+}
+
+// ListPunchCorrections retrieves the punch correction requests filtered by status, employee_id and
+// company_id, restricted to the departments and teams of the manager. The review queue is
+// status=pending.
+func (h *PunchCorrectionHandler) ListPunchCorrections(c *gin.Context) {
+	filters := map[string]interface{}{}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	for _, key := range []string{"employee_id", "company_id"} {
+		if value := c.Query(key); value != "" {
+			id, err := strconv.Atoi(value)
+			if err == nil {
+				filters[key] = id
+			}
+		}
+	}
+	if scope := currentOrgScope(c); scope != nil {
+		filters["org_scope"] = scope
+	}
+
+	corrections, err := h.punchCorrectionService.ListPunchCorrections(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": corrections})
+}
+
+// GetPunchCorrectionByID retrieves a punch correction request by its ID.
+func (h *PunchCorrectionHandler) GetPunchCorrectionByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid punch correction request ID"})
+		return
+	}
+
+	correction, err := h.punchCorrectionService.GetPunchCorrectionByID(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if correction == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Punch correction request not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": correction})
+}
+
+// ApprovePunchCorrection approves a pending punch correction request and applies it. Managers
+// review the requests of their departments and teams only.
+func (h *PunchCorrectionHandler) ApprovePunchCorrection(c *gin.Context) {
+	h.reviewPunchCorrection(c, true)
+}
+
+// RejectPunchCorrection rejects a pending punch correction request.
+func (h *PunchCorrectionHandler) RejectPunchCorrection(c *gin.Context) {
+	h.reviewPunchCorrection(c, false)
+}
+
+func (h *PunchCorrectionHandler) reviewPunchCorrection(c *gin.Context, approve bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid punch correction request ID"})
+		return
+	}
+
+	var payload struct {
+		Comment string `json:"comment"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&payload); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+			return
+		}
+	}
+
+	if approve {
+		approval, err := h.punchCorrectionService.ApprovePunchCorrection(c.Request.Context(), uint(id), currentUserID(c), payload.Comment, currentOrgScope(c))
+		if err != nil {
+			c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+		manager.broadcast <- []byte("UPDATE_PUNCH_CORRECTION")
+		c.JSON(http.StatusOK, gin.H{"data": approval, "message": "Punch correction request " + models.PunchCorrectionApproved})
+		return
+	}
+
+	correction, err := h.punchCorrectionService.RejectPunchCorrection(c.Request.Context(), uint(id), currentUserID(c), payload.Comment, currentOrgScope(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	manager.broadcast <- []byte("UPDATE_PUNCH_CORRECTION")
+	c.JSON(http.StatusOK, gin.H{"data": correction, "message": "Punch correction request " + correction.Status})
+}
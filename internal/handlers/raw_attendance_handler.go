@@ -60,19 +60,20 @@ func transformRawAttendance(ra *models.RawAttendance) types.RawAttendanceRespons
 	userID := ra.UserID
 
 	return types.RawAttendanceResponse{
-		ID:           ra.ID,
-		CreatedAt:    &createdAt,
-		UpdatedAt:    &updatedAt,
-		WorkDayID:    &workDayID,
-		CompanyID:    &companyID,
-		UserID:       &userID,
-		EmployeeName: employeeName,
-		Position:     position,
-		StartAt:      startAt,
-		EndAt:        endAt,
-		TotalHours:   totalHours,
-		Status:       status,
-		Notes:        notes,
+		ID:            ra.ID,
+		CreatedAt:     &createdAt,
+		UpdatedAt:     &updatedAt,
+		WorkDayID:     &workDayID,
+		CompanyID:     &companyID,
+		UserID:        &userID,
+		EmployeeName:  employeeName,
+		Position:      position,
+		StartAt:       startAt,
+		EndAt:         endAt,
+		TotalHours:    totalHours,
+		Status:        status,
+		Notes:         notes,
+		Justification: ra.Justification,
 	}
 }
 
@@ -90,7 +91,7 @@ func (h *RawAttendanceHandler) CreateRawAttendance(c *gin.Context) {
 	}
 
 	if err := h.rawAttendanceService.CreateRawAttendance(c.Request.Context(), &rawAttendance); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	response := transformRawAttendance(&rawAttendance)
@@ -110,9 +111,19 @@ func (h *RawAttendanceHandler) GetRawAttendanceByID(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	if rawAttendance == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "raw attendance not found"})
+		return
+	}
+	version, err := h.rawAttendanceService.GetCurrentVersion(c.Request.Context(), rawAttendance.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	response := transformRawAttendance(rawAttendance)
-	c.JSON(http.StatusCreated, response)
+	response.Version = &version
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *RawAttendanceHandler) GetRawAttendancesByCompanyAndWorkDay(c *gin.Context) {
@@ -128,7 +139,7 @@ func (h *RawAttendanceHandler) GetRawAttendancesByCompanyAndWorkDay(c *gin.Conte
 		return
 	}
 
-	rawAttendances, err := h.rawAttendanceService.GetRawAttendancesByCompanyIDAndWorkDay(c.Request.Context(), uint(companyID), uint(workDayID))
+	rawAttendances, err := h.rawAttendanceService.GetRawAttendancesByCompanyIDAndWorkDay(c.Request.Context(), uint(companyID), uint(workDayID), currentOrgScope(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -171,7 +182,7 @@ func (h *RawAttendanceHandler) UpdateRawAttendance(c *gin.Context) {
 	}
 
 	if err := h.rawAttendanceService.UpdateRawAttendance(c.Request.Context(), &rawAttendance, uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -187,7 +198,7 @@ func (h *RawAttendanceHandler) DeleteRawAttendance(c *gin.Context) {
 	}
 
 	if err := h.rawAttendanceService.DeleteRawAttendance(c.Request.Context(), uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -195,7 +206,7 @@ func (h *RawAttendanceHandler) DeleteRawAttendance(c *gin.Context) {
 }
 
 func (h *RawAttendanceHandler) ListRawAttendances(c *gin.Context) {
-	rawAttendances, err := h.rawAttendanceService.ListRawAttendances(c.Request.Context())
+	rawAttendances, err := h.rawAttendanceService.ListRawAttendances(c.Request.Context(), currentOrgScope(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -203,3 +214,76 @@ func (h *RawAttendanceHandler) ListRawAttendances(c *gin.Context) {
 
 	c.JSON(http.StatusOK, rawAttendances)
 }
+
+// ListRawAttendanceVersions retrieves the versions of a raw attendance, oldest first.
+func (h *RawAttendanceHandler) ListRawAttendanceVersions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	versions, err := h.rawAttendanceService.ListVersions(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
+
+// DiffRawAttendanceVersions compares the versions given by the from and to query parameters.
+func (h *RawAttendanceHandler) DiffRawAttendanceVersions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	from, err := strconv.ParseUint(c.Query("from"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from version"})
+		return
+	}
+	to, err := strconv.ParseUint(c.Query("to"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to version"})
+		return
+	}
+
+	diff, err := h.rawAttendanceService.DiffVersions(c.Request.Context(), uint(id), uint(from), uint(to))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": diff})
+}
+
+// RevertRawAttendance restores the values of a version of a raw attendance.
+func (h *RawAttendanceHandler) RevertRawAttendance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	version, err := strconv.ParseUint(c.Param("version"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	rawAttendance, err := h.rawAttendanceService.RevertToVersion(c.Request.Context(), uint(id), uint(version))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	current, err := h.rawAttendanceService.GetCurrentVersion(c.Request.Context(), rawAttendance.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := transformRawAttendance(rawAttendance)
+	response.Version = &current
+	c.JSON(http.StatusOK, gin.H{"data": response, "message": "Raw attendance reverted successfully"})
+}
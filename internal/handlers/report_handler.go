@@ -1,12 +1,13 @@
 package handlers
 
 import (
-	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"point-system-api/internal/services"
+	"point-system-api/internal/types"
 
 	"github.com/gin-gonic/gin"
 )
@@ -45,7 +46,11 @@ func (h *ReportHandler) GenerateReport(c *gin.Context) {
 	}
 
 	// Generate report
-	report, err := h.reportService.GenerateReport(context.Background(), uint(companyIDUint), startDate, endDate)
+	report, err := h.reportService.GenerateReport(c.Request.Context(), uint(companyIDUint), startDate, endDate, currentOrgScope(c))
+	if errors.Is(err, types.ErrOutsideTenant) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate report"})
 		return
@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/services"
+	"point-system-api/internal/types"
+)
+
+// maxSelfServiceRange bounds the date ranges of the self-service, about a quarter.
+const maxSelfServiceRange = 93
+
+// SelfServiceHandler handles the HTTP requests of employees on their own data. The employee is
+// always the one of the authenticated user.
+type SelfServiceHandler struct {
+	selfService services.SelfService
+}
+
+// NewSelfServiceHandler creates a new instance of SelfServiceHandler.
+func NewSelfServiceHandler(selfService services.SelfService) *SelfServiceHandler {
+	return &SelfServiceHandler{
+		selfService: selfService,
+	}
+}
+
+// GetProfile retrieves the employee record, account and company of the user.
+func (h *SelfServiceHandler) GetProfile(c *gin.Context) {
+	profile, err := h.selfService.GetProfile(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+// ListPunches retrieves the punches of the user between the from and to dates.
+func (h *SelfServiceHandler) ListPunches(c *gin.Context) {
+	from, to, ok := dateRangeParams(c)
+	if !ok {
+		return
+	}
+
+	punches, err := h.selfService.ListPunches(c.Request.Context(), currentUserID(c), from, to)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": punches})
+}
+
+// GetAttendance retrieves the daily attendances of the user between the from and to dates, and
+// their totals.
+func (h *SelfServiceHandler) GetAttendance(c *gin.Context) {
+	from, to, ok := dateRangeParams(c)
+	if !ok {
+		return
+	}
+
+	attendance, err := h.selfService.GetAttendance(c.Request.Context(), currentUserID(c), from, to)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": attendance})
+}
+
+// GetSchedule retrieves the schedule of the user between the from and to dates.
+func (h *SelfServiceHandler) GetSchedule(c *gin.Context) {
+	from, to, ok := dateRangeParams(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.selfService.GetSchedule(c.Request.Context(), currentUserID(c), from, to)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedule})
+}
+
+// GetLeaveBalances retrieves the leave balances of the user, at today or at the as_of date.
+func (h *SelfServiceHandler) GetLeaveBalances(c *gin.Context) {
+	asOf, ok := asOfParam(c)
+	if !ok {
+		return
+	}
+
+	balances, err := h.selfService.GetLeaveBalances(c.Request.Context(), currentUserID(c), asOf)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": balances})
+}
+
+// ListLeaveRequests retrieves the leave requests of the user.
+func (h *SelfServiceHandler) ListLeaveRequests(c *gin.Context) {
+	requests, err := h.selfService.ListLeaveRequests(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+// CreateLeaveRequest handles the submission of a leave request by the user.
+func (h *SelfServiceHandler) CreateLeaveRequest(c *gin.Context) {
+	var request models.LeaveRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.selfService.SubmitLeaveRequest(c.Request.Context(), currentUserID(c), &request); err != nil {
+		// Leave requests are rejected with plain errors, as by the leave routes
+		status := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_LEAVE_REQUEST")
+	c.JSON(http.StatusCreated, gin.H{"data": request, "message": "Leave request submitted successfully"})
+}
+
+// ListPunchCorrections retrieves the punch correction requests of the user.
+func (h *SelfServiceHandler) ListPunchCorrections(c *gin.Context) {
+	corrections, err := h.selfService.ListPunchCorrections(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": corrections})
+}
+
+// CreatePunchCorrection handles the submission of a punch correction request by the user.
+func (h *SelfServiceHandler) CreatePunchCorrection(c *gin.Context) {
+	var submission types.PunchCorrectionSubmission
+	if err := c.ShouldBindJSON(&submission); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	correction, err := h.selfService.SubmitPunchCorrection(c.Request.Context(), currentUserID(c), submission)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_PUNCH_CORRECTION")
+	c.JSON(http.StatusCreated, gin.H{"data": correction, "message": "Punch correction submitted successfully"})
+}
+
+// AttachPunchCorrectionDocument handles the upload of a document backing a pending punch
+// correction request of the user, sent as multipart form data with a file and an optional
+// description.
+func (h *SelfServiceHandler) AttachPunchCorrectionDocument(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid punch correction request ID"})
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unable to read the uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	document := models.Document{
+		FileName:    fileHeader.Filename,
+		Description: c.PostForm("description"),
+	}
+	if err := h.selfService.AttachPunchCorrectionDocument(c.Request.Context(), currentUserID(c), uint(id), &document, file); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_DOCUMENT")
+	c.JSON(http.StatusCreated, gin.H{"data": document, "message": "Document uploaded successfully"})
+}
+
+// dateRangeParams reads the from and to dates of a query, defaulting to the current month up to
+// today. It responds with 400 and returns false when they are invalid.
+func dateRangeParams(c *gin.Context) (time.Time, time.Time, bool) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from := today.AddDate(0, 0, 1-today.Day())
+	to := today
+
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		if value := c.Query(param.name); value != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param.name + " date, expected YYYY-MM-DD"})
+				return time.Time{}, time.Time{}, false
+			}
+			*param.value = parsed
+		}
+	}
+
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The to date must not be before the from date"})
+		return time.Time{}, time.Time{}, false
+	}
+	if to.After(from.AddDate(0, 0, maxSelfServiceRange)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The date range must not exceed 93 days"})
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"point-system-api/internal/database"
+	"point-system-api/internal/services"
+	"point-system-api/internal/types"
 
 	"github.com/gin-gonic/gin"
 )
@@ -46,3 +49,51 @@ func HealthHandler(dbService database.Service) gin.HandlerFunc {
 		c.JSON(http.StatusOK, healthStatus)
 	}
 }
+
+// errorStatus maps service errors to an HTTP status code, defaulting to 500.
+func errorStatus(err error) int {
+	if errors.Is(err, services.ErrPeriodClosed) || errors.Is(err, services.ErrWorkDayLocked) ||
+		errors.Is(err, services.ErrTwoFactorConflict) || errors.Is(err, services.ErrDevicePunch) {
+		return http.StatusConflict
+	}
+	if errors.Is(err, services.ErrInvalidDocument) || errors.Is(err, services.ErrInvalidImport) ||
+		errors.Is(err, services.ErrInvalidDeviceIdentity) || errors.Is(err, services.ErrInvalidEmploymentChange) ||
+		errors.Is(err, services.ErrInvalidOrganization) || errors.Is(err, services.ErrWeakPassword) ||
+		errors.Is(err, services.ErrInvalidResetToken) || errors.Is(err, services.ErrInvalidRole) ||
+		errors.Is(err, services.ErrInvalidServiceAccount) || errors.Is(err, services.ErrExternalPassword) ||
+		errors.Is(err, services.ErrInvalidAuthProvider) || errors.Is(err, services.ErrInvalidPunchCorrection) ||
+		errors.Is(err, services.ErrInvalidPunch) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, services.ErrInvalidCredentials) || errors.Is(err, services.ErrInvalidTwoFactorCode) ||
+		errors.Is(err, services.ErrInvalidPreAuthToken) || errors.Is(err, services.ErrInvalidOIDCLogin) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, types.ErrOutsideTenant) || errors.Is(err, services.ErrNoEmployeeRecord) ||
+		errors.Is(err, services.ErrPunchNotFound) || errors.Is(err, services.ErrPunchCorrectionNotFound) ||
+		errors.Is(err, services.ErrRawAttendanceVersionNotFound) || errors.Is(err, services.ErrWorkDayNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// currentUserID returns the ID of the authenticated user set by the auth middleware, or 0.
+func currentUserID(c *gin.Context) uint {
+	if userID, ok := c.Get("userID"); ok {
+		if id, ok := userID.(uint); ok {
+			return id
+		}
+	}
+	return 0
+}
+
+// currentOrgScope returns the departments and teams set by the org scope middleware, or nil when
+// the request is not restricted.
+func currentOrgScope(c *gin.Context) *types.OrgScope {
+	if scope, ok := c.Get("orgScope"); ok {
+		if orgScope, ok := scope.(*types.OrgScope); ok {
+			return orgScope
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/services"
+	"point-system-api/internal/types"
+)
+
+// UnmatchedPunchHandler handles HTTP requests for punches not linked to any employee.
+type UnmatchedPunchHandler struct {
+	unmatchedPunchService services.UnmatchedPunchService
+}
+
+// NewUnmatchedPunchHandler creates a new instance of UnmatchedPunchHandler.
+func NewUnmatchedPunchHandler(unmatchedPunchService services.UnmatchedPunchService) *UnmatchedPunchHandler {
+	return &UnmatchedPunchHandler{
+		unmatchedPunchService: unmatchedPunchService,
+	}
+}
+
+// ListUnmatchedPunches retrieves the unmatched punches grouped by device and device user ID,
+// optionally filtered by serial_number, device_user_id and company_id.
+func (h *UnmatchedPunchHandler) ListUnmatchedPunches(c *gin.Context) {
+	filters := map[string]interface{}{}
+	if serial := c.Query("serial_number"); serial != "" {
+		filters["serial_number"] = serial
+	}
+	if deviceUserID := c.Query("device_user_id"); deviceUserID != "" {
+		filters["device_user_id"] = deviceUserID
+	}
+	if companyID := c.Query("company_id"); companyID != "" {
+		companyIDInt, err := strconv.Atoi(companyID)
+		if err == nil {
+			filters["company_id"] = companyIDInt
+		}
+	}
+
+	groups, err := h.unmatchedPunchService.ListUnmatchedPunches(c.Request.Context(), filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+// AssignUnmatchedPunches links a group of unmatched punches to an existing employee.
+func (h *UnmatchedPunchHandler) AssignUnmatchedPunches(c *gin.Context) {
+	var request struct {
+		SerialNumber string `json:"serial_number" binding:"required"`
+		DeviceUserID string `json:"device_user_id" binding:"required"`
+		EmployeeID   uint   `json:"employee_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	reconciliation, err := h.unmatchedPunchService.AssignToEmployee(c.Request.Context(), request.SerialNumber, request.DeviceUserID, request.EmployeeID)
+	if err != nil {
+		c.JSON(unmatchedPunchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("ASSIGN_UNMATCHED_PUNCHES")
+	c.JSON(http.StatusOK, gin.H{"data": reconciliation, "message": "Punches assigned successfully"})
+}
+
+// CreateEmployeeFromUnmatchedPunches creates an employee from a group of unmatched punches.
+// The registration number defaults to the device user ID and the company to the device's.
+func (h *UnmatchedPunchHandler) CreateEmployeeFromUnmatchedPunches(c *gin.Context) {
+	var request struct {
+		SerialNumber       string          `json:"serial_number" binding:"required"`
+		DeviceUserID       string          `json:"device_user_id" binding:"required"`
+		RegistrationNumber string          `json:"RegistrationNumber"`
+		Qualification      string          `json:"Qualification"`
+		CompanyID          uint            `json:"CompanyID"`
+		StartHour          string          `json:"StartHour"`
+		EndHour            string          `json:"EndHour"`
+		HireDate           *types.DateOnly `json:"HireDate"`
+		FirstName          string          `json:"firstName"`
+		LastName           string          `json:"lastName"`
+		Username           string          `json:"username"`
+		Password           string          `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	employee := models.Employee{
+		RegistrationNumber: request.RegistrationNumber,
+		Qualification:      request.Qualification,
+		CompanyID:          request.CompanyID,
+		StartHour:          request.StartHour,
+		EndHour:            request.EndHour,
+		HireDate:           request.HireDate,
+	}
+	user := models.User{
+		FirstName: request.FirstName,
+		LastName:  request.LastName,
+		Username:  request.Username,
+		Password:  request.Password,
+	}
+
+	reconciliation, err := h.unmatchedPunchService.CreateEmployeeFromGroup(c.Request.Context(), request.SerialNumber, request.DeviceUserID, employee, user)
+	if err != nil {
+		c.JSON(unmatchedPunchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("CREATE_EMPLOYEE")
+	manager.broadcast <- []byte("ASSIGN_UNMATCHED_PUNCHES")
+	c.JSON(http.StatusCreated, gin.H{"data": reconciliation, "message": "Employee created and punches assigned successfully"})
+}
+
+// unmatchedPunchErrorStatus maps reconciliation errors to an HTTP status code.
+func unmatchedPunchErrorStatus(err error) int {
+	if errors.Is(err, services.ErrUnmatchedGroupNotFound) {
+		return http.StatusNotFound
+	}
+	if errors.Is(err, services.ErrInvalidReconciliation) {
+		return http.StatusBadRequest
+	}
+	return errorStatus(err)
+}
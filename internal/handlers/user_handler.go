@@ -8,7 +8,6 @@ import (
 
 	"point-system-api/internal/models"
 	"point-system-api/internal/services"
-	"point-system-api/pkg/utils"
 )
 
 // UserHandler handles HTTP requests for user-related operations.
@@ -33,7 +32,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 
 	userID, err := h.userService.CreateUser(c.Request.Context(), user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -183,38 +182,107 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully", "id": userID})
 }
 
-// AuthenticateUser handles user authentication.
-func (h *UserHandler) AuthenticateUser(c *gin.Context) {
-	var credentials struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}
-	if err := c.ShouldBindJSON(&credentials); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+// ListUsersForSelect retrieves all users for use in select options.
+func (h *UserHandler) ListUsersForSelect(c *gin.Context) {
+	users, err := h.userService.ListUsersForSelect(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	user, err := h.userService.AuthenticateUser(c.Request.Context(), credentials.Username, credentials.Password)
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUserCompanies retrieves the companies a user may reach.
+func (h *UserHandler) GetUserCompanies(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
 	}
 
-	token, err := utils.GenerateToken(user.ID, user.Role)
+	companyIDs, err := h.userService.GetUserCompanyIDs(c.Request.Context(), uint(userID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"token": token})
+
+	c.JSON(http.StatusOK, gin.H{"data": companyIDs})
 }
 
-// ListUsersForSelect retrieves all users for use in select options.
-func (h *UserHandler) ListUsersForSelect(c *gin.Context) {
-	users, err := h.userService.ListUsersForSelect(c.Request.Context())
+// SetUserCompanies links a user to companies. The links take effect at their next login.
+func (h *UserHandler) SetUserCompanies(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var request struct {
+		CompanyIDs []uint `json:"company_ids"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	if err := h.userService.SetUserCompanies(c.Request.Context(), uint(userID), request.CompanyIDs); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	companyIDs, err := h.userService.GetUserCompanyIDs(c.Request.Context(), uint(userID))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	manager.broadcast <- []byte("UPDATE_USER_COMPANIES")
+	c.JSON(http.StatusOK, gin.H{"data": companyIDs, "message": "User companies updated successfully"})
+}
+
+// ChangeUserRole changes the role of a user.
+func (h *UserHandler) ChangeUserRole(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var request struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	success, err := h.userService.ChangeUserRole(c.Request.Context(), uint(userID), request.Role)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !success {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_USER")
+	c.JSON(http.StatusOK, gin.H{"message": "User role updated successfully"})
+}
+
+// SendPasswordReset sends a password reset token to a user, who then chooses their new password.
+func (h *UserHandler) SendPasswordReset(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.userService.SendPasswordReset(c.Request.Context(), uint(userID)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Password reset sent successfully"})
 }
@@ -8,6 +8,7 @@ import (
 
 	"point-system-api/internal/models"
 	"point-system-api/internal/services"
+	"point-system-api/internal/types"
 )
 
 // WorkDayHandler handles HTTP requests for workday-related operations.
@@ -31,7 +32,7 @@ func (h *WorkDayHandler) CreateWorkDay(c *gin.Context) {
 	}
 
 	if err := h.workDayService.CreateWorkDay(c.Request.Context(), &workday); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -94,7 +95,7 @@ func (h *WorkDayHandler) UpdateWorkDay(c *gin.Context) {
 
 	workday.ID = uint(id)
 	if err := h.workDayService.UpdateWorkDay(c.Request.Context(), &workday); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -115,10 +116,69 @@ func (h *WorkDayHandler) DeleteWorkDay(c *gin.Context) {
 	}
 
 	if err := h.workDayService.DeleteWorkDay(c.Request.Context(), uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	manager.broadcast <- []byte("DELETE_WORKDAY")
 	c.JSON(http.StatusOK, gin.H{"message": "Workday deleted successfully"})
 }
+
+// RegenerateWorkDay recomputes the raw attendances of a workday from the current punches.
+func (h *WorkDayHandler) RegenerateWorkDay(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workday ID"})
+		return
+	}
+
+	// The body is optional: without it every row of the workday is regenerated.
+	var opts types.RegenerateWorkDayOptions
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&opts); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+			return
+		}
+	}
+
+	diffs, err := h.workDayService.RegenerateWorkDay(c.Request.Context(), uint(id), opts)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("REGENERATE_WORKDAY")
+	c.JSON(http.StatusOK, gin.H{
+		"data":    diffs,
+		"message": "Workday regenerated successfully",
+	})
+}
+
+// TransitionWorkDay moves a workday to another lifecycle state (draft, under_review, approved, locked).
+func (h *WorkDayHandler) TransitionWorkDay(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workday ID"})
+		return
+	}
+
+	var request struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload " + err.Error()})
+		return
+	}
+
+	workday, err := h.workDayService.TransitionWorkDay(c.Request.Context(), uint(id), request.Status)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	manager.broadcast <- []byte("UPDATE_WORKDAY")
+	c.JSON(http.StatusOK, gin.H{
+		"data":    workday,
+		"message": "Workday status updated successfully",
+	})
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/rbac"
+	"point-system-api/internal/types"
+)
+
+// APIKeyAuthenticator authenticates the API keys of service accounts.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key, clientIP string) (*types.APIKeyPrincipal, error)
+}
+
+// APIKeyMiddleware authenticates the requests of service accounts, which send their API key in the
+// X-API-Key header or as a Bearer token. It sets the service account, its permissions and its
+// companies in the context, and AuthMiddleware lets the request through. Requests without an API
+// key are left to AuthMiddleware. The allow-lists of the accounts are checked against the client
+// address gin resolves from the trusted proxies of the router.
+func APIKeyMiddleware(authenticator APIKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && strings.HasPrefix(token, types.APIKeyPrefix) {
+			key = token
+		}
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		principal, err := authenticator.AuthenticateAPIKey(c.Request.Context(), key, c.ClientIP())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid, expired or revoked API key"})
+			return
+		}
+
+		permissions := make([]rbac.Permission, len(principal.Permissions))
+		for i, permission := range principal.Permissions {
+			permissions[i] = rbac.Permission(permission)
+		}
+		c.Set("serviceAccountID", principal.ServiceAccountID)
+		c.Set("apiKeyID", principal.APIKeyID)
+		c.Set("permissions", permissions)
+		c.Set("companyIDs", principal.CompanyIDs)
+		serviceAccountID := principal.ServiceAccountID
+		auditActor(c).ServiceAccountID = &serviceAccountID
+		c.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/types"
+)
+
+// AuditMiddleware names the actor of the changes of the request in the audit trail by its address.
+// It is registered once, before the authentication middlewares, which then name the user or
+// service account they authenticate.
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := &types.AuditActor{IPAddress: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+		c.Request = c.Request.WithContext(types.WithAuditActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
+// auditActor returns the audit actor set by AuditMiddleware, or a detached one when the request
+// is not audited.
+func auditActor(c *gin.Context) *types.AuditActor {
+	if actor := types.AuditActorFrom(c.Request.Context()); actor != nil {
+		return actor
+	}
+	return &types.AuditActor{}
+}
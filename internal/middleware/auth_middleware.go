@@ -2,16 +2,25 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"point-system-api/internal/rbac"
 	"point-system-api/pkg/utils"
 )
 
-// AuthMiddleware is a middleware that checks for a valid JWT token in the request header.
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware is a middleware that checks for a valid JWT token in the request header. Tokens
+// issued for a login step are refused, unless their purpose is one of purposes. Requests already
+// authenticated by APIKeyMiddleware pass through.
+func AuthMiddleware(purposes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if _, ok := c.Get("serviceAccountID"); ok {
+			c.Next()
+			return
+		}
+
 		// Get the Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -35,18 +44,26 @@ func AuthMiddleware() gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			return
 		}
+		if claims.Purpose != "" && !slices.Contains(purposes, claims.Purpose) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			return
+		}
 
-		// Set the user ID and role in the context
+		// Set the user ID, role and companies in the context
 		c.Set("userID", claims.UserID)
 		c.Set("userRole", claims.Role)
+		c.Set("companyIDs", claims.CompanyIDs)
+		c.Set("tokenPurpose", claims.Purpose)
+		userID := claims.UserID
+		auditActor(c).UserID = &userID
 
 		// Continue to the next handler
 		c.Next()
 	}
 }
 
-// RoleMiddleware is a middleware that checks if the user has the required role to access a route.
-func RoleMiddleware(requiredRole string) gin.HandlerFunc {
+// RoleMiddleware is a middleware that checks if the user has one of the required roles to access a route.
+func RoleMiddleware(requiredRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get the user role from the context
 		userRole, exists := c.Get("userRole")
@@ -55,13 +72,45 @@ func RoleMiddleware(requiredRole string) gin.HandlerFunc {
 			return
 		}
 
-		// Check if the user has the required role
-		if userRole != requiredRole {
+		// Check if the user has one of the required roles
+		for _, requiredRole := range requiredRoles {
+			if userRole == requiredRole {
+				// Continue to the next handler
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	}
+}
+
+// PermissionMiddleware is a middleware that checks if the role of the user, or the permissions of
+// the service account, grant the permission required to access a route.
+func PermissionMiddleware(permission rbac.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if granted, ok := c.Get("permissions"); ok {
+			permissions, _ := granted.([]rbac.Permission)
+			if !slices.Contains(permissions, permission) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		userRole, exists := c.Get("userRole")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User role not found"})
+			return
+		}
+
+		role, _ := userRole.(string)
+		if !rbac.HasPermission(role, permission) {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 			return
 		}
 
-		// Continue to the next handler
 		c.Next()
 	}
 }
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/rbac"
+	"point-system-api/internal/types"
+)
+
+// OrgScopeResolver resolves the departments and teams managed by a user.
+type OrgScopeResolver interface {
+	ManagerScope(ctx context.Context, userID uint) (*types.OrgScope, error)
+}
+
+// OrgScopeMiddleware stores in the context, under "orgScope", the departments and teams a listing
+// is restricted to. The department_id and team_id query parameters select them explicitly; without
+// them, managers are restricted to the subtree they manage and everyone else, including managers
+// who manage no department nor team yet, sees everything.
+func OrgScopeMiddleware(resolver OrgScopeResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		departmentID := c.Query("department_id")
+		teamID := c.Query("team_id")
+
+		if departmentID != "" || teamID != "" {
+			scope := &types.OrgScope{DepartmentIDs: []uint{}, TeamIDs: []uint{}}
+			if departmentID != "" {
+				id, err := strconv.ParseUint(departmentID, 10, 64)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid department ID"})
+					return
+				}
+				scope.DepartmentIDs = append(scope.DepartmentIDs, uint(id))
+			}
+			if teamID != "" {
+				id, err := strconv.ParseUint(teamID, 10, 64)
+				if err != nil {
+					c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+					return
+				}
+				scope.TeamIDs = append(scope.TeamIDs, uint(id))
+			}
+			c.Set("orgScope", scope)
+			c.Next()
+			return
+		}
+
+		setManagerScope(c, resolver)
+	}
+}
+
+// ManagerOrgScopeMiddleware stores in the context, under "orgScope", the subtree managed by
+// managers, as OrgScopeMiddleware does without query parameters. Routes acting on a single record
+// use it, so that a query parameter cannot reach the records of other departments.
+func ManagerOrgScopeMiddleware(resolver OrgScopeResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		setManagerScope(c, resolver)
+	}
+}
+
+// setManagerScope restricts managers who manage a department or a team to their subtree.
+func setManagerScope(c *gin.Context, resolver OrgScopeResolver) {
+	userID, _ := c.Get("userID")
+	role, _ := c.Get("userRole")
+	if id, ok := userID.(uint); ok && role == rbac.RoleManager {
+		scope, err := resolver.ManagerScope(c.Request.Context(), id)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(scope.DepartmentIDs) > 0 || len(scope.TeamIDs) > 0 {
+			c.Set("orgScope", scope)
+		}
+	}
+	c.Next()
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/rbac"
+	"point-system-api/internal/types"
+)
+
+// TenantMiddleware restricts the request context to the companies of the token, so that the
+// repositories only reach their data. Super-admins and service accounts without a company scope
+// are not restricted. A company explicitly
+// requested through the companyID or companyId path parameter or the company_id query parameter
+// outside of the scope answers 404, as if it did not exist, and is logged.
+func TenantMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("userRole")
+		_, serviceAccount := c.Get("serviceAccountID")
+		companyIDs, _ := c.Get("companyIDs")
+		scope := &types.TenantScope{}
+		scope.CompanyIDs, _ = companyIDs.([]uint)
+		if role == rbac.RoleSuperAdmin || (serviceAccount && len(scope.CompanyIDs) == 0) {
+			c.Next()
+			return
+		}
+
+		userID, _ := c.Get("userID")
+		scope.UserID, _ = userID.(uint)
+
+		for _, value := range []string{c.Param("companyID"), c.Param("companyId"), c.Query("company_id")} {
+			if value == "" {
+				continue
+			}
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				continue // Left to the handler to reject
+			}
+			if !scope.Allows(uint(id)) {
+				slog.Warn("cross-tenant access attempt", "user_id", scope.UserID, "company_id", id, "path", c.Request.URL.Path)
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not found"})
+				return
+			}
+		}
+
+		c.Request = c.Request.WithContext(types.WithTenantScope(c.Request.Context(), scope))
+		c.Next()
+	}
+}
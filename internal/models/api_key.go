@@ -0,0 +1,65 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ServiceAccount is the identity of a machine integration, such as the payroll exporter or a
+// device gateway. It authenticates with API keys and is granted Permissions, a comma-separated
+// list, instead of a role. CompanyID restricts it to one company; AllowedIPs, a comma-separated
+// list of addresses and CIDR ranges, restricts the clients using its keys.
+type ServiceAccount struct {
+	gorm.Model
+	Name        string `gorm:"size:255;not null;uniqueIndex" json:"name"`
+	Description string `gorm:"size:500" json:"description"`
+	Permissions string `gorm:"size:2000;not null" json:"permissions"`
+	CompanyID   *uint  `gorm:"index" json:"company_id"` // Nil for every company
+	AllowedIPs  string `gorm:"size:2000" json:"allowed_ips"`
+	CreatedBy   uint   `json:"created_by"`
+}
+
+// PermissionList returns the permissions of the account.
+func (a *ServiceAccount) PermissionList() []string {
+	return splitList(a.Permissions)
+}
+
+// AllowedIPList returns the addresses and ranges allowed to use the keys of the account, empty
+// when any client is.
+func (a *ServiceAccount) AllowedIPList() []string {
+	return splitList(a.AllowedIPs)
+}
+
+// APIKey is a long-lived key of a service account. Only the SHA-256 hash of the key is stored;
+// Prefix, its first characters, identifies it in listings.
+type APIKey struct {
+	gorm.Model
+	ServiceAccountID uint           `gorm:"not null;index" json:"service_account_id"`
+	ServiceAccount   ServiceAccount `gorm:"foreignKey:ServiceAccountID" json:"-"`
+	Name             string         `gorm:"size:255" json:"name"`
+	Prefix           string         `gorm:"size:16;not null" json:"prefix"`
+	KeyHash          string         `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt        *time.Time     `json:"expires_at"` // Nil for keys that do not expire
+	LastUsedAt       *time.Time     `json:"last_used_at"`
+	LastUsedIP       string         `gorm:"size:45" json:"last_used_ip"`
+	RevokedAt        *time.Time     `json:"revoked_at"`
+	CreatedBy        uint           `json:"created_by"`
+}
+
+// IsActive reports whether the key may still authenticate.
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// splitList splits a comma-separated list, dropping blanks.
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -6,14 +6,41 @@ import (
 	"gorm.io/gorm"
 )
 
+// Sources of punches.
+const (
+	PunchSourceDevice = "device" // Pushed by a device, never deleted
+	PunchSourceManual = "manual" // Entered by a supervisor
+	PunchSourceImport = "import"
+	PunchSourceMobile = "mobile"
+)
+
 // Define the database model for attendance logs
 type AttendanceLog struct {
 	gorm.Model
-	SerialNumber string    `json:"serial_number"` // Serial number of the device
-	UID          uint16    `json:"uid"`           // User ID (unsigned short)
-	UserID       int       `json:"user_id"`       // User ID as an integer
-	Status       uint8     `json:"status"`        // Status of the attendance record
-	Punch        uint8     `json:"punch"`         // Punch type (e.g., check-in, check-out)
-	SystemPunch  string    `json:"system_punch"`  // System punch type
-	Timestamp    time.Time `json:"timestamp"`     // Timestamp of the attendance record
+	SerialNumber string    `json:"serial_number"`                       // Serial number of the device
+	UID          uint16    `json:"uid"`                                 // User ID (unsigned short)
+	UserID       int       `json:"user_id"`                             // User ID as an integer, 0 when the device ID is not numeric
+	DeviceUserID string    `gorm:"size:50;index" json:"device_user_id"` // User ID as enrolled on the device
+	EmployeeID   *uint     `gorm:"index" json:"employee_id"`            // Employee resolved through the device identities, nil when unmatched
+	Status       uint8     `json:"status"`                              // Status of the attendance record
+	Punch        uint8     `json:"punch"`                               // Punch type (e.g., check-in, check-out)
+	SystemPunch  string    `json:"system_punch"`                        // System punch type
+	Timestamp    time.Time `json:"timestamp"`                           // Timestamp of the attendance record
+	// Source is where the punch comes from: device, manual, import or mobile.
+	Source     string `gorm:"size:20;not null;default:device;index" json:"source"`
+	Reason     string `gorm:"size:500" json:"reason"`   // Why a punch was entered by hand
+	CreatedBy  *uint  `json:"created_by"`               // User who entered the punch, nil for device punches
+	CorrectsID *uint  `gorm:"index" json:"corrects_id"` // Punch voided and replaced by this one
+	// A voided punch is kept but no longer counts: it is left out of the classification and of
+	// the raw attendances.
+	VoidedAt   *time.Time `gorm:"index" json:"voided_at"`
+	VoidedBy   *uint      `json:"voided_by"`
+	VoidReason string     `gorm:"size:500" json:"void_reason"`
+	// PunchCorrectionID is the approved request of the employee that entered or voided the punch.
+	PunchCorrectionID *uint `gorm:"index" json:"punch_correction_id"`
+}
+
+// IsVoided reports whether the punch was voided.
+func (a *AttendanceLog) IsVoided() bool {
+	return a.VoidedAt != nil
 }
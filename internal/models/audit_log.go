@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"point-system-api/internal/types"
+)
+
+// Audit log actions.
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// AuditLog records a change of a user, employee, company, device, workday, raw attendance or punch:
+// who made it, from where, and the columns it changed. Audit logs are never updated nor deleted,
+// hence they have no UpdatedAt nor DeletedAt.
+type AuditLog struct {
+	ID                    uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt             time.Time `gorm:"index" json:"created_at"`
+	ActorUserID           *uint     `gorm:"index" json:"actor_user_id"` // Nil with ActorServiceAccountID for changes of the system
+	ActorServiceAccountID *uint     `gorm:"index" json:"actor_service_account_id"`
+	IPAddress             string    `gorm:"size:45" json:"ip_address"`
+	UserAgent             string    `gorm:"size:255" json:"user_agent"`
+	// EntityType is user, employee, company, device, workday, raw_attendance or attendance_log.
+	EntityType string             `gorm:"size:50;not null;index:idx_audit_entity" json:"entity_type"`
+	EntityID   uint               `gorm:"not null;index:idx_audit_entity" json:"entity_id"`
+	Action     string             `gorm:"size:20;not null" json:"action"` // create, update, delete
+	Changes    types.AuditChanges `gorm:"type:text" json:"changes"`
+	// CompanyID is the company of the entity, nil for the users and workdays shared by every
+	// company, whose audit logs only super-admins see.
+	CompanyID *uint `gorm:"index" json:"company_id"`
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a long-lived token exchanged for new access tokens. Only the SHA-256 hash of
+// the token is stored. Each refresh revokes the token and issues the next one of the family.
+type RefreshToken struct {
+	gorm.Model
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	FamilyID   string     `gorm:"size:64;not null;index" json:"-"` // Tokens issued from the same login
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`
+	IPAddress  string     `gorm:"size:45" json:"ip_address"`
+	ReplacedBy *uint      `json:"replaced_by"` // Token issued when this one was refreshed
+}
+
+// IsActive reports whether the token may still be exchanged.
+func (t *RefreshToken) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// PasswordHistory keeps the hashes of the passwords a user had, so that they are not reused.
+type PasswordHistory struct {
+	gorm.Model
+	UserID       uint   `gorm:"not null;index" json:"user_id"`
+	PasswordHash string `gorm:"not null" json:"-"`
+}
+
+// PasswordResetToken lets a user set a new password without the current one. Only the SHA-256
+// hash of the token is stored, and it can be used once before it expires.
+type PasswordResetToken struct {
+	gorm.Model
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// LoginAttempt records a login, successful or not, for the login history of a user.
+type LoginAttempt struct {
+	gorm.Model
+	UserID    *uint  `gorm:"index" json:"user_id"` // Nil when the username matches no user
+	Username  string `gorm:"size:255;not null;index" json:"username"`
+	IPAddress string `gorm:"size:45;index" json:"ip_address"`
+	UserAgent string `gorm:"size:255" json:"user_agent"`
+	Success   bool   `gorm:"not null" json:"success"`
+	Reason    string `gorm:"size:50" json:"reason"` // Why a login failed: invalid_credentials, throttled
+}
+
+// LoginThrottle counts the consecutive failed logins of a username or an IP address, named by Key
+// ("user:<username>" or "ip:<address>"), to slow down and lock out password guessing.
+type LoginThrottle struct {
+	gorm.Model
+	Key           string     `gorm:"size:300;not null;uniqueIndex" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+// TwoFactor holds the TOTP secret of a user. A new secret stays pending until the user proves
+// their authenticator app holds it by entering a code, which enables the second factor.
+type TwoFactor struct {
+	gorm.Model
+	UserID       uint       `gorm:"not null;uniqueIndex" json:"user_id"`
+	Secret       string     `gorm:"size:64;not null" json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at"`
+	LastUsedStep int64      `gorm:"not null;default:0" json:"-"` // Time step of the last accepted code, which cannot be replayed
+}
+
+// IsEnabled reports whether logins of the user require a second factor.
+func (t *TwoFactor) IsEnabled() bool {
+	return t != nil && t.EnabledAt != nil
+}
+
+// RecoveryCode replaces a TOTP code once, when the authenticator app is lost. Only the bcrypt hash
+// of the code is stored.
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash string     `gorm:"size:64;not null" json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// OIDCLoginState binds the callback of a single sign-on login to its start: the state sent to the
+// identity provider, only its SHA-256 hash stored, and the PKCE verifier and nonce kept here.
+type OIDCLoginState struct {
+	gorm.Model
+	StateHash    string    `gorm:"size:64;not null;uniqueIndex"`
+	CodeVerifier string    `gorm:"size:128;not null"`
+	Nonce        string    `gorm:"size:64;not null"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+}
+
+// ExternalIdentity links the subject of an identity provider to the account of a user, who then
+// logs in with single sign-on.
+type ExternalIdentity struct {
+	gorm.Model
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	Issuer      string     `gorm:"size:255;not null;uniqueIndex:idx_external_identity" json:"issuer"`
+	Subject     string     `gorm:"size:255;not null;uniqueIndex:idx_external_identity" json:"subject"`
+	Email       string     `gorm:"size:255" json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
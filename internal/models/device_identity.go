@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// Device identity sources.
+const (
+	DeviceIdentitySourceManual       = "manual"
+	DeviceIdentitySourceRegistration = "registration_number"
+)
+
+// DeviceIdentity maps the user ID an employee is enrolled under on a terminal to the employee.
+// A mapping applies either to a single device or to every device of a company, between
+// EffectiveFrom and EffectiveTo (inclusive, open-ended when nil).
+type DeviceIdentity struct {
+	gorm.Model
+	DeviceID      *uint           `gorm:"index" json:"device_id"`
+	CompanyID     *uint           `gorm:"index" json:"company_id"`
+	DeviceUserID  string          `gorm:"size:50;not null;index" json:"device_user_id"`
+	EmployeeID    uint            `gorm:"not null;index" json:"employee_id"`
+	EffectiveFrom types.DateOnly  `gorm:"type:date;not null" json:"effective_from"`
+	EffectiveTo   *types.DateOnly `gorm:"type:date" json:"effective_to"`
+	Source        string          `gorm:"size:30;not null;default:manual" json:"source"` // manual, registration_number
+}
+
+// CoversDate reports whether the mapping is effective on the day of t.
+func (d *DeviceIdentity) CoversDate(t time.Time) bool {
+	return effectiveOn(t, d.EffectiveFrom, d.EffectiveTo)
+}
+
+// ResolveDeviceIdentity picks the mapping of a device user ID for a punch on a device at t,
+// among the mappings of that device user ID. A mapping of the device itself wins over a mapping
+// of its company. Punches of a device not assigned to a company use the company mappings only
+// when they all point to the same employee. device may be nil for unknown terminals.
+func ResolveDeviceIdentity(identities []DeviceIdentity, device *Device, t time.Time) *DeviceIdentity {
+	var companyMatch *DeviceIdentity
+	var unassigned []*DeviceIdentity
+	for i := range identities {
+		identity := &identities[i]
+		if !identity.CoversDate(t) {
+			continue
+		}
+		switch {
+		case identity.DeviceID != nil:
+			if device != nil && *identity.DeviceID == device.ID {
+				return identity
+			}
+		case identity.CompanyID != nil:
+			if device != nil && device.CompanyID != 0 {
+				if *identity.CompanyID == device.CompanyID {
+					companyMatch = identity
+				}
+			} else {
+				unassigned = append(unassigned, identity)
+			}
+		}
+	}
+	if companyMatch != nil {
+		return companyMatch
+	}
+	if len(unassigned) == 0 {
+		return nil
+	}
+	for _, identity := range unassigned[1:] {
+		if identity.EmployeeID != unassigned[0].EmployeeID {
+			return nil // Ambiguous: the same ID is enrolled for different employees in different companies
+		}
+	}
+	return unassigned[0]
+}
+
+// effectiveOn reports whether the day of t lies between from and to (inclusive, open-ended when
+// to is nil).
+func effectiveOn(t time.Time, from types.DateOnly, to *types.DateOnly) bool {
+	day := t.Format("2006-01-02")
+	if day < from.ToTime().Format("2006-01-02") {
+		return false
+	}
+	return to == nil || day <= to.ToTime().Format("2006-01-02")
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+func TestResolveDeviceIdentity(t *testing.T) {
+	day := func(s string) types.DateOnly {
+		d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+		return types.DateOnly(d)
+	}
+	uintPtr := func(v uint) *uint { return &v }
+	endOfMarch := day("2024-03-31")
+
+	identities := []DeviceIdentity{
+		{Model: gorm.Model{ID: 1}, CompanyID: uintPtr(1), DeviceUserID: "007", EmployeeID: 10, EffectiveFrom: day("2024-01-01"), EffectiveTo: &endOfMarch},
+		{Model: gorm.Model{ID: 2}, CompanyID: uintPtr(1), DeviceUserID: "007", EmployeeID: 11, EffectiveFrom: day("2024-04-01")},
+		{Model: gorm.Model{ID: 3}, DeviceID: uintPtr(5), DeviceUserID: "007", EmployeeID: 12, EffectiveFrom: day("2024-01-01")},
+		{Model: gorm.Model{ID: 4}, CompanyID: uintPtr(2), DeviceUserID: "007", EmployeeID: 13, EffectiveFrom: day("2024-01-01")},
+	}
+	at := func(s string) time.Time { return day(s).ToTime().Add(9 * time.Hour) }
+
+	tests := []struct {
+		name   string
+		device *Device
+		t      time.Time
+		want   uint // expected employee, 0 for none
+	}{
+		{"company mapping before its end", &Device{Model: gorm.Model{ID: 4}, CompanyID: 1}, at("2024-03-31"), 10},
+		{"company mapping after the reassignment", &Device{Model: gorm.Model{ID: 4}, CompanyID: 1}, at("2024-04-01"), 11},
+		{"device mapping wins over company", &Device{Model: gorm.Model{ID: 5}, CompanyID: 1}, at("2024-04-01"), 12},
+		{"other company", &Device{Model: gorm.Model{ID: 6}, CompanyID: 2}, at("2024-04-01"), 13},
+		{"before every mapping", &Device{Model: gorm.Model{ID: 4}, CompanyID: 1}, at("2023-12-31"), 0},
+		{"unassigned device is ambiguous", &Device{Model: gorm.Model{ID: 7}}, at("2024-04-01"), 0},
+		{"unknown device is ambiguous", nil, at("2024-04-01"), 0},
+	}
+	for _, tt := range tests {
+		got := ResolveDeviceIdentity(identities, tt.device, tt.t)
+		var gotEmployee uint
+		if got != nil {
+			gotEmployee = got.EmployeeID
+		}
+		if gotEmployee != tt.want {
+			t.Errorf("%s: got employee %d, want %d", tt.name, gotEmployee, tt.want)
+		}
+	}
+}
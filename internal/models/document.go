@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Entities a document can be attached to.
+const (
+	DocumentOwnerRawAttendance   = "raw_attendance"
+	DocumentOwnerLeaveRequest    = "leave_request"
+	DocumentOwnerEmployee        = "employee"
+	DocumentOwnerPunchCorrection = "punch_correction"
+)
+
+// Document review states.
+const (
+	DocumentStatusPending  = "pending"
+	DocumentStatusApproved = "approved"
+	DocumentStatusRejected = "rejected"
+)
+
+// Justification states of an absence.
+const (
+	AbsenceUnjustified = "unjustified"
+	AbsenceJustified   = "justified"
+)
+
+// Document is an uploaded file (medical certificate, ...) justifying an absence or kept on an employee's record.
+type Document struct {
+	gorm.Model
+	OwnerType     string     `gorm:"size:30;not null;index:idx_document_owner" json:"owner_type"` // raw_attendance, leave_request, employee, punch_correction
+	OwnerID       uint       `gorm:"not null;index:idx_document_owner" json:"owner_id"`
+	FileName      string     `gorm:"size:255;not null" json:"file_name"`
+	ContentType   string     `gorm:"size:100;not null" json:"content_type"`
+	Size          int64      `gorm:"not null" json:"size"`
+	StorageKey    string     `gorm:"size:255;not null" json:"-"`
+	Description   string     `gorm:"size:500" json:"description"`
+	UploadedBy    *uint      `json:"uploaded_by"`
+	Status        string     `gorm:"size:20;not null;default:pending" json:"status"` // pending, approved, rejected
+	ReviewedBy    *uint      `json:"reviewed_by"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+	ReviewComment string     `gorm:"size:500" json:"review_comment"`
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"point-system-api/internal/types"
+
 	"gorm.io/gorm"
 )
 
@@ -12,5 +14,8 @@ type Employee struct {
 	CompanyID          uint   `gorm:"not null"` // Foreign key to Company
 	StartHour          string
 	EndHour            string
+	HireDate           *types.DateOnly `gorm:"type:date"` // First day of employment; CreatedAt is used when unknown
+	TerminationDate    *types.DateOnly `gorm:"type:date"` // Last day of employment, nil while employed
+	TerminationReason  string          `gorm:"size:255"`
 	gorm.Model
 }
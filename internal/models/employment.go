@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// Employment assignment kinds.
+const (
+	EmploymentKindHire     = "hire"
+	EmploymentKindTransfer = "transfer"
+)
+
+// EmploymentAssignment is the company and position of an employee between EffectiveFrom and
+// EffectiveTo (inclusive, open-ended when nil). The assignments of an employee never overlap,
+// and an employee without an assignment covering a date was not employed on that date.
+type EmploymentAssignment struct {
+	gorm.Model
+	EmployeeID    uint            `gorm:"not null;index" json:"employee_id"`
+	CompanyID     uint            `gorm:"not null;index" json:"company_id"`
+	Qualification string          `gorm:"size:255" json:"qualification"`
+	EffectiveFrom types.DateOnly  `gorm:"type:date;not null;index" json:"effective_from"`
+	EffectiveTo   *types.DateOnly `gorm:"type:date;index" json:"effective_to"`
+	Kind          string          `gorm:"size:20;not null" json:"kind"` // hire, transfer
+	Reason        string          `gorm:"size:255" json:"reason"`
+	CreatedBy     *uint           `json:"created_by"`
+}
+
+// CoversDate reports whether the assignment is effective on the day of t.
+func (a *EmploymentAssignment) CoversDate(t time.Time) bool {
+	return effectiveOn(t, a.EffectiveFrom, a.EffectiveTo)
+}
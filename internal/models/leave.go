@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// Leave request states.
+const (
+	LeaveStatusPending   = "pending"
+	LeaveStatusApproved  = "approved"
+	LeaveStatusRejected  = "rejected"
+	LeaveStatusCancelled = "cancelled"
+)
+
+// Raw attendance statuses set by approved leave.
+const (
+	RawAttendanceStatusLeave        = "leave"
+	RawAttendanceStatusHalfDayLeave = "half-day-leave"
+)
+
+// LeaveType is a kind of leave offered by a company (paid, sick, unpaid, maternity, ...).
+type LeaveType struct {
+	gorm.Model
+	CompanyID uint   `gorm:"not null;index" json:"company_id"`
+	Name      string `gorm:"size:255;not null" json:"name"`
+	Code      string `gorm:"size:50;not null" json:"code"`
+	IsPaid    bool   `gorm:"not null;default:true" json:"is_paid"` // Whether leave days count as paid days in reports
+}
+
+// LeaveRequest is an employee's request for leave over a date range or a half-day.
+type LeaveRequest struct {
+	gorm.Model
+	EmployeeID    uint           `gorm:"not null;index" json:"employee_id"`
+	LeaveTypeID   uint           `gorm:"not null;index" json:"leave_type_id"`
+	StartDate     types.DateOnly `gorm:"type:date;not null" json:"start_date"`
+	EndDate       types.DateOnly `gorm:"type:date;not null" json:"end_date"`
+	HalfDay       string         `gorm:"size:20" json:"half_day"` // "", morning, afternoon (single-day requests only)
+	Days          float64        `gorm:"not null" json:"days"`    // Number of working days requested
+	Reason        string         `gorm:"size:500" json:"reason"`
+	Status        string         `gorm:"size:20;not null;default:pending" json:"status"` // pending, approved, rejected, cancelled
+	ReviewedBy    *uint          `json:"reviewed_by"`
+	ReviewedAt    *time.Time     `json:"reviewed_at"`
+	ReviewComment string         `gorm:"size:500" json:"review_comment"`
+}
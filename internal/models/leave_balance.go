@@ -0,0 +1,46 @@
+package models
+
+import (
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// Leave balance entry kinds recorded in the database. Accruals and usage are derived.
+const (
+	LeaveBalanceEntryAdjustment = "adjustment"
+	LeaveBalanceEntryRollover   = "rollover"
+)
+
+// LeaveAccrualPolicy describes how days of a leave type are earned and carried over.
+type LeaveAccrualPolicy struct {
+	gorm.Model
+	LeaveTypeID           uint                  `gorm:"not null;uniqueIndex" json:"leave_type_id"`
+	MonthlyAccrual        float64               `gorm:"not null;default:0" json:"monthly_accrual"`          // Days earned per month of service
+	CarryOverCap          *float64              `json:"carry_over_cap"`                                     // Max days carried into the next year, nil for unlimited
+	CarryOverExpiryMonths int                   `gorm:"not null;default:0" json:"carry_over_expiry_months"` // Months after Jan 1 before carried days expire, 0 for never
+	SeniorityBonuses      []LeaveSeniorityBonus `gorm:"foreignKey:PolicyID" json:"seniority_bonuses"`
+}
+
+// LeaveSeniorityBonus grants extra days per year once an employee reaches AfterYears of service.
+type LeaveSeniorityBonus struct {
+	gorm.Model
+	PolicyID         uint    `gorm:"not null;index" json:"policy_id"`
+	AfterYears       int     `gorm:"not null" json:"after_years"`
+	ExtraDaysPerYear float64 `gorm:"not null" json:"extra_days_per_year"`
+}
+
+// LeaveBalanceEntry is a stored movement of a leave balance: a manual adjustment or a year-end rollover.
+type LeaveBalanceEntry struct {
+	gorm.Model
+	EmployeeID  uint            `gorm:"not null;index" json:"employee_id"`
+	LeaveTypeID uint            `gorm:"not null;index" json:"leave_type_id"`
+	Date        types.DateOnly  `gorm:"type:date;not null" json:"date"`
+	Kind        string          `gorm:"size:20;not null" json:"kind"` // adjustment, rollover
+	Days        float64         `gorm:"not null" json:"days"`         // Signed change of the balance
+	CarriedDays float64         `json:"carried_days"`                 // Rollover only: days carried into the new year
+	ExpiresOn   *types.DateOnly `gorm:"type:date" json:"expires_on"`  // Rollover only: when carried days lapse
+	Year        int             `gorm:"index" json:"year"`            // Rollover only: the year that was closed
+	Reason      string          `gorm:"size:500" json:"reason"`
+	CreatedBy   *uint           `json:"created_by"`
+}
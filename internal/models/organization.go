@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// Department groups employees of a company under a manager.
+type Department struct {
+	gorm.Model
+	CompanyID uint   `gorm:"not null;index" json:"company_id"`
+	Name      string `gorm:"size:255;not null" json:"name"`
+	ManagerID *uint  `gorm:"index" json:"manager_id"` // User managing the department
+}
+
+// Team groups employees of a department under a manager.
+type Team struct {
+	gorm.Model
+	DepartmentID uint   `gorm:"not null;index" json:"department_id"`
+	Name         string `gorm:"size:255;not null" json:"name"`
+	ManagerID    *uint  `gorm:"index" json:"manager_id"` // User managing the team
+}
+
+// OrgAssignment places an employee in a department, and optionally one of its teams, between
+// EffectiveFrom and EffectiveTo (inclusive, open-ended when nil). The assignments of an employee
+// never overlap.
+type OrgAssignment struct {
+	gorm.Model
+	EmployeeID    uint            `gorm:"not null;index" json:"employee_id"`
+	DepartmentID  uint            `gorm:"not null;index" json:"department_id"`
+	TeamID        *uint           `gorm:"index" json:"team_id"`
+	EffectiveFrom types.DateOnly  `gorm:"type:date;not null" json:"effective_from"`
+	EffectiveTo   *types.DateOnly `gorm:"type:date" json:"effective_to"`
+}
+
+// CoversDate reports whether the assignment is effective on the day of t.
+func (a *OrgAssignment) CoversDate(t time.Time) bool {
+	return effectiveOn(t, a.EffectiveFrom, a.EffectiveTo)
+}
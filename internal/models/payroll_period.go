@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// Payroll period states.
+const (
+	PayrollPeriodStatusOpen   = "open"
+	PayrollPeriodStatusClosed = "closed"
+)
+
+// PayrollPeriod is a date range of a company whose attendance is frozen once payroll has been run.
+type PayrollPeriod struct {
+	gorm.Model
+	CompanyID uint           `gorm:"not null;index" json:"company_id"`
+	StartDate types.DateOnly `gorm:"type:date;not null" json:"start_date"`
+	EndDate   types.DateOnly `gorm:"type:date;not null" json:"end_date"`
+	Status    string         `gorm:"size:20;not null;default:open" json:"status"` // open, closed
+	ClosedAt  *time.Time     `json:"closed_at"`
+	ClosedBy  *uint          `json:"closed_by"`
+}
+
+// PayrollPeriodEvent records every state change of a payroll period (audit trail).
+type PayrollPeriodEvent struct {
+	gorm.Model
+	PayrollPeriodID uint   `gorm:"not null;index" json:"payroll_period_id"`
+	Action          string `gorm:"size:20;not null" json:"action"` // created, closed, reopened
+	Reason          string `gorm:"size:500" json:"reason"`
+	UserID          *uint  `json:"user_id"`
+}
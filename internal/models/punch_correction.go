@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Punch correction kinds.
+const (
+	PunchCorrectionAdd    = "add"    // A forgotten punch
+	PunchCorrectionAdjust = "adjust" // A punch recorded at the wrong time
+	PunchCorrectionVoid   = "void"   // A punch that should not count
+)
+
+// Punch correction request states.
+const (
+	PunchCorrectionPending   = "pending"
+	PunchCorrectionApproved  = "approved"
+	PunchCorrectionRejected  = "rejected"
+	PunchCorrectionCancelled = "cancelled"
+)
+
+// PunchCorrectionRequest is an employee's request to fix their punches, reviewed by a manager.
+type PunchCorrectionRequest struct {
+	gorm.Model
+	EmployeeID      uint       `gorm:"not null;index" json:"employee_id"`
+	CompanyID       uint       `gorm:"not null;index" json:"company_id"`
+	Kind            string     `gorm:"size:20;not null" json:"kind"`   // add, adjust, void
+	AttendanceLogID *uint      `gorm:"index" json:"attendance_log_id"` // Punch to adjust or void
+	Timestamp       *time.Time `json:"timestamp"`                      // Time of the punch to add, or the corrected time
+	Reason          string     `gorm:"size:500;not null" json:"reason"`
+	Status          string     `gorm:"size:20;not null;default:pending;index" json:"status"` // pending, approved, rejected, cancelled
+	ReviewedBy      *uint      `json:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewed_at"`
+	ReviewComment   string     `gorm:"size:500" json:"review_comment"`
+	// ResultAttendanceLogID is the punch the approval entered, or voided for a void request.
+	ResultAttendanceLogID *uint `gorm:"index" json:"result_attendance_log_id"`
+}
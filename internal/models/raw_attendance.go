@@ -24,4 +24,10 @@ type RawAttendance struct {
 	// New field: CalculateOverTime always false until user modifies to confirm calculation over time.
 	CalculateOverTime  bool `gorm:"default:false"`
 	CalculateLunchHour bool `gorm:"default:true"`
+	// ManuallyEdited is set once a user corrects the row, so regeneration keeps the hand-entered values.
+	ManuallyEdited bool `gorm:"default:false"`
+	// LeaveRequestID links the row to the approved leave that covers it, if any.
+	LeaveRequestID *uint `gorm:"index"`
+	// Justification is unjustified for absences until a manager approves a supporting document.
+	Justification string `gorm:"size:20"`
 }
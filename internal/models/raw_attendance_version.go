@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"point-system-api/internal/types"
+)
+
+// RawAttendanceVersion is the state of a raw attendance after one of its changes: its generation,
+// a manual edit, a regeneration, ... Versions are numbered from 1 for each row and written by
+// repositories.RegisterRawAttendanceVersions only.
+type RawAttendanceVersion struct {
+	ID              uint            `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time       `json:"created_at"`
+	RawAttendanceID uint            `gorm:"not null;uniqueIndex:idx_raw_attendance_version" json:"raw_attendance_id"`
+	Version         uint            `gorm:"not null;uniqueIndex:idx_raw_attendance_version" json:"version"`
+	CompanyID       uint            `gorm:"not null;index" json:"company_id"`
+	Source          string          `gorm:"size:20;not null" json:"source"`        // generation, regeneration, manual, leave, justification, revert, system, baseline
+	ChangedBy       *uint           `json:"changed_by"`                            // User who made the change, nil for the system
+	Values          types.RowValues `gorm:"type:text" json:"values"`               // Columns of the row, by name
+	Deleted         bool            `gorm:"not null;default:false" json:"deleted"` // Set on the version recording the deletion of the row, with its last values
+}
@@ -7,7 +7,31 @@ type User struct {
 	FirstName string `gorm:"size:255;not null"`
 	LastName  string `gorm:"size:255;not null"`
 	Username  string `gorm:"size:255;unique;not null"`
+	Email     string `gorm:"size:255;index"`   // Optional, links single sign-on identities to the account
 	Password  string `gorm:"not null"`         // Hashed password
 	Role      string `gorm:"size:50;not null"` // super-admin, manager, employee
+	// AuthProvider checks the password of the user: local (Password) or ldap (directory bind).
+	// Users created by single sign-on are oidc and have no usable password.
+	AuthProvider string `gorm:"size:50;not null;default:local"`
 	gorm.Model
 }
+
+// Authentication providers of users.
+const (
+	AuthProviderLocal = "local"
+	AuthProviderLDAP  = "ldap"
+	AuthProviderOIDC  = "oidc"
+)
+
+// HasLocalPassword reports whether the password of the user is Password, managed by this service.
+func (u *User) HasLocalPassword() bool {
+	return u.AuthProvider == "" || u.AuthProvider == AuthProviderLocal
+}
+
+// UserCompany links a user to a company whose data they may reach, besides the company employing
+// them. Managers working for several companies are linked to each of them.
+type UserCompany struct {
+	gorm.Model
+	UserID    uint `gorm:"not null;uniqueIndex:idx_user_company" json:"user_id"`
+	CompanyID uint `gorm:"not null;uniqueIndex:idx_user_company;index" json:"company_id"`
+}
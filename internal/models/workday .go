@@ -6,9 +6,23 @@ import (
 	"gorm.io/gorm"
 )
 
+// Workday lifecycle states.
+const (
+	WorkDayStatusDraft       = "draft"
+	WorkDayStatusUnderReview = "under_review"
+	WorkDayStatusApproved    = "approved"
+	WorkDayStatusLocked      = "locked"
+)
+
 // WorkDay represents a single workday.
 type WorkDay struct {
 	gorm.Model
-	Date    types.DateOnly `gorm:"type:date;not null" json:"date"`  // Date of the workday
-	DayType string         `gorm:"size:50;not null" json:"dayType"` // Type of day: workday, free, holiday
+	Date    types.DateOnly `gorm:"type:date;not null" json:"date"`               // Date of the workday
+	DayType string         `gorm:"size:50;not null" json:"dayType"`              // Type of day: workday, free, holiday
+	Status  string         `gorm:"size:20;not null;default:draft" json:"status"` // Lifecycle: draft, under_review, approved, locked
+}
+
+// IsEditable reports whether the workday and its raw attendances may still be changed.
+func (w *WorkDay) IsEditable() bool {
+	return w.Status == "" || w.Status == WorkDayStatusDraft || w.Status == WorkDayStatusUnderReview
 }
@@ -0,0 +1,76 @@
+// Package notify delivers notifications to users through a pluggable channel.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Message is a notification addressed to a user. Users are identified by their ID and username;
+// resolving where to deliver the message is left to the Notifier.
+type Message struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+}
+
+// Notifier delivers messages to users.
+type Notifier interface {
+	Notify(ctx context.Context, message Message) error
+}
+
+// LogNotifier writes messages to the log, for development. Messages carry secrets such as reset
+// codes, so their body is only logged when LogBodies is set.
+type LogNotifier struct {
+	LogBodies bool
+}
+
+// Notify logs that the message was sent, and its body when enabled.
+func (n LogNotifier) Notify(ctx context.Context, message Message) error {
+	attrs := []any{"user_id", message.UserID, "username", message.Username, "subject", message.Subject}
+	if n.LogBodies {
+		attrs = append(attrs, "body", message.Body)
+	}
+	slog.Info("notification", attrs...)
+	return nil
+}
+
+// WebhookNotifier posts messages as JSON to a URL, for a mail or chat gateway to deliver.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier creates a WebhookNotifier posting to url.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify posts the message and fails unless the webhook answers with a 2xx status.
+func (n *WebhookNotifier) Notify(ctx context.Context, message Message) error {
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook answered %s", resp.Status)
+	}
+	return nil
+}
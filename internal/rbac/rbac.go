@@ -0,0 +1,118 @@
+// Package rbac defines the permissions of the API and the roles granting them.
+package rbac
+
+// Roles of a models.User.
+const (
+	RoleSuperAdmin = "super-admin"
+	RoleManager    = "manager"
+	RoleEmployee   = "employee"
+)
+
+// Permission allows an action on a resource, written "resource:action".
+type Permission string
+
+// Permissions required by the routes.
+const (
+	AttendanceRead     Permission = "attendance:read"
+	AttendanceWrite    Permission = "attendance:write"
+	RawAttendanceRead  Permission = "rawattendance:read"
+	RawAttendanceWrite Permission = "rawattendance:write"
+	WorkDayRead        Permission = "workday:read"
+	WorkDayWrite       Permission = "workday:write"
+	PayrollRead        Permission = "payroll:read"
+	PayrollWrite       Permission = "payroll:write"
+	PayrollAdmin       Permission = "payroll:admin" // Reopen closed periods
+	LeaveRequest       Permission = "leave:request" // File and cancel leave requests
+	LeaveRead          Permission = "leave:read"
+	LeaveWrite         Permission = "leave:write" // Leave types, approvals and balances
+	LeaveAdmin         Permission = "leave:admin" // Year-end rollover
+	DocumentRead       Permission = "document:read"
+	DocumentWrite      Permission = "document:write"
+	DocumentReview     Permission = "document:review"
+	UserRead           Permission = "user:read"
+	UserAdmin          Permission = "user:admin"
+	APIKeyAdmin        Permission = "apikey:admin" // Service accounts and their API keys
+	CompanyRead        Permission = "company:read"
+	CompanyWrite       Permission = "company:write"
+	EmployeeRead       Permission = "employee:read"
+	EmployeeWrite      Permission = "employee:write"
+	OrganizationRead   Permission = "organization:read"
+	OrganizationWrite  Permission = "organization:write"
+	DeviceRead         Permission = "device:read"
+	DeviceWrite        Permission = "device:write"
+	DeviceIngest       Permission = "device:ingest" // Push punches, held by the API keys of device gateways
+	ReportGenerate     Permission = "report:generate"
+	AuditRead          Permission = "audit:read"
+)
+
+// AllPermissions lists every permission.
+var AllPermissions = []Permission{
+	AttendanceRead, AttendanceWrite,
+	RawAttendanceRead, RawAttendanceWrite,
+	WorkDayRead, WorkDayWrite,
+	PayrollRead, PayrollWrite, PayrollAdmin,
+	LeaveRequest, LeaveRead, LeaveWrite, LeaveAdmin,
+	DocumentRead, DocumentWrite, DocumentReview,
+	UserRead, UserAdmin, APIKeyAdmin,
+	CompanyRead, CompanyWrite,
+	EmployeeRead, EmployeeWrite,
+	OrganizationRead, OrganizationWrite,
+	DeviceRead, DeviceWrite, DeviceIngest,
+	ReportGenerate,
+	AuditRead,
+}
+
+// rolePermissions maps each role to the permissions it grants. Super-admins are granted everything.
+var rolePermissions = map[string][]Permission{
+	RoleManager: {
+		AttendanceRead, AttendanceWrite,
+		RawAttendanceRead, RawAttendanceWrite,
+		WorkDayRead, WorkDayWrite,
+		PayrollRead, PayrollWrite,
+		LeaveRequest, LeaveRead, LeaveWrite,
+		DocumentRead, DocumentWrite, DocumentReview,
+		UserRead,
+		CompanyRead,
+		EmployeeRead, EmployeeWrite,
+		OrganizationRead, OrganizationWrite,
+		DeviceRead, DeviceWrite,
+		ReportGenerate,
+		AuditRead,
+	},
+	// Employees reach their own leave requests, punch corrections and documents through the
+	// self-service routes only: the routes of these permissions take records of any employee.
+	RoleEmployee: {
+		CompanyRead,
+	},
+}
+
+// HasPermission reports whether role grants permission.
+func HasPermission(role string, permission Permission) bool {
+	if role == RoleSuperAdmin {
+		return true
+	}
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions returns the permissions granted by role.
+func RolePermissions(role string) []Permission {
+	if role == RoleSuperAdmin {
+		return AllPermissions
+	}
+	return rolePermissions[role]
+}
+
+// IsValid reports whether permission exists.
+func IsValid(permission Permission) bool {
+	for _, known := range AllPermissions {
+		if known == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// APIKeyRepository defines the interface for service accounts and their API keys.
+type APIKeyRepository interface {
+	CreateServiceAccount(ctx context.Context, account *models.ServiceAccount) error
+	GetServiceAccountByID(ctx context.Context, id uint) (*models.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]models.ServiceAccount, error)
+	// DeleteServiceAccount deletes an account and revokes its keys, reporting false when it does not exist.
+	DeleteServiceAccount(ctx context.Context, id uint) (bool, error)
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	// GetAPIKeyByHash retrieves a key by the hash of its value, with its service account.
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, serviceAccountID uint) ([]models.APIKey, error)
+	// RevokeAPIKey revokes a key of a service account, reporting false when there is no such active key.
+	RevokeAPIKey(ctx context.Context, serviceAccountID, id uint) (bool, error)
+	// TouchAPIKey records the last use of a key.
+	TouchAPIKey(ctx context.Context, id uint, at time.Time, ip string) error
+}
+
+// apiKeyRepository implements the APIKeyRepository interface.
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new instance of APIKeyRepository.
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+// CreateServiceAccount inserts a new service account into the database.
+func (r *apiKeyRepository) CreateServiceAccount(ctx context.Context, account *models.ServiceAccount) error {
+	if err := conn(ctx, r.db).Create(account).Error; err != nil {
+		return fmt.Errorf("failed to create service account: %w", err)
+	}
+	return nil
+}
+
+// GetServiceAccountByID retrieves a service account by its ID.
+func (r *apiKeyRepository) GetServiceAccountByID(ctx context.Context, id uint) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	if err := conn(ctx, r.db).First(&account, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No service account found
+		}
+		return nil, fmt.Errorf("failed to retrieve service account: %w", err)
+	}
+	return &account, nil
+}
+
+// ListServiceAccounts retrieves every service account.
+func (r *apiKeyRepository) ListServiceAccounts(ctx context.Context) ([]models.ServiceAccount, error) {
+	var accounts []models.ServiceAccount
+	if err := conn(ctx, r.db).Order("name").Find(&accounts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list service accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+// DeleteServiceAccount soft-deletes a service account and revokes its active keys in one transaction.
+func (r *apiKeyRepository) DeleteServiceAccount(ctx context.Context, id uint) (bool, error) {
+	var found bool
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.ServiceAccount{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete service account: %w", result.Error)
+		}
+		if found = result.RowsAffected > 0; !found {
+			return nil
+		}
+		err := tx.Model(&models.APIKey{}).
+			Where("service_account_id = ? AND revoked_at IS NULL", id).
+			Update("revoked_at", time.Now()).Error
+		if err != nil {
+			return fmt.Errorf("failed to revoke API keys: %w", err)
+		}
+		return nil
+	})
+	return found, err
+}
+
+// CreateAPIKey inserts a new API key into the database.
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	if err := conn(ctx, r.db).Create(key).Error; err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+	return nil
+}
+
+// GetAPIKeyByHash retrieves an API key of an existing service account by the hash of its value.
+func (r *apiKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := conn(ctx, r.db).Joins("ServiceAccount").Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No API key found
+		}
+		return nil, fmt.Errorf("failed to retrieve API key: %w", err)
+	}
+	return &key, nil
+}
+
+// ListAPIKeys retrieves the keys of a service account, newest first.
+func (r *apiKeyRepository) ListAPIKeys(ctx context.Context, serviceAccountID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := conn(ctx, r.db).Where("service_account_id = ?", serviceAccountID).Order("id DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marks an active key of a service account revoked.
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, serviceAccountID, id uint) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.APIKey{}).
+		Where("id = ? AND service_account_id = ? AND revoked_at IS NULL", id, serviceAccountID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to revoke API key: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// TouchAPIKey updates the last use of a key.
+func (r *apiKeyRepository) TouchAPIKey(ctx context.Context, id uint, at time.Time, ip string) error {
+	err := conn(ctx, r.db).Model(&models.APIKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"last_used_at": at, "last_used_ip": ip}).Error
+	if err != nil {
+		return fmt.Errorf("failed to record API key use: %w", err)
+	}
+	return nil
+}
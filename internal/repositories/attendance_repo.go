@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -25,18 +26,32 @@ type AttendanceRepository interface {
 	// UpdateAttendanceLog updates an existing attendance log.
 	UpdateAttendanceLog(ctx context.Context, attendanceLog *models.AttendanceLog) error
 
-	GetCurrentAndPreviousLogsByUserID(ctx context.Context, userID int) (*models.AttendanceLog, *models.AttendanceLog, error)
+	// GetCurrentAndPreviousLogs retrieves the latest punch and the one before it of the owner of attendanceLog.
+	GetCurrentAndPreviousLogs(ctx context.Context, attendanceLog *models.AttendanceLog) (*models.AttendanceLog, *models.AttendanceLog, error)
 
-	// GetFirstInLogOfDay retrieves the first IN attendance log of the day for a user.
-
-	GetFirstInLogOfDay(ctx context.Context, userID int, date time.Time) (*models.AttendanceLog, error)
+	// GetFirstInLogOfDay retrieves the first IN punch of the day of the owner of attendanceLog.
+	GetFirstInLogOfDay(ctx context.Context, attendanceLog *models.AttendanceLog, date time.Time) (*models.AttendanceLog, error)
 
 	// DeleteAttendanceLog deletes an attendance log by its ID.
 	DeleteAttendanceLog(ctx context.Context, id uint) error
 
 	GetAttendanceLogsByUserAndTimeRange(ctx context.Context, userID int, start, end time.Time) ([]models.AttendanceLog, error)
 
-	GetTotalHourOutByUserAndTimeRange(ctx context.Context, userID uint, start, end time.Time) (float64, error)
+	GetTotalHourOutByEmployeeAndTimeRange(ctx context.Context, employeeID uint, start, end time.Time) (float64, error)
+
+	// GetUnmatchedPunchGroups groups the punches not linked to an employee by device and device user ID.
+	GetUnmatchedPunchGroups(ctx context.Context, filters map[string]interface{}) ([]types.UnmatchedPunchGroup, error)
+
+	// GetEmployeePunchesSince retrieves the punches of an employee from since onwards that are not
+	// voided, oldest first.
+	GetEmployeePunchesSince(ctx context.Context, employeeID uint, since time.Time) ([]models.AttendanceLog, error)
+
+	// ListEmployeePunches retrieves the punches of an employee in [start, end), voided or not,
+	// oldest first.
+	ListEmployeePunches(ctx context.Context, employeeID uint, start, end time.Time) ([]models.AttendanceLog, error)
+
+	// UpdateSystemPunches stores the system punch of the given attendance logs.
+	UpdateSystemPunches(ctx context.Context, attendanceLogs []*models.AttendanceLog) error
 }
 
 type attendanceRepository struct {
@@ -50,13 +65,16 @@ func NewAttendanceRepository(db *gorm.DB) AttendanceRepository {
 
 // CreateAttendanceLog adds a new attendance log to the database
 func (r *attendanceRepository) CreateAttendanceLog(ctx context.Context, attendanceLog *models.AttendanceLog) error {
-	return r.db.WithContext(ctx).Create(attendanceLog).Error
+	return conn(ctx, r.db).Create(attendanceLog).Error
 }
 
-// GetAttendanceByID retrieves an attendance log by its ID
+// GetAttendanceByID retrieves an attendance log by its ID, or nil when it does not exist
 func (r *attendanceRepository) GetAttendanceByID(ctx context.Context, id uint) (*models.AttendanceLog, error) {
 	var attendanceLog models.AttendanceLog
-	if err := r.db.WithContext(ctx).First(&attendanceLog, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&attendanceLog, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
 		return nil, err
 	}
 	return &attendanceLog, nil
@@ -65,7 +83,7 @@ func (r *attendanceRepository) GetAttendanceByID(ctx context.Context, id uint) (
 // GetAllAttendanceLogs retrieves all attendance logs with optional filters
 func (r *attendanceRepository) GetAllAttendanceLogs(ctx context.Context, filters map[string]interface{}) ([]models.AttendanceLog, error) {
 	var attendanceLogs []models.AttendanceLog
-	query := r.db.WithContext(ctx).Model(&models.AttendanceLog{})
+	query := conn(ctx, r.db).Model(&models.AttendanceLog{})
 	for key, value := range filters {
 		query = query.Where(key+" = ?", value)
 	}
@@ -77,12 +95,12 @@ func (r *attendanceRepository) GetAllAttendanceLogs(ctx context.Context, filters
 
 // UpdateAttendanceLog updates an existing attendance log
 func (r *attendanceRepository) UpdateAttendanceLog(ctx context.Context, attendanceLog *models.AttendanceLog) error {
-	return r.db.WithContext(ctx).Save(attendanceLog).Error
+	return conn(ctx, r.db).Save(attendanceLog).Error
 }
 
 // DeleteAttendanceLog deletes an attendance log by its ID
 func (r *attendanceRepository) DeleteAttendanceLog(ctx context.Context, id uint) error {
-	return r.db.WithContext(ctx).Delete(&models.AttendanceLog{}, id).Error
+	return conn(ctx, r.db).Delete(&models.AttendanceLog{}, id).Error
 }
 
 func (r *attendanceRepository) GetAllAttendanceLogsWithFilters(ctx context.Context, page, limit int, filters map[string]interface{}, search string) ([]types.AttendanceLogResponse, int64, error) {
@@ -90,7 +108,7 @@ func (r *attendanceRepository) GetAllAttendanceLogsWithFilters(ctx context.Conte
 	var total int64
 	offset := (page - 1) * limit
 
-	query := r.db.WithContext(ctx).Table("attendance_logs al").
+	query := conn(ctx, r.db).Table("attendance_logs al").
 		Select(`
             al.*,
             e.registration_number as employee_registration,
@@ -103,10 +121,16 @@ func (r *attendanceRepository) GetAllAttendanceLogsWithFilters(ctx context.Conte
             u.username as employee_username,
             u.role as employee_role
         `).
-		Joins("JOIN employees e ON CAST(al.user_id AS CHAR) = e.registration_number").
-		Joins("JOIN users u ON e.user_id = u.id").
+		Joins("LEFT JOIN employees e ON e.id = al.employee_id").
+		Joins("LEFT JOIN users u ON e.user_id = u.id").
 		Order("al.timestamp DESC")
 
+	// Unmatched punches belong to the company of their device
+	if condition, args := tenantFilter(ctx, "e.company_id"); condition != "" {
+		deviceCondition, deviceArgs := tenantFilter(ctx, "company_id")
+		query = query.Where(fmt.Sprintf("(%s OR (al.employee_id IS NULL AND al.serial_number IN (SELECT serial_number FROM devices WHERE %s)))", condition, deviceCondition), append(args, deviceArgs...)...)
+	}
+
 	// Apply filters
 	for key, value := range filters {
 		if key == "search" {
@@ -114,6 +138,11 @@ func (r *attendanceRepository) GetAllAttendanceLogsWithFilters(ctx context.Conte
 		}
 		if key == "company_id" {
 			query = query.Where("e.company_id = ?", value)
+		} else if key == "unmatched" {
+			query = query.Where("al.employee_id IS NULL")
+		} else if key == "org_scope" {
+			condition, args := OrgScopeCondition("al.employee_id", "al.timestamp", value.(*types.OrgScope))
+			query = query.Where(condition, args...)
 		} else if key == "start_date" {
 			query = query.Where("al.timestamp >= ?", value)
 		} else if key == "end_date" {
@@ -127,7 +156,7 @@ func (r *attendanceRepository) GetAllAttendanceLogsWithFilters(ctx context.Conte
 	if search != "" {
 		query = query.Where(`
         al.serial_number LIKE ? OR 
-        CAST(al.user_id AS CHAR) LIKE ? OR 
+        al.device_user_id LIKE ? OR 
         e.registration_number LIKE ? OR 
         e.qualification LIKE ? OR 
         e.company_id LIKE ? OR 
@@ -151,13 +180,25 @@ func (r *attendanceRepository) GetAllAttendanceLogsWithFilters(ctx context.Conte
 	return attendanceLogs, total, nil
 }
 
-// GetCurrentAndPreviousLogsByUserID retrieves the current and previous attendance logs for a user.
-func (r *attendanceRepository) GetCurrentAndPreviousLogsByUserID(ctx context.Context, userID int) (*models.AttendanceLog, *models.AttendanceLog, error) {
+// punchOwner restricts a query to the punches that count of the same person as attendanceLog: its
+// employee when it is linked to one, otherwise the unlinked punches of its device user ID on the
+// same device. Voided punches are left out.
+func punchOwner(query *gorm.DB, attendanceLog *models.AttendanceLog) *gorm.DB {
+	query = query.Where("voided_at IS NULL")
+	if attendanceLog.EmployeeID != nil {
+		return query.Where("employee_id = ?", *attendanceLog.EmployeeID)
+	}
+	return query.Where("employee_id IS NULL AND serial_number = ? AND device_user_id = ?",
+		attendanceLog.SerialNumber, attendanceLog.DeviceUserID)
+}
+
+// GetCurrentAndPreviousLogs retrieves the current and previous attendance logs of the owner of attendanceLog.
+func (r *attendanceRepository) GetCurrentAndPreviousLogs(ctx context.Context, attendanceLog *models.AttendanceLog) (*models.AttendanceLog, *models.AttendanceLog, error) {
 
 	var currentLog, previousLog models.AttendanceLog
 
 	// Retrieve the current log
-	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("timestamp DESC").First(&currentLog).Error
+	err := punchOwner(conn(ctx, r.db), attendanceLog).Order("timestamp DESC").First(&currentLog).Error
 
 	if err != nil {
 		return nil, nil, err
@@ -165,7 +206,7 @@ func (r *attendanceRepository) GetCurrentAndPreviousLogsByUserID(ctx context.Con
 
 	// Retrieve the previous log
 
-	err = r.db.WithContext(ctx).Where("user_id = ? AND id < ?", userID, currentLog.ID).Order("timestamp DESC").First(&previousLog).Error
+	err = punchOwner(conn(ctx, r.db), attendanceLog).Where("id < ?", currentLog.ID).Order("timestamp DESC").First(&previousLog).Error
 
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return nil, nil, err
@@ -174,53 +215,139 @@ func (r *attendanceRepository) GetCurrentAndPreviousLogsByUserID(ctx context.Con
 	return &currentLog, &previousLog, nil
 }
 
-// GetFirstInLogOfDay retrieves the first IN attendance log of the day for a user.
-func (r *attendanceRepository) GetFirstInLogOfDay(ctx context.Context, userID int, date time.Time) (*models.AttendanceLog, error) {
-	var attendanceLog models.AttendanceLog
+// GetFirstInLogOfDay retrieves the first IN attendance log of the day of the owner of attendanceLog.
+func (r *attendanceRepository) GetFirstInLogOfDay(ctx context.Context, attendanceLog *models.AttendanceLog, date time.Time) (*models.AttendanceLog, error) {
+	var firstLog models.AttendanceLog
 
-	err := r.db.WithContext(ctx).
-		Where("user_id = ? AND DATE(timestamp) = ? AND system_punch = ?", userID, date.Format("2006-01-02"), "IN").
+	err := punchOwner(conn(ctx, r.db), attendanceLog).
+		Where("DATE(timestamp) = ? AND system_punch = ?", date.Format("2006-01-02"), "IN").
 		Order("timestamp ASC").
-		First(&attendanceLog).Error
+		First(&firstLog).Error
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &attendanceLog, nil
+	return &firstLog, nil
 }
 
 func (r *attendanceRepository) GetAttendanceLogsByUserAndTimeRange(ctx context.Context, userID int, start, end time.Time) ([]models.AttendanceLog, error) {
 	var logs []models.AttendanceLog
-	if err := r.db.WithContext(ctx).
-		Where("user_id = ? AND timestamp BETWEEN ? AND ?", userID, start, end).
+	if err := conn(ctx, r.db).
+		Where("user_id = ? AND timestamp BETWEEN ? AND ? AND voided_at IS NULL", userID, start, end).
 		Find(&logs).Error; err != nil {
 		return nil, err
 	}
 	return logs, nil
 }
 
-// GetTotalHourOutByUserAndTimeRange uses window functions and TIMESTAMPDIFF (MySQL syntax)
+// GetTotalHourOutByEmployeeAndTimeRange uses window functions and TIMESTAMPDIFF (MySQL syntax)
 // to calculate total hours out in one query.
-func (r *attendanceRepository) GetTotalHourOutByUserAndTimeRange(ctx context.Context, userID uint, start, end time.Time) (float64, error) {
+func (r *attendanceRepository) GetTotalHourOutByEmployeeAndTimeRange(ctx context.Context, employeeID uint, start, end time.Time) (float64, error) {
 	var totalSeconds float64
 
-	err := r.db.WithContext(ctx).Raw(`
+	err := conn(ctx, r.db).Raw(`
         WITH ordered_logs AS (
             SELECT 
                 timestamp,
                 system_punch,
-                LAG(timestamp) OVER (PARTITION BY user_id ORDER BY timestamp) AS prev_timestamp,
-                LAG(system_punch) OVER (PARTITION BY user_id ORDER BY timestamp) AS prev_system_punch
+                LAG(timestamp) OVER (PARTITION BY employee_id ORDER BY timestamp) AS prev_timestamp,
+                LAG(system_punch) OVER (PARTITION BY employee_id ORDER BY timestamp) AS prev_system_punch
             FROM attendance_logs
-            WHERE user_id = ? AND timestamp BETWEEN ? AND ?
+            WHERE employee_id = ? AND timestamp BETWEEN ? AND ? AND voided_at IS NULL AND deleted_at IS NULL
         )
         SELECT COALESCE(SUM(TIMESTAMPDIFF(SECOND, prev_timestamp, timestamp)),0) AS total_seconds
         FROM ordered_logs
         WHERE system_punch = 'IN' AND prev_system_punch = 'OUT'
-    `, userID, start, end).Scan(&totalSeconds).Error
+    `, employeeID, start, end).Scan(&totalSeconds).Error
 	if err != nil {
 		return 0, err
 	}
 	return totalSeconds / 3600, nil
 }
+
+// GetUnmatchedPunchGroups retrieves the unmatched punch groups, optionally filtered by
+// serial_number, device_user_id and company_id (the company of the device).
+func (r *attendanceRepository) GetUnmatchedPunchGroups(ctx context.Context, filters map[string]interface{}) ([]types.UnmatchedPunchGroup, error) {
+	var groups []types.UnmatchedPunchGroup
+
+	query := conn(ctx, r.db).Table("attendance_logs al").
+		Select(`
+            al.serial_number,
+            d.id AS device_id,
+            COALESCE(d.name, '') AS device_name,
+            COALESCE(d.company_id, 0) AS company_id,
+            al.device_user_id,
+            COUNT(*) AS punch_count,
+            MIN(al.timestamp) AS first_punch,
+            MAX(al.timestamp) AS last_punch
+        `).
+		Joins("LEFT JOIN devices d ON d.serial_number = al.serial_number AND d.deleted_at IS NULL").
+		Where("al.employee_id IS NULL").
+		Group("al.serial_number, d.id, d.name, d.company_id, al.device_user_id").
+		Order("last_punch DESC")
+	if condition, args := tenantFilter(ctx, "d.company_id"); condition != "" {
+		query = query.Where(condition, args...)
+	}
+
+	for key, value := range filters {
+		if key == "company_id" {
+			query = query.Where("d.company_id = ?", value)
+		} else {
+			query = query.Where(fmt.Sprintf("al.%s = ?", key), value)
+		}
+	}
+
+	if err := query.Scan(&groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch unmatched punches: %w", err)
+	}
+	return groups, nil
+}
+
+// GetEmployeePunchesSince retrieves the punches of an employee from since onwards that are not
+// voided, oldest first.
+func (r *attendanceRepository) GetEmployeePunchesSince(ctx context.Context, employeeID uint, since time.Time) ([]models.AttendanceLog, error) {
+	var logs []models.AttendanceLog
+	err := conn(ctx, r.db).
+		Where("employee_id = ? AND timestamp >= ? AND voided_at IS NULL", employeeID, since).
+		Order("timestamp ASC, id ASC").
+		Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch punches: %w", err)
+	}
+	return logs, nil
+}
+
+// ListEmployeePunches retrieves the punches of an employee between start and end.
+func (r *attendanceRepository) ListEmployeePunches(ctx context.Context, employeeID uint, start, end time.Time) ([]models.AttendanceLog, error) {
+	var logs []models.AttendanceLog
+	err := conn(ctx, r.db).
+		Where("employee_id = ? AND timestamp >= ? AND timestamp < ?", employeeID, start, end).
+		Order("timestamp ASC, id ASC").
+		Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch punches: %w", err)
+	}
+	return logs, nil
+}
+
+// UpdateSystemPunches stores the system punch of the given attendance logs in a single transaction.
+func (r *attendanceRepository) UpdateSystemPunches(ctx context.Context, attendanceLogs []*models.AttendanceLog) error {
+	byPunch := map[string][]uint{}
+	for _, attendanceLog := range attendanceLogs {
+		byPunch[attendanceLog.SystemPunch] = append(byPunch[attendanceLog.SystemPunch], attendanceLog.ID)
+	}
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for systemPunch, ids := range byPunch {
+			for start := 0; start < len(ids); start += 1000 {
+				end := min(start+1000, len(ids))
+				err := tx.Model(&models.AttendanceLog{}).Where("id IN ?", ids[start:end]).Update("system_punch", systemPunch).Error
+				if err != nil {
+					return fmt.Errorf("failed to update system punches: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,332 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// auditedTables maps the tables whose changes are recorded by the audit trail to their entity type.
+var auditedTables = map[string]string{
+	"users":           "user",
+	"employees":       "employee",
+	"companies":       "company",
+	"devices":         "device",
+	"work_days":       "workday",
+	"raw_attendances": "raw_attendance",
+	"attendance_logs": "attendance_log",
+}
+
+// auditIgnoredColumns are left out of the changes: the bookkeeping of gorm.Model, already given by
+// the action and the time of the audit log.
+var auditIgnoredColumns = map[string]bool{"id": true, "created_at": true, "updated_at": true, "deleted_at": true}
+
+// auditRedactedColumns are recorded as changed without their values.
+var auditRedactedColumns = map[string]bool{"password": true}
+
+// auditRedacted replaces the values of auditRedactedColumns.
+const auditRedacted = "[redacted]"
+
+// auditBeforeKey stores the rows an update or delete is about to change.
+const auditBeforeKey = "audit:before"
+
+// ErrImmutableAuditLog is returned when an audit log is updated or deleted.
+var ErrImmutableAuditLog = errors.New("audit logs cannot be changed")
+
+// RegisterAuditTrail records every create, update and delete of the audited tables in an audit
+// log, in the transaction of the change, naming the audit actor of its context. Changes made
+// through raw SQL are not recorded. Audit logs themselves are refused any update or delete.
+func RegisterAuditTrail(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("audit:create", auditCreate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("audit:immutable", refuseAuditLogChange); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("audit:before", auditLoadBefore); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("audit:update", auditUpdate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("audit:immutable", refuseAuditLogChange); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("audit:before", auditLoadBefore); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Register("audit:delete", auditDelete)
+}
+
+// refuseAuditLogChange fails the updates and deletes of audit logs.
+func refuseAuditLogChange(db *gorm.DB) {
+	if db.Statement.Table == "audit_logs" {
+		db.AddError(ErrImmutableAuditLog)
+	}
+}
+
+// auditCreate records the rows just created.
+func auditCreate(db *gorm.DB) {
+	stmt := db.Statement
+	if _, ok := auditedTables[stmt.Table]; !ok || db.Error != nil || stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return
+	}
+
+	ids := createdIDs(db)
+	if len(ids) == 0 {
+		return
+	}
+
+	rows, err := auditRowsByID(db, ids)
+	if err != nil {
+		db.AddError(fmt.Errorf("failed to record audit trail: %w", err))
+		return
+	}
+	var entries []models.AuditLog
+	for _, row := range rows {
+		entries = append(entries, auditEntry(db, models.AuditActionCreate, row, diffRows(nil, row)))
+	}
+	saveAuditEntries(db, entries)
+}
+
+// createdIDs returns the primary keys of the rows a create statement inserted.
+func createdIDs(db *gorm.DB) []interface{} {
+	stmt := db.Statement
+	var ids []interface{}
+	primaryKey := stmt.Schema.PrioritizedPrimaryField
+	collect := func(value reflect.Value) {
+		if id, zero := primaryKey.ValueOf(stmt.Context, value); !zero {
+			ids = append(ids, id)
+		}
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			collect(reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		collect(stmt.ReflectValue)
+	}
+	return ids
+}
+
+// auditLoadBefore loads the rows an update or delete is about to change: the rows of its
+// conditions, or of the primary key of its model.
+func auditLoadBefore(db *gorm.DB) {
+	stmt := db.Statement
+	if _, ok := auditedTables[stmt.Table]; !ok || db.Error != nil || stmt.Schema == nil || stmt.Schema.PrioritizedPrimaryField == nil {
+		return
+	}
+
+	var conditions []clause.Expression
+	if where, ok := stmt.Clauses["WHERE"]; ok {
+		if w, ok := where.Expression.(clause.Where); ok {
+			conditions = append(conditions, w.Exprs...)
+		}
+	}
+	if stmt.ReflectValue.Kind() == reflect.Struct {
+		primaryKey := stmt.Schema.PrioritizedPrimaryField
+		if id, zero := primaryKey.ValueOf(stmt.Context, stmt.ReflectValue); !zero {
+			conditions = append(conditions, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: primaryKey.DBName}, Value: id})
+		}
+	}
+	if len(conditions) == 0 {
+		return // Refused by gorm as a global update or delete
+	}
+
+	var rows []map[string]interface{}
+	tx := db.Session(&gorm.Session{NewDB: true, Context: stmt.Context}).Model(reflect.New(stmt.Schema.ModelType).Interface())
+	if stmt.Unscoped {
+		tx = tx.Unscoped()
+	}
+	tx.Statement.AddClause(clause.Where{Exprs: conditions})
+	if err := tx.Find(&rows).Error; err != nil {
+		db.AddError(fmt.Errorf("failed to record audit trail: %w", err))
+		return
+	}
+	stmt.Settings.Store(auditBeforeKey, rows)
+}
+
+// auditUpdate records the columns the update changed, for each row it changed.
+func auditUpdate(db *gorm.DB) {
+	before, ok := db.Statement.Settings.Load(auditBeforeKey)
+	if !ok || db.Error != nil || len(before.([]map[string]interface{})) == 0 {
+		return
+	}
+
+	beforeRows := before.([]map[string]interface{})
+	ids := make([]interface{}, len(beforeRows))
+	for i, row := range beforeRows {
+		ids[i] = row["id"]
+	}
+	afterRows, err := auditRowsByID(db, ids)
+	if err != nil {
+		db.AddError(fmt.Errorf("failed to record audit trail: %w", err))
+		return
+	}
+	after := map[uint]map[string]interface{}{}
+	for _, row := range afterRows {
+		after[auditID(row)] = row
+	}
+
+	var entries []models.AuditLog
+	for _, row := range beforeRows {
+		changed, ok := after[auditID(row)]
+		if !ok {
+			continue
+		}
+		if changes := diffRows(row, changed); len(changes) > 0 {
+			entries = append(entries, auditEntry(db, models.AuditActionUpdate, changed, changes))
+		}
+	}
+	saveAuditEntries(db, entries)
+}
+
+// auditDelete records the rows the delete removed, with their last values.
+func auditDelete(db *gorm.DB) {
+	before, ok := db.Statement.Settings.Load(auditBeforeKey)
+	if !ok || db.Error != nil {
+		return
+	}
+
+	var entries []models.AuditLog
+	for _, row := range before.([]map[string]interface{}) {
+		entries = append(entries, auditEntry(db, models.AuditActionDelete, row, diffRows(row, nil)))
+	}
+	saveAuditEntries(db, entries)
+}
+
+// auditRowsByID loads the rows of the table of the statement by their primary key, deleted or not.
+func auditRowsByID(db *gorm.DB, ids []interface{}) ([]map[string]interface{}, error) {
+	stmt := db.Statement
+	var rows []map[string]interface{}
+	err := db.Session(&gorm.Session{NewDB: true, Context: types.WithoutTenantScope(stmt.Context)}).
+		Model(reflect.New(stmt.Schema.ModelType).Interface()).
+		Unscoped().
+		Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: stmt.Schema.PrioritizedPrimaryField.DBName}, Values: ids}).
+		Find(&rows).Error
+	return rows, err
+}
+
+// diffRows returns the columns whose values differ between two versions of a row, by name. A nil
+// before lists the columns set on creation and a nil after the columns of a deleted row.
+func diffRows(before, after map[string]interface{}) types.AuditChanges {
+	changes := types.AuditChanges{}
+	columns := map[string]bool{}
+	for column := range before {
+		columns[column] = true
+	}
+	for column := range after {
+		columns[column] = true
+	}
+
+	for _, column := range slices.Sorted(maps.Keys(columns)) {
+		if auditIgnoredColumns[column] {
+			continue
+		}
+		from, to := before[column], after[column]
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+		if auditRedactedColumns[column] {
+			if from != nil {
+				from = auditRedacted
+			}
+			if to != nil {
+				to = auditRedacted
+			}
+		}
+		changes = append(changes, types.FieldChange{Field: column, Old: from, New: to})
+	}
+	return changes
+}
+
+// auditEntry builds the audit log of a change of row, naming the audit actor of the statement.
+func auditEntry(db *gorm.DB, action string, row map[string]interface{}, changes types.AuditChanges) models.AuditLog {
+	entry := models.AuditLog{
+		EntityType: auditedTables[db.Statement.Table],
+		EntityID:   auditID(row),
+		Action:     action,
+		Changes:    changes,
+		CompanyID:  auditCompanyID(db, row),
+	}
+	if actor := types.AuditActorFrom(db.Statement.Context); actor != nil {
+		entry.ActorUserID = actor.UserID
+		entry.ActorServiceAccountID = actor.ServiceAccountID
+		entry.IPAddress = actor.IPAddress
+		entry.UserAgent = actor.UserAgent
+	}
+	return entry
+}
+
+// auditCompanyID returns the company of a row: itself for companies, its company_id column, or
+// the company of the employee or, for unmatched punches, of the device of a punch.
+func auditCompanyID(db *gorm.DB, row map[string]interface{}) *uint {
+	if db.Statement.Table == "companies" {
+		id := auditID(row)
+		return &id
+	}
+	if companyID, ok := auditUint(row["company_id"]); ok {
+		return &companyID
+	}
+	if db.Statement.Table != "attendance_logs" {
+		return nil
+	}
+
+	var companyIDs []uint
+	tx := db.Session(&gorm.Session{NewDB: true, Context: types.WithoutTenantScope(db.Statement.Context)})
+	if employeeID, ok := auditUint(row["employee_id"]); ok {
+		tx.Table("employees").Where("id = ?", employeeID).Limit(1).Pluck("company_id", &companyIDs)
+	} else if serialNumber, ok := row["serial_number"]; ok && serialNumber != nil {
+		tx.Table("devices").Where("serial_number = ?", serialNumber).Limit(1).Pluck("company_id", &companyIDs)
+	}
+	if len(companyIDs) == 0 {
+		return nil
+	}
+	return &companyIDs[0]
+}
+
+// saveAuditEntries stores audit logs in the transaction of the change, failing it on error.
+func saveAuditEntries(db *gorm.DB, entries []models.AuditLog) {
+	if len(entries) == 0 {
+		return
+	}
+	err := db.Session(&gorm.Session{NewDB: true, Context: types.WithoutTenantScope(db.Statement.Context)}).Create(&entries).Error
+	if err != nil {
+		db.AddError(fmt.Errorf("failed to record audit trail: %w", err))
+	}
+}
+
+// auditID returns the primary key of a row.
+func auditID(row map[string]interface{}) uint {
+	id, _ := auditUint(row["id"])
+	return id
+}
+
+// auditUint converts an integer column read into a map.
+func auditUint(value interface{}) (uint, bool) {
+	switch v := value.(type) {
+	case int64:
+		return uint(v), v > 0
+	case uint64:
+		return uint(v), v > 0
+	case int32:
+		return uint(v), v > 0
+	case uint32:
+		return uint(v), v > 0
+	case int:
+		return uint(v), v > 0
+	case uint:
+		return v, v > 0
+	}
+	return 0, false
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// AuditLogRepository reads the audit trail. Audit logs are written by RegisterAuditTrail only.
+type AuditLogRepository interface {
+	GetAuditLogByID(ctx context.Context, id uint) (*models.AuditLog, error)
+	// ListAuditLogs returns a page of the audit logs matching filters on entity_type, entity_id,
+	// actor_user_id, actor_service_account_id, action and company_id, and created between from and
+	// to, newest first, with their total count.
+	ListAuditLogs(ctx context.Context, page, limit int, filters map[string]interface{}) ([]models.AuditLog, int64, error)
+}
+
+// auditLogRepository implements the AuditLogRepository interface.
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository creates a new instance of AuditLogRepository.
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{
+		db: db,
+	}
+}
+
+// GetAuditLogByID retrieves an audit log by its ID.
+func (r *auditLogRepository) GetAuditLogByID(ctx context.Context, id uint) (*models.AuditLog, error) {
+	var auditLog models.AuditLog
+	if err := conn(ctx, r.db).First(&auditLog, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	return &auditLog, nil
+}
+
+// ListAuditLogs retrieves audit logs with pagination and filters.
+func (r *auditLogRepository) ListAuditLogs(ctx context.Context, page, limit int, filters map[string]interface{}) ([]models.AuditLog, int64, error) {
+	offset := (page - 1) * limit
+
+	query := conn(ctx, r.db).Model(&models.AuditLog{})
+	for key, value := range filters {
+		switch key {
+		case "from":
+			query = query.Where("created_at >= ?", value)
+		case "to":
+			query = query.Where("created_at < ?", value)
+		case "entity_type", "entity_id", "actor_user_id", "actor_service_account_id", "action", "company_id":
+			query = query.Where(key+" = ?", value)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	var auditLogs []models.AuditLog
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&auditLogs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	return auditLogs, total, nil
+}
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+
+	"point-system-api/internal/types"
+)
+
+func TestDiffRows(t *testing.T) {
+	before := map[string]interface{}{"id": int64(4), "updated_at": "10:00", "start_at": "08:45", "notes": nil, "password": "hash1"}
+	after := map[string]interface{}{"id": int64(4), "updated_at": "10:05", "start_at": "08:00", "notes": nil, "password": "hash2"}
+
+	want := types.AuditChanges{
+		{Field: "password", Old: auditRedacted, New: auditRedacted},
+		{Field: "start_at", Old: "08:45", New: "08:00"},
+	}
+	if got := diffRows(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("update: got %+v, want %+v", got, want)
+	}
+
+	// A created row lists the columns it was given, without the bookkeeping of gorm.
+	want = types.AuditChanges{
+		{Field: "password", Old: nil, New: auditRedacted},
+		{Field: "start_at", Old: nil, New: "08:00"},
+	}
+	if got := diffRows(nil, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("create: got %+v, want %+v", got, want)
+	}
+}
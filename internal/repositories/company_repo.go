@@ -41,7 +41,7 @@ func (r *companyRepository) CreateCompany(ctx context.Context, company models.Co
 	}
 
 	// Create the company in the database
-	if err := r.db.WithContext(ctx).Create(&company).Error; err != nil {
+	if err := conn(ctx, r.db).Create(&company).Error; err != nil {
 		return 0, fmt.Errorf("failed to create company: %w", err)
 	}
 
@@ -52,7 +52,7 @@ func (r *companyRepository) CreateCompany(ctx context.Context, company models.Co
 // GetCompanyByID retrieves a company by its ID.
 func (r *companyRepository) GetCompanyByID(ctx context.Context, id uint) (*models.Company, error) {
 	var company models.Company
-	if err := r.db.WithContext(ctx).First(&company, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&company, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // No company found
 		}
@@ -64,7 +64,7 @@ func (r *companyRepository) GetCompanyByID(ctx context.Context, id uint) (*model
 // GetCompanyByName retrieves a company by its name.
 func (r *companyRepository) GetCompanyByName(ctx context.Context, name string) (*models.Company, error) {
 	var company models.Company
-	if err := r.db.WithContext(ctx).Where("company_name = ?", name).First(&company).Error; err != nil {
+	if err := conn(ctx, r.db).Where("company_name = ?", name).First(&company).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // No company found
 		}
@@ -76,7 +76,7 @@ func (r *companyRepository) GetCompanyByName(ctx context.Context, name string) (
 // ListCompanies retrieves all companies from the database.
 func (r *companyRepository) ListCompanies(ctx context.Context) ([]models.Company, error) {
 	var companies []models.Company
-	if err := r.db.WithContext(ctx).Find(&companies).Error; err != nil {
+	if err := conn(ctx, r.db).Find(&companies).Error; err != nil {
 		return nil, fmt.Errorf("failed to list companies: %w", err)
 	}
 	return companies, nil
@@ -90,7 +90,7 @@ func (r *companyRepository) UpdateCompany(ctx context.Context, company models.Co
 	}
 
 	// Update the company in the database
-	if err := r.db.WithContext(ctx).Save(&company).Error; err != nil {
+	if err := conn(ctx, r.db).Save(&company).Error; err != nil {
 		return false, fmt.Errorf("failed to update company: %w", err)
 	}
 
@@ -105,7 +105,7 @@ func (r *companyRepository) DeleteCompany(ctx context.Context, id uint) (bool, e
 	}
 
 	// Delete the company from the database
-	if err := r.db.WithContext(ctx).Delete(&models.Company{}, id).Error; err != nil {
+	if err := conn(ctx, r.db).Delete(&models.Company{}, id).Error; err != nil {
 		return false, fmt.Errorf("failed to delete company: %w", err)
 	}
 
@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// DeviceIdentityRepository defines the interface for device identity mappings and the
+// linking of punches to employees.
+type DeviceIdentityRepository interface {
+	CreateDeviceIdentity(ctx context.Context, identity *models.DeviceIdentity) error
+	GetDeviceIdentityByID(ctx context.Context, id uint) (*models.DeviceIdentity, error)
+	ListDeviceIdentities(ctx context.Context, filters map[string]interface{}) ([]models.DeviceIdentity, error)
+	UpdateDeviceIdentity(ctx context.Context, identity *models.DeviceIdentity) error
+	DeleteDeviceIdentity(ctx context.Context, id uint) error
+	// GetIdentitiesByDeviceUserID returns every mapping of a device user ID, whatever its scope.
+	GetIdentitiesByDeviceUserID(ctx context.Context, deviceUserID string) ([]models.DeviceIdentity, error)
+	// FindOverlappingIdentities returns the mappings with the same scope and device user ID whose
+	// effective dates overlap those of identity, excluding identity itself.
+	FindOverlappingIdentities(ctx context.Context, identity *models.DeviceIdentity) ([]models.DeviceIdentity, error)
+
+	// BackfillDeviceUserIDs fills the device user ID of punches stored before it was recorded.
+	BackfillDeviceUserIDs(ctx context.Context) error
+	// ListUnlinkedDeviceUserIDs returns the device user IDs having punches not linked to an employee.
+	ListUnlinkedDeviceUserIDs(ctx context.Context) ([]string, error)
+	// RelinkPunches resolves again the employee of every punch of a device user ID and returns
+	// the number of punches whose employee changed.
+	RelinkPunches(ctx context.Context, deviceUserID string) (int64, error)
+}
+
+// deviceIdentityRepository implements the DeviceIdentityRepository interface.
+type deviceIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewDeviceIdentityRepository creates a new instance of DeviceIdentityRepository.
+func NewDeviceIdentityRepository(db *gorm.DB) DeviceIdentityRepository {
+	return &deviceIdentityRepository{
+		db: db,
+	}
+}
+
+// CreateDeviceIdentity inserts a new mapping into the database.
+func (r *deviceIdentityRepository) CreateDeviceIdentity(ctx context.Context, identity *models.DeviceIdentity) error {
+	if err := conn(ctx, r.db).Create(identity).Error; err != nil {
+		return fmt.Errorf("failed to create device identity: %w", err)
+	}
+	return nil
+}
+
+// GetDeviceIdentityByID retrieves a mapping by its ID.
+func (r *deviceIdentityRepository) GetDeviceIdentityByID(ctx context.Context, id uint) (*models.DeviceIdentity, error) {
+	var identity models.DeviceIdentity
+	if err := conn(ctx, r.db).First(&identity, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No mapping found
+		}
+		return nil, fmt.Errorf("failed to retrieve device identity by ID: %w", err)
+	}
+	return &identity, nil
+}
+
+// ListDeviceIdentities retrieves mappings matching the given column filters.
+func (r *deviceIdentityRepository) ListDeviceIdentities(ctx context.Context, filters map[string]interface{}) ([]models.DeviceIdentity, error) {
+	var identities []models.DeviceIdentity
+	query := conn(ctx, r.db).Model(&models.DeviceIdentity{})
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+	if err := query.Order("device_user_id ASC, effective_from ASC").Find(&identities).Error; err != nil {
+		return nil, fmt.Errorf("failed to list device identities: %w", err)
+	}
+	return identities, nil
+}
+
+// UpdateDeviceIdentity updates an existing mapping in the database.
+func (r *deviceIdentityRepository) UpdateDeviceIdentity(ctx context.Context, identity *models.DeviceIdentity) error {
+	if identity.ID == 0 {
+		return errors.New("device identity ID is required")
+	}
+	if err := conn(ctx, r.db).Save(identity).Error; err != nil {
+		return fmt.Errorf("failed to update device identity: %w", err)
+	}
+	return nil
+}
+
+// DeleteDeviceIdentity deletes a mapping by its ID.
+func (r *deviceIdentityRepository) DeleteDeviceIdentity(ctx context.Context, id uint) error {
+	if id == 0 {
+		return errors.New("device identity ID is required")
+	}
+	if err := conn(ctx, r.db).Delete(&models.DeviceIdentity{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete device identity: %w", err)
+	}
+	return nil
+}
+
+// GetIdentitiesByDeviceUserID retrieves every mapping of a device user ID.
+func (r *deviceIdentityRepository) GetIdentitiesByDeviceUserID(ctx context.Context, deviceUserID string) ([]models.DeviceIdentity, error) {
+	var identities []models.DeviceIdentity
+	if err := conn(ctx, r.db).Where("device_user_id = ?", deviceUserID).Find(&identities).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve device identities: %w", err)
+	}
+	return identities, nil
+}
+
+// FindOverlappingIdentities retrieves the mappings conflicting with identity.
+func (r *deviceIdentityRepository) FindOverlappingIdentities(ctx context.Context, identity *models.DeviceIdentity) ([]models.DeviceIdentity, error) {
+	query := conn(ctx, r.db).
+		Where("device_user_id = ? AND id <> ?", identity.DeviceUserID, identity.ID)
+	if identity.DeviceID != nil {
+		query = query.Where("device_id = ?", *identity.DeviceID)
+	} else {
+		query = query.Where("device_id IS NULL AND company_id = ?", identity.CompanyID)
+	}
+	// Two ranges overlap when each starts before the other ends.
+	query = query.Where("effective_to IS NULL OR effective_to >= ?", identity.EffectiveFrom)
+	if identity.EffectiveTo != nil {
+		query = query.Where("effective_from <= ?", *identity.EffectiveTo)
+	}
+
+	var identities []models.DeviceIdentity
+	if err := query.Find(&identities).Error; err != nil {
+		return nil, fmt.Errorf("failed to check overlapping device identities: %w", err)
+	}
+	return identities, nil
+}
+
+// BackfillDeviceUserIDs copies the numeric user ID of older punches into their device user ID.
+func (r *deviceIdentityRepository) BackfillDeviceUserIDs(ctx context.Context) error {
+	err := conn(ctx, r.db).
+		Model(&models.AttendanceLog{}).
+		Where("device_user_id = '' OR device_user_id IS NULL").
+		Update("device_user_id", gorm.Expr("CAST(user_id AS CHAR)")).Error
+	if err != nil {
+		return fmt.Errorf("failed to backfill device user IDs: %w", err)
+	}
+	return nil
+}
+
+// ListUnlinkedDeviceUserIDs retrieves the device user IDs of the punches without an employee.
+func (r *deviceIdentityRepository) ListUnlinkedDeviceUserIDs(ctx context.Context) ([]string, error) {
+	var deviceUserIDs []string
+	err := conn(ctx, r.db).
+		Model(&models.AttendanceLog{}).
+		Where("employee_id IS NULL AND device_user_id <> ''").
+		Distinct().
+		Pluck("device_user_id", &deviceUserIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unlinked device user IDs: %w", err)
+	}
+	return deviceUserIDs, nil
+}
+
+// RelinkPunches applies the current mappings of a device user ID to all of its punches.
+func (r *deviceIdentityRepository) RelinkPunches(ctx context.Context, deviceUserID string) (int64, error) {
+	identities, err := r.GetIdentitiesByDeviceUserID(ctx, deviceUserID)
+	if err != nil {
+		return 0, err
+	}
+
+	var punches []models.AttendanceLog
+	err = conn(ctx, r.db).
+		Select("id", "serial_number", "timestamp", "employee_id").
+		Where("device_user_id = ?", deviceUserID).
+		Find(&punches).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve punches: %w", err)
+	}
+	if len(punches) == 0 {
+		return 0, nil
+	}
+
+	devices := map[string]*models.Device{}
+	var deviceRows []models.Device
+	if err := conn(ctx, r.db).Find(&deviceRows).Error; err != nil {
+		return 0, fmt.Errorf("failed to retrieve devices: %w", err)
+	}
+	for i := range deviceRows {
+		devices[deviceRows[i].SerialNumber] = &deviceRows[i]
+	}
+
+	// Group the punches whose employee changes by their new employee (0 for unlinked).
+	changes := map[uint][]uint{}
+	for _, punch := range punches {
+		var employeeID uint
+		if identity := models.ResolveDeviceIdentity(identities, devices[punch.SerialNumber], punch.Timestamp); identity != nil {
+			employeeID = identity.EmployeeID
+		}
+		var current uint
+		if punch.EmployeeID != nil {
+			current = *punch.EmployeeID
+		}
+		if employeeID != current {
+			changes[employeeID] = append(changes[employeeID], punch.ID)
+		}
+	}
+
+	var changed int64
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for employeeID, ids := range changes {
+			var value interface{}
+			if employeeID != 0 {
+				value = employeeID
+			}
+			// Stay well below the placeholder limit of a single statement.
+			for start := 0; start < len(ids); start += 1000 {
+				end := min(start+1000, len(ids))
+				result := tx.Model(&models.AttendanceLog{}).Where("id IN ?", ids[start:end]).Update("employee_id", value)
+				if result.Error != nil {
+					return fmt.Errorf("failed to relink punches: %w", result.Error)
+				}
+				changed += result.RowsAffected
+			}
+		}
+		return nil
+	})
+	return changed, err
+}
@@ -61,7 +61,7 @@ func (r *deviceRepository) CreateDevice(device *models.Device) error {
 // GetDeviceByID retrieves a device by its ID
 func (r *deviceRepository) GetDeviceByID(ctx context.Context, id uint) (*models.Device, error) {
 	var device models.Device
-	if err := r.db.WithContext(ctx).First(&device, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&device, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // Device not found
 		}
@@ -100,7 +100,7 @@ func (r *deviceRepository) ListDevicesWithFilters(ctx context.Context, page, lim
 	offset := (page - 1) * limit
 
 	// Build the query
-	query := r.db.WithContext(ctx).Model(&models.Device{})
+	query := conn(ctx, r.db).Model(&models.Device{})
 
 	// Apply search filter
 	if search != "" {
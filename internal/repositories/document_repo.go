@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// DocumentRepository defines the interface for document-related database operations.
+type DocumentRepository interface {
+	CreateDocument(ctx context.Context, document *models.Document) error
+	GetDocumentByID(ctx context.Context, id uint) (*models.Document, error)
+	ListDocuments(ctx context.Context, filters map[string]interface{}) ([]models.Document, error)
+	UpdateDocument(ctx context.Context, document *models.Document) error
+	DeleteDocument(ctx context.Context, id uint) error
+}
+
+// documentRepository implements the DocumentRepository interface.
+type documentRepository struct {
+	db *gorm.DB
+}
+
+// NewDocumentRepository creates a new instance of DocumentRepository.
+func NewDocumentRepository(db *gorm.DB) DocumentRepository {
+	return &documentRepository{
+		db: db,
+	}
+}
+
+// CreateDocument inserts a new document into the database.
+func (r *documentRepository) CreateDocument(ctx context.Context, document *models.Document) error {
+	if err := conn(ctx, r.db).Create(document).Error; err != nil {
+		return fmt.Errorf("failed to create document: %w", err)
+	}
+	return nil
+}
+
+// GetDocumentByID retrieves a document by its ID.
+func (r *documentRepository) GetDocumentByID(ctx context.Context, id uint) (*models.Document, error) {
+	var document models.Document
+	if err := conn(ctx, r.db).First(&document, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No document found
+		}
+		return nil, fmt.Errorf("failed to retrieve document by ID: %w", err)
+	}
+	return &document, nil
+}
+
+// ListDocuments retrieves documents matching the given column filters, newest first.
+func (r *documentRepository) ListDocuments(ctx context.Context, filters map[string]interface{}) ([]models.Document, error) {
+	var documents []models.Document
+	query := conn(ctx, r.db).Model(&models.Document{})
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+	if err := query.Order("created_at DESC").Find(&documents).Error; err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	return documents, nil
+}
+
+// UpdateDocument updates an existing document in the database.
+func (r *documentRepository) UpdateDocument(ctx context.Context, document *models.Document) error {
+	if document.ID == 0 {
+		return errors.New("document ID is required")
+	}
+	if err := conn(ctx, r.db).Save(document).Error; err != nil {
+		return fmt.Errorf("failed to update document: %w", err)
+	}
+	return nil
+}
+
+// DeleteDocument deletes a document by its ID.
+func (r *documentRepository) DeleteDocument(ctx context.Context, id uint) error {
+	if id == 0 {
+		return errors.New("document ID is required")
+	}
+	if err := conn(ctx, r.db).Delete(&models.Document{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete document: %w", err)
+	}
+	return nil
+}
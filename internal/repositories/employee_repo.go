@@ -14,8 +14,13 @@ import (
 // EmployeeRepository defines the interface for employee-related database operations.
 type EmployeeRepository interface {
 	CreateEmployee(ctx context.Context, employee *models.Employee) error
+	// CreateEmployeesWithUsers creates each user and the employee at the same index in one transaction.
+	CreateEmployeesWithUsers(ctx context.Context, users []*models.User, employees []*models.Employee) error
 	GetEmployeeByIDWithUser(ctx context.Context, id uint) (*types.EmployeeWithUser, error)
 	GetEmployeeByID(ctx context.Context, id uint) (*models.Employee, error)
+	GetEmployeeByRegistrationNumber(ctx context.Context, registrationNumber string) (*models.Employee, error)
+	// GetEmployeeByUserID retrieves the employee record of a user account, nil when it has none.
+	GetEmployeeByUserID(ctx context.Context, userID uint) (*models.Employee, error)
 	GetEmployeesByCompanyID(ctx context.Context, companyID uint) ([]*models.Employee, error)
 	UpdateEmployee(ctx context.Context, employee *models.Employee) error
 	DeleteEmployee(ctx context.Context, id uint) error
@@ -45,18 +50,49 @@ func (r *employeeRepository) CreateEmployee(ctx context.Context, employee *model
 		return errors.New("company ID is required")
 	}
 
-	// Create the employee in the database
-	if err := r.db.WithContext(ctx).Create(employee).Error; err != nil {
-		return fmt.Errorf("failed to create employee: %w", err)
+	// Create the employee and its first employment assignment in the database
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(employee).Error; err != nil {
+			return fmt.Errorf("failed to create employee: %w", err)
+		}
+		if err := tx.Create(initialAssignment(employee)).Error; err != nil {
+			return fmt.Errorf("failed to create employment assignment: %w", err)
+		}
+		return nil
+	})
+}
+
+// CreateEmployeesWithUsers inserts users and their employees atomically, linking each employee to its user.
+// The passwords of the users are already hashed, and start their password history.
+func (r *employeeRepository) CreateEmployeesWithUsers(ctx context.Context, users []*models.User, employees []*models.Employee) error {
+	if len(users) != len(employees) {
+		return errors.New("each employee needs exactly one user")
 	}
 
-	return nil
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for i, user := range users {
+			if err := tx.Create(user).Error; err != nil {
+				return fmt.Errorf("failed to create user %s: %w", user.Username, err)
+			}
+			if err := tx.Create(&models.PasswordHistory{UserID: user.ID, PasswordHash: user.Password}).Error; err != nil {
+				return fmt.Errorf("failed to record password history of %s: %w", user.Username, err)
+			}
+			employees[i].UserID = user.ID
+			if err := tx.Create(employees[i]).Error; err != nil {
+				return fmt.Errorf("failed to create employee %s: %w", employees[i].RegistrationNumber, err)
+			}
+			if err := tx.Create(initialAssignment(employees[i])).Error; err != nil {
+				return fmt.Errorf("failed to create employment assignment of %s: %w", employees[i].RegistrationNumber, err)
+			}
+		}
+		return nil
+	})
 }
 
 // GetEmployeeByID retrieves an employee by their ID.
 func (r *employeeRepository) GetEmployeeByID(ctx context.Context, id uint) (*models.Employee, error) {
 	var employee models.Employee
-	if err := r.db.WithContext(ctx).First(&employee, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&employee, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // No employee found
 		}
@@ -65,6 +101,30 @@ func (r *employeeRepository) GetEmployeeByID(ctx context.Context, id uint) (*mod
 	return &employee, nil
 }
 
+// GetEmployeeByRegistrationNumber retrieves an employee by their registration number.
+func (r *employeeRepository) GetEmployeeByRegistrationNumber(ctx context.Context, registrationNumber string) (*models.Employee, error) {
+	var employee models.Employee
+	if err := conn(ctx, r.db).Where("registration_number = ?", registrationNumber).First(&employee).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No employee found
+		}
+		return nil, fmt.Errorf("failed to retrieve employee by registration number: %w", err)
+	}
+	return &employee, nil
+}
+
+// GetEmployeeByUserID retrieves the employee whose account is userID.
+func (r *employeeRepository) GetEmployeeByUserID(ctx context.Context, userID uint) (*models.Employee, error) {
+	var employee models.Employee
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).First(&employee).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No employee found
+		}
+		return nil, fmt.Errorf("failed to retrieve employee by user ID: %w", err)
+	}
+	return &employee, nil
+}
+
 // GetEmployeeByID retrieves an employee by their ID.
 func (r *employeeRepository) GetEmployeeByIDWithUser(ctx context.Context, id uint) (*types.EmployeeWithUser, error) {
 	var employeeWithUser types.EmployeeWithUser
@@ -73,7 +133,8 @@ func (r *employeeRepository) GetEmployeeByIDWithUser(ctx context.Context, id uin
 	query := `
 		SELECT 
 			e.id, e.user_id, e.registration_number, e.qualification, e.company_id, 
-			e.start_hour, e.end_hour, e.created_at, e.updated_at,
+			e.start_hour, e.end_hour, e.hire_date, e.termination_date, e.termination_reason,
+			e.created_at, e.updated_at,
 			u.id AS user_id, u.first_name, u.last_name, u.username, u.role
 		FROM 
 			employees e
@@ -82,14 +143,22 @@ func (r *employeeRepository) GetEmployeeByIDWithUser(ctx context.Context, id uin
 		WHERE 
 			e.id = ? && e.deleted_at is null
 	`
+	args := []interface{}{id}
+	if condition, tenantArgs := tenantFilter(ctx, "e.company_id"); condition != "" {
+		query += " AND " + condition
+		args = append(args, tenantArgs...)
+	}
 
 	// Execute the query
-	if err := r.db.WithContext(ctx).Raw(query, id).Scan(&employeeWithUser).Error; err != nil {
+	if err := conn(ctx, r.db).Raw(query, args...).Scan(&employeeWithUser).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // No employee found
 		}
 		return nil, fmt.Errorf("failed to retrieve employee by ID: %w", err)
 	}
+	if employeeWithUser.ID == 0 {
+		return nil, nil // No employee found, or outside of the tenant scope
+	}
 
 	return &employeeWithUser, nil
 }
@@ -97,7 +166,7 @@ func (r *employeeRepository) GetEmployeeByIDWithUser(ctx context.Context, id uin
 // GetEmployeesByCompanyID retrieves all employees for a specific company.
 func (r *employeeRepository) GetEmployeesByCompanyID(ctx context.Context, companyID uint) ([]*models.Employee, error) {
 	var employees []*models.Employee
-	if err := r.db.WithContext(ctx).Where("company_id = ?", companyID).Find(&employees).Error; err != nil {
+	if err := conn(ctx, r.db).Where("company_id = ?", companyID).Find(&employees).Error; err != nil {
 		return nil, fmt.Errorf("failed to retrieve employees by company ID: %w", err)
 	}
 	return employees, nil
@@ -111,7 +180,7 @@ func (r *employeeRepository) UpdateEmployee(ctx context.Context, employee *model
 	}
 
 	// Update the employee in the database
-	if err := r.db.WithContext(ctx).Save(employee).Error; err != nil {
+	if err := conn(ctx, r.db).Save(employee).Error; err != nil {
 		return fmt.Errorf("failed to update employee: %w", err)
 	}
 
@@ -126,7 +195,7 @@ func (r *employeeRepository) DeleteEmployee(ctx context.Context, id uint) error
 	}
 
 	// Delete the employee from the database
-	if err := r.db.WithContext(ctx).Delete(&models.Employee{}, id).Error; err != nil {
+	if err := conn(ctx, r.db).Delete(&models.Employee{}, id).Error; err != nil {
 		return fmt.Errorf("failed to delete employee: %w", err)
 	}
 
@@ -136,7 +205,7 @@ func (r *employeeRepository) DeleteEmployee(ctx context.Context, id uint) error
 // FetchEmployees retrieves all employees from the database.
 func (r *employeeRepository) FetchEmployees(ctx context.Context) ([]*models.Employee, error) {
 	var employees []*models.Employee
-	if err := r.db.WithContext(ctx).Find(&employees).Error; err != nil {
+	if err := conn(ctx, r.db).Find(&employees).Error; err != nil {
 		return nil, fmt.Errorf("failed to fetch employees: %w", err)
 	}
 	return employees, nil
@@ -148,13 +217,17 @@ func (r *employeeRepository) ListEmployeesWithFilters(ctx context.Context, limit
 	offset := (page - 1) * limit
 
 	// Base query
-	query := r.db.WithContext(ctx).Table("employees e").
+	query := conn(ctx, r.db).Table("employees e").
 		Select(`
 			e.id, e.user_id, e.registration_number, e.qualification, e.company_id, 
-			e.start_hour, e.end_hour, e.created_at, e.updated_at,
+			e.start_hour, e.end_hour, e.hire_date, e.termination_date, e.termination_reason,
+			e.created_at, e.updated_at,
 			u.id AS user_id, u.first_name, u.last_name, u.username, u.role
 		`).
 		Joins("JOIN users u ON e.user_id = u.id")
+	if condition, args := tenantFilter(ctx, "e.company_id"); condition != "" {
+		query = query.Where(condition, args...)
+	}
 
 	// Apply filters
 	for key, value := range filters {
@@ -43,7 +43,7 @@ func (r *employeeWorkDayRepository) CreateEmployeeWorkDay(ctx context.Context, e
 		return errors.New("employee ID is required")
 	}
 
-	if err := r.db.WithContext(ctx).Create(employeeWorkDay).Error; err != nil {
+	if err := conn(ctx, r.db).Create(employeeWorkDay).Error; err != nil {
 		return fmt.Errorf("failed to create employee workday: %w", err)
 	}
 
@@ -57,7 +57,7 @@ func (r *employeeWorkDayRepository) GetEmployeeWorkDayByID(ctx context.Context,
 	}
 
 	var employeeWorkDay models.EmployeeWorkDay
-	if err := r.db.WithContext(ctx).First(&employeeWorkDay, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&employeeWorkDay, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // No employee workday found
 		}
@@ -80,7 +80,7 @@ func (r *employeeWorkDayRepository) UpdateEmployeeWorkDay(ctx context.Context, e
 		return errors.New("employee ID is required")
 	}
 
-	if err := r.db.WithContext(ctx).Save(employeeWorkDay).Error; err != nil {
+	if err := conn(ctx, r.db).Save(employeeWorkDay).Error; err != nil {
 		return fmt.Errorf("failed to update employee workday: %w", err)
 	}
 
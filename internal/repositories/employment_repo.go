@@ -0,0 +1,253 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// employmentEpoch starts the first assignment of employees whose hire date is unknown, so that
+// all of their recorded history stays covered.
+var employmentEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.Local)
+
+// EmploymentRepository defines the interface for the employment history of employees.
+type EmploymentRepository interface {
+	ListAssignments(ctx context.Context, employeeID uint) ([]models.EmploymentAssignment, error)
+	// GetAssignmentAt returns the assignment of an employee effective on date, or nil when they
+	// were not employed on that date.
+	GetAssignmentAt(ctx context.Context, employeeID uint, date time.Time) (*models.EmploymentAssignment, error)
+	// Transfer ends the current assignment and starts the next one atomically.
+	Transfer(ctx context.Context, current, next *models.EmploymentAssignment) error
+	// Terminate stores the termination of an employee and ends their assignments on its date.
+	Terminate(ctx context.Context, employee *models.Employee) error
+	// Reinstate cancels the termination of an employee and reopens the assignment it ended.
+	Reinstate(ctx context.Context, employee *models.Employee) error
+	// CorrectCurrentAssignment aligns the latest assignment with the company and position of the
+	// employee, and the start of the first one with their hire date.
+	CorrectCurrentAssignment(ctx context.Context, employee *models.Employee) error
+	// EnsureInitialAssignments creates the first assignment of the employees having none.
+	EnsureInitialAssignments(ctx context.Context) (int64, error)
+	// SyncCurrentAssignments copies the assignment effective on date into the employees whose
+	// company or position differs, and returns those employees.
+	SyncCurrentAssignments(ctx context.Context, date time.Time) ([]*models.Employee, error)
+}
+
+// employmentRepository implements the EmploymentRepository interface.
+type employmentRepository struct {
+	db *gorm.DB
+}
+
+// NewEmploymentRepository creates a new instance of EmploymentRepository.
+func NewEmploymentRepository(db *gorm.DB) EmploymentRepository {
+	return &employmentRepository{db: db}
+}
+
+// initialAssignment returns the first assignment of a newly created employee.
+func initialAssignment(employee *models.Employee) *models.EmploymentAssignment {
+	from := types.DateOnly(employmentEpoch)
+	if employee.HireDate != nil {
+		from = *employee.HireDate
+	}
+	return &models.EmploymentAssignment{
+		EmployeeID:    employee.ID,
+		CompanyID:     employee.CompanyID,
+		Qualification: employee.Qualification,
+		EffectiveFrom: from,
+		Kind:          models.EmploymentKindHire,
+	}
+}
+
+// ListAssignments retrieves the assignments of an employee, oldest first.
+func (r *employmentRepository) ListAssignments(ctx context.Context, employeeID uint) ([]models.EmploymentAssignment, error) {
+	var assignments []models.EmploymentAssignment
+	if err := conn(ctx, r.db).
+		Where("employee_id = ?", employeeID).
+		Order("effective_from ASC").
+		Find(&assignments).Error; err != nil {
+		return nil, fmt.Errorf("failed to list employment assignments: %w", err)
+	}
+	return assignments, nil
+}
+
+// GetAssignmentAt retrieves the assignment of an employee effective on date.
+func (r *employmentRepository) GetAssignmentAt(ctx context.Context, employeeID uint, date time.Time) (*models.EmploymentAssignment, error) {
+	var assignment models.EmploymentAssignment
+	day := date.Format("2006-01-02")
+	err := conn(ctx, r.db).
+		Where("employee_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to >= ?)", employeeID, day, day).
+		First(&assignment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Not employed on that date
+		}
+		return nil, fmt.Errorf("failed to retrieve employment assignment: %w", err)
+	}
+	return &assignment, nil
+}
+
+// Transfer ends current and creates next in a single transaction.
+func (r *employmentRepository) Transfer(ctx context.Context, current, next *models.EmploymentAssignment) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(current).Error; err != nil {
+			return fmt.Errorf("failed to end employment assignment: %w", err)
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return fmt.Errorf("failed to create employment assignment: %w", err)
+		}
+		return nil
+	})
+}
+
+// Terminate deletes the assignments starting after the termination date, ends the others on it
+// and saves the employee, in a single transaction.
+func (r *employmentRepository) Terminate(ctx context.Context, employee *models.Employee) error {
+	if employee.TerminationDate == nil {
+		return errors.New("termination date is required")
+	}
+	day := employee.TerminationDate.ToTime().Format("2006-01-02")
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("employee_id = ? AND effective_from > ?", employee.ID, day).
+			Delete(&models.EmploymentAssignment{}).Error; err != nil {
+			return fmt.Errorf("failed to delete employment assignments: %w", err)
+		}
+		if err := tx.Model(&models.EmploymentAssignment{}).
+			Where("employee_id = ? AND (effective_to IS NULL OR effective_to > ?)", employee.ID, day).
+			Update("effective_to", day).Error; err != nil {
+			return fmt.Errorf("failed to end employment assignments: %w", err)
+		}
+		if err := tx.Save(employee).Error; err != nil {
+			return fmt.Errorf("failed to update employee: %w", err)
+		}
+		return nil
+	})
+}
+
+// Reinstate reopens the latest assignment, when it ended on the termination date, and clears the
+// termination of the employee, in a single transaction.
+func (r *employmentRepository) Reinstate(ctx context.Context, employee *models.Employee) error {
+	if employee.TerminationDate == nil {
+		return errors.New("employee is not terminated")
+	}
+	day := employee.TerminationDate.ToTime().Format("2006-01-02")
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var latest models.EmploymentAssignment
+		err := tx.Where("employee_id = ?", employee.ID).Order("effective_from DESC").First(&latest).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to retrieve employment assignment: %w", err)
+		}
+		if err == nil && latest.EffectiveTo != nil && latest.EffectiveTo.ToTime().Format("2006-01-02") == day {
+			if err := tx.Model(&latest).Update("effective_to", nil).Error; err != nil {
+				return fmt.Errorf("failed to reopen employment assignment: %w", err)
+			}
+		}
+
+		employee.TerminationDate = nil
+		employee.TerminationReason = ""
+		if err := tx.Save(employee).Error; err != nil {
+			return fmt.Errorf("failed to update employee: %w", err)
+		}
+		return nil
+	})
+}
+
+// CorrectCurrentAssignment updates the latest and first assignments of an employee in place.
+func (r *employmentRepository) CorrectCurrentAssignment(ctx context.Context, employee *models.Employee) error {
+	assignments, err := r.ListAssignments(ctx, employee.ID)
+	if err != nil {
+		return err
+	}
+	if len(assignments) == 0 {
+		return conn(ctx, r.db).Create(initialAssignment(employee)).Error
+	}
+
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		latest := &assignments[len(assignments)-1]
+		if latest.CompanyID != employee.CompanyID || latest.Qualification != employee.Qualification {
+			latest.CompanyID = employee.CompanyID
+			latest.Qualification = employee.Qualification
+			if err := tx.Save(latest).Error; err != nil {
+				return fmt.Errorf("failed to correct employment assignment: %w", err)
+			}
+		}
+
+		// The hire date moves the start of the first assignment, within its own bounds.
+		first := &assignments[0]
+		if employee.HireDate != nil && first.Kind == models.EmploymentKindHire &&
+			!employee.HireDate.ToTime().Equal(first.EffectiveFrom.ToTime()) &&
+			(first.EffectiveTo == nil || !employee.HireDate.ToTime().After(first.EffectiveTo.ToTime())) {
+			if err := tx.Model(first).Update("effective_from", *employee.HireDate).Error; err != nil {
+				return fmt.Errorf("failed to correct hire date: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// EnsureInitialAssignments creates the first assignment of every employee that has none.
+func (r *employmentRepository) EnsureInitialAssignments(ctx context.Context) (int64, error) {
+	var employees []*models.Employee
+	err := conn(ctx, r.db).
+		Where("NOT EXISTS (SELECT 1 FROM employment_assignments a WHERE a.employee_id = employees.id AND a.deleted_at IS NULL)").
+		Find(&employees).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to list employees without assignment: %w", err)
+	}
+	if len(employees) == 0 {
+		return 0, nil
+	}
+
+	assignments := make([]*models.EmploymentAssignment, 0, len(employees))
+	for _, employee := range employees {
+		assignment := initialAssignment(employee)
+		if employee.TerminationDate != nil {
+			assignment.EffectiveTo = employee.TerminationDate
+		}
+		assignments = append(assignments, assignment)
+	}
+	if err := conn(ctx, r.db).CreateInBatches(assignments, 500).Error; err != nil {
+		return 0, fmt.Errorf("failed to create employment assignments: %w", err)
+	}
+	return int64(len(assignments)), nil
+}
+
+// SyncCurrentAssignments updates the company and position of employees from their assignment on date.
+func (r *employmentRepository) SyncCurrentAssignments(ctx context.Context, date time.Time) ([]*models.Employee, error) {
+	var rows []struct {
+		EmployeeID    uint
+		CompanyID     uint
+		Qualification string
+	}
+	day := date.Format("2006-01-02")
+	err := conn(ctx, r.db).Table("employment_assignments a").
+		Select("a.employee_id, a.company_id, a.qualification").
+		Joins("JOIN employees e ON e.id = a.employee_id AND e.deleted_at IS NULL").
+		Where("a.deleted_at IS NULL AND a.effective_from <= ? AND (a.effective_to IS NULL OR a.effective_to >= ?)", day, day).
+		Where("a.company_id <> e.company_id OR a.qualification <> e.qualification").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list outdated employees: %w", err)
+	}
+
+	changed := make([]*models.Employee, 0, len(rows))
+	for _, row := range rows {
+		var employee models.Employee
+		if err := conn(ctx, r.db).First(&employee, row.EmployeeID).Error; err != nil {
+			return changed, fmt.Errorf("failed to retrieve employee: %w", err)
+		}
+		employee.CompanyID = row.CompanyID
+		employee.Qualification = row.Qualification
+		if err := conn(ctx, r.db).Save(&employee).Error; err != nil {
+			return changed, fmt.Errorf("failed to update employee: %w", err)
+		}
+		changed = append(changed, &employee)
+	}
+	return changed, nil
+}
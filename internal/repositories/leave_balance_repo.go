@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// LeaveBalanceRepository defines the interface for accrual policy and leave balance entry operations.
+type LeaveBalanceRepository interface {
+	// GetAccrualPolicy returns the accrual policy of a leave type with its seniority bonuses, or nil.
+	GetAccrualPolicy(ctx context.Context, leaveTypeID uint) (*models.LeaveAccrualPolicy, error)
+	// SaveAccrualPolicy creates or replaces the accrual policy of a leave type, including its bonuses.
+	SaveAccrualPolicy(ctx context.Context, policy *models.LeaveAccrualPolicy) error
+	ListAccrualPolicies(ctx context.Context) ([]models.LeaveAccrualPolicy, error)
+
+	CreateBalanceEntry(ctx context.Context, entry *models.LeaveBalanceEntry) error
+	ListBalanceEntries(ctx context.Context, employeeID, leaveTypeID uint) ([]models.LeaveBalanceEntry, error)
+	// GetRolloverEntry returns the rollover entry closing the given year, or nil.
+	GetRolloverEntry(ctx context.Context, employeeID, leaveTypeID uint, year int) (*models.LeaveBalanceEntry, error)
+}
+
+// leaveBalanceRepository implements the LeaveBalanceRepository interface.
+type leaveBalanceRepository struct {
+	db *gorm.DB
+}
+
+// NewLeaveBalanceRepository creates a new instance of LeaveBalanceRepository.
+func NewLeaveBalanceRepository(db *gorm.DB) LeaveBalanceRepository {
+	return &leaveBalanceRepository{
+		db: db,
+	}
+}
+
+// GetAccrualPolicy retrieves the accrual policy of a leave type.
+func (r *leaveBalanceRepository) GetAccrualPolicy(ctx context.Context, leaveTypeID uint) (*models.LeaveAccrualPolicy, error) {
+	var policy models.LeaveAccrualPolicy
+	err := conn(ctx, r.db).
+		Preload("SeniorityBonuses", func(db *gorm.DB) *gorm.DB { return db.Order("after_years ASC") }).
+		Where("leave_type_id = ?", leaveTypeID).
+		First(&policy).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No policy configured
+		}
+		return nil, fmt.Errorf("failed to retrieve accrual policy: %w", err)
+	}
+	return &policy, nil
+}
+
+// SaveAccrualPolicy upserts the policy of policy.LeaveTypeID and replaces its seniority bonuses.
+func (r *leaveBalanceRepository) SaveAccrualPolicy(ctx context.Context, policy *models.LeaveAccrualPolicy) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var existing models.LeaveAccrualPolicy
+		err := tx.Where("leave_type_id = ?", policy.LeaveTypeID).First(&existing).Error
+		switch {
+		case err == nil:
+			policy.ID = existing.ID
+			policy.CreatedAt = existing.CreatedAt
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("failed to retrieve accrual policy: %w", err)
+		}
+
+		bonuses := policy.SeniorityBonuses
+		policy.SeniorityBonuses = nil
+		if err := tx.Save(policy).Error; err != nil {
+			return fmt.Errorf("failed to save accrual policy: %w", err)
+		}
+		if err := tx.Unscoped().Where("policy_id = ?", policy.ID).Delete(&models.LeaveSeniorityBonus{}).Error; err != nil {
+			return fmt.Errorf("failed to replace seniority bonuses: %w", err)
+		}
+		for i := range bonuses {
+			bonuses[i].ID = 0
+			bonuses[i].PolicyID = policy.ID
+		}
+		if len(bonuses) > 0 {
+			if err := tx.Create(&bonuses).Error; err != nil {
+				return fmt.Errorf("failed to save seniority bonuses: %w", err)
+			}
+		}
+		policy.SeniorityBonuses = bonuses
+		return nil
+	})
+}
+
+// ListAccrualPolicies retrieves every accrual policy with its seniority bonuses.
+func (r *leaveBalanceRepository) ListAccrualPolicies(ctx context.Context) ([]models.LeaveAccrualPolicy, error) {
+	var policies []models.LeaveAccrualPolicy
+	if err := conn(ctx, r.db).Preload("SeniorityBonuses").Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to list accrual policies: %w", err)
+	}
+	return policies, nil
+}
+
+// CreateBalanceEntry inserts a new leave balance entry into the database.
+func (r *leaveBalanceRepository) CreateBalanceEntry(ctx context.Context, entry *models.LeaveBalanceEntry) error {
+	if err := conn(ctx, r.db).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to create leave balance entry: %w", err)
+	}
+	return nil
+}
+
+// ListBalanceEntries retrieves the balance entries of an employee for a leave type, oldest first.
+func (r *leaveBalanceRepository) ListBalanceEntries(ctx context.Context, employeeID, leaveTypeID uint) ([]models.LeaveBalanceEntry, error) {
+	var entries []models.LeaveBalanceEntry
+	err := conn(ctx, r.db).
+		Where("employee_id = ? AND leave_type_id = ?", employeeID, leaveTypeID).
+		Order("date ASC, id ASC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leave balance entries: %w", err)
+	}
+	return entries, nil
+}
+
+// GetRolloverEntry retrieves the rollover entry of an employee and leave type for a year.
+func (r *leaveBalanceRepository) GetRolloverEntry(ctx context.Context, employeeID, leaveTypeID uint, year int) (*models.LeaveBalanceEntry, error) {
+	var entry models.LeaveBalanceEntry
+	err := conn(ctx, r.db).
+		Where("employee_id = ? AND leave_type_id = ? AND kind = ? AND year = ?",
+			employeeID, leaveTypeID, models.LeaveBalanceEntryRollover, year).
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // Year not rolled over yet
+		}
+		return nil, fmt.Errorf("failed to retrieve rollover entry: %w", err)
+	}
+	return &entry, nil
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// LeaveRequestRepository defines the interface for leave request-related database operations.
+type LeaveRequestRepository interface {
+	CreateLeaveRequest(ctx context.Context, request *models.LeaveRequest) error
+	GetLeaveRequestByID(ctx context.Context, id uint) (*models.LeaveRequest, error)
+	ListLeaveRequests(ctx context.Context, filters map[string]interface{}) ([]models.LeaveRequest, error)
+	UpdateLeaveRequest(ctx context.Context, request *models.LeaveRequest) error
+	// FindActiveLeaveRequests returns the pending and approved requests of an employee overlapping [start, end].
+	FindActiveLeaveRequests(ctx context.Context, employeeID uint, start, end time.Time, excludeID uint) ([]models.LeaveRequest, error)
+	// GetApprovedLeaveRequestsForDate returns every approved request covering the date.
+	GetApprovedLeaveRequestsForDate(ctx context.Context, date time.Time) ([]models.LeaveRequest, error)
+}
+
+// leaveRequestRepository implements the LeaveRequestRepository interface.
+type leaveRequestRepository struct {
+	db *gorm.DB
+}
+
+// NewLeaveRequestRepository creates a new instance of LeaveRequestRepository.
+func NewLeaveRequestRepository(db *gorm.DB) LeaveRequestRepository {
+	return &leaveRequestRepository{
+		db: db,
+	}
+}
+
+// CreateLeaveRequest inserts a new leave request into the database.
+func (r *leaveRequestRepository) CreateLeaveRequest(ctx context.Context, request *models.LeaveRequest) error {
+	if err := conn(ctx, r.db).Create(request).Error; err != nil {
+		return fmt.Errorf("failed to create leave request: %w", err)
+	}
+	return nil
+}
+
+// GetLeaveRequestByID retrieves a leave request by its ID.
+func (r *leaveRequestRepository) GetLeaveRequestByID(ctx context.Context, id uint) (*models.LeaveRequest, error) {
+	var request models.LeaveRequest
+	if err := conn(ctx, r.db).First(&request, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No leave request found
+		}
+		return nil, fmt.Errorf("failed to retrieve leave request by ID: %w", err)
+	}
+	return &request, nil
+}
+
+// ListLeaveRequests retrieves leave requests matching the given filters.
+// The company_id filter is resolved through the requesting employee.
+func (r *leaveRequestRepository) ListLeaveRequests(ctx context.Context, filters map[string]interface{}) ([]models.LeaveRequest, error) {
+	var requests []models.LeaveRequest
+	query := conn(ctx, r.db).Model(&models.LeaveRequest{})
+	for key, value := range filters {
+		if key == "company_id" {
+			query = query.Where("employee_id IN (?)", r.db.Model(&models.Employee{}).Select("id").Where("company_id = ?", value))
+		} else {
+			query = query.Where("leave_requests."+key+" = ?", value)
+		}
+	}
+	if err := query.Order("start_date DESC").Find(&requests).Error; err != nil {
+		return nil, fmt.Errorf("failed to list leave requests: %w", err)
+	}
+	return requests, nil
+}
+
+// UpdateLeaveRequest updates an existing leave request in the database.
+func (r *leaveRequestRepository) UpdateLeaveRequest(ctx context.Context, request *models.LeaveRequest) error {
+	if request.ID == 0 {
+		return errors.New("leave request ID is required")
+	}
+	if err := conn(ctx, r.db).Save(request).Error; err != nil {
+		return fmt.Errorf("failed to update leave request: %w", err)
+	}
+	return nil
+}
+
+// FindActiveLeaveRequests returns the pending and approved requests of an employee overlapping [start, end].
+func (r *leaveRequestRepository) FindActiveLeaveRequests(ctx context.Context, employeeID uint, start, end time.Time, excludeID uint) ([]models.LeaveRequest, error) {
+	var requests []models.LeaveRequest
+	err := conn(ctx, r.db).
+		Where("employee_id = ? AND status IN ? AND start_date <= ? AND end_date >= ? AND id <> ?",
+			employeeID,
+			[]string{models.LeaveStatusPending, models.LeaveStatusApproved},
+			end.Format("2006-01-02"), start.Format("2006-01-02"), excludeID).
+		Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to check overlapping leave requests: %w", err)
+	}
+	return requests, nil
+}
+
+// GetApprovedLeaveRequestsForDate returns every approved request covering the date.
+func (r *leaveRequestRepository) GetApprovedLeaveRequestsForDate(ctx context.Context, date time.Time) ([]models.LeaveRequest, error) {
+	var requests []models.LeaveRequest
+	day := date.Format("2006-01-02")
+	err := conn(ctx, r.db).
+		Where("status = ? AND start_date <= ? AND end_date >= ?", models.LeaveStatusApproved, day, day).
+		Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve approved leave requests: %w", err)
+	}
+	return requests, nil
+}
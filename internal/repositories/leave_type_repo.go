@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// LeaveTypeRepository defines the interface for leave type-related database operations.
+type LeaveTypeRepository interface {
+	CreateLeaveType(ctx context.Context, leaveType *models.LeaveType) error
+	GetLeaveTypeByID(ctx context.Context, id uint) (*models.LeaveType, error)
+	GetLeaveTypeByCode(ctx context.Context, companyID uint, code string) (*models.LeaveType, error)
+	ListLeaveTypes(ctx context.Context, filters map[string]interface{}) ([]models.LeaveType, error)
+	UpdateLeaveType(ctx context.Context, leaveType *models.LeaveType) error
+	DeleteLeaveType(ctx context.Context, id uint) error
+}
+
+// leaveTypeRepository implements the LeaveTypeRepository interface.
+type leaveTypeRepository struct {
+	db *gorm.DB
+}
+
+// NewLeaveTypeRepository creates a new instance of LeaveTypeRepository.
+func NewLeaveTypeRepository(db *gorm.DB) LeaveTypeRepository {
+	return &leaveTypeRepository{
+		db: db,
+	}
+}
+
+// CreateLeaveType inserts a new leave type into the database.
+func (r *leaveTypeRepository) CreateLeaveType(ctx context.Context, leaveType *models.LeaveType) error {
+	if err := conn(ctx, r.db).Create(leaveType).Error; err != nil {
+		return fmt.Errorf("failed to create leave type: %w", err)
+	}
+	return nil
+}
+
+// GetLeaveTypeByID retrieves a leave type by its ID.
+func (r *leaveTypeRepository) GetLeaveTypeByID(ctx context.Context, id uint) (*models.LeaveType, error) {
+	var leaveType models.LeaveType
+	if err := conn(ctx, r.db).First(&leaveType, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No leave type found
+		}
+		return nil, fmt.Errorf("failed to retrieve leave type by ID: %w", err)
+	}
+	return &leaveType, nil
+}
+
+// GetLeaveTypeByCode retrieves a leave type of a company by its code.
+func (r *leaveTypeRepository) GetLeaveTypeByCode(ctx context.Context, companyID uint, code string) (*models.LeaveType, error) {
+	var leaveType models.LeaveType
+	if err := conn(ctx, r.db).Where("company_id = ? AND code = ?", companyID, code).First(&leaveType).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No leave type found
+		}
+		return nil, fmt.Errorf("failed to retrieve leave type by code: %w", err)
+	}
+	return &leaveType, nil
+}
+
+// ListLeaveTypes retrieves leave types matching the given column filters.
+func (r *leaveTypeRepository) ListLeaveTypes(ctx context.Context, filters map[string]interface{}) ([]models.LeaveType, error) {
+	var leaveTypes []models.LeaveType
+	query := conn(ctx, r.db).Model(&models.LeaveType{})
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+	if err := query.Order("name ASC").Find(&leaveTypes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list leave types: %w", err)
+	}
+	return leaveTypes, nil
+}
+
+// UpdateLeaveType updates an existing leave type in the database.
+func (r *leaveTypeRepository) UpdateLeaveType(ctx context.Context, leaveType *models.LeaveType) error {
+	if leaveType.ID == 0 {
+		return errors.New("leave type ID is required")
+	}
+	if err := conn(ctx, r.db).Save(leaveType).Error; err != nil {
+		return fmt.Errorf("failed to update leave type: %w", err)
+	}
+	return nil
+}
+
+// DeleteLeaveType deletes a leave type by its ID.
+func (r *leaveTypeRepository) DeleteLeaveType(ctx context.Context, id uint) error {
+	if id == 0 {
+		return errors.New("leave type ID is required")
+	}
+	if err := conn(ctx, r.db).Delete(&models.LeaveType{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete leave type: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"point-system-api/internal/models"
+)
+
+// LoginRepository defines the interface for the login history and the throttling of failed logins.
+type LoginRepository interface {
+	CreateLoginAttempt(ctx context.Context, attempt *models.LoginAttempt) error
+	// ListLoginAttempts returns the last limit logins of a user, newest first.
+	ListLoginAttempts(ctx context.Context, userID uint, limit int) ([]models.LoginAttempt, error)
+	// GetLoginThrottles returns the throttles of the given keys that exist.
+	GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, error)
+	// LockLoginThrottle returns the throttle of a key, created when missing, locked until the end
+	// of the unit of work so that concurrent failures are counted one after the other.
+	LockLoginThrottle(ctx context.Context, key string) (*models.LoginThrottle, error)
+	SaveLoginThrottle(ctx context.Context, throttle *models.LoginThrottle) error
+	// ResetLoginThrottle forgets the failures of a key, lifting any lockout.
+	ResetLoginThrottle(ctx context.Context, key string) error
+}
+
+// loginRepository implements the LoginRepository interface.
+type loginRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginRepository creates a new instance of LoginRepository.
+func NewLoginRepository(db *gorm.DB) LoginRepository {
+	return &loginRepository{
+		db: db,
+	}
+}
+
+// CreateLoginAttempt inserts a login into the history.
+func (r *loginRepository) CreateLoginAttempt(ctx context.Context, attempt *models.LoginAttempt) error {
+	if err := conn(ctx, r.db).Create(attempt).Error; err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	return nil
+}
+
+// ListLoginAttempts retrieves the most recent logins of a user.
+func (r *loginRepository) ListLoginAttempts(ctx context.Context, userID uint, limit int) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	err := conn(ctx, r.db).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Find(&attempts).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve login history: %w", err)
+	}
+	return attempts, nil
+}
+
+// GetLoginThrottles retrieves the throttles of keys.
+func (r *loginRepository) GetLoginThrottles(ctx context.Context, keys []string) ([]models.LoginThrottle, error) {
+	var throttles []models.LoginThrottle
+	if err := conn(ctx, r.db).Where("`key` IN ?", keys).Find(&throttles).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve login throttles: %w", err)
+	}
+	return throttles, nil
+}
+
+// LockLoginThrottle inserts the throttle of a key unless it exists, then reads it FOR UPDATE.
+func (r *loginRepository) LockLoginThrottle(ctx context.Context, key string) (*models.LoginThrottle, error) {
+	db := conn(ctx, r.db)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginThrottle{Key: key}).Error; err != nil {
+		return nil, fmt.Errorf("failed to create login throttle: %w", err)
+	}
+	var throttle models.LoginThrottle
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("`key` = ?", key).First(&throttle).Error; err != nil {
+		return nil, fmt.Errorf("failed to lock login throttle: %w", err)
+	}
+	return &throttle, nil
+}
+
+// SaveLoginThrottle creates or updates a throttle.
+func (r *loginRepository) SaveLoginThrottle(ctx context.Context, throttle *models.LoginThrottle) error {
+	if err := conn(ctx, r.db).Save(throttle).Error; err != nil {
+		return fmt.Errorf("failed to save login throttle: %w", err)
+	}
+	return nil
+}
+
+// ResetLoginThrottle deletes the throttle of a key.
+func (r *loginRepository) ResetLoginThrottle(ctx context.Context, key string) error {
+	if err := conn(ctx, r.db).Unscoped().Where("`key` = ?", key).Delete(&models.LoginThrottle{}).Error; err != nil {
+		return fmt.Errorf("failed to reset login throttle: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// OIDCRepository defines the interface for the pending single sign-on logins and the identities
+// linked to users.
+type OIDCRepository interface {
+	// CreateLoginState stores a pending login, purging the expired ones.
+	CreateLoginState(ctx context.Context, state *models.OIDCLoginState) error
+	// ConsumeLoginState deletes and returns the pending login of a state hash, nil when there is
+	// none, so that each state completes one login.
+	ConsumeLoginState(ctx context.Context, stateHash string) (*models.OIDCLoginState, error)
+	GetExternalIdentity(ctx context.Context, issuer, subject string) (*models.ExternalIdentity, error)
+	CreateExternalIdentity(ctx context.Context, identity *models.ExternalIdentity) error
+	// TouchExternalIdentity records a login with an identity and its current email address.
+	TouchExternalIdentity(ctx context.Context, id uint, email string, at time.Time) error
+}
+
+// oidcRepository implements the OIDCRepository interface.
+type oidcRepository struct {
+	db *gorm.DB
+}
+
+// NewOIDCRepository creates a new instance of OIDCRepository.
+func NewOIDCRepository(db *gorm.DB) OIDCRepository {
+	return &oidcRepository{
+		db: db,
+	}
+}
+
+// CreateLoginState inserts a pending login.
+func (r *oidcRepository) CreateLoginState(ctx context.Context, state *models.OIDCLoginState) error {
+	db := conn(ctx, r.db)
+	if err := db.Unscoped().Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{}).Error; err != nil {
+		return fmt.Errorf("failed to purge login states: %w", err)
+	}
+	if err := db.Create(state).Error; err != nil {
+		return fmt.Errorf("failed to create login state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeLoginState retrieves a pending login and deletes it. Of concurrent callbacks with the
+// same state, only the one deleting it gets it.
+func (r *oidcRepository) ConsumeLoginState(ctx context.Context, stateHash string) (*models.OIDCLoginState, error) {
+	db := conn(ctx, r.db)
+	var state models.OIDCLoginState
+	if err := db.Where("state_hash = ?", stateHash).First(&state).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve login state: %w", err)
+	}
+	result := db.Unscoped().Delete(&models.OIDCLoginState{}, state.ID)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to delete login state: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// GetExternalIdentity retrieves the identity of a subject of an issuer, nil when it is not linked.
+func (r *oidcRepository) GetExternalIdentity(ctx context.Context, issuer, subject string) (*models.ExternalIdentity, error) {
+	var identity models.ExternalIdentity
+	if err := conn(ctx, r.db).Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve external identity: %w", err)
+	}
+	return &identity, nil
+}
+
+// CreateExternalIdentity links an identity to a user.
+func (r *oidcRepository) CreateExternalIdentity(ctx context.Context, identity *models.ExternalIdentity) error {
+	if err := conn(ctx, r.db).Create(identity).Error; err != nil {
+		return fmt.Errorf("failed to create external identity: %w", err)
+	}
+	return nil
+}
+
+// TouchExternalIdentity updates the last login and email of an identity.
+func (r *oidcRepository) TouchExternalIdentity(ctx context.Context, id uint, email string, at time.Time) error {
+	err := conn(ctx, r.db).Model(&models.ExternalIdentity{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"email": email, "last_login_at": at}).Error
+	if err != nil {
+		return fmt.Errorf("failed to update external identity: %w", err)
+	}
+	return nil
+}
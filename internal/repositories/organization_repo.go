@@ -0,0 +1,257 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// OrgMember is an employee with their department and team on a given date.
+type OrgMember struct {
+	EmployeeID   uint
+	UserID       uint
+	FirstName    string
+	LastName     string
+	DepartmentID uint
+	TeamID       *uint
+}
+
+// OrganizationRepository defines the interface for departments, teams and the assignment of
+// employees to them.
+type OrganizationRepository interface {
+	CreateDepartment(ctx context.Context, department *models.Department) error
+	GetDepartmentByID(ctx context.Context, id uint) (*models.Department, error)
+	ListDepartments(ctx context.Context, filters map[string]interface{}) ([]models.Department, error)
+	UpdateDepartment(ctx context.Context, department *models.Department) error
+	DeleteDepartment(ctx context.Context, id uint) error
+
+	CreateTeam(ctx context.Context, team *models.Team) error
+	GetTeamByID(ctx context.Context, id uint) (*models.Team, error)
+	ListTeams(ctx context.Context, filters map[string]interface{}) ([]models.Team, error)
+	UpdateTeam(ctx context.Context, team *models.Team) error
+	DeleteTeam(ctx context.Context, id uint) error
+
+	ListOrgAssignments(ctx context.Context, employeeID uint) ([]models.OrgAssignment, error)
+	// CountActiveOrgAssignments counts the assignments to a department or team that are open or
+	// end on or after date.
+	CountActiveOrgAssignments(ctx context.Context, column string, id uint, date time.Time) (int64, error)
+	// ReplaceOrgAssignment ends current (when not nil) and creates next atomically.
+	ReplaceOrgAssignment(ctx context.Context, current, next *models.OrgAssignment) error
+	UpdateOrgAssignment(ctx context.Context, assignment *models.OrgAssignment) error
+	// ListOrgMembersAt retrieves the employees assigned to the departments of a company (all
+	// companies when companyID is zero) on date.
+	ListOrgMembersAt(ctx context.Context, companyID uint, date time.Time) ([]OrgMember, error)
+	ListUsersByIDs(ctx context.Context, ids []uint) ([]models.User, error)
+}
+
+// organizationRepository implements the OrganizationRepository interface.
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates a new instance of OrganizationRepository.
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+// OrgScopeCondition returns an SQL condition keeping the rows whose employee, given by
+// employeeColumn, was assigned to the scope on the day of dateColumn. It returns an empty
+// condition for a nil scope.
+func OrgScopeCondition(employeeColumn, dateColumn string, scope *types.OrgScope) (string, []interface{}) {
+	if scope == nil {
+		return "", nil
+	}
+	condition := fmt.Sprintf(`EXISTS (
+            SELECT 1 FROM org_assignments oa
+            WHERE oa.deleted_at IS NULL AND oa.employee_id = %s
+                AND oa.effective_from <= DATE(%s) AND (oa.effective_to IS NULL OR oa.effective_to >= DATE(%s))
+                AND (oa.department_id IN ? OR oa.team_id IN ?)
+        )`, employeeColumn, dateColumn, dateColumn)
+	return condition, []interface{}{scope.DepartmentIDs, scope.TeamIDs}
+}
+
+// CreateDepartment inserts a new department into the database.
+func (r *organizationRepository) CreateDepartment(ctx context.Context, department *models.Department) error {
+	if err := conn(ctx, r.db).Create(department).Error; err != nil {
+		return fmt.Errorf("failed to create department: %w", err)
+	}
+	return nil
+}
+
+// GetDepartmentByID retrieves a department by its ID.
+func (r *organizationRepository) GetDepartmentByID(ctx context.Context, id uint) (*models.Department, error) {
+	var department models.Department
+	if err := conn(ctx, r.db).First(&department, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No department found
+		}
+		return nil, fmt.Errorf("failed to retrieve department by ID: %w", err)
+	}
+	return &department, nil
+}
+
+// ListDepartments retrieves departments matching the given column filters.
+func (r *organizationRepository) ListDepartments(ctx context.Context, filters map[string]interface{}) ([]models.Department, error) {
+	var departments []models.Department
+	query := conn(ctx, r.db).Model(&models.Department{})
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+	if err := query.Order("name ASC").Find(&departments).Error; err != nil {
+		return nil, fmt.Errorf("failed to list departments: %w", err)
+	}
+	return departments, nil
+}
+
+// UpdateDepartment updates an existing department in the database.
+func (r *organizationRepository) UpdateDepartment(ctx context.Context, department *models.Department) error {
+	if err := conn(ctx, r.db).Save(department).Error; err != nil {
+		return fmt.Errorf("failed to update department: %w", err)
+	}
+	return nil
+}
+
+// DeleteDepartment deletes a department by its ID.
+func (r *organizationRepository) DeleteDepartment(ctx context.Context, id uint) error {
+	if err := conn(ctx, r.db).Delete(&models.Department{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete department: %w", err)
+	}
+	return nil
+}
+
+// CreateTeam inserts a new team into the database.
+func (r *organizationRepository) CreateTeam(ctx context.Context, team *models.Team) error {
+	if err := conn(ctx, r.db).Create(team).Error; err != nil {
+		return fmt.Errorf("failed to create team: %w", err)
+	}
+	return nil
+}
+
+// GetTeamByID retrieves a team by its ID.
+func (r *organizationRepository) GetTeamByID(ctx context.Context, id uint) (*models.Team, error) {
+	var team models.Team
+	if err := conn(ctx, r.db).First(&team, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No team found
+		}
+		return nil, fmt.Errorf("failed to retrieve team by ID: %w", err)
+	}
+	return &team, nil
+}
+
+// ListTeams retrieves teams matching the given column filters.
+func (r *organizationRepository) ListTeams(ctx context.Context, filters map[string]interface{}) ([]models.Team, error) {
+	var teams []models.Team
+	query := conn(ctx, r.db).Model(&models.Team{})
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+	if err := query.Order("name ASC").Find(&teams).Error; err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	return teams, nil
+}
+
+// UpdateTeam updates an existing team in the database.
+func (r *organizationRepository) UpdateTeam(ctx context.Context, team *models.Team) error {
+	if err := conn(ctx, r.db).Save(team).Error; err != nil {
+		return fmt.Errorf("failed to update team: %w", err)
+	}
+	return nil
+}
+
+// DeleteTeam deletes a team by its ID.
+func (r *organizationRepository) DeleteTeam(ctx context.Context, id uint) error {
+	if err := conn(ctx, r.db).Delete(&models.Team{}, id).Error; err != nil {
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+	return nil
+}
+
+// ListOrgAssignments retrieves the department and team assignments of an employee, oldest first.
+func (r *organizationRepository) ListOrgAssignments(ctx context.Context, employeeID uint) ([]models.OrgAssignment, error) {
+	var assignments []models.OrgAssignment
+	if err := conn(ctx, r.db).
+		Where("employee_id = ?", employeeID).
+		Order("effective_from ASC").
+		Find(&assignments).Error; err != nil {
+		return nil, fmt.Errorf("failed to list org assignments: %w", err)
+	}
+	return assignments, nil
+}
+
+// CountActiveOrgAssignments counts the assignments whose column (department_id or team_id) is id
+// and which are still effective on date or later.
+func (r *organizationRepository) CountActiveOrgAssignments(ctx context.Context, column string, id uint, date time.Time) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&models.OrgAssignment{}).
+		Where(column+" = ? AND (effective_to IS NULL OR effective_to >= ?)", id, date.Format("2006-01-02")).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count org assignments: %w", err)
+	}
+	return count, nil
+}
+
+// ReplaceOrgAssignment ends current and creates next in a single transaction.
+func (r *organizationRepository) ReplaceOrgAssignment(ctx context.Context, current, next *models.OrgAssignment) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if current != nil {
+			if err := tx.Save(current).Error; err != nil {
+				return fmt.Errorf("failed to end org assignment: %w", err)
+			}
+		}
+		if err := tx.Create(next).Error; err != nil {
+			return fmt.Errorf("failed to create org assignment: %w", err)
+		}
+		return nil
+	})
+}
+
+// UpdateOrgAssignment updates an existing assignment in the database.
+func (r *organizationRepository) UpdateOrgAssignment(ctx context.Context, assignment *models.OrgAssignment) error {
+	if err := conn(ctx, r.db).Save(assignment).Error; err != nil {
+		return fmt.Errorf("failed to update org assignment: %w", err)
+	}
+	return nil
+}
+
+// ListOrgMembersAt retrieves the employees assigned to a department on date.
+func (r *organizationRepository) ListOrgMembersAt(ctx context.Context, companyID uint, date time.Time) ([]OrgMember, error) {
+	var members []OrgMember
+	day := date.Format("2006-01-02")
+	query := conn(ctx, r.db).Table("org_assignments oa").
+		Select("e.id AS employee_id, u.id AS user_id, u.first_name, u.last_name, oa.department_id, oa.team_id").
+		Joins("JOIN departments d ON d.id = oa.department_id AND d.deleted_at IS NULL").
+		Joins("JOIN employees e ON e.id = oa.employee_id AND e.deleted_at IS NULL").
+		Joins("JOIN users u ON u.id = e.user_id").
+		Where("oa.deleted_at IS NULL AND oa.effective_from <= ? AND (oa.effective_to IS NULL OR oa.effective_to >= ?)", day, day)
+	if companyID != 0 {
+		query = query.Where("d.company_id = ?", companyID)
+	}
+	if condition, args := tenantFilter(ctx, "d.company_id"); condition != "" {
+		query = query.Where(condition, args...)
+	}
+	if err := query.Order("u.last_name ASC, u.first_name ASC").Scan(&members).Error; err != nil {
+		return nil, fmt.Errorf("failed to list org members: %w", err)
+	}
+	return members, nil
+}
+
+// ListUsersByIDs retrieves the users with the given IDs.
+func (r *organizationRepository) ListUsersByIDs(ctx context.Context, ids []uint) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := conn(ctx, r.db).Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve users: %w", err)
+	}
+	return users, nil
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// PasswordRepository defines the interface for password changes, history and reset tokens.
+type PasswordRepository interface {
+	// SetPassword stores the new password hash of a user and records it in their history.
+	SetPassword(ctx context.Context, userID uint, hash string) error
+	// ListPasswordHistory returns the last limit password hashes of a user, newest first.
+	ListPasswordHistory(ctx context.Context, userID uint, limit int) ([]string, error)
+	CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error
+	GetPasswordResetTokenByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error)
+	// UsePasswordResetToken marks a token used and reports false when it already was.
+	UsePasswordResetToken(ctx context.Context, id uint) (bool, error)
+	// InvalidatePasswordResetTokens marks every unused token of a user used.
+	InvalidatePasswordResetTokens(ctx context.Context, userID uint) error
+}
+
+// passwordRepository implements the PasswordRepository interface.
+type passwordRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordRepository creates a new instance of PasswordRepository.
+func NewPasswordRepository(db *gorm.DB) PasswordRepository {
+	return &passwordRepository{
+		db: db,
+	}
+}
+
+// SetPassword updates the password of a user and appends it to their history in one transaction.
+func (r *passwordRepository) SetPassword(ctx context.Context, userID uint, hash string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", userID).Update("password", hash)
+		if result.Error != nil {
+			return fmt.Errorf("failed to update password: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("user not found")
+		}
+		if err := tx.Create(&models.PasswordHistory{UserID: userID, PasswordHash: hash}).Error; err != nil {
+			return fmt.Errorf("failed to record password history: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListPasswordHistory retrieves the most recent password hashes of a user.
+func (r *passwordRepository) ListPasswordHistory(ctx context.Context, userID uint, limit int) ([]string, error) {
+	var hashes []string
+	err := conn(ctx, r.db).Model(&models.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Pluck("password_hash", &hashes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve password history: %w", err)
+	}
+	return hashes, nil
+}
+
+// CreatePasswordResetToken inserts a new reset token into the database.
+func (r *passwordRepository) CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	if err := conn(ctx, r.db).Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	return nil
+}
+
+// GetPasswordResetTokenByHash retrieves a reset token by the hash of its value.
+func (r *passwordRepository) GetPasswordResetTokenByHash(ctx context.Context, hash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := conn(ctx, r.db).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No token found
+		}
+		return nil, fmt.Errorf("failed to retrieve password reset token: %w", err)
+	}
+	return &token, nil
+}
+
+// UsePasswordResetToken marks a token used unless it already was.
+func (r *passwordRepository) UsePasswordResetToken(ctx context.Context, id uint) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use password reset token: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// InvalidatePasswordResetTokens marks the unused tokens of a user used.
+func (r *passwordRepository) InvalidatePasswordResetTokens(ctx context.Context, userID uint) error {
+	err := conn(ctx, r.db).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to invalidate password reset tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/types"
+)
+
+// PayrollPeriodRepository defines the interface for payroll period-related database operations.
+type PayrollPeriodRepository interface {
+	CreatePayrollPeriod(ctx context.Context, period *models.PayrollPeriod) error
+	GetPayrollPeriodByID(ctx context.Context, id uint) (*models.PayrollPeriod, error)
+	ListPayrollPeriods(ctx context.Context, filters map[string]interface{}) ([]models.PayrollPeriod, error)
+	UpdatePayrollPeriod(ctx context.Context, period *models.PayrollPeriod) error
+	// FindOverlappingPeriod returns a period of the company overlapping the given range, if any.
+	FindOverlappingPeriod(ctx context.Context, companyID uint, start, end time.Time, excludeID uint) (*models.PayrollPeriod, error)
+	// FindClosedPeriod returns a closed period covering the date. A zero companyID matches any company.
+	FindClosedPeriod(ctx context.Context, companyID uint, date time.Time) (*models.PayrollPeriod, error)
+	CreatePayrollPeriodEvent(ctx context.Context, event *models.PayrollPeriodEvent) error
+	ListPayrollPeriodEvents(ctx context.Context, periodID uint) ([]models.PayrollPeriodEvent, error)
+}
+
+// payrollPeriodRepository implements the PayrollPeriodRepository interface.
+type payrollPeriodRepository struct {
+	db *gorm.DB
+}
+
+// NewPayrollPeriodRepository creates a new instance of PayrollPeriodRepository.
+func NewPayrollPeriodRepository(db *gorm.DB) PayrollPeriodRepository {
+	return &payrollPeriodRepository{
+		db: db,
+	}
+}
+
+// CreatePayrollPeriod inserts a new payroll period into the database.
+func (r *payrollPeriodRepository) CreatePayrollPeriod(ctx context.Context, period *models.PayrollPeriod) error {
+	if err := conn(ctx, r.db).Create(period).Error; err != nil {
+		return fmt.Errorf("failed to create payroll period: %w", err)
+	}
+	return nil
+}
+
+// GetPayrollPeriodByID retrieves a payroll period by its ID.
+func (r *payrollPeriodRepository) GetPayrollPeriodByID(ctx context.Context, id uint) (*models.PayrollPeriod, error) {
+	var period models.PayrollPeriod
+	if err := conn(ctx, r.db).First(&period, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No payroll period found
+		}
+		return nil, fmt.Errorf("failed to retrieve payroll period by ID: %w", err)
+	}
+	return &period, nil
+}
+
+// ListPayrollPeriods retrieves payroll periods matching the given column filters.
+func (r *payrollPeriodRepository) ListPayrollPeriods(ctx context.Context, filters map[string]interface{}) ([]models.PayrollPeriod, error) {
+	var periods []models.PayrollPeriod
+	query := conn(ctx, r.db).Model(&models.PayrollPeriod{})
+	for key, value := range filters {
+		query = query.Where(key+" = ?", value)
+	}
+	if err := query.Order("start_date DESC").Find(&periods).Error; err != nil {
+		return nil, fmt.Errorf("failed to list payroll periods: %w", err)
+	}
+	return periods, nil
+}
+
+// UpdatePayrollPeriod updates an existing payroll period in the database.
+func (r *payrollPeriodRepository) UpdatePayrollPeriod(ctx context.Context, period *models.PayrollPeriod) error {
+	if period.ID == 0 {
+		return errors.New("payroll period ID is required")
+	}
+	if err := conn(ctx, r.db).Save(period).Error; err != nil {
+		return fmt.Errorf("failed to update payroll period: %w", err)
+	}
+	return nil
+}
+
+// FindOverlappingPeriod returns a period of the company overlapping [start, end], if any.
+func (r *payrollPeriodRepository) FindOverlappingPeriod(ctx context.Context, companyID uint, start, end time.Time, excludeID uint) (*models.PayrollPeriod, error) {
+	var period models.PayrollPeriod
+	err := conn(ctx, r.db).
+		Where("company_id = ? AND start_date <= ? AND end_date >= ? AND id <> ?",
+			companyID, end.Format("2006-01-02"), start.Format("2006-01-02"), excludeID).
+		First(&period).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to check overlapping payroll periods: %w", err)
+	}
+	return &period, nil
+}
+
+// FindClosedPeriod returns a closed period covering the date. A zero companyID matches any company.
+func (r *payrollPeriodRepository) FindClosedPeriod(ctx context.Context, companyID uint, date time.Time) (*models.PayrollPeriod, error) {
+	var period models.PayrollPeriod
+	day := date.Format("2006-01-02")
+	// Periods closed by other companies lock shared data too, whatever the tenant of the request.
+	query := conn(types.WithoutTenantScope(ctx), r.db).
+		Where("status = ? AND start_date <= ? AND end_date >= ?", models.PayrollPeriodStatusClosed, day, day)
+	if companyID != 0 {
+		query = query.Where("company_id = ?", companyID)
+	}
+	if err := query.First(&period).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to check closed payroll periods: %w", err)
+	}
+	return &period, nil
+}
+
+// CreatePayrollPeriodEvent records a state change of a payroll period.
+func (r *payrollPeriodRepository) CreatePayrollPeriodEvent(ctx context.Context, event *models.PayrollPeriodEvent) error {
+	if err := conn(ctx, r.db).Create(event).Error; err != nil {
+		return fmt.Errorf("failed to record payroll period event: %w", err)
+	}
+	return nil
+}
+
+// ListPayrollPeriodEvents retrieves the audit trail of a payroll period, oldest first.
+func (r *payrollPeriodRepository) ListPayrollPeriodEvents(ctx context.Context, periodID uint) ([]models.PayrollPeriodEvent, error) {
+	var events []models.PayrollPeriodEvent
+	if err := conn(ctx, r.db).Where("payroll_period_id = ?", periodID).Order("created_at ASC").Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to list payroll period events: %w", err)
+	}
+	return events, nil
+}
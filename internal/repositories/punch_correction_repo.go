@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/types"
+)
+
+// PunchCorrectionRepository defines the interface for punch correction requests.
+type PunchCorrectionRepository interface {
+	CreatePunchCorrection(ctx context.Context, request *models.PunchCorrectionRequest) error
+	GetPunchCorrectionByID(ctx context.Context, id uint) (*models.PunchCorrectionRequest, error)
+	// ListPunchCorrections returns the requests matching filters on employee_id, company_id, status
+	// and org_scope, newest first.
+	ListPunchCorrections(ctx context.Context, filters map[string]interface{}) ([]models.PunchCorrectionRequest, error)
+	UpdatePunchCorrection(ctx context.Context, request *models.PunchCorrectionRequest) error
+}
+
+// punchCorrectionRepository implements the PunchCorrectionRepository interface.
+type punchCorrectionRepository struct {
+	db *gorm.DB
+}
+
+// NewPunchCorrectionRepository creates a new instance of PunchCorrectionRepository.
+func NewPunchCorrectionRepository(db *gorm.DB) PunchCorrectionRepository {
+	return &punchCorrectionRepository{
+		db: db,
+	}
+}
+
+// CreatePunchCorrection inserts a new punch correction request.
+func (r *punchCorrectionRepository) CreatePunchCorrection(ctx context.Context, request *models.PunchCorrectionRequest) error {
+	if err := conn(ctx, r.db).Create(request).Error; err != nil {
+		return fmt.Errorf("failed to create punch correction request: %w", err)
+	}
+	return nil
+}
+
+// GetPunchCorrectionByID retrieves a punch correction request by its ID.
+func (r *punchCorrectionRepository) GetPunchCorrectionByID(ctx context.Context, id uint) (*models.PunchCorrectionRequest, error) {
+	var request models.PunchCorrectionRequest
+	if err := conn(ctx, r.db).First(&request, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve punch correction request: %w", err)
+	}
+	return &request, nil
+}
+
+// ListPunchCorrections retrieves punch correction requests with filters.
+func (r *punchCorrectionRepository) ListPunchCorrections(ctx context.Context, filters map[string]interface{}) ([]models.PunchCorrectionRequest, error) {
+	var requests []models.PunchCorrectionRequest
+	query := conn(ctx, r.db).Model(&models.PunchCorrectionRequest{})
+	for _, key := range []string{"employee_id", "company_id", "status"} {
+		if value, ok := filters[key]; ok {
+			query = query.Where(key+" = ?", value)
+		}
+	}
+	// Requests belong to the department or team of the employee on the day they were filed
+	if scope, ok := filters["org_scope"].(*types.OrgScope); ok {
+		condition, args := OrgScopeCondition("punch_correction_requests.employee_id", "punch_correction_requests.created_at", scope)
+		query = query.Where(condition, args...)
+	}
+	if err := query.Order("created_at DESC").Find(&requests).Error; err != nil {
+		return nil, fmt.Errorf("failed to list punch correction requests: %w", err)
+	}
+	return requests, nil
+}
+
+// UpdatePunchCorrection saves the changes to a punch correction request.
+func (r *punchCorrectionRepository) UpdatePunchCorrection(ctx context.Context, request *models.PunchCorrectionRequest) error {
+	if err := conn(ctx, r.db).Save(request).Error; err != nil {
+		return fmt.Errorf("failed to update punch correction request: %w", err)
+	}
+	return nil
+}
@@ -3,7 +3,11 @@ package repositories
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
+
 	"point-system-api/internal/models"
+	"point-system-api/internal/types"
 
 	"gorm.io/gorm"
 )
@@ -11,11 +15,20 @@ import (
 // RawAttendanceRepository defines the interface for raw attendance-related database operations.
 type RawAttendanceRepository interface {
 	CreateRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance) error
-	GetRawAttendancesByCompanyIDAndWorkDay(ctx context.Context, companyID uint, workDayID uint) ([]*models.RawAttendance, error)
+	GetRawAttendancesByCompanyIDAndWorkDay(ctx context.Context, companyID uint, workDayID uint, scope *types.OrgScope) ([]*models.RawAttendance, error)
+	GetRawAttendancesByWorkDay(ctx context.Context, workDayID uint) ([]*models.RawAttendance, error)
 	GetRawAttendanceByID(ctx context.Context, id uint) (*models.RawAttendance, error)
+	GetRawAttendanceByWorkDayAndUser(ctx context.Context, workDayID uint, userID uint) (*models.RawAttendance, error)
+	SaveRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance) error
+	// RestoreRawAttendance sets the given columns of a raw attendance.
+	RestoreRawAttendance(ctx context.Context, id uint, values map[string]interface{}) error
 	UpdateRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance, id uint) error
 	DeleteRawAttendance(ctx context.Context, id uint) error
-	ListRawAttendances(ctx context.Context) ([]*models.RawAttendance, error)
+	ListRawAttendances(ctx context.Context, scope *types.OrgScope) ([]*models.RawAttendance, error)
+	GetRawAttendancesByLeaveRequest(ctx context.Context, leaveRequestID uint) ([]*models.RawAttendance, error)
+	// ListEmployeeDailyAttendances returns the rows of an employee for the workdays in [from, to],
+	// with their date, oldest first.
+	ListEmployeeDailyAttendances(ctx context.Context, employeeID uint, from, to time.Time) ([]types.DailyAttendance, error)
 }
 
 type rawAttendanceRepo struct {
@@ -27,13 +40,13 @@ func NewRawAttendanceRepo(db *gorm.DB) RawAttendanceRepository {
 }
 
 func (r *rawAttendanceRepo) CreateRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance) error {
-	return r.db.WithContext(ctx).Create(rawAttendance).Error
+	return conn(ctx, r.db).Create(rawAttendance).Error
 }
 
-func (r *rawAttendanceRepo) GetRawAttendancesByCompanyIDAndWorkDay(ctx context.Context, companyID uint, workDayID uint) ([]*models.RawAttendance, error) {
+func (r *rawAttendanceRepo) GetRawAttendancesByCompanyIDAndWorkDay(ctx context.Context, companyID uint, workDayID uint, scope *types.OrgScope) ([]*models.RawAttendance, error) {
 	var rawAttendances []*models.RawAttendance
 
-	err := r.db.WithContext(ctx).
+	err := withRawAttendanceScope(conn(ctx, r.db), scope).
 		Where("company_id = ? AND work_day_id = ?", companyID, workDayID).
 		Find(&rawAttendances).Error
 
@@ -44,30 +57,72 @@ func (r *rawAttendanceRepo) GetRawAttendancesByCompanyIDAndWorkDay(ctx context.C
 	return rawAttendances, nil
 }
 
+func (r *rawAttendanceRepo) GetRawAttendancesByWorkDay(ctx context.Context, workDayID uint) ([]*models.RawAttendance, error) {
+	var rawAttendances []*models.RawAttendance
+
+	err := conn(ctx, r.db).
+		Where("work_day_id = ?", workDayID).
+		Find(&rawAttendances).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rawAttendances, nil
+}
+
 func (r *rawAttendanceRepo) GetRawAttendanceByID(ctx context.Context, id uint) (*models.RawAttendance, error) {
 	var rawAttendance models.RawAttendance
-	if err := r.db.WithContext(ctx).First(&rawAttendance, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&rawAttendance, id).Error; err != nil {
 		return nil, err
 	}
 
 	return &rawAttendance, nil
 }
 
+// GetRawAttendanceByWorkDayAndUser returns the row of an employee for a workday, or nil if there is none.
+func (r *rawAttendanceRepo) GetRawAttendanceByWorkDayAndUser(ctx context.Context, workDayID uint, userID uint) (*models.RawAttendance, error) {
+	var rawAttendance models.RawAttendance
+	if err := conn(ctx, r.db).Where("work_day_id = ? AND user_id = ?", workDayID, userID).First(&rawAttendance).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rawAttendance, nil
+}
+
 func (s *rawAttendanceRepo) UpdateRawAttendance(ctx context.Context, rawAtt *models.RawAttendance, id uint) error {
-	return s.db.WithContext(ctx).
+	return conn(ctx, s.db).
 		Model(&models.RawAttendance{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"start_at":    rawAtt.StartAt,
-			"end_at":      rawAtt.EndAt,
-			"notes":       rawAtt.Notes,
-			"status":      rawAtt.Status,
-			"total_hours": rawAtt.TotalHours,
+			"start_at":      rawAtt.StartAt,
+			"end_at":        rawAtt.EndAt,
+			"notes":         rawAtt.Notes,
+			"status":        rawAtt.Status,
+			"total_hours":   rawAtt.TotalHours,
+			"justification": rawAtt.Justification,
+			// Marks the row so that workday regeneration preserves the correction.
+			"manually_edited": rawAtt.ManuallyEdited,
 		}).Error
 }
 
+// RestoreRawAttendance updates the columns of a raw attendance, recording a new version of it.
+func (r *rawAttendanceRepo) RestoreRawAttendance(ctx context.Context, id uint, values map[string]interface{}) error {
+	if err := conn(ctx, r.db).Model(&models.RawAttendance{}).Where("id = ?", id).Updates(values).Error; err != nil {
+		return fmt.Errorf("failed to restore raw attendance: %w", err)
+	}
+	return nil
+}
+
+// SaveRawAttendance persists every column of an existing raw attendance.
+func (r *rawAttendanceRepo) SaveRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance) error {
+	return conn(ctx, r.db).Save(rawAttendance).Error
+}
+
 func (r *rawAttendanceRepo) DeleteRawAttendance(ctx context.Context, id uint) error {
-	result := r.db.WithContext(ctx).Delete(&models.RawAttendance{}, id)
+	result := conn(ctx, r.db).Delete(&models.RawAttendance{}, id)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -77,10 +132,54 @@ func (r *rawAttendanceRepo) DeleteRawAttendance(ctx context.Context, id uint) er
 	return nil
 }
 
-func (r *rawAttendanceRepo) ListRawAttendances(ctx context.Context) ([]*models.RawAttendance, error) {
+func (r *rawAttendanceRepo) ListRawAttendances(ctx context.Context, scope *types.OrgScope) ([]*models.RawAttendance, error) {
 	var rawAttendances []*models.RawAttendance
-	if err := r.db.WithContext(ctx).Find(&rawAttendances).Error; err != nil {
+	if err := withRawAttendanceScope(conn(ctx, r.db), scope).Find(&rawAttendances).Error; err != nil {
 		return nil, err
 	}
 	return rawAttendances, nil
 }
+
+func (r *rawAttendanceRepo) GetRawAttendancesByLeaveRequest(ctx context.Context, leaveRequestID uint) ([]*models.RawAttendance, error) {
+	var rawAttendances []*models.RawAttendance
+
+	err := conn(ctx, r.db).
+		Where("leave_request_id = ?", leaveRequestID).
+		Find(&rawAttendances).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rawAttendances, nil
+}
+
+func (r *rawAttendanceRepo) ListEmployeeDailyAttendances(ctx context.Context, employeeID uint, from, to time.Time) ([]types.DailyAttendance, error) {
+	var days []types.DailyAttendance
+
+	err := conn(ctx, r.db).
+		Model(&models.RawAttendance{}).
+		Select("raw_attendances.id, raw_attendances.work_day_id, work_days.date, work_days.day_type, "+
+			"raw_attendances.start_at, raw_attendances.end_at, raw_attendances.total_hours, raw_attendances.total_hour_out, "+
+			"raw_attendances.status, raw_attendances.justification, raw_attendances.notes, raw_attendances.leave_request_id").
+		Joins("JOIN work_days ON work_days.id = raw_attendances.work_day_id AND work_days.deleted_at IS NULL").
+		Where("raw_attendances.user_id = ? AND work_days.date BETWEEN ? AND ?", employeeID, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("work_days.date ASC").
+		Scan(&days).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return days, nil
+}
+
+// withRawAttendanceScope keeps the rows of employees assigned to the scope on the date of their workday.
+func withRawAttendanceScope(query *gorm.DB, scope *types.OrgScope) *gorm.DB {
+	condition, args := OrgScopeCondition("raw_attendances.user_id",
+		"(SELECT wd.date FROM work_days wd WHERE wd.id = raw_attendances.work_day_id)", scope)
+	if condition == "" {
+		return query
+	}
+	return query.Where(condition, args...)
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// RawAttendanceVersionRepository reads the versions of raw attendances. Versions are written by
+// RegisterRawAttendanceVersions only.
+type RawAttendanceVersionRepository interface {
+	// ListRawAttendanceVersions returns the versions of a raw attendance, oldest first.
+	ListRawAttendanceVersions(ctx context.Context, rawAttendanceID uint) ([]models.RawAttendanceVersion, error)
+	// GetRawAttendanceVersion returns a version of a raw attendance, or nil when it does not exist.
+	GetRawAttendanceVersion(ctx context.Context, rawAttendanceID uint, version uint) (*models.RawAttendanceVersion, error)
+	// GetCurrentVersion returns the number of the latest version of a raw attendance, 0 for rows
+	// never versioned.
+	GetCurrentVersion(ctx context.Context, rawAttendanceID uint) (uint, error)
+}
+
+// rawAttendanceVersionRepository implements the RawAttendanceVersionRepository interface.
+type rawAttendanceVersionRepository struct {
+	db *gorm.DB
+}
+
+// NewRawAttendanceVersionRepository creates a new instance of RawAttendanceVersionRepository.
+func NewRawAttendanceVersionRepository(db *gorm.DB) RawAttendanceVersionRepository {
+	return &rawAttendanceVersionRepository{
+		db: db,
+	}
+}
+
+// ListRawAttendanceVersions retrieves the versions of a raw attendance.
+func (r *rawAttendanceVersionRepository) ListRawAttendanceVersions(ctx context.Context, rawAttendanceID uint) ([]models.RawAttendanceVersion, error) {
+	var versions []models.RawAttendanceVersion
+	if err := conn(ctx, r.db).Where("raw_attendance_id = ?", rawAttendanceID).Order("version").Find(&versions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list raw attendance versions: %w", err)
+	}
+	return versions, nil
+}
+
+// GetRawAttendanceVersion retrieves a version of a raw attendance by its number.
+func (r *rawAttendanceVersionRepository) GetRawAttendanceVersion(ctx context.Context, rawAttendanceID uint, version uint) (*models.RawAttendanceVersion, error) {
+	var found models.RawAttendanceVersion
+	if err := conn(ctx, r.db).Where("raw_attendance_id = ? AND version = ?", rawAttendanceID, version).First(&found).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get raw attendance version: %w", err)
+	}
+	return &found, nil
+}
+
+// GetCurrentVersion retrieves the number of the latest version of a raw attendance.
+func (r *rawAttendanceVersionRepository) GetCurrentVersion(ctx context.Context, rawAttendanceID uint) (uint, error) {
+	var version uint
+	if err := conn(ctx, r.db).Model(&models.RawAttendanceVersion{}).
+		Where("raw_attendance_id = ?", rawAttendanceID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&version).Error; err != nil {
+		return 0, fmt.Errorf("failed to get raw attendance version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/types"
+)
+
+// RegisterRawAttendanceVersions records a version of a raw attendance each time a create or an
+// update changes its values, and a final version when it is deleted, in the transaction of the
+// change, with the source and the user of its context. It reuses the rows the audit trail loads
+// before an update or a delete, and must be registered after RegisterAuditTrail.
+func RegisterRawAttendanceVersions(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("audit:create").Register("versions:create", versionCreated); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("audit:update").Register("versions:update", versionUpdated); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("audit:delete").Register("versions:delete", versionDeleted)
+}
+
+// versionCreated records the first version of the raw attendances just created.
+func versionCreated(db *gorm.DB) {
+	stmt := db.Statement
+	if stmt.Table != "raw_attendances" || db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	ids := createdIDs(db)
+	if len(ids) == 0 {
+		return
+	}
+	rows, err := auditRowsByID(db, ids)
+	if err != nil {
+		db.AddError(fmt.Errorf("failed to record raw attendance version: %w", err))
+		return
+	}
+	saveRawAttendanceVersions(db, rows, false)
+}
+
+// versionUpdated records a version of the raw attendances the update changed. Rows created before
+// versions were recorded first get their values before the update as version 1, so that the
+// change can be reverted.
+func versionUpdated(db *gorm.DB) {
+	beforeRows := versionedRowsBefore(db)
+	if len(beforeRows) == 0 {
+		return
+	}
+	saveBaselineVersions(db, beforeRows)
+	ids := make([]interface{}, len(beforeRows))
+	for i, row := range beforeRows {
+		ids[i] = row["id"]
+	}
+	rows, err := auditRowsByID(db, ids)
+	if err != nil {
+		db.AddError(fmt.Errorf("failed to record raw attendance version: %w", err))
+		return
+	}
+	saveRawAttendanceVersions(db, rows, false)
+}
+
+// versionDeleted records the last values of the raw attendances the delete removed.
+func versionDeleted(db *gorm.DB) {
+	if beforeRows := versionedRowsBefore(db); len(beforeRows) > 0 {
+		saveRawAttendanceVersions(db, beforeRows, true)
+	}
+}
+
+// versionedRowsBefore returns the raw attendances the audit trail loaded before an update or a
+// delete, none for the other tables or when the statement failed.
+func versionedRowsBefore(db *gorm.DB) []map[string]interface{} {
+	if db.Statement.Table != "raw_attendances" || db.Error != nil {
+		return nil
+	}
+	before, ok := db.Statement.Settings.Load(auditBeforeKey)
+	if !ok {
+		return nil
+	}
+	return before.([]map[string]interface{})
+}
+
+// saveBaselineVersions stores the values of the rows without any version as their version 1.
+func saveBaselineVersions(db *gorm.DB, rows []map[string]interface{}) {
+	tx := db.Session(&gorm.Session{NewDB: true, Context: types.WithoutTenantScope(db.Statement.Context)})
+	for _, row := range rows {
+		var count int64
+		if err := tx.Model(&models.RawAttendanceVersion{}).Where("raw_attendance_id = ?", auditID(row)).Count(&count).Error; err != nil {
+			db.AddError(fmt.Errorf("failed to record raw attendance version: %w", err))
+			return
+		}
+		if count > 0 {
+			continue
+		}
+		values, err := versionValues(row)
+		if err != nil {
+			db.AddError(fmt.Errorf("failed to record raw attendance version: %w", err))
+			return
+		}
+		companyID, _ := auditUint(row["company_id"])
+		baseline := models.RawAttendanceVersion{
+			RawAttendanceID: auditID(row),
+			Version:         1,
+			CompanyID:       companyID,
+			Source:          types.RawAttendanceSourceBaseline,
+			Values:          values,
+		}
+		if err := tx.Create(&baseline).Error; err != nil {
+			db.AddError(fmt.Errorf("failed to record raw attendance version: %w", err))
+			return
+		}
+	}
+}
+
+// saveRawAttendanceVersions stores the values of each row as its next version, unless they are
+// those of its latest version already. The deletion of a row is recorded whatever its values.
+func saveRawAttendanceVersions(db *gorm.DB, rows []map[string]interface{}, deleted bool) {
+	ctx := db.Statement.Context
+	tx := db.Session(&gorm.Session{NewDB: true, Context: types.WithoutTenantScope(ctx)})
+	var changedBy *uint
+	if actor := types.AuditActorFrom(ctx); actor != nil {
+		changedBy = actor.UserID
+	}
+
+	for _, row := range rows {
+		values, err := versionValues(row)
+		if err != nil {
+			db.AddError(fmt.Errorf("failed to record raw attendance version: %w", err))
+			return
+		}
+		var latest models.RawAttendanceVersion
+		if err := tx.Where("raw_attendance_id = ?", auditID(row)).Order("version DESC").Limit(1).Find(&latest).Error; err != nil {
+			db.AddError(fmt.Errorf("failed to record raw attendance version: %w", err))
+			return
+		}
+		if !deleted && latest.ID != 0 && reflect.DeepEqual(latest.Values, values) {
+			continue
+		}
+
+		companyID, _ := auditUint(row["company_id"])
+		version := models.RawAttendanceVersion{
+			RawAttendanceID: auditID(row),
+			Version:         latest.Version + 1,
+			CompanyID:       companyID,
+			Source:          types.RawAttendanceSourceFrom(ctx),
+			ChangedBy:       changedBy,
+			Values:          values,
+			Deleted:         deleted,
+		}
+		if err := tx.Create(&version).Error; err != nil {
+			db.AddError(fmt.Errorf("failed to record raw attendance version: %w", err))
+			return
+		}
+	}
+}
+
+// versionValues returns the columns of a row without the bookkeeping of gorm, as they read back
+// once stored as JSON.
+func versionValues(row map[string]interface{}) (types.RowValues, error) {
+	columns := map[string]interface{}{}
+	for column, value := range row {
+		if !auditIgnoredColumns[column] {
+			columns[column] = value
+		}
+	}
+	data, err := json.Marshal(columns)
+	if err != nil {
+		return nil, err
+	}
+	var values types.RowValues
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+
+	"point-system-api/internal/types"
+)
+
+func TestVersionValues(t *testing.T) {
+	row := map[string]interface{}{"id": int64(7), "updated_at": "10:05", "company_id": int64(2), "start_at": "08:00", "total_hours": 8.5, "notes": nil}
+
+	values, err := versionValues(row)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Values read as they are once stored, so that an unchanged row compares equal to its latest version.
+	want := types.RowValues{"company_id": float64(2), "start_at": "08:00", "total_hours": 8.5, "notes": nil}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("got %+v, want %+v", values, want)
+	}
+
+	stored, err := values.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var scanned types.RowValues
+	if err := scanned.Scan(stored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(scanned, values) {
+		t.Errorf("scanned %+v, want %+v", scanned, values)
+	}
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// RefreshTokenRepository defines the interface for refresh token-related database operations.
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	// RotateRefreshToken revokes token and records next as its replacement.
+	RotateRefreshToken(ctx context.Context, token *models.RefreshToken, next *models.RefreshToken) error
+	RevokeRefreshToken(ctx context.Context, id uint) error
+	// RevokeRefreshTokenFamily revokes every active token issued from the same login.
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	// RevokeUserRefreshTokens revokes every active token of a user.
+	RevokeUserRefreshTokens(ctx context.Context, userID uint) error
+}
+
+// refreshTokenRepository implements the RefreshTokenRepository interface.
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new instance of RefreshTokenRepository.
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{
+		db: db,
+	}
+}
+
+// CreateRefreshToken inserts a new refresh token into the database.
+func (r *refreshTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	if err := conn(ctx, r.db).Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by the hash of its value.
+func (r *refreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := conn(ctx, r.db).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil // No token found
+		}
+		return nil, fmt.Errorf("failed to retrieve refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken stores next and revokes token in favour of it, in one transaction. It fails
+// when token was revoked concurrently, so that a token is only exchanged once.
+func (r *refreshTokenRepository) RotateRefreshToken(ctx context.Context, token *models.RefreshToken, next *models.RefreshToken) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return fmt.Errorf("failed to create refresh token: %w", err)
+		}
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", token.ID).
+			Updates(map[string]interface{}{"revoked_at": time.Now(), "replaced_by": next.ID})
+		if result.Error != nil {
+			return fmt.Errorf("failed to revoke refresh token: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("refresh token already used")
+		}
+		return nil
+	})
+}
+
+// RevokeRefreshToken revokes a refresh token by its ID.
+func (r *refreshTokenRepository) RevokeRefreshToken(ctx context.Context, id uint) error {
+	err := conn(ctx, r.db).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token: %w", err)
+	}
+	return nil
+}
+
+// RevokeRefreshTokenFamily revokes the active tokens issued from the same login.
+func (r *refreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	err := conn(ctx, r.db).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserRefreshTokens revokes the active tokens of a user.
+func (r *refreshTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID uint) error {
+	err := conn(ctx, r.db).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// tenantConditions restricts the tables that are not filtered by their own company_id column to
+// the companies of the tenant scope. {table} stands for the quoted table name and every ? for
+// the company IDs.
+var tenantConditions = map[string]string{
+	"companies": "{table}.id IN ?",
+	"users": `(EXISTS (SELECT 1 FROM employees te WHERE te.user_id = {table}.id AND te.company_id IN ?)
+        OR EXISTS (SELECT 1 FROM user_companies tuc WHERE tuc.user_id = {table}.id AND tuc.company_id IN ?))`,
+	"teams": "{table}.department_id IN (SELECT id FROM departments WHERE company_id IN ?)",
+	// Unmatched punches belong to the company of their device.
+	"attendance_logs": `({table}.employee_id IN (SELECT id FROM employees WHERE company_id IN ?)
+        OR ({table}.employee_id IS NULL AND {table}.serial_number IN (SELECT serial_number FROM devices WHERE company_id IN ?)))`,
+	"device_identities":     "({table}.company_id IN ? OR {table}.employee_id IN (SELECT id FROM employees WHERE company_id IN ?))",
+	"payroll_period_events": "{table}.payroll_period_id IN (SELECT id FROM payroll_periods WHERE company_id IN ?)",
+	"documents": `(({table}.owner_type = 'employee' AND {table}.owner_id IN (SELECT id FROM employees WHERE company_id IN ?))
+        OR ({table}.owner_type = 'raw_attendance' AND {table}.owner_id IN (SELECT id FROM raw_attendances WHERE company_id IN ?))
+        OR ({table}.owner_type = 'leave_request' AND {table}.owner_id IN (
+            SELECT tlr.id FROM leave_requests tlr JOIN employees tle ON tle.id = tlr.employee_id WHERE tle.company_id IN ?))
+        OR ({table}.owner_type = 'punch_correction' AND {table}.owner_id IN (SELECT id FROM punch_correction_requests WHERE company_id IN ?)))`,
+}
+
+// RegisterTenantScope makes every query on a model filter its rows by the tenant scope carried by
+// its context, and every create or update refuse rows of a company outside of it. Queries built
+// on an explicit table (Table) or raw SQL are not filtered: the repositories restrict them with
+// tenantFilter.
+func RegisterTenantScope(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("tenant:filter", filterByTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:query").Register("tenant:audit", auditTenantMiss); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("tenant:filter", filterByTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:filter", filterByTenant); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("tenant:check", checkTenantWrite); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("tenant:filter", filterByTenant); err != nil {
+		return err
+	}
+	return callbacks.Create().Before("gorm:create").Register("tenant:check", checkTenantWrite)
+}
+
+// tenantFilter returns an SQL condition keeping the rows whose company, given by column, is in the
+// tenant scope of ctx. It returns an empty condition outside of a tenant scope.
+func tenantFilter(ctx context.Context, column string) (string, []interface{}) {
+	scope := types.TenantScopeFrom(ctx)
+	if scope == nil {
+		return "", nil
+	}
+	return column + " IN ?", []interface{}{companyIDs(scope)}
+}
+
+// tenantCondition returns the condition restricting the table of s, if any.
+func tenantCondition(s *schema.Schema) string {
+	if condition, ok := tenantConditions[s.Table]; ok {
+		return condition
+	}
+	if s.LookUpField("CompanyID") != nil {
+		return "{table}.company_id IN ?"
+	}
+	if s.LookUpField("EmployeeID") != nil {
+		return "{table}.employee_id IN (SELECT id FROM employees WHERE company_id IN ?)"
+	}
+	return ""
+}
+
+// filterByTenant adds the tenant condition of the model to the statement.
+func filterByTenant(db *gorm.DB) {
+	stmt := db.Statement
+	scope := types.TenantScopeFrom(stmt.Context)
+	if scope == nil || stmt.Schema == nil || stmt.TableExpr != nil || stmt.SQL.Len() > 0 {
+		return
+	}
+	condition := tenantCondition(stmt.Schema)
+	if condition == "" {
+		return
+	}
+	if where, ok := stmt.Clauses["WHERE"]; ok {
+		if conditions, ok := where.Expression.(clause.Where); ok {
+			stmt.Settings.Store(tenantWhereKey, conditions)
+		}
+	}
+	condition = strings.ReplaceAll(condition, "{table}", stmt.Quote(stmt.Table))
+	vars := make([]interface{}, strings.Count(condition, "?"))
+	for i := range vars {
+		vars[i] = companyIDs(scope)
+	}
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: condition, Vars: vars}}})
+}
+
+// tenantWhereKey stores the conditions of a statement before the tenant condition was added.
+const tenantWhereKey = "tenant:where"
+
+// auditTenantMiss logs the lookups of a single row that found nothing only because the row
+// belongs to a company outside of the tenant scope.
+func auditTenantMiss(db *gorm.DB) {
+	stmt := db.Statement
+	if !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		return
+	}
+	scope := types.TenantScopeFrom(stmt.Context)
+	conditions, ok := stmt.Settings.Load(tenantWhereKey)
+	if scope == nil || !ok {
+		return
+	}
+
+	var count int64
+	tx := db.Session(&gorm.Session{NewDB: true, Context: types.WithoutTenantScope(stmt.Context)}).Model(stmt.Model)
+	tx.Statement.AddClause(conditions.(clause.Where))
+	if err := tx.Count(&count).Error; err == nil && count > 0 {
+		slog.Warn("cross-tenant access attempt", "user_id", scope.UserID, "table", stmt.Table)
+	}
+}
+
+// checkTenantWrite refuses to store a row whose company is outside of the tenant scope.
+func checkTenantWrite(db *gorm.DB) {
+	stmt := db.Statement
+	scope := types.TenantScopeFrom(stmt.Context)
+	if scope == nil || stmt.Schema == nil || db.Error != nil {
+		return
+	}
+	field := stmt.Schema.LookUpField("CompanyID")
+	if field == nil {
+		return
+	}
+
+	check := func(value interface{}) bool {
+		companyID, ok := asCompanyID(value)
+		if !ok || companyID == 0 || scope.Allows(companyID) {
+			return true
+		}
+		slog.Warn("cross-tenant write attempt", "user_id", scope.UserID, "table", stmt.Table, "company_id", companyID)
+		db.AddError(fmt.Errorf("%w: company %d", types.ErrOutsideTenant, companyID))
+		return false
+	}
+
+	if updates, ok := stmt.Dest.(map[string]interface{}); ok {
+		if value, ok := updates[field.DBName]; ok && !check(value) {
+			return
+		}
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			value, zero := field.ValueOf(stmt.Context, stmt.ReflectValue.Index(i))
+			if !zero && !check(value) {
+				return
+			}
+		}
+	case reflect.Struct:
+		value, zero := field.ValueOf(stmt.Context, stmt.ReflectValue)
+		if !zero {
+			check(value)
+		}
+	}
+}
+
+// asCompanyID converts the value of a CompanyID field.
+func asCompanyID(value interface{}) (uint, bool) {
+	switch v := value.(type) {
+	case uint:
+		return v, true
+	case *uint:
+		if v == nil {
+			return 0, false
+		}
+		return *v, true
+	case int:
+		return uint(v), v >= 0
+	}
+	return 0, false
+}
+
+// companyIDs returns the companies of the scope as a query argument.
+func companyIDs(scope *types.TenantScope) []uint {
+	if scope.CompanyIDs == nil {
+		return []uint{}
+	}
+	return scope.CompanyIDs
+}
@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"point-system-api/internal/models"
+)
+
+// TwoFactorRepository defines the interface for the TOTP secrets and recovery codes of users.
+type TwoFactorRepository interface {
+	GetTwoFactor(ctx context.Context, userID uint) (*models.TwoFactor, error)
+	// SaveTwoFactor replaces the TOTP secret of a user by a pending one.
+	SaveTwoFactor(ctx context.Context, twoFactor *models.TwoFactor) error
+	// EnableTwoFactor enables the second factor of a user and replaces their recovery codes.
+	EnableTwoFactor(ctx context.Context, twoFactor *models.TwoFactor, codeHashes []string) error
+	// UseTOTPStep records the time step of an accepted code, reporting false when a code of the
+	// same or a later step was already accepted.
+	UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error)
+	// ReplaceRecoveryCodes discards the recovery codes of a user for new ones.
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error
+	ListUnusedRecoveryCodes(ctx context.Context, userID uint) ([]models.RecoveryCode, error)
+	// UseRecoveryCode marks a recovery code used, reporting false when it already was.
+	UseRecoveryCode(ctx context.Context, id uint) (bool, error)
+	CountUnusedRecoveryCodes(ctx context.Context, userID uint) (int64, error)
+	// DeleteTwoFactor removes the secret and recovery codes of a user, disabling the second factor.
+	DeleteTwoFactor(ctx context.Context, userID uint) error
+}
+
+// twoFactorRepository implements the TwoFactorRepository interface.
+type twoFactorRepository struct {
+	db *gorm.DB
+}
+
+// NewTwoFactorRepository creates a new instance of TwoFactorRepository.
+func NewTwoFactorRepository(db *gorm.DB) TwoFactorRepository {
+	return &twoFactorRepository{
+		db: db,
+	}
+}
+
+// GetTwoFactor retrieves the TOTP secret of a user, nil when they have none.
+func (r *twoFactorRepository) GetTwoFactor(ctx context.Context, userID uint) (*models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).First(&twoFactor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to retrieve two-factor settings: %w", err)
+	}
+	return &twoFactor, nil
+}
+
+// SaveTwoFactor deletes the previous secret of the user and inserts the new one.
+func (r *twoFactorRepository) SaveTwoFactor(ctx context.Context, twoFactor *models.TwoFactor) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", twoFactor.UserID).Delete(&models.TwoFactor{}).Error; err != nil {
+			return fmt.Errorf("failed to replace two-factor settings: %w", err)
+		}
+		if err := tx.Create(twoFactor).Error; err != nil {
+			return fmt.Errorf("failed to save two-factor settings: %w", err)
+		}
+		return nil
+	})
+}
+
+// EnableTwoFactor stores the enabled secret and the new recovery codes in one transaction.
+func (r *twoFactorRepository) EnableTwoFactor(ctx context.Context, twoFactor *models.TwoFactor, codeHashes []string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(twoFactor).Error; err != nil {
+			return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+		}
+		return replaceRecoveryCodes(tx, twoFactor.UserID, codeHashes)
+	})
+}
+
+// UseTOTPStep moves the last used time step of a user forward.
+func (r *twoFactorRepository) UseTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.TwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record TOTP code: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes deletes the recovery codes of a user and inserts new ones in one transaction.
+func (r *twoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// replaceRecoveryCodes swaps the recovery codes of a user within tx.
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	codes := make([]models.RecoveryCode, len(codeHashes))
+	for i, hash := range codeHashes {
+		codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	if len(codes) > 0 {
+		if err := tx.Create(&codes).Error; err != nil {
+			return fmt.Errorf("failed to create recovery codes: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListUnusedRecoveryCodes retrieves the recovery codes a user has left.
+func (r *twoFactorRepository) ListUnusedRecoveryCodes(ctx context.Context, userID uint) ([]models.RecoveryCode, error) {
+	var codes []models.RecoveryCode
+	if err := conn(ctx, r.db).Where("user_id = ? AND used_at IS NULL", userID).Find(&codes).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// UseRecoveryCode marks the recovery code used, so that it cannot be presented again.
+func (r *twoFactorRepository) UseRecoveryCode(ctx context.Context, id uint) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CountUnusedRecoveryCodes counts the recovery codes a user has left.
+func (r *twoFactorRepository) CountUnusedRecoveryCodes(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteTwoFactor deletes the secret and recovery codes of a user in one transaction.
+func (r *twoFactorRepository) DeleteTwoFactor(ctx context.Context, userID uint) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.TwoFactor{}).Error; err != nil {
+			return fmt.Errorf("failed to delete two-factor settings: %w", err)
+		}
+		return replaceRecoveryCodes(tx, userID, nil)
+	})
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the context key of the transaction opened by a UnitOfWork.
+type txKey struct{}
+
+// UnitOfWork runs several repository calls in a single database transaction.
+type UnitOfWork interface {
+	// Do runs fn in a transaction, committed when fn returns nil and rolled back otherwise.
+	// Repository calls made with the context passed to fn join the transaction, and so do nested
+	// calls to Do.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// unitOfWork implements the UnitOfWork interface.
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+// NewUnitOfWork creates a new instance of UnitOfWork.
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+// Do runs fn in a new transaction, or in the one already carried by ctx.
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn returns the transaction carried by ctx, or db outside of a unit of work.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
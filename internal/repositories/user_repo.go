@@ -16,10 +16,18 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user models.User) (uint, error)
 	GetUserByID(ctx context.Context, id uint) (*models.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	// GetUserByEmail retrieves the user with an email address, nil when none or several have it.
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	ListUsers(ctx context.Context) ([]models.User, error)
+	// UpdateUser updates the username, email and names of a user, never their role nor password.
 	UpdateUser(ctx context.Context, user models.User) (bool, error)
+	UpdateUserRole(ctx context.Context, id uint, role string) (bool, error)
 	DeleteUser(ctx context.Context, id uint) (bool, error)
 	ListUsersWithFilters(ctx context.Context, page, limit int, filters map[string]interface{}) ([]models.User, int64, error)
+	// ListUserCompanyIDs returns the companies a user is linked to or employed by.
+	ListUserCompanyIDs(ctx context.Context, userID uint) ([]uint, error)
+	// SetUserCompanies replaces the companies a user is linked to.
+	SetUserCompanies(ctx context.Context, userID uint, companyIDs []uint) error
 }
 
 // userRepository implements the UserRepository interface.
@@ -34,7 +42,8 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	}
 }
 
-// CreateUser inserts a new user into the database after hashing the password.
+// CreateUser inserts a new user into the database after hashing the password, which starts their
+// password history.
 func (r *userRepository) CreateUser(ctx context.Context, user models.User) (uint, error) {
 	// Hash the user's password before saving
 	hashedPassword, err := utils.HashPassword(user.Password)
@@ -44,8 +53,17 @@ func (r *userRepository) CreateUser(ctx context.Context, user models.User) (uint
 	user.Password = hashedPassword
 
 	// Create the user in the database
-	if err := r.db.WithContext(ctx).Create(&user).Error; err != nil {
-		return 0, fmt.Errorf("failed to insert user: %w", err)
+	err = conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return fmt.Errorf("failed to insert user: %w", err)
+		}
+		if err := tx.Create(&models.PasswordHistory{UserID: user.ID, PasswordHash: hashedPassword}).Error; err != nil {
+			return fmt.Errorf("failed to record password history: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
 
 	// Return the ID of the newly created user
@@ -55,7 +73,7 @@ func (r *userRepository) CreateUser(ctx context.Context, user models.User) (uint
 // GetUserByID retrieves a user by their ID.
 func (r *userRepository) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // No user found
 		}
@@ -67,7 +85,7 @@ func (r *userRepository) GetUserByID(ctx context.Context, id uint) (*models.User
 // GetUserByUsername retrieves a user by their username.
 func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	if err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error; err != nil {
+	if err := conn(ctx, r.db).Where("username = ?", username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // No user found
 		}
@@ -76,37 +94,55 @@ func (r *userRepository) GetUserByUsername(ctx context.Context, username string)
 	return &user, nil
 }
 
+// GetUserByEmail retrieves the only user with an email address.
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var users []models.User
+	if err := conn(ctx, r.db).Where("email = ?", email).Limit(2).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve user by email: %w", err)
+	}
+	if len(users) != 1 {
+		return nil, nil // No user, or an ambiguous address
+	}
+	return &users[0], nil
+}
+
 // ListUsers retrieves all users from the database.
 func (r *userRepository) ListUsers(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	if err := r.db.WithContext(ctx).Find(&users).Error; err != nil {
+	if err := conn(ctx, r.db).Find(&users).Error; err != nil {
 		return nil, fmt.Errorf("failed to retrieve users: %w", err)
 	}
 	return users, nil
 }
 
-// UpdateUser updates an existing user in the database.
+// UpdateUser updates the profile of an existing user in the database.
 func (r *userRepository) UpdateUser(ctx context.Context, user models.User) (bool, error) {
-	// Hash the user's password before updating
-	if user.Password != "" {
-		hashedPassword, err := utils.HashPassword(user.Password)
-		if err != nil {
-			return false, fmt.Errorf("failed to hash password: %w", err)
-		}
-		user.Password = hashedPassword
-	}
-
-	// Update the user in the database
-	if err := r.db.WithContext(ctx).Save(&user).Error; err != nil {
+	err := conn(ctx, r.db).Model(&models.User{}).
+		Where("id = ?", user.ID).
+		Updates(map[string]interface{}{
+			"username":   user.Username,
+			"email":      user.Email,
+			"first_name": user.FirstName,
+			"last_name":  user.LastName,
+		}).Error
+	if err != nil {
 		return false, fmt.Errorf("failed to update user: %w", err)
 	}
 
 	return true, nil
 }
 
+// UpdateUserRole changes the role of a user.
+func (r *userRepository) UpdateUserRole(ctx context.Context, id uint, role string) (bool, error) {
+	if err := conn(ctx, r.db).Model(&models.User{}).Where("id = ?", id).Update("role", role).Error; err != nil {
+		return false, fmt.Errorf("failed to update user role: %w", err)
+	}
+	return true, nil
+}
+
 // DeleteUser deletes a user by their ID.
 func (r *userRepository) DeleteUser(ctx context.Context, id uint) (bool, error) {
-	if err := r.db.WithContext(ctx).Delete(&models.User{}, id).Error; err != nil {
+	if err := conn(ctx, r.db).Delete(&models.User{}, id).Error; err != nil {
 
 		return false, fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -118,7 +154,7 @@ func (r *userRepository) ListUsersWithFilters(ctx context.Context, page, limit i
 	offset := (page - 1) * limit
 
 	// Build the query
-	query := r.db.Model(&models.User{})
+	query := conn(ctx, r.db).Model(&models.User{})
 
 	// Apply search filter
 	if search, ok := filters["search"]; ok {
@@ -146,3 +182,33 @@ func (r *userRepository) ListUsersWithFilters(ctx context.Context, page, limit i
 
 	return users, total, nil
 }
+
+// ListUserCompanyIDs retrieves the companies a user is linked to, along with the company employing them.
+func (r *userRepository) ListUserCompanyIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var companyIDs []uint
+	err := conn(ctx, r.db).Raw(`
+		SELECT company_id FROM user_companies WHERE user_id = ? AND deleted_at IS NULL
+		UNION
+		SELECT company_id FROM employees WHERE user_id = ? AND deleted_at IS NULL
+		ORDER BY company_id
+	`, userID, userID).Scan(&companyIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve companies of user: %w", err)
+	}
+	return companyIDs, nil
+}
+
+// SetUserCompanies replaces the companies a user is linked to.
+func (r *userRepository) SetUserCompanies(ctx context.Context, userID uint, companyIDs []uint) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.UserCompany{}).Error; err != nil {
+			return fmt.Errorf("failed to unlink companies of user: %w", err)
+		}
+		for _, companyID := range companyIDs {
+			if err := tx.Create(&models.UserCompany{UserID: userID, CompanyID: companyID}).Error; err != nil {
+				return fmt.Errorf("failed to link company %d to user: %w", companyID, err)
+			}
+		}
+		return nil
+	})
+}
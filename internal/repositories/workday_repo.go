@@ -17,6 +17,7 @@ type WorkDayRepository interface {
 	CreateWorkDay(ctx context.Context, workday *models.WorkDay) error
 	GetWorkDayByID(ctx context.Context, id uint) (*models.WorkDay, error)
 	ListWorkDays(ctx context.Context) ([]*models.WorkDay, error)
+	ListWorkDaysBetween(ctx context.Context, start, end time.Time) ([]*models.WorkDay, error)
 	UpdateWorkDay(ctx context.Context, workday *models.WorkDay) error
 	DeleteWorkDay(ctx context.Context, id uint) error
 	GetEmployeesWithAttendance(ctx context.Context, date time.Time) ([]types.EmployeeAttendance, error)
@@ -40,7 +41,7 @@ func (r *workDayRepository) CreateWorkDay(ctx context.Context, workday *models.W
 		return errors.New("workday is nil")
 	}
 
-	if err := r.db.WithContext(ctx).Create(workday).Error; err != nil {
+	if err := conn(ctx, r.db).Create(workday).Error; err != nil {
 		return fmt.Errorf("failed to create workday: %w", err)
 	}
 
@@ -50,7 +51,7 @@ func (r *workDayRepository) CreateWorkDay(ctx context.Context, workday *models.W
 // GetWorkDayByID retrieves a workday by its ID.
 func (r *workDayRepository) GetWorkDayByID(ctx context.Context, id uint) (*models.WorkDay, error) {
 	var workday models.WorkDay
-	if err := r.db.WithContext(ctx).First(&workday, id).Error; err != nil {
+	if err := conn(ctx, r.db).First(&workday, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // No workday found
 		}
@@ -62,7 +63,19 @@ func (r *workDayRepository) GetWorkDayByID(ctx context.Context, id uint) (*model
 // ListWorkDays retrieves all workdays from the database.
 func (r *workDayRepository) ListWorkDays(ctx context.Context) ([]*models.WorkDay, error) {
 	var workdays []*models.WorkDay
-	if err := r.db.WithContext(ctx).Find(&workdays).Error; err != nil {
+	if err := conn(ctx, r.db).Find(&workdays).Error; err != nil {
+		return nil, fmt.Errorf("failed to list workdays: %w", err)
+	}
+	return workdays, nil
+}
+
+// ListWorkDaysBetween retrieves the workdays whose date lies in [start, end].
+func (r *workDayRepository) ListWorkDaysBetween(ctx context.Context, start, end time.Time) ([]*models.WorkDay, error) {
+	var workdays []*models.WorkDay
+	if err := conn(ctx, r.db).
+		Where("date BETWEEN ? AND ?", start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Order("date ASC").
+		Find(&workdays).Error; err != nil {
 		return nil, fmt.Errorf("failed to list workdays: %w", err)
 	}
 	return workdays, nil
@@ -74,7 +87,7 @@ func (r *workDayRepository) UpdateWorkDay(ctx context.Context, workday *models.W
 		return errors.New("invalid workday data")
 	}
 
-	if err := r.db.WithContext(ctx).Save(workday).Error; err != nil {
+	if err := conn(ctx, r.db).Save(workday).Error; err != nil {
 		return fmt.Errorf("failed to update workday: %w", err)
 	}
 
@@ -87,7 +100,7 @@ func (r *workDayRepository) DeleteWorkDay(ctx context.Context, id uint) error {
 		return errors.New("invalid workday ID")
 	}
 
-	if err := r.db.WithContext(ctx).Delete(&models.WorkDay{}, id).Error; err != nil {
+	if err := conn(ctx, r.db).Delete(&models.WorkDay{}, id).Error; err != nil {
 		return fmt.Errorf("failed to delete workday: %w", err)
 	}
 
@@ -99,11 +112,11 @@ func (r *workDayRepository) GetEmployeesWithAttendance(ctx context.Context, date
 	query := `
         SELECT 
             e.id AS user_id, 
-            e.company_id, 
+            a.company_id, 
             us.first_name, 
             us.last_name, 
 			e.registration_number,
-            e.qualification, 
+            a.qualification, 
             u.date, 
             u.checkin, 
             u.checkout 
@@ -111,12 +124,24 @@ func (r *workDayRepository) GetEmployeesWithAttendance(ctx context.Context, date
             employees e
         INNER JOIN
             users us ON e.user_id = us.id
+        INNER JOIN
+            employment_assignments a ON a.employee_id = e.id AND a.deleted_at IS NULL
+                AND a.effective_from <= ? AND (a.effective_to IS NULL OR a.effective_to >= ?)
         LEFT JOIN 
-            user_daily_checkin_checkout u ON e.registration_number = u.user_id 
+            user_daily_checkin_checkout u ON e.id = u.employee_id 
         WHERE 
             (u.checkin IS NOT NULL OR u.checkout IS NOT NULL) AND (u.date = ? or u.date is null)
+            AND e.deleted_at IS NULL
     `
-	rows, err := r.db.Raw(query, date.Format("2006-01-02")).Rows()
+	// The company and position are those of the assignment effective on the date, so employees
+	// who were not employed on that date (not yet hired or terminated) are left out.
+	day := date.Format("2006-01-02")
+	args := []interface{}{day, day, day}
+	if condition, tenantArgs := tenantFilter(ctx, "a.company_id"); condition != "" {
+		query += " AND " + condition
+		args = append(args, tenantArgs...)
+	}
+	rows, err := conn(ctx, r.db).Raw(query, args...).Rows()
 	if err != nil {
 		return nil, err
 	}
@@ -1,12 +1,16 @@
 package server
 
 import (
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"point-system-api/internal/handlers"
+	"point-system-api/internal/middleware"
+	"point-system-api/internal/rbac"
+	"point-system-api/pkg/utils"
 
 	"github.com/gin-contrib/cors"
 )
@@ -14,80 +18,282 @@ import (
 // RegisterRoutes sets up all the routes for the application.
 func (s *Server) RegisterRoutes() http.Handler {
 	r := gin.Default()
+	// Client addresses decide the API key allow-lists, the login throttling and the audit trail:
+	// X-Forwarded-For is only believed when set by one of the trusted proxies.
+	if err := r.SetTrustedProxies(s.trustedProxies); err != nil {
+		log.Fatalf("failed to set trusted proxies: %v", err)
+	}
 
+	r.Use(middleware.AuditMiddleware())
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "DELETE", "PUT", "PATCH", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Referer", "Accept", "Content-Type"},
+		AllowHeaders:     []string{"Origin", "Referer", "Accept", "Content-Type", "Authorization", "X-API-Key"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// Health check endpoint
+	// Public routes: download links carry their own signature, refresh tokens authenticate themselves and single sign-on, when configured, is
+	// authenticated by the identity provider.
 	r.GET("/health", handlers.HealthHandler(s.db))
+	r.GET("/", s.HelloWorldHandler)
+	authHandler := handlers.NewAuthHandler(s.authService, s.userService)
+	r.POST("/users/authenticate", authHandler.Login)
+	r.POST("/auth/refresh", authHandler.Refresh)
+	r.POST("/auth/logout", authHandler.Logout)
+	r.POST("/auth/password/forgot", authHandler.ForgotPassword)
+	r.POST("/auth/password/reset", authHandler.ResetPassword)
+	r.POST("/auth/2fa/verify", authHandler.VerifyTwoFactor)
+	if s.oidcService != nil {
+		oidcHandler := handlers.NewOIDCHandler(s.oidcService)
+		r.GET("/auth/oidc/login", oidcHandler.StartLogin)
+		r.POST("/auth/oidc/callback", oidcHandler.CompleteLogin)
+	}
+	r.GET("/documents/:id/download", handlers.NewDocumentHandler(s.documentService).DownloadDocument)
+	r.GET("/ws", handlers.ServeWs)
+
+	// Every other route requires a valid token whose role grants the permission of its group, and
+	// only reaches the companies of the token. Service accounts reach the routes of their
+	// permissions with an API key instead of a token. Their changes are audited as made by them.
+	api := r.Group("", middleware.AuthMiddleware(), middleware.TenantMiddleware())
+	apiOrKey := r.Group("", middleware.APIKeyMiddleware(s.apiKeyService), middleware.AuthMiddleware(), middleware.TenantMiddleware())
+	can := func(permission rbac.Permission) *gin.RouterGroup {
+		return apiOrKey.Group("", middleware.PermissionMiddleware(permission))
+	}
+	orgScope := middleware.OrgScopeMiddleware(s.organizationService)
+	managerScope := middleware.ManagerOrgScopeMiddleware(s.organizationService)
+
+	// Every authenticated user manages their own password and second factor. Users whose role
+	// requires a second factor enroll it with the pre-auth token of their login.
+	api.POST("/auth/password/change", authHandler.ChangePassword)
+	api.GET("/auth/2fa", authHandler.GetTwoFactorStatus)
+	api.POST("/auth/2fa/disable", authHandler.DisableTwoFactor)
+	api.POST("/auth/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+	enrollment := r.Group("", middleware.AuthMiddleware(utils.TokenPurposeTwoFactorEnrollment), middleware.TenantMiddleware())
+	enrollment.POST("/auth/2fa/enroll", authHandler.EnrollTwoFactor)
+	enrollment.POST("/auth/2fa/activate", authHandler.ActivateTwoFactor)
+
+	// Self-service routes. Every authenticated user reaches the records of their own employee,
+	// found from their token.
+	selfServiceHandler := handlers.NewSelfServiceHandler(s.selfService)
+	api.GET("/me", selfServiceHandler.GetProfile)
+	api.GET("/me/punches", selfServiceHandler.ListPunches)
+	api.GET("/me/attendance", selfServiceHandler.GetAttendance)
+	api.GET("/me/schedule", selfServiceHandler.GetSchedule)
+	api.GET("/me/leave-balances", selfServiceHandler.GetLeaveBalances)
+	api.GET("/me/leave-requests", selfServiceHandler.ListLeaveRequests)
+	api.POST("/me/leave-requests", selfServiceHandler.CreateLeaveRequest)
+	api.GET("/me/punch-corrections", selfServiceHandler.ListPunchCorrections)
+	api.POST("/me/punch-corrections", selfServiceHandler.CreatePunchCorrection)
+	api.POST("/me/punch-corrections/:id/documents", selfServiceHandler.AttachPunchCorrectionDocument)
 
 	// RawAttendance routes
 	rawAttendanceHandler := handlers.NewRawAttendanceHandler(s.rawAttendanceService)
-	r.POST("/raw-attendances", rawAttendanceHandler.CreateRawAttendance)
-	r.GET("/raw-attendances/:id", rawAttendanceHandler.GetRawAttendanceByID)
-	r.GET("/raw-attendances/by-company/:companyId/work-day/:workDayId", rawAttendanceHandler.GetRawAttendancesByCompanyAndWorkDay)
-	r.PUT("/raw-attendances/:id", rawAttendanceHandler.UpdateRawAttendance)
-	r.DELETE("/raw-attendances/:id", rawAttendanceHandler.DeleteRawAttendance)
-	r.GET("/raw-attendances", rawAttendanceHandler.ListRawAttendances)
+	rawAttendanceRead := can(rbac.RawAttendanceRead)
+	rawAttendanceRead.GET("/raw-attendances/:id", rawAttendanceHandler.GetRawAttendanceByID)
+	rawAttendanceRead.GET("/raw-attendances/by-company/:companyId/work-day/:workDayId", orgScope, rawAttendanceHandler.GetRawAttendancesByCompanyAndWorkDay)
+	rawAttendanceRead.GET("/raw-attendances", orgScope, rawAttendanceHandler.ListRawAttendances)
+	rawAttendanceRead.GET("/raw-attendances/:id/versions", rawAttendanceHandler.ListRawAttendanceVersions)
+	rawAttendanceRead.GET("/raw-attendances/:id/versions/diff", rawAttendanceHandler.DiffRawAttendanceVersions)
+	rawAttendanceWrite := can(rbac.RawAttendanceWrite)
+	rawAttendanceWrite.POST("/raw-attendances", rawAttendanceHandler.CreateRawAttendance)
+	rawAttendanceWrite.PUT("/raw-attendances/:id", rawAttendanceHandler.UpdateRawAttendance)
+	rawAttendanceWrite.DELETE("/raw-attendances/:id", rawAttendanceHandler.DeleteRawAttendance)
+	rawAttendanceWrite.POST("/raw-attendances/:id/versions/:version/revert", rawAttendanceHandler.RevertRawAttendance)
 
 	// WorkDay routes
 	workDayHandler := handlers.NewWorkDayHandler(s.workDayService)
-	r.POST("/workdays", workDayHandler.CreateWorkDay)
-	r.GET("/workdays/:id", workDayHandler.GetWorkDayByID)
-	r.GET("/workdays", workDayHandler.ListWorkDays)
-	r.PUT("/workdays/:id", workDayHandler.UpdateWorkDay)
-	r.DELETE("/workdays/:id", workDayHandler.DeleteWorkDay)
+	workDayRead := can(rbac.WorkDayRead)
+	workDayRead.GET("/workdays/:id", workDayHandler.GetWorkDayByID)
+	workDayRead.GET("/workdays", workDayHandler.ListWorkDays)
+	workDayWrite := can(rbac.WorkDayWrite)
+	workDayWrite.POST("/workdays", workDayHandler.CreateWorkDay)
+	workDayWrite.PUT("/workdays/:id", workDayHandler.UpdateWorkDay)
+	workDayWrite.DELETE("/workdays/:id", workDayHandler.DeleteWorkDay)
+	workDayWrite.POST("/workdays/:id/regenerate", workDayHandler.RegenerateWorkDay)
+	workDayWrite.POST("/workdays/:id/transition", workDayHandler.TransitionWorkDay)
+
+	// Payroll period routes
+	payrollPeriodHandler := handlers.NewPayrollPeriodHandler(s.payrollPeriodService)
+	payrollRead := can(rbac.PayrollRead)
+	payrollRead.GET("/payroll-periods", payrollPeriodHandler.ListPayrollPeriods)
+	payrollRead.GET("/payroll-periods/:id", payrollPeriodHandler.GetPayrollPeriodByID)
+	payrollRead.GET("/payroll-periods/:id/events", payrollPeriodHandler.ListPayrollPeriodEvents)
+	payrollWrite := can(rbac.PayrollWrite)
+	payrollWrite.POST("/payroll-periods", payrollPeriodHandler.CreatePayrollPeriod)
+	payrollWrite.POST("/payroll-periods/:id/close", payrollPeriodHandler.ClosePayrollPeriod)
+	can(rbac.PayrollAdmin).POST("/payroll-periods/:id/reopen", payrollPeriodHandler.ReopenPayrollPeriod)
+
+	// Leave routes
+	leaveHandler := handlers.NewLeaveHandler(s.leaveService)
+	leaveRequest := can(rbac.LeaveRequest)
+	leaveRequest.GET("/leave-types", leaveHandler.ListLeaveTypes) // Needed to file a request
+	leaveRequest.GET("/leave-types/:id", leaveHandler.GetLeaveTypeByID)
+	leaveRequest.POST("/leave-requests", leaveHandler.CreateLeaveRequest)
+	leaveRequest.POST("/leave-requests/:id/cancel", leaveHandler.CancelLeaveRequest)
+	leaveRead := can(rbac.LeaveRead)
+	leaveRead.GET("/leave-requests", leaveHandler.ListLeaveRequests)
+	leaveRead.GET("/leave-requests/:id", leaveHandler.GetLeaveRequestByID)
+	leaveWrite := can(rbac.LeaveWrite)
+	leaveWrite.POST("/leave-types", leaveHandler.CreateLeaveType)
+	leaveWrite.PUT("/leave-types/:id", leaveHandler.UpdateLeaveType)
+	leaveWrite.DELETE("/leave-types/:id", leaveHandler.DeleteLeaveType)
+	leaveWrite.POST("/leave-requests/:id/approve", leaveHandler.ApproveLeaveRequest)
+	leaveWrite.POST("/leave-requests/:id/reject", leaveHandler.RejectLeaveRequest)
+
+	// Leave balance routes
+	leaveBalanceHandler := handlers.NewLeaveBalanceHandler(s.leaveBalanceService)
+	leaveRead.GET("/leave-types/:id/accrual-policy", leaveBalanceHandler.GetAccrualPolicy)
+	leaveRead.GET("/employees/:id/leave-balances", leaveBalanceHandler.GetLeaveBalances)
+	leaveRead.GET("/employees/:id/leave-balances/:leaveTypeId/ledger", leaveBalanceHandler.GetLeaveLedger)
+	leaveWrite.PUT("/leave-types/:id/accrual-policy", leaveBalanceHandler.SaveAccrualPolicy)
+	leaveWrite.POST("/employees/:id/leave-balances/:leaveTypeId/adjustments", leaveBalanceHandler.AdjustLeaveBalance)
+	can(rbac.LeaveAdmin).POST("/leave-balances/rollover", leaveBalanceHandler.RunYearEndRollover)
+
+	// Document routes
+	documentHandler := handlers.NewDocumentHandler(s.documentService)
+	documentRead := can(rbac.DocumentRead)
+	documentRead.GET("/documents", documentHandler.ListDocuments)
+	documentRead.GET("/documents/:id", documentHandler.GetDocumentByID)
+	documentRead.GET("/documents/:id/link", documentHandler.GetDownloadLink)
+	documentWrite := can(rbac.DocumentWrite)
+	documentWrite.POST("/documents", documentHandler.UploadDocument)
+	documentWrite.DELETE("/documents/:id", documentHandler.DeleteDocument)
+	can(rbac.DocumentReview).POST("/documents/:id/review", documentHandler.ReviewDocument)
 
 	// User routes
 	userHandler := handlers.NewUserHandler(s.userService)
-	r.POST("/users", userHandler.CreateUser)
-	r.GET("/users/:id", userHandler.GetUserByID)
-	r.GET("/users/username/:username", userHandler.GetUserByUsername)
-	r.GET("/users", userHandler.ListUsers)
-	r.PUT("/users/:id", userHandler.UpdateUser)
-	r.DELETE("/users/:id", userHandler.DeleteUser)
-	r.POST("/users/authenticate", userHandler.AuthenticateUser)
-	r.GET("/users/select", userHandler.ListUsersForSelect)
+	userRead := can(rbac.UserRead)
+	userRead.GET("/users/:id", userHandler.GetUserByID)
+	userRead.GET("/users/username/:username", userHandler.GetUserByUsername)
+	userRead.GET("/users", userHandler.ListUsers)
+	userRead.GET("/users/select", userHandler.ListUsersForSelect)
+	userAdmin := can(rbac.UserAdmin)
+	userAdmin.POST("/users", userHandler.CreateUser)
+	userAdmin.PUT("/users/:id", userHandler.UpdateUser)
+	userAdmin.DELETE("/users/:id", userHandler.DeleteUser)
+	userAdmin.GET("/users/:id/companies", userHandler.GetUserCompanies)
+	userAdmin.PUT("/users/:id/companies", userHandler.SetUserCompanies)
+	userAdmin.PUT("/users/:id/role", userHandler.ChangeUserRole)
+	userAdmin.POST("/users/:id/password-reset", userHandler.SendPasswordReset)
+	userAdmin.POST("/users/:id/unlock", authHandler.UnlockUser)
+	userAdmin.GET("/users/:id/login-history", authHandler.ListLoginHistory)
+	userAdmin.DELETE("/users/:id/2fa", authHandler.ResetTwoFactor)
+
+	// Service account and API key routes
+	apiKeyHandler := handlers.NewAPIKeyHandler(s.apiKeyService)
+	apiKeyAdmin := can(rbac.APIKeyAdmin)
+	apiKeyAdmin.GET("/service-accounts", apiKeyHandler.ListServiceAccounts)
+	apiKeyAdmin.GET("/service-accounts/:id", apiKeyHandler.GetServiceAccount)
+	apiKeyAdmin.POST("/service-accounts", apiKeyHandler.CreateServiceAccount)
+	apiKeyAdmin.DELETE("/service-accounts/:id", apiKeyHandler.DeleteServiceAccount)
+	apiKeyAdmin.GET("/service-accounts/:id/api-keys", apiKeyHandler.ListAPIKeys)
+	apiKeyAdmin.POST("/service-accounts/:id/api-keys", apiKeyHandler.CreateAPIKey)
+	apiKeyAdmin.DELETE("/service-accounts/:id/api-keys/:keyId", apiKeyHandler.RevokeAPIKey)
 
 	// Company routes
 	companyHandler := handlers.NewCompanyHandler(s.companyService)
-	r.POST("/companies", companyHandler.CreateCompany)
-	r.GET("/companies/:id", companyHandler.GetCompanyByID)
-	r.GET("/companies", companyHandler.ListCompanies)
-	r.PUT("/companies/:id", companyHandler.UpdateCompany)
-	r.DELETE("/companies/:id", companyHandler.DeleteCompany)
-	r.GET("/companies/select", companyHandler.ListCompaniesForSelect)
+	companyRead := can(rbac.CompanyRead)
+	companyRead.GET("/companies/:id", companyHandler.GetCompanyByID)
+	companyRead.GET("/companies", companyHandler.ListCompanies)
+	companyRead.GET("/companies/select", companyHandler.ListCompaniesForSelect)
+	companyWrite := can(rbac.CompanyWrite)
+	companyWrite.POST("/companies", companyHandler.CreateCompany)
+	companyWrite.PUT("/companies/:id", companyHandler.UpdateCompany)
+	companyWrite.DELETE("/companies/:id", companyHandler.DeleteCompany)
 
 	// Employee routes
 	employeeHandler := handlers.NewEmployeeHandler(s.employeeService)
-	r.POST("/employees", employeeHandler.CreateEmployee)
-	r.GET("/employees/:id", employeeHandler.GetEmployeeByID)
-	r.GET("/employees/by-company/:id", employeeHandler.GetEmployeesByCompanyID)
-	r.GET("/employees", employeeHandler.FetchEmployees)
-	r.PUT("/employees/:id", employeeHandler.UpdateEmployee)
-	r.DELETE("/employees/:id", employeeHandler.DeleteEmployee)
-
-	// Hello World endpoint
-	r.GET("/", s.HelloWorldHandler)
+	employeeImportHandler := handlers.NewEmployeeImportHandler(s.employeeImportService)
+	employeeRead := can(rbac.EmployeeRead)
+	employeeRead.GET("/employees/:id", employeeHandler.GetEmployeeByID)
+	employeeRead.GET("/employees/by-company/:id", employeeHandler.GetEmployeesByCompanyID)
+	employeeRead.GET("/employees", employeeHandler.FetchEmployees)
+	employeeWrite := can(rbac.EmployeeWrite)
+	employeeWrite.POST("/employees", employeeHandler.CreateEmployee)
+	employeeWrite.POST("/employees/import", employeeImportHandler.ImportEmployees)
+	employeeWrite.PUT("/employees/:id", employeeHandler.UpdateEmployee)
+	employeeWrite.DELETE("/employees/:id", employeeHandler.DeleteEmployee)
+
+	// Employment history routes
+	employmentHandler := handlers.NewEmploymentHandler(s.employmentService)
+	employeeRead.GET("/employees/:id/employment", employmentHandler.GetEmploymentHistory)
+	employeeWrite.POST("/employees/:id/transfers", employmentHandler.TransferEmployee)
+	employeeWrite.POST("/employees/:id/termination", employmentHandler.TerminateEmployee)
+	employeeWrite.DELETE("/employees/:id/termination", employmentHandler.ReinstateEmployee)
 
+	// Organization routes
+	organizationHandler := handlers.NewOrganizationHandler(s.organizationService)
+	organizationRead := can(rbac.OrganizationRead)
+	organizationRead.GET("/departments", organizationHandler.ListDepartments)
+	organizationRead.GET("/departments/:id", organizationHandler.GetDepartmentByID)
+	organizationRead.GET("/teams", organizationHandler.ListTeams)
+	organizationRead.GET("/teams/:id", organizationHandler.GetTeamByID)
+	organizationRead.GET("/employees/:id/org-assignments", organizationHandler.ListOrgAssignments)
+	organizationRead.GET("/org/tree", organizationHandler.GetReportingTree)
+	organizationWrite := can(rbac.OrganizationWrite)
+	organizationWrite.POST("/departments", organizationHandler.CreateDepartment)
+	organizationWrite.PUT("/departments/:id", organizationHandler.UpdateDepartment)
+	organizationWrite.DELETE("/departments/:id", organizationHandler.DeleteDepartment)
+	organizationWrite.POST("/teams", organizationHandler.CreateTeam)
+	organizationWrite.PUT("/teams/:id", organizationHandler.UpdateTeam)
+	organizationWrite.DELETE("/teams/:id", organizationHandler.DeleteTeam)
+	organizationWrite.POST("/employees/:id/org-assignments", organizationHandler.AssignEmployee)
+	organizationWrite.POST("/employees/:id/org-assignments/end", organizationHandler.EndOrgAssignment)
+
+	// Attendance log routes
 	attendanceHandler := handlers.NewAttendanceHandler(s.attendanceService)
-	r.POST("/process-hex", attendanceHandler.CreateAttendanceLog)
-	r.GET("/attendance-logs", attendanceHandler.ListAttendanceLogs)
-	r.GET("/attendance-logs/:id", attendanceHandler.GetAttendanceLogByID)
+	attendanceRead := can(rbac.AttendanceRead)
+	attendanceRead.GET("/attendance-logs", orgScope, attendanceHandler.ListAttendanceLogs)
+	attendanceRead.GET("/attendance-logs/:id", attendanceHandler.GetAttendanceLogByID)
+	manualPunchHandler := handlers.NewManualPunchHandler(s.manualPunchService)
+	attendanceWrite := can(rbac.AttendanceWrite)
+	attendanceWrite.POST("/attendance-logs", manualPunchHandler.AddPunch)
+	attendanceWrite.PUT("/attendance-logs/:id", manualPunchHandler.UpdatePunch)
+	attendanceWrite.DELETE("/attendance-logs/:id", manualPunchHandler.DeletePunch)
+	attendanceWrite.POST("/attendance-logs/:id/adjust", manualPunchHandler.AdjustPunch)
+	attendanceWrite.POST("/attendance-logs/:id/void", manualPunchHandler.VoidPunch)
+
+	// Device routes. Devices push their punches through a gateway holding an API key.
 	deviceHandler := handlers.NewDeviceHandler(s.deviceService)
-	RegisterDeviceRoutes(r, deviceHandler)
+	RegisterDeviceRoutes(can(rbac.DeviceRead), can(rbac.DeviceWrite), deviceHandler)
+	can(rbac.DeviceIngest).POST("/process-hex", attendanceHandler.CreateAttendanceLog)
+
+	// Device identity routes
+	deviceIdentityHandler := handlers.NewDeviceIdentityHandler(s.deviceIdentityService)
+	deviceRead := can(rbac.DeviceRead)
+	deviceRead.GET("/device-identities", deviceIdentityHandler.ListDeviceIdentities)
+	deviceRead.GET("/device-identities/:id", deviceIdentityHandler.GetDeviceIdentityByID)
+	deviceWrite := can(rbac.DeviceWrite)
+	deviceWrite.POST("/device-identities", deviceIdentityHandler.CreateDeviceIdentity)
+	deviceWrite.PUT("/device-identities/:id", deviceIdentityHandler.UpdateDeviceIdentity)
+	deviceWrite.DELETE("/device-identities/:id", deviceIdentityHandler.DeleteDeviceIdentity)
+	deviceWrite.POST("/device-identities/sync", deviceIdentityHandler.SyncDeviceIdentities)
 
-	// Initialize your handlers
+	// Unmatched punch routes
+	unmatchedPunchHandler := handlers.NewUnmatchedPunchHandler(s.unmatchedPunchService)
+	attendanceRead.GET("/unmatched-punches", unmatchedPunchHandler.ListUnmatchedPunches)
+	attendanceWrite.POST("/unmatched-punches/assign", unmatchedPunchHandler.AssignUnmatchedPunches)
+	attendanceWrite.POST("/unmatched-punches/create-employee", unmatchedPunchHandler.CreateEmployeeFromUnmatchedPunches)
+
+	// Punch correction review routes, the requests being filed through the self-service
+	punchCorrectionHandler := handlers.NewPunchCorrectionHandler(s.punchCorrectionService)
+	attendanceRead.GET("/punch-corrections", orgScope, punchCorrectionHandler.ListPunchCorrections)
+	attendanceRead.GET("/punch-corrections/:id", punchCorrectionHandler.GetPunchCorrectionByID)
+	attendanceWrite.POST("/punch-corrections/:id/approve", managerScope, punchCorrectionHandler.ApprovePunchCorrection)
+	attendanceWrite.POST("/punch-corrections/:id/reject", managerScope, punchCorrectionHandler.RejectPunchCorrection)
+
+	// Report routes
 	reportHandler := handlers.NewReportHandler(s.reportService)
-	r.GET("/report/:companyID", reportHandler.GenerateReport)
+	can(rbac.ReportGenerate).GET("/report/:companyID", orgScope, reportHandler.GenerateReport)
+
+	// Audit trail routes, read-only
+	auditHandler := handlers.NewAuditHandler(s.auditService)
+	auditRead := can(rbac.AuditRead)
+	auditRead.GET("/audit-logs", auditHandler.ListAuditLogs)
+	auditRead.GET("/audit-logs/:id", auditHandler.GetAuditLogByID)
 
-	r.GET("/ws", handlers.ServeWs)
 	s.httpServer.Handler = r
 	return r
 }
@@ -97,13 +303,11 @@ func (s *Server) HelloWorldHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Hello World"})
 }
 
-func RegisterDeviceRoutes(router *gin.Engine, deviceHandler *handlers.DeviceHandler) {
-	devices := router.Group("/devices")
-	{
-		devices.GET("", deviceHandler.GetAllDevices)       // Retrieve all devices (with filters)
-		devices.GET("/:id", deviceHandler.GetDeviceByID)   // Retrieve a single device
-		devices.POST("", deviceHandler.CreateDevice)       // Create a new device
-		devices.PUT("/:id", deviceHandler.UpdateDevice)    // Update a device
-		devices.DELETE("/:id", deviceHandler.DeleteDevice) // Delete a device
-	}
+// RegisterDeviceRoutes registers the device routes, reads on read and changes on write.
+func RegisterDeviceRoutes(read, write *gin.RouterGroup, deviceHandler *handlers.DeviceHandler) {
+	read.GET("/devices", deviceHandler.GetAllDevices)        // Retrieve all devices (with filters)
+	read.GET("/devices/:id", deviceHandler.GetDeviceByID)    // Retrieve a single device
+	write.POST("/devices", deviceHandler.CreateDevice)       // Create a new device
+	write.PUT("/devices/:id", deviceHandler.UpdateDevice)    // Update a device
+	write.DELETE("/devices/:id", deviceHandler.DeleteDevice) // Delete a device
 }
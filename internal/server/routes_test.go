@@ -1,10 +1,22 @@
 package server
 
 import (
-	"github.com/gin-gonic/gin"
+	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"point-system-api/internal/middleware"
+	"point-system-api/internal/rbac"
+	"point-system-api/internal/services"
+	"point-system-api/internal/types"
+	"point-system-api/pkg/utils"
 )
 
 func TestHelloWorldHandler(t *testing.T) {
@@ -30,3 +42,511 @@ func TestHelloWorldHandler(t *testing.T) {
 		t.Errorf("Handler returned unexpected body: got %v want %v", rr.Body.String(), expected)
 	}
 }
+
+// routePermissions is the permission expected on every authenticated route.
+var routePermissions = map[string]rbac.Permission{
+	"GET /raw-attendances/:id":                                       rbac.RawAttendanceRead,
+	"GET /raw-attendances/by-company/:companyId/work-day/:workDayId": rbac.RawAttendanceRead,
+	"GET /raw-attendances":                                           rbac.RawAttendanceRead,
+	"POST /raw-attendances":                                          rbac.RawAttendanceWrite,
+	"PUT /raw-attendances/:id":                                       rbac.RawAttendanceWrite,
+	"DELETE /raw-attendances/:id":                                    rbac.RawAttendanceWrite,
+	"GET /raw-attendances/:id/versions":                              rbac.RawAttendanceRead,
+	"GET /raw-attendances/:id/versions/diff":                         rbac.RawAttendanceRead,
+	"POST /raw-attendances/:id/versions/:version/revert":             rbac.RawAttendanceWrite,
+
+	"GET /workdays/:id":             rbac.WorkDayRead,
+	"GET /workdays":                 rbac.WorkDayRead,
+	"POST /workdays":                rbac.WorkDayWrite,
+	"PUT /workdays/:id":             rbac.WorkDayWrite,
+	"DELETE /workdays/:id":          rbac.WorkDayWrite,
+	"POST /workdays/:id/regenerate": rbac.WorkDayWrite,
+	"POST /workdays/:id/transition": rbac.WorkDayWrite,
+
+	"GET /payroll-periods":             rbac.PayrollRead,
+	"GET /payroll-periods/:id":         rbac.PayrollRead,
+	"GET /payroll-periods/:id/events":  rbac.PayrollRead,
+	"POST /payroll-periods":            rbac.PayrollWrite,
+	"POST /payroll-periods/:id/close":  rbac.PayrollWrite,
+	"POST /payroll-periods/:id/reopen": rbac.PayrollAdmin,
+
+	"GET /leave-types":                                            rbac.LeaveRequest,
+	"GET /leave-types/:id":                                        rbac.LeaveRequest,
+	"POST /leave-requests":                                        rbac.LeaveRequest,
+	"POST /leave-requests/:id/cancel":                             rbac.LeaveRequest,
+	"GET /leave-requests":                                         rbac.LeaveRead,
+	"GET /leave-requests/:id":                                     rbac.LeaveRead,
+	"POST /leave-types":                                           rbac.LeaveWrite,
+	"PUT /leave-types/:id":                                        rbac.LeaveWrite,
+	"DELETE /leave-types/:id":                                     rbac.LeaveWrite,
+	"POST /leave-requests/:id/approve":                            rbac.LeaveWrite,
+	"POST /leave-requests/:id/reject":                             rbac.LeaveWrite,
+	"GET /leave-types/:id/accrual-policy":                         rbac.LeaveRead,
+	"GET /employees/:id/leave-balances":                           rbac.LeaveRead,
+	"GET /employees/:id/leave-balances/:leaveTypeId/ledger":       rbac.LeaveRead,
+	"PUT /leave-types/:id/accrual-policy":                         rbac.LeaveWrite,
+	"POST /employees/:id/leave-balances/:leaveTypeId/adjustments": rbac.LeaveWrite,
+	"POST /leave-balances/rollover":                               rbac.LeaveAdmin,
+
+	"GET /documents":             rbac.DocumentRead,
+	"GET /documents/:id":         rbac.DocumentRead,
+	"GET /documents/:id/link":    rbac.DocumentRead,
+	"POST /documents":            rbac.DocumentWrite,
+	"DELETE /documents/:id":      rbac.DocumentWrite,
+	"POST /documents/:id/review": rbac.DocumentReview,
+
+	"GET /users/:id":                 rbac.UserRead,
+	"GET /users/username/:username":  rbac.UserRead,
+	"GET /users":                     rbac.UserRead,
+	"GET /users/select":              rbac.UserRead,
+	"POST /users":                    rbac.UserAdmin,
+	"PUT /users/:id":                 rbac.UserAdmin,
+	"DELETE /users/:id":              rbac.UserAdmin,
+	"GET /users/:id/companies":       rbac.UserAdmin,
+	"PUT /users/:id/companies":       rbac.UserAdmin,
+	"PUT /users/:id/role":            rbac.UserAdmin,
+	"POST /users/:id/password-reset": rbac.UserAdmin,
+	"POST /users/:id/unlock":         rbac.UserAdmin,
+	"GET /users/:id/login-history":   rbac.UserAdmin,
+	"DELETE /users/:id/2fa":          rbac.UserAdmin,
+
+	"GET /service-accounts":                        rbac.APIKeyAdmin,
+	"GET /service-accounts/:id":                    rbac.APIKeyAdmin,
+	"POST /service-accounts":                       rbac.APIKeyAdmin,
+	"DELETE /service-accounts/:id":                 rbac.APIKeyAdmin,
+	"GET /service-accounts/:id/api-keys":           rbac.APIKeyAdmin,
+	"POST /service-accounts/:id/api-keys":          rbac.APIKeyAdmin,
+	"DELETE /service-accounts/:id/api-keys/:keyId": rbac.APIKeyAdmin,
+
+	"GET /companies/:id":    rbac.CompanyRead,
+	"GET /companies":        rbac.CompanyRead,
+	"GET /companies/select": rbac.CompanyRead,
+	"POST /companies":       rbac.CompanyWrite,
+	"PUT /companies/:id":    rbac.CompanyWrite,
+	"DELETE /companies/:id": rbac.CompanyWrite,
+
+	"GET /employees/:id":                rbac.EmployeeRead,
+	"GET /employees/by-company/:id":     rbac.EmployeeRead,
+	"GET /employees":                    rbac.EmployeeRead,
+	"GET /employees/:id/employment":     rbac.EmployeeRead,
+	"POST /employees":                   rbac.EmployeeWrite,
+	"POST /employees/import":            rbac.EmployeeWrite,
+	"PUT /employees/:id":                rbac.EmployeeWrite,
+	"DELETE /employees/:id":             rbac.EmployeeWrite,
+	"POST /employees/:id/transfers":     rbac.EmployeeWrite,
+	"POST /employees/:id/termination":   rbac.EmployeeWrite,
+	"DELETE /employees/:id/termination": rbac.EmployeeWrite,
+
+	"GET /departments":                        rbac.OrganizationRead,
+	"GET /departments/:id":                    rbac.OrganizationRead,
+	"GET /teams":                              rbac.OrganizationRead,
+	"GET /teams/:id":                          rbac.OrganizationRead,
+	"GET /employees/:id/org-assignments":      rbac.OrganizationRead,
+	"GET /org/tree":                           rbac.OrganizationRead,
+	"POST /departments":                       rbac.OrganizationWrite,
+	"PUT /departments/:id":                    rbac.OrganizationWrite,
+	"DELETE /departments/:id":                 rbac.OrganizationWrite,
+	"POST /teams":                             rbac.OrganizationWrite,
+	"PUT /teams/:id":                          rbac.OrganizationWrite,
+	"DELETE /teams/:id":                       rbac.OrganizationWrite,
+	"POST /employees/:id/org-assignments":     rbac.OrganizationWrite,
+	"POST /employees/:id/org-assignments/end": rbac.OrganizationWrite,
+
+	"GET /attendance-logs":                    rbac.AttendanceRead,
+	"POST /process-hex":                       rbac.DeviceIngest,
+	"GET /attendance-logs/:id":                rbac.AttendanceRead,
+	"POST /attendance-logs":                   rbac.AttendanceWrite,
+	"PUT /attendance-logs/:id":                rbac.AttendanceWrite,
+	"DELETE /attendance-logs/:id":             rbac.AttendanceWrite,
+	"POST /attendance-logs/:id/adjust":        rbac.AttendanceWrite,
+	"POST /attendance-logs/:id/void":          rbac.AttendanceWrite,
+	"GET /punch-corrections":                  rbac.AttendanceRead,
+	"GET /punch-corrections/:id":              rbac.AttendanceRead,
+	"POST /punch-corrections/:id/approve":     rbac.AttendanceWrite,
+	"POST /punch-corrections/:id/reject":      rbac.AttendanceWrite,
+	"GET /unmatched-punches":                  rbac.AttendanceRead,
+	"POST /unmatched-punches/assign":          rbac.AttendanceWrite,
+	"POST /unmatched-punches/create-employee": rbac.AttendanceWrite,
+
+	"GET /devices":                  rbac.DeviceRead,
+	"GET /devices/:id":              rbac.DeviceRead,
+	"POST /devices":                 rbac.DeviceWrite,
+	"PUT /devices/:id":              rbac.DeviceWrite,
+	"DELETE /devices/:id":           rbac.DeviceWrite,
+	"GET /device-identities":        rbac.DeviceRead,
+	"GET /device-identities/:id":    rbac.DeviceRead,
+	"POST /device-identities":       rbac.DeviceWrite,
+	"PUT /device-identities/:id":    rbac.DeviceWrite,
+	"DELETE /device-identities/:id": rbac.DeviceWrite,
+	"POST /device-identities/sync":  rbac.DeviceWrite,
+
+	"GET /report/:companyID": rbac.ReportGenerate,
+	"GET /audit-logs":        rbac.AuditRead,
+	"GET /audit-logs/:id":    rbac.AuditRead,
+
+	"POST /auth/password/change":               anyUser,
+	"GET /auth/2fa":                            anyUser,
+	"POST /auth/2fa/disable":                   anyUser,
+	"POST /auth/2fa/recovery-codes":            anyUser,
+	"POST /auth/2fa/enroll":                    anyUser,
+	"POST /auth/2fa/activate":                  anyUser,
+	"GET /me":                                  anyUser,
+	"GET /me/punches":                          anyUser,
+	"GET /me/attendance":                       anyUser,
+	"GET /me/schedule":                         anyUser,
+	"GET /me/leave-balances":                   anyUser,
+	"GET /me/leave-requests":                   anyUser,
+	"POST /me/leave-requests":                  anyUser,
+	"GET /me/punch-corrections":                anyUser,
+	"POST /me/punch-corrections":               anyUser,
+	"POST /me/punch-corrections/:id/documents": anyUser,
+}
+
+// anyUser marks the routes open to every authenticated user.
+const anyUser rbac.Permission = ""
+
+// publicRoutes are reachable without a token.
+var publicRoutes = map[string]bool{
+	"GET /health":                 true,
+	"GET /":                       true,
+	"POST /users/authenticate":    true,
+	"POST /auth/refresh":          true,
+	"POST /auth/logout":           true,
+	"POST /auth/password/forgot":  true,
+	"POST /auth/password/reset":   true,
+	"POST /auth/2fa/verify":       true,
+	"GET /auth/oidc/login":        true,
+	"POST /auth/oidc/callback":    true,
+	"GET /documents/:id/download": true,
+	"GET /ws":                     true,
+}
+
+func TestRoutePermissionMatrix(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter, gin.DefaultErrorWriter = io.Discard, io.Discard
+	s := &Server{httpServer: &http.Server{}}
+	r := s.RegisterRoutes().(*gin.Engine)
+
+	tokens := map[string]string{}
+	for _, role := range []string{rbac.RoleSuperAdmin, rbac.RoleManager, rbac.RoleEmployee} {
+		token, err := utils.GenerateToken(1, role, []uint{1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[role] = token
+	}
+	placeholder := regexp.MustCompile(`:[A-Za-z]+`)
+
+	for _, route := range r.Routes() {
+		key := route.Method + " " + route.Path
+		if publicRoutes[key] {
+			continue
+		}
+		permission, ok := routePermissions[key]
+		if !ok {
+			t.Errorf("%s: no permission expected, add the route to the matrix", key)
+			continue
+		}
+		path := placeholder.ReplaceAllString(route.Path, "1")
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(route.Method, path, nil))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s without token: got %d, want %d", key, rr.Code, http.StatusUnauthorized)
+		}
+
+		for role, token := range tokens {
+			req := httptest.NewRequest(route.Method, path, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			allowed := permission == anyUser || rbac.HasPermission(role, permission)
+			denied := rr.Code == http.StatusForbidden || rr.Code == http.StatusUnauthorized
+			if allowed == denied {
+				t.Errorf("%s as %s: got %d, want allowed=%v", key, role, rr.Code, allowed)
+			}
+		}
+	}
+
+	// The sensitive routes the permission model exists for.
+	for _, check := range []struct {
+		role      string
+		key       string
+		permitted bool
+	}{
+		{rbac.RoleEmployee, "DELETE /users/:id", false},
+		{rbac.RoleManager, "POST /users", false},
+		{rbac.RoleManager, "POST /service-accounts", false},
+		{rbac.RoleManager, "POST /payroll-periods/:id/reopen", false},
+		{rbac.RoleManager, "GET /report/:companyID", true},
+		{rbac.RoleEmployee, "GET /attendance-logs", false},
+		{rbac.RoleEmployee, "POST /leave-requests", false},
+		{rbac.RoleEmployee, "POST /leave-requests/:id/cancel", false},
+		{rbac.RoleEmployee, "GET /documents", false},
+		{rbac.RoleEmployee, "GET /documents/:id/link", false},
+		{rbac.RoleEmployee, "POST /documents", false},
+		{rbac.RoleEmployee, "DELETE /documents/:id", false},
+		{rbac.RoleSuperAdmin, "DELETE /users/:id", true},
+	} {
+		if got := rbac.HasPermission(check.role, routePermissions[check.key]); got != check.permitted {
+			t.Errorf("%s on %s: got permitted=%v, want %v", check.role, check.key, got, check.permitted)
+		}
+	}
+}
+
+func TestCrossTenantRequestsAreNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter, gin.DefaultErrorWriter = io.Discard, io.Discard
+	s := &Server{httpServer: &http.Server{}}
+	r := s.RegisterRoutes().(*gin.Engine)
+
+	token, err := utils.GenerateToken(1, rbac.RoleManager, []uint{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{
+		"/report/2?start_date=2025-01-01&end_date=2025-01-31",
+		"/raw-attendances/by-company/2/work-day/1",
+		"/attendance-logs?company_id=2",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("%s: got %d, want %d", path, rr.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestEmployeesCannotReachRecordsOfOtherEmployees(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter, gin.DefaultErrorWriter = io.Discard, io.Discard
+	s := &Server{httpServer: &http.Server{}}
+	r := s.RegisterRoutes().(*gin.Engine)
+
+	token, err := utils.GenerateToken(1, rbac.RoleEmployee, []uint{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Employee 2, leave request 2 and document 2 are of a colleague in the same company.
+	for _, check := range []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/leave-requests", `{"employee_id": 2, "leave_type_id": 1, "start_date": "2025-03-03", "end_date": "2025-03-04"}`},
+		{http.MethodPost, "/leave-requests/2/cancel", ""},
+		{http.MethodGet, "/leave-requests?employee_id=2", ""},
+		{http.MethodGet, "/documents?owner_type=employee&owner_id=2", ""},
+		{http.MethodGet, "/documents/2", ""},
+		{http.MethodGet, "/documents/2/link", ""},
+		{http.MethodPost, "/documents", ""},
+		{http.MethodDelete, "/documents/2", ""},
+	} {
+		req := httptest.NewRequest(check.method, check.path, strings.NewReader(check.body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != http.StatusForbidden && rr.Code != http.StatusNotFound {
+			t.Errorf("%s %s: got %d, want 403 or 404", check.method, check.path, rr.Code)
+		}
+	}
+}
+
+func TestPreAuthTokensOnlyReachTheirLoginStep(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter, gin.DefaultErrorWriter = io.Discard, io.Discard
+	s := &Server{httpServer: &http.Server{}}
+	r := s.RegisterRoutes().(*gin.Engine)
+
+	enrollment, err := utils.GeneratePurposeToken(1, rbac.RoleSuperAdmin, utils.TokenPurposeTwoFactorEnrollment, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verification, err := utils.GeneratePurposeToken(1, rbac.RoleSuperAdmin, utils.TokenPurposeTwoFactor, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, check := range []struct {
+		token, method, path string
+		allowed             bool
+	}{
+		{enrollment, http.MethodPost, "/auth/2fa/activate", true},
+		{enrollment, http.MethodGet, "/users", false},
+		{enrollment, http.MethodPost, "/auth/password/change", false},
+		{verification, http.MethodPost, "/auth/2fa/activate", false},
+		{verification, http.MethodGet, "/users", false},
+	} {
+		req := httptest.NewRequest(check.method, check.path, nil)
+		req.Header.Set("Authorization", "Bearer "+check.token)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if allowed := rr.Code != http.StatusUnauthorized; allowed != check.allowed {
+			t.Errorf("%s %s: got %d, want allowed=%v", check.method, check.path, rr.Code, check.allowed)
+		}
+	}
+}
+
+// stubAPIKeys authenticates the key "psk_test", of a service account of company 1 that reads
+// devices and generates reports, and the same key as "psk_office" from 203.0.113.7 only.
+type stubAPIKeys struct {
+	services.APIKeyService
+}
+
+func (stubAPIKeys) AuthenticateAPIKey(ctx context.Context, key, clientIP string) (*types.APIKeyPrincipal, error) {
+	if key != "psk_test" && (key != "psk_office" || clientIP != "203.0.113.7") {
+		return nil, services.ErrInvalidAPIKey
+	}
+	return &types.APIKeyPrincipal{
+		APIKeyID:         1,
+		ServiceAccountID: 1,
+		Permissions:      []string{string(rbac.DeviceRead), string(rbac.ReportGenerate)},
+		CompanyIDs:       []uint{1},
+	}, nil
+}
+
+func TestAPIKeysReachTheRoutesOfTheirPermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter, gin.DefaultErrorWriter = io.Discard, io.Discard
+	s := &Server{httpServer: &http.Server{}, apiKeyService: stubAPIKeys{}}
+	r := s.RegisterRoutes().(*gin.Engine)
+
+	for _, check := range []struct {
+		header, key, method, path string
+		code                      int
+	}{
+		{"X-API-Key", "psk_test", http.MethodPost, "/users", http.StatusForbidden},
+		{"Authorization", "Bearer psk_test", http.MethodPost, "/users", http.StatusForbidden},
+		{"X-API-Key", "psk_test", http.MethodGet, "/report/2?start_date=2025-01-01&end_date=2025-01-31", http.StatusNotFound},
+		{"X-API-Key", "psk_revoked", http.MethodGet, "/devices", http.StatusUnauthorized},
+		// Pushing punches takes a key holding the device-ingest permission.
+		{"X-API-Key", "", http.MethodPost, "/process-hex", http.StatusUnauthorized},
+		{"X-API-Key", "psk_test", http.MethodPost, "/process-hex", http.StatusForbidden},
+		// Service accounts have no password nor second factor.
+		{"X-API-Key", "psk_test", http.MethodPost, "/auth/password/change", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(check.method, check.path, nil)
+		req.Header.Set(check.header, check.key)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != check.code {
+			t.Errorf("%s %s with %s: got %d, want %d", check.method, check.path, check.key, rr.Code, check.code)
+		}
+	}
+
+	// A permitted route goes past the authentication and permission checks.
+	req := httptest.NewRequest(http.MethodGet, "/report/1?start_date=bad", nil)
+	req.Header.Set("X-API-Key", "psk_test")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code == http.StatusUnauthorized || rr.Code == http.StatusForbidden || rr.Code == http.StatusNotFound {
+		t.Errorf("GET /report/1 with an API key: got %d", rr.Code)
+	}
+}
+
+func TestAPIKeyAllowListsIgnoreForwardedForOfUntrustedClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter, gin.DefaultErrorWriter = io.Discard, io.Discard
+
+	for _, tc := range []struct {
+		name           string
+		trustedProxies []string
+		code           int
+	}{
+		{"spoofed by the client", nil, http.StatusUnauthorized},
+		{"set by a trusted proxy", []string{"192.0.2.0/24"}, http.StatusOK},
+	} {
+		s := &Server{httpServer: &http.Server{}, apiKeyService: stubAPIKeys{}, trustedProxies: tc.trustedProxies}
+		r := s.RegisterRoutes().(*gin.Engine)
+		r.GET("/test/client-ip", middleware.APIKeyMiddleware(s.apiKeyService), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		// httptest requests come from 192.0.2.1.
+		req := httptest.NewRequest(http.MethodGet, "/test/client-ip", nil)
+		req.Header.Set("X-API-Key", "psk_office")
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != tc.code {
+			t.Errorf("%s: got %d, want %d", tc.name, rr.Code, tc.code)
+		}
+	}
+}
+
+// stubLogins refuses every login and keeps the client it was made from.
+type stubLogins struct {
+	services.AuthService
+	client *types.ClientInfo
+}
+
+func (s stubLogins) Login(ctx context.Context, username, password string, client types.ClientInfo) (*types.LoginResult, error) {
+	*s.client = client
+	return nil, services.ErrInvalidCredentials
+}
+
+func TestLoginsAreThrottledByTheAddressOfTheConnection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter, gin.DefaultErrorWriter = io.Discard, io.Discard
+
+	for _, tc := range []struct {
+		name           string
+		trustedProxies []string
+		ip             string
+	}{
+		{"spoofed by the client", nil, "192.0.2.1"},
+		{"set by a trusted proxy", []string{"192.0.2.1"}, "203.0.113.7"},
+	} {
+		client := &types.ClientInfo{}
+		s := &Server{httpServer: &http.Server{}, authService: stubLogins{client: client}, trustedProxies: tc.trustedProxies}
+		r := s.RegisterRoutes().(*gin.Engine)
+
+		// httptest requests come from 192.0.2.1.
+		req := httptest.NewRequest(http.MethodPost, "/users/authenticate", strings.NewReader(`{"username":"ana","password":"guess"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		if client.IPAddress != tc.ip {
+			t.Errorf("%s: login throttled by %q, want %q", tc.name, client.IPAddress, tc.ip)
+		}
+	}
+}
+
+func TestChangesAreAuditedAsTheAuthenticatedActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter, gin.DefaultErrorWriter = io.Discard, io.Discard
+	token, err := utils.GenerateToken(7, rbac.RoleEmployee, []uint{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{httpServer: &http.Server{}, apiKeyService: stubAPIKeys{}}
+	r := s.RegisterRoutes().(*gin.Engine)
+	var actor types.AuditActor
+	r.GET("/test/actor", middleware.APIKeyMiddleware(s.apiKeyService), middleware.AuthMiddleware(), func(c *gin.Context) {
+		actor = *types.AuditActorFrom(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	for _, tc := range []struct {
+		header, value string
+		user, account uint
+	}{
+		{"Authorization", "Bearer " + token, 7, 0},
+		{"X-API-Key", "psk_test", 0, 1},
+	} {
+		actor = types.AuditActor{}
+		req := httptest.NewRequest(http.MethodGet, "/test/actor", nil)
+		req.Header.Set(tc.header, tc.value)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+		var user, account uint
+		if actor.UserID != nil {
+			user = *actor.UserID
+		}
+		if actor.ServiceAccountID != nil {
+			account = *actor.ServiceAccountID
+		}
+		if user != tc.user || account != tc.account || actor.IPAddress != "192.0.2.1" {
+			t.Errorf("%s: audited as user %d, service account %d from %q", tc.header, user, account, actor.IPAddress)
+		}
+	}
+}
@@ -2,32 +2,55 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"point-system-api/internal/database"
 	"point-system-api/internal/handlers"
+	"point-system-api/internal/notify"
 	"point-system-api/internal/repositories"
 	"point-system-api/internal/services"
+	"point-system-api/internal/storage"
+	"point-system-api/pkg/utils"
 )
 
 // Server represents the HTTP server and its dependencies.
 type Server struct {
-	httpServer           *http.Server
-	port                 int
-	db                   database.Service
-	userService          services.UserService
-	companyService       services.CompanyService
-	employeeService      services.EmployeeService
-	workDayService       services.WorkDayService
-	attendanceService    services.AttendanceService
-	deviceService        services.DeviceService
-	rawAttendanceService services.RawAttendanceService
-	reportService        services.ReportService
+	httpServer             *http.Server
+	port                   int
+	trustedProxies         []string // Proxies whose X-Forwarded-For is believed, none by default
+	db                     database.Service
+	userService            services.UserService
+	companyService         services.CompanyService
+	employeeService        services.EmployeeService
+	workDayService         services.WorkDayService
+	attendanceService      services.AttendanceService
+	deviceService          services.DeviceService
+	rawAttendanceService   services.RawAttendanceService
+	reportService          services.ReportService
+	payrollPeriodService   services.PayrollPeriodService
+	leaveService           services.LeaveService
+	leaveBalanceService    services.LeaveBalanceService
+	documentService        services.DocumentService
+	employeeImportService  services.EmployeeImportService
+	deviceIdentityService  services.DeviceIdentityService
+	unmatchedPunchService  services.UnmatchedPunchService
+	manualPunchService     services.ManualPunchService
+	punchCorrectionService services.PunchCorrectionService
+	employmentService      services.EmploymentService
+	organizationService    services.OrganizationService
+	authService            services.AuthService
+	apiKeyService          services.APIKeyService
+	oidcService            services.OIDCService // Nil without single sign-on
+	selfService            services.SelfService
+	auditService           services.AuditService
 }
 
 // NewServer creates a new instance of the Server.
@@ -39,6 +62,32 @@ func NewServer() *Server {
 	// Initialize database
 	db := database.New()
 
+	// Load the keys signing the tokens
+	if err := utils.ConfigureJWTFromEnv(); err != nil {
+		log.Fatalf("failed to configure JWT keys: %v", err)
+	}
+	refreshTTL, err := time.ParseDuration(os.Getenv("JWT_REFRESH_TTL"))
+	if err != nil {
+		refreshTTL = services.DefaultRefreshTokenTTL
+	}
+	trustedProxies, err := trustedProxiesFromEnv()
+	if err != nil {
+		log.Fatalf("failed to load trusted proxies: %v", err)
+	}
+
+	// Filter every repository query by the tenant scope of its request
+	if err := repositories.RegisterTenantScope(db.GetDB()); err != nil {
+		log.Fatalf("failed to register tenant scope: %v", err)
+	}
+
+	// Record every change of the audited tables
+	if err := repositories.RegisterAuditTrail(db.GetDB()); err != nil {
+		log.Fatalf("failed to register audit trail: %v", err)
+	}
+	if err := repositories.RegisterRawAttendanceVersions(db.GetDB()); err != nil {
+		log.Fatalf("failed to register raw attendance versions: %v", err)
+	}
+
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db.GetDB())
 	companyRepo := repositories.NewCompanyRepository(db.GetDB())
@@ -47,16 +96,113 @@ func NewServer() *Server {
 	deviceRepo := repositories.NewDeviceRepository(db.GetDB())
 	attendanceRepo := repositories.NewAttendanceRepository(db.GetDB())
 	rawAttendanceRepo := repositories.NewRawAttendanceRepo(db.GetDB())
+	payrollPeriodRepo := repositories.NewPayrollPeriodRepository(db.GetDB())
+	leaveTypeRepo := repositories.NewLeaveTypeRepository(db.GetDB())
+	leaveRequestRepo := repositories.NewLeaveRequestRepository(db.GetDB())
+	leaveBalanceRepo := repositories.NewLeaveBalanceRepository(db.GetDB())
+	documentRepo := repositories.NewDocumentRepository(db.GetDB())
+	deviceIdentityRepo := repositories.NewDeviceIdentityRepository(db.GetDB())
+	employmentRepo := repositories.NewEmploymentRepository(db.GetDB())
+	organizationRepo := repositories.NewOrganizationRepository(db.GetDB())
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db.GetDB())
+	passwordRepo := repositories.NewPasswordRepository(db.GetDB())
+	loginRepo := repositories.NewLoginRepository(db.GetDB())
+	twoFactorRepo := repositories.NewTwoFactorRepository(db.GetDB())
+	apiKeyRepo := repositories.NewAPIKeyRepository(db.GetDB())
+	oidcRepo := repositories.NewOIDCRepository(db.GetDB())
+	punchCorrectionRepo := repositories.NewPunchCorrectionRepository(db.GetDB())
+	auditLogRepo := repositories.NewAuditLogRepository(db.GetDB())
+	rawAttendanceVersionRepo := repositories.NewRawAttendanceVersionRepository(db.GetDB())
+	unitOfWork := repositories.NewUnitOfWork(db.GetDB())
+
+	// Initialize document storage
+	storageDir := os.Getenv("STORAGE_DIR")
+	if storageDir == "" {
+		storageDir = "./uploads"
+	}
+	documentStorage, err := storage.NewLocalStorage(storageDir)
+	if err != nil {
+		log.Fatalf("failed to initialize document storage: %v", err)
+	}
+	maxDocumentSizeMB, _ := strconv.Atoi(os.Getenv("DOCUMENT_MAX_SIZE_MB"))
+	if maxDocumentSizeMB <= 0 {
+		maxDocumentSizeMB = 10
+	}
+
+	// Initialize the password policy, login throttling, second factors, external authentication
+	// providers, single sign-on and the channel delivering password resets
+	passwordPolicy, err := services.LoadPasswordPolicyFromEnv()
+	if err != nil {
+		log.Fatalf("failed to load password policy: %v", err)
+	}
+	throttlePolicy, err := services.LoadLoginThrottlePolicyFromEnv()
+	if err != nil {
+		log.Fatalf("failed to load login throttling: %v", err)
+	}
+	twoFactorPolicy, err := services.LoadTwoFactorPolicyFromEnv()
+	if err != nil {
+		log.Fatalf("failed to load two-factor policy: %v", err)
+	}
+	var authProviders []services.AuthProvider
+	ldapConfig, err := services.LoadLDAPConfigFromEnv()
+	if err != nil {
+		log.Fatalf("failed to load LDAP configuration: %v", err)
+	}
+	if ldapConfig != nil {
+		provider, err := services.NewLDAPAuthProvider(*ldapConfig, userRepo)
+		if err != nil {
+			log.Fatalf("failed to configure LDAP authentication: %v", err)
+		}
+		authProviders = append(authProviders, provider)
+	}
+	oidcConfig, err := services.LoadOIDCConfigFromEnv()
+	if err != nil {
+		log.Fatalf("failed to load OIDC configuration: %v", err)
+	}
+	notifier, err := notifierFromEnv()
+	if err != nil {
+		log.Fatalf("failed to set up notifications: %v", err)
+	}
 
 	// Initialize services
-	userService := services.NewUserService(userRepo)
+	userService := services.NewUserService(userRepo, passwordRepo, refreshTokenRepo, notifier, passwordPolicy, authProviders...)
+	authService := services.NewAuthService(unitOfWork, userService, refreshTokenRepo, loginRepo, twoFactorRepo, throttlePolicy, twoFactorPolicy, refreshTTL)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, companyRepo)
+	var oidcService services.OIDCService
+	if oidcConfig != nil {
+		if oidcService, err = services.NewOIDCService(*oidcConfig, authService, userRepo, oidcRepo); err != nil {
+			log.Fatalf("failed to configure single sign-on: %v", err)
+		}
+	}
 	companyService := services.NewCompanyService(companyRepo)
-	employeeService := services.NewEmployeeService(employeeRepo, userService)
-	workDayService := services.NewWorkDayService(workDayRepo, rawAttendanceRepo, attendanceRepo)
-	attendanceService := services.NewAttendanceService(deviceRepo, attendanceRepo)
-	rawAttendanceService := services.NewRawAttendanceService(rawAttendanceRepo)
+	deviceIdentityService := services.NewDeviceIdentityService(deviceIdentityRepo, employeeRepo, deviceRepo, companyRepo)
+	employeeService := services.NewEmployeeService(unitOfWork, employeeRepo, employmentRepo, userService, deviceIdentityService)
+	employeeImportService := services.NewEmployeeImportService(unitOfWork, employeeRepo, userRepo, companyRepo, deviceIdentityService, passwordPolicy)
+	payrollPeriodService := services.NewPayrollPeriodService(payrollPeriodRepo)
+	leaveService := services.NewLeaveService(unitOfWork, leaveTypeRepo, leaveRequestRepo, employeeRepo, workDayRepo, rawAttendanceRepo, employmentRepo, payrollPeriodService)
+	leaveBalanceService := services.NewLeaveBalanceService(leaveBalanceRepo, leaveTypeRepo, leaveRequestRepo, employeeRepo)
+	workDayService := services.NewWorkDayService(unitOfWork, workDayRepo, rawAttendanceRepo, attendanceRepo, payrollPeriodService, leaveService)
+	attendanceService := services.NewAttendanceService(deviceRepo, attendanceRepo, employeeRepo, payrollPeriodService, deviceIdentityService)
+	rawAttendanceService := services.NewRawAttendanceService(rawAttendanceRepo, rawAttendanceVersionRepo, workDayRepo, payrollPeriodService)
 	deviceService := services.NewDeviceService(deviceRepo)
 	reportService := services.NewReportService(db.GetDB())
+	employmentService := services.NewEmploymentService(employmentRepo, employeeRepo, companyRepo, payrollPeriodService, deviceIdentityService)
+	organizationService := services.NewOrganizationService(organizationRepo, employeeRepo, companyRepo, userRepo)
+	unmatchedPunchService := services.NewUnmatchedPunchService(attendanceRepo, employeeRepo, deviceIdentityService, employeeService, attendanceService, workDayService)
+	manualPunchService := services.NewManualPunchService(unitOfWork, attendanceRepo, employeeRepo, payrollPeriodService, attendanceService, workDayService)
+	documentService := services.NewDocumentService(documentRepo, documentStorage, rawAttendanceRepo, leaveRequestRepo, employeeRepo, workDayRepo, punchCorrectionRepo, payrollPeriodService, int64(maxDocumentSizeMB)<<20)
+	punchCorrectionService := services.NewPunchCorrectionService(unitOfWork, punchCorrectionRepo, employeeRepo, attendanceRepo, payrollPeriodService, manualPunchService)
+	selfService := services.NewSelfService(employeeRepo, userRepo, companyRepo, attendanceRepo, rawAttendanceRepo, workDayRepo, leaveRequestRepo, leaveService, leaveBalanceService, punchCorrectionService, documentService)
+	auditService := services.NewAuditService(auditLogRepo)
+	services.StartLeaveRolloverJob(leaveBalanceService)
+	services.StartEmploymentSyncJob(employmentRepo, employmentService)
+
+	// Map the registration numbers of existing employees and link the punches stored so far
+	go func() {
+		if _, err := deviceIdentityService.SyncFromRegistrationNumbers(context.Background()); err != nil {
+			log.Printf("device identity sync failed: %v", err)
+		}
+	}()
 
 	// Create the HTTP server
 	httpServer := &http.Server{
@@ -68,18 +214,72 @@ func NewServer() *Server {
 	}
 
 	return &Server{
-		httpServer:           httpServer,
-		port:                 port,
-		db:                   db,
-		userService:          userService,
-		companyService:       companyService,
-		employeeService:      employeeService,
-		workDayService:       workDayService,
-		attendanceService:    attendanceService,
-		deviceService:        deviceService,
-		rawAttendanceService: rawAttendanceService,
-		reportService:        reportService,
+		httpServer:             httpServer,
+		port:                   port,
+		trustedProxies:         trustedProxies,
+		db:                     db,
+		userService:            userService,
+		companyService:         companyService,
+		employeeService:        employeeService,
+		workDayService:         workDayService,
+		attendanceService:      attendanceService,
+		deviceService:          deviceService,
+		rawAttendanceService:   rawAttendanceService,
+		reportService:          reportService,
+		payrollPeriodService:   payrollPeriodService,
+		leaveService:           leaveService,
+		leaveBalanceService:    leaveBalanceService,
+		documentService:        documentService,
+		employeeImportService:  employeeImportService,
+		deviceIdentityService:  deviceIdentityService,
+		unmatchedPunchService:  unmatchedPunchService,
+		manualPunchService:     manualPunchService,
+		punchCorrectionService: punchCorrectionService,
+		employmentService:      employmentService,
+		organizationService:    organizationService,
+		authService:            authService,
+		apiKeyService:          apiKeyService,
+		oidcService:            oidcService,
+		selfService:            selfService,
+		auditService:           auditService,
+	}
+}
+
+// notifierFromEnv posts notifications to NOTIFY_WEBHOOK_URL. Without it, notifications are only
+// logged, which is refused unless APP_ENV is development since users would never get their
+// password reset codes; NOTIFY_LOG_BODIES then logs the codes too.
+func notifierFromEnv() (notify.Notifier, error) {
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		return notify.NewWebhookNotifier(url), nil
+	}
+	if os.Getenv("APP_ENV") != "development" {
+		return nil, errors.New("NOTIFY_WEBHOOK_URL is required unless APP_ENV is development")
+	}
+	logBodies := false
+	if value := os.Getenv("NOTIFY_LOG_BODIES"); value != "" {
+		var err error
+		if logBodies, err = strconv.ParseBool(value); err != nil {
+			return nil, fmt.Errorf("invalid NOTIFY_LOG_BODIES: %q", value)
+		}
+	}
+	return notify.LogNotifier{LogBodies: logBodies}, nil
+}
+
+// trustedProxiesFromEnv reads TRUSTED_PROXIES, the comma separated addresses and CIDR ranges of
+// the reverse proxies in front of the server. Without it, no proxy is trusted and the client
+// address is the remote address of the connection.
+func trustedProxiesFromEnv() ([]string, error) {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+		proxies = append(proxies, proxy)
 	}
+	return proxies, nil
 }
 
 // Start starts the HTTP server.
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"testing"
+
+	"point-system-api/internal/notify"
+)
+
+func TestNotifierFromEnv(t *testing.T) {
+	t.Setenv("NOTIFY_WEBHOOK_URL", "")
+	t.Setenv("APP_ENV", "")
+	if _, err := notifierFromEnv(); err == nil {
+		t.Error("notifications only logged outside development")
+	}
+
+	// In development, reset codes only reach the logs when asked for.
+	t.Setenv("APP_ENV", "development")
+	if notifier, err := notifierFromEnv(); err != nil || notifier != (notify.LogNotifier{}) {
+		t.Errorf("development: got %#v, %v, want a log notifier without bodies", notifier, err)
+	}
+	t.Setenv("NOTIFY_LOG_BODIES", "true")
+	if notifier, err := notifierFromEnv(); err != nil || notifier != (notify.LogNotifier{LogBodies: true}) {
+		t.Errorf("development with bodies: got %#v, %v", notifier, err)
+	}
+
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("NOTIFY_WEBHOOK_URL", "https://mail.example.com/notify")
+	if notifier, err := notifierFromEnv(); err != nil {
+		t.Errorf("webhook: got %v", err)
+	} else if _, ok := notifier.(*notify.WebhookNotifier); !ok {
+		t.Errorf("webhook: got %#v", notifier)
+	}
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/rbac"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+var (
+	// ErrInvalidAPIKey is returned when an API key is unknown, expired, revoked or used from an
+	// address its service account does not allow.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrInvalidServiceAccount is returned when a service account or API key is malformed.
+	ErrInvalidServiceAccount = errors.New("invalid service account")
+)
+
+// apiKeyTouchInterval is how often the last use of an API key is written, so that busy
+// integrations do not update their key on every request.
+const apiKeyTouchInterval = time.Minute
+
+// unassignablePermissions manage identities and are never granted to service accounts.
+var unassignablePermissions = []rbac.Permission{rbac.UserAdmin, rbac.APIKeyAdmin}
+
+// APIKeyService defines the interface for service accounts and the API keys authenticating them.
+type APIKeyService interface {
+	CreateServiceAccount(ctx context.Context, account *models.ServiceAccount) error
+	GetServiceAccountByID(ctx context.Context, id uint) (*models.ServiceAccount, error)
+	ListServiceAccounts(ctx context.Context) ([]models.ServiceAccount, error)
+	// DeleteServiceAccount deletes a service account, revoking its keys.
+	DeleteServiceAccount(ctx context.Context, id uint) (bool, error)
+	// CreateAPIKey issues a key for a service account. The key is returned only here; only its
+	// hash is stored.
+	CreateAPIKey(ctx context.Context, serviceAccountID uint, name string, expiresAt *time.Time, createdBy uint) (string, *models.APIKey, error)
+	ListAPIKeys(ctx context.Context, serviceAccountID uint) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, serviceAccountID, id uint) (bool, error)
+	// AuthenticateAPIKey checks a key presented by clientIP and returns the identity it grants.
+	AuthenticateAPIKey(ctx context.Context, key, clientIP string) (*types.APIKeyPrincipal, error)
+}
+
+// apiKeyService implements the APIKeyService interface.
+type apiKeyService struct {
+	apiKeyRepo  repositories.APIKeyRepository
+	companyRepo repositories.CompanyRepository
+}
+
+// NewAPIKeyService creates a new instance of APIKeyService.
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository, companyRepo repositories.CompanyRepository) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo:  apiKeyRepo,
+		companyRepo: companyRepo,
+	}
+}
+
+// CreateServiceAccount validates and creates a service account. Its permissions and allowed
+// addresses are normalized.
+func (s *apiKeyService) CreateServiceAccount(ctx context.Context, account *models.ServiceAccount) error {
+	account.Name = strings.TrimSpace(account.Name)
+	if account.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidServiceAccount)
+	}
+
+	permissions := account.PermissionList()
+	if len(permissions) == 0 {
+		return fmt.Errorf("%w: at least one permission is required", ErrInvalidServiceAccount)
+	}
+	for _, permission := range permissions {
+		if !rbac.IsValid(rbac.Permission(permission)) {
+			return fmt.Errorf("%w: unknown permission %q", ErrInvalidServiceAccount, permission)
+		}
+		for _, forbidden := range unassignablePermissions {
+			if rbac.Permission(permission) == forbidden {
+				return fmt.Errorf("%w: permission %q cannot be granted to a service account", ErrInvalidServiceAccount, permission)
+			}
+		}
+	}
+	account.Permissions = strings.Join(permissions, ",")
+
+	allowed := account.AllowedIPList()
+	for _, entry := range allowed {
+		if _, err := parseIPRange(entry); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidServiceAccount, err)
+		}
+	}
+	account.AllowedIPs = strings.Join(allowed, ",")
+
+	if account.CompanyID != nil {
+		company, err := s.companyRepo.GetCompanyByID(ctx, *account.CompanyID)
+		if err != nil {
+			return err
+		}
+		if company == nil {
+			return fmt.Errorf("%w: company %d not found", ErrInvalidServiceAccount, *account.CompanyID)
+		}
+	}
+	return s.apiKeyRepo.CreateServiceAccount(ctx, account)
+}
+
+// GetServiceAccountByID retrieves a service account by its ID.
+func (s *apiKeyService) GetServiceAccountByID(ctx context.Context, id uint) (*models.ServiceAccount, error) {
+	return s.apiKeyRepo.GetServiceAccountByID(ctx, id)
+}
+
+// ListServiceAccounts retrieves every service account.
+func (s *apiKeyService) ListServiceAccounts(ctx context.Context) ([]models.ServiceAccount, error) {
+	return s.apiKeyRepo.ListServiceAccounts(ctx)
+}
+
+// DeleteServiceAccount deletes a service account and revokes its keys.
+func (s *apiKeyService) DeleteServiceAccount(ctx context.Context, id uint) (bool, error) {
+	return s.apiKeyRepo.DeleteServiceAccount(ctx, id)
+}
+
+// CreateAPIKey draws a new key for an existing service account.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, serviceAccountID uint, name string, expiresAt *time.Time, createdBy uint) (string, *models.APIKey, error) {
+	account, err := s.apiKeyRepo.GetServiceAccountByID(ctx, serviceAccountID)
+	if err != nil {
+		return "", nil, err
+	}
+	if account == nil {
+		return "", nil, nil
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return "", nil, fmt.Errorf("%w: the expiry must be in the future", ErrInvalidServiceAccount)
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	key := types.APIKeyPrefix + secret
+	apiKey := &models.APIKey{
+		ServiceAccountID: serviceAccountID,
+		Name:             truncate(strings.TrimSpace(name), 255),
+		Prefix:           key[:len(types.APIKeyPrefix)+8],
+		KeyHash:          hashToken(key),
+		ExpiresAt:        expiresAt,
+		CreatedBy:        createdBy,
+	}
+	if err := s.apiKeyRepo.CreateAPIKey(ctx, apiKey); err != nil {
+		return "", nil, err
+	}
+	return key, apiKey, nil
+}
+
+// ListAPIKeys retrieves the keys of a service account, without their values.
+func (s *apiKeyService) ListAPIKeys(ctx context.Context, serviceAccountID uint) ([]models.APIKey, error) {
+	return s.apiKeyRepo.ListAPIKeys(ctx, serviceAccountID)
+}
+
+// RevokeAPIKey revokes a key of a service account. Requests using it are refused at once.
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, serviceAccountID, id uint) (bool, error) {
+	return s.apiKeyRepo.RevokeAPIKey(ctx, serviceAccountID, id)
+}
+
+// AuthenticateAPIKey resolves an active key of an existing service account, checks the address of
+// the client against the allow-list of the account and records the use of the key.
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, key, clientIP string) (*types.APIKeyPrincipal, error) {
+	if !strings.HasPrefix(key, types.APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	apiKey, err := s.apiKeyRepo.GetAPIKeyByHash(ctx, hashToken(key))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if apiKey == nil || apiKey.ServiceAccount.ID == 0 || !apiKey.IsActive(now) {
+		return nil, ErrInvalidAPIKey
+	}
+	account := apiKey.ServiceAccount
+	if !ipAllowed(account.AllowedIPList(), clientIP) {
+		slog.Warn("API key used from a disallowed address", "api_key_id", apiKey.ID, "service_account_id", account.ID, "ip", clientIP)
+		return nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval || apiKey.LastUsedIP != clientIP {
+		if err := s.apiKeyRepo.TouchAPIKey(ctx, apiKey.ID, now, clientIP); err != nil {
+			return nil, err
+		}
+	}
+
+	principal := &types.APIKeyPrincipal{
+		APIKeyID:         apiKey.ID,
+		ServiceAccountID: account.ID,
+		Permissions:      account.PermissionList(),
+	}
+	if account.CompanyID != nil {
+		principal.CompanyIDs = []uint{*account.CompanyID}
+	}
+	return principal, nil
+}
+
+// parseIPRange parses an allow-list entry, an address or a CIDR range.
+func parseIPRange(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid IP range %q", entry)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid IP address %q", entry)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// ipAllowed reports whether clientIP matches an allow-list. An empty list allows every address.
+func ipAllowed(allowed []string, clientIP string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(clientIP)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, entry := range allowed {
+		if prefix, err := parseIPRange(entry); err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package services
+
+import "testing"
+
+func TestIPAllowed(t *testing.T) {
+	allowed := []string{"10.0.0.0/8", "203.0.113.7", "2001:db8::/32"}
+	for ip, want := range map[string]bool{
+		"10.1.2.3":        true,
+		"::ffff:10.1.2.3": true, // IPv4 over an IPv6 socket
+		"203.0.113.7":     true,
+		"203.0.113.8":     false,
+		"2001:db8::1":     true,
+		"2001:db9::1":     false,
+		"not an address":  false,
+	} {
+		if got := ipAllowed(allowed, ip); got != want {
+			t.Errorf("%s: got %v, want %v", ip, got, want)
+		}
+	}
+	if !ipAllowed(nil, "198.51.100.1") {
+		t.Error("an empty allow-list refused an address")
+	}
+	if _, err := parseIPRange("10.0.0.0/33"); err == nil {
+		t.Error("invalid range accepted")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"time"
 
 	"point-system-api/internal/models"
 	"point-system-api/internal/repositories"
@@ -14,6 +15,10 @@ import (
 	"point-system-api/pkg/utils"
 )
 
+// ErrDevicePunch is returned when a punch pushed by a device is edited or deleted. Device punches
+// are corrected by voiding or adjusting them.
+var ErrDevicePunch = errors.New("device punches cannot be edited nor deleted")
+
 // AttendanceService defines the interface for attendance-related business logic.
 type AttendanceService interface {
 	// CreateAttendanceLog creates a new attendance log in the database.
@@ -25,28 +30,59 @@ type AttendanceService interface {
 	// GetAllAttendanceLogs retrieves all attendance logs with optional filters.
 	GetAllAttendanceLogs(ctx context.Context, page, limit int, filters map[string]interface{}, search string) ([]types.AttendanceLogResponse, int64, error)
 
-	// UpdateAttendanceLog updates an existing attendance log.
+	// UpdateAttendanceLog updates an existing attendance log entered by hand, keeping its source.
 	UpdateAttendanceLog(ctx context.Context, attendanceLog *models.AttendanceLog) error
 
-	// DeleteAttendanceLog deletes an attendance log by its ID.
+	// DeleteAttendanceLog deletes an attendance log entered by hand by its ID.
 	DeleteAttendanceLog(ctx context.Context, id uint) error
+
+	// ReclassifyPunches recomputes the system punch of an employee's punches from the day of from
+	// onwards and returns the punches whose system punch changed.
+	ReclassifyPunches(ctx context.Context, employeeID uint, from time.Time) ([]*models.AttendanceLog, error)
 }
 
 // AttendanceService handles business logic for attendance logs.
 type attendanceService struct {
-	deviceRepo     repositories.DeviceRepository
-	attendanceRepo repositories.AttendanceRepository
+	deviceRepo      repositories.DeviceRepository
+	attendanceRepo  repositories.AttendanceRepository
+	employeeRepo    repositories.EmployeeRepository
+	periodService   PayrollPeriodService
+	identityService DeviceIdentityService
 }
 
 // NewAttendanceService creates a new instance of AttendanceService.
 func NewAttendanceService(deviceRepo repositories.DeviceRepository,
-	attendanceRepo repositories.AttendanceRepository) AttendanceService {
+	attendanceRepo repositories.AttendanceRepository,
+	employeeRepo repositories.EmployeeRepository,
+	periodService PayrollPeriodService,
+	identityService DeviceIdentityService) AttendanceService {
 	return &attendanceService{
-		deviceRepo:     deviceRepo,
-		attendanceRepo: attendanceRepo,
+		deviceRepo:      deviceRepo,
+		attendanceRepo:  attendanceRepo,
+		employeeRepo:    employeeRepo,
+		periodService:   periodService,
+		identityService: identityService,
 	}
 }
 
+// ensurePunchEditable rejects changes to a punch that falls inside a closed payroll period
+// of the company its employee belongs to.
+func (s *attendanceService) ensurePunchEditable(ctx context.Context, attendanceLog *models.AttendanceLog) error {
+	if attendanceLog.EmployeeID == nil {
+		// Punches of unknown employees do not belong to any payroll period.
+		return nil
+	}
+	employee, err := s.employeeRepo.GetEmployeeByID(ctx, *attendanceLog.EmployeeID)
+	if err != nil {
+		return err
+	}
+	if employee == nil {
+		// Punches of unknown employees do not belong to any payroll period.
+		return nil
+	}
+	return s.periodService.EnsureDateOpen(ctx, employee.CompanyID, attendanceLog.Timestamp)
+}
+
 // CreateAttendanceLog processes hex data, checks/creates the device, and saves the attendance log to the database.
 func (s *attendanceService) CreateAttendanceLog(ctx context.Context, serialNumber string, hexData string) (*models.AttendanceLog, error) {
 	// Convert the hex string to bytes
@@ -74,13 +110,17 @@ func (s *attendanceService) CreateAttendanceLog(ctx context.Context, serialNumbe
 	// Clean the UserID by removing null bytes and converting to a string
 	userIDClean := string(bytes.TrimRight(record.UserID[:], "\x00"))
 
-	// Convert UserID to an integer
-	var userIDNumber int
-	_, err = fmt.Sscanf(userIDClean, "%d", &userIDNumber)
-	if err != nil {
+	if userIDClean == "" {
 		return nil, errors.New("failed to parse user ID")
 	}
 
+	// Keep the numeric form of the user ID when there is one; employees are matched on the
+	// device user ID as enrolled, which may contain letters or leading zeros.
+	var userIDNumber int
+	if _, err := fmt.Sscanf(userIDClean, "%d", &userIDNumber); err != nil {
+		userIDNumber = 0
+	}
+
 	// Decode the timestamp
 	timestamp := binary.LittleEndian.Uint32(record.Timestamp[:])
 	decodedTime := utils.DecodeTime(timestamp)
@@ -100,14 +140,23 @@ func (s *attendanceService) CreateAttendanceLog(ctx context.Context, serialNumbe
 		}
 	}
 
+	// Resolve the employee enrolled under this user ID on this device
+	employeeID, err := s.identityService.ResolveEmployee(ctx, device, userIDClean, decodedTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve employee: %w", err)
+	}
+
 	// Create the attendance log model
 	attendanceLog := models.AttendanceLog{
 		SerialNumber: serialNumber,
 		UID:          record.UID,
 		UserID:       userIDNumber,
+		DeviceUserID: userIDClean,
+		EmployeeID:   employeeID,
 		Status:       record.Status,
 		Punch:        record.Punch,
 		Timestamp:    decodedTime,
+		Source:       models.PunchSourceDevice,
 	}
 
 	// Save the attendance log to the database
@@ -118,7 +167,7 @@ func (s *attendanceService) CreateAttendanceLog(ctx context.Context, serialNumbe
 
 	// Retrieve the last attendance log for the user
 	// Retrieve the current and previous attendance logs for the user
-	currentLog, previousLog, err := s.attendanceRepo.GetCurrentAndPreviousLogsByUserID(ctx, userIDNumber)
+	currentLog, previousLog, err := s.attendanceRepo.GetCurrentAndPreviousLogs(ctx, &attendanceLog)
 	if err != nil {
 		// Handle error (e.g., log it)
 		fmt.Println("Error retrieving current and previous logs:", err)
@@ -137,7 +186,7 @@ func (s *attendanceService) CreateAttendanceLog(ctx context.Context, serialNumbe
 		// Check the last system punch
 		if previousLog.SystemPunch == "IN" {
 			// Get the first IN of the current day
-			firstInLog, err := s.attendanceRepo.GetFirstInLogOfDay(ctx, userIDNumber, previousLog.Timestamp)
+			firstInLog, err := s.attendanceRepo.GetFirstInLogOfDay(ctx, &attendanceLog, previousLog.Timestamp)
 			if err != nil {
 				// Handle error (e.g., log it)
 				fmt.Println("Error retrieving first IN log of the day:", err)
@@ -146,7 +195,7 @@ func (s *attendanceService) CreateAttendanceLog(ctx context.Context, serialNumbe
 
 			// Calculate work hours
 			workHours := decodedTime.Sub(firstInLog.Timestamp).Hours()
-			if workHours <= 12 {
+			if workHours <= maxShiftHours {
 				systemPunch = "OUT"
 			} else {
 				systemPunch = "IN"
@@ -188,7 +237,7 @@ func (s *attendanceService) GetAttendanceByID(ctx context.Context, id uint) (*mo
 
 	// Check if the attendance log exists
 	if attendanceLog == nil {
-		return nil, errors.New("attendance log not found")
+		return nil, ErrPunchNotFound
 	}
 
 	return attendanceLog, nil
@@ -225,7 +274,27 @@ func (s *attendanceService) UpdateAttendanceLog(ctx context.Context, attendanceL
 		return fmt.Errorf("failed to check existing attendance log: %w", err)
 	}
 	if existingLog == nil {
-		return errors.New("attendance log not found")
+		return ErrPunchNotFound
+	}
+	if existingLog.Source == models.PunchSourceDevice {
+		return ErrDevicePunch
+	}
+	if existingLog.IsVoided() {
+		return fmt.Errorf("%w: attendance log %d is voided", ErrInvalidPunch, existingLog.ID)
+	}
+
+	// The provenance of a punch cannot be rewritten
+	attendanceLog.Source = existingLog.Source
+	attendanceLog.CreatedBy = existingLog.CreatedBy
+	attendanceLog.CorrectsID = existingLog.CorrectsID
+	attendanceLog.VoidedAt, attendanceLog.VoidedBy, attendanceLog.VoidReason = nil, nil, ""
+
+	// Both the current and the new timestamp must lie outside closed payroll periods
+	if err := s.ensurePunchEditable(ctx, existingLog); err != nil {
+		return err
+	}
+	if err := s.ensurePunchEditable(ctx, attendanceLog); err != nil {
+		return err
 	}
 
 	// Update the attendance log in the database
@@ -249,7 +318,14 @@ func (s *attendanceService) DeleteAttendanceLog(ctx context.Context, id uint) er
 		return fmt.Errorf("failed to check existing attendance log: %w", err)
 	}
 	if existingLog == nil {
-		return errors.New("attendance log not found")
+		return ErrPunchNotFound
+	}
+	if existingLog.Source == models.PunchSourceDevice {
+		return ErrDevicePunch
+	}
+
+	if err := s.ensurePunchEditable(ctx, existingLog); err != nil {
+		return err
 	}
 
 	// Delete the attendance log from the database
@@ -259,3 +335,23 @@ func (s *attendanceService) DeleteAttendanceLog(ctx context.Context, id uint) er
 
 	return nil
 }
+
+// ReclassifyPunches recomputes the system punch of an employee's punches from the day of from onwards.
+func (s *attendanceService) ReclassifyPunches(ctx context.Context, employeeID uint, from time.Time) ([]*models.AttendanceLog, error) {
+	from = truncateToDay(from)
+
+	// The punches of the previous day seed the classification of the first ones.
+	punches, err := s.attendanceRepo.GetEmployeePunchesSince(ctx, employeeID, from.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+
+	changed := classifyPunches(punches, from)
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	if err := s.attendanceRepo.UpdateSystemPunches(ctx, changed); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+)
+
+// AuditService defines the interface for reading the audit trail of the data mutations. The audit
+// logs are recorded by the repositories and cannot be changed.
+type AuditService interface {
+	GetAuditLogByID(ctx context.Context, id uint) (*models.AuditLog, error)
+	ListAuditLogs(ctx context.Context, page, limit int, filters map[string]interface{}) ([]models.AuditLog, int64, error)
+}
+
+// auditService implements the AuditService interface.
+type auditService struct {
+	auditLogRepo repositories.AuditLogRepository
+}
+
+// NewAuditService creates a new instance of AuditService.
+func NewAuditService(auditLogRepo repositories.AuditLogRepository) AuditService {
+	return &auditService{
+		auditLogRepo: auditLogRepo,
+	}
+}
+
+// GetAuditLogByID retrieves an audit log by its ID.
+func (s *auditService) GetAuditLogByID(ctx context.Context, id uint) (*models.AuditLog, error) {
+	return s.auditLogRepo.GetAuditLogByID(ctx, id)
+}
+
+// ListAuditLogs retrieves a page of audit logs, newest first. A page holds 100 logs at most.
+func (s *auditService) ListAuditLogs(ctx context.Context, page, limit int, filters map[string]interface{}) ([]models.AuditLog, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 100
+	}
+	return s.auditLogRepo.ListAuditLogs(ctx, page, limit, filters)
+}
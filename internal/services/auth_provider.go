@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"point-system-api/internal/models"
+	"point-system-api/pkg/utils"
+)
+
+var (
+	// ErrExternalPassword is returned when changing or resetting the password of a user
+	// authenticated by an external provider, which manages it.
+	ErrExternalPassword = errors.New("the password of this user is managed by their identity provider")
+	// ErrInvalidAuthProvider is returned when a user is given a provider that is not configured.
+	ErrInvalidAuthProvider = errors.New("invalid authentication provider")
+)
+
+// AuthProvider checks the passwords of the users whose AuthProvider is its Name.
+type AuthProvider interface {
+	Name() string
+	// Authenticate checks the password of username. user is their account, nil when there is
+	// none yet: providers able to, create it. It returns the account, updated from the provider,
+	// or ErrInvalidCredentials.
+	Authenticate(ctx context.Context, username, password string, user *models.User) (*models.User, error)
+}
+
+// localAuthProvider checks passwords against the bcrypt hash of models.User.Password.
+type localAuthProvider struct{}
+
+// Name identifies the provider.
+func (localAuthProvider) Name() string { return models.AuthProviderLocal }
+
+// Authenticate compares password with the stored hash.
+func (localAuthProvider) Authenticate(ctx context.Context, username, password string, user *models.User) (*models.User, error) {
+	if user == nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !utils.CheckPassword(user.Password, password) {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+	"point-system-api/pkg/utils"
+)
+
+// DefaultRefreshTokenTTL is the lifetime of refresh tokens when none is configured.
+const DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired or revoked.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// AuthService issues the access and refresh tokens of authenticated users.
+type AuthService interface {
+	// Login authenticates a user by their credentials and issues their first token pair, or a
+	// pre-auth token when a second factor is needed. Failed logins slow down and lock out further
+	// attempts of the username and IP address.
+	Login(ctx context.Context, username, password string, client types.ClientInfo) (*types.LoginResult, error)
+	// LoginUser logs in a user authenticated by other means, such as single sign-on: it issues
+	// their token pair, or a pre-auth token when a second factor is needed.
+	LoginUser(ctx context.Context, user *models.User, client types.ClientInfo) (*types.LoginResult, error)
+	// IssueTokens starts a new session for an already authenticated user.
+	IssueTokens(ctx context.Context, user *models.User, client types.ClientInfo) (*types.TokenPair, error)
+	// Refresh exchanges a refresh token for a new token pair, revoking it.
+	Refresh(ctx context.Context, refreshToken string, client types.ClientInfo) (*types.TokenPair, error)
+	// Logout revokes a refresh token and every token refreshed from the same login.
+	Logout(ctx context.Context, refreshToken string) error
+	// UnlockUser lifts the lockout of a user after failed logins.
+	UnlockUser(ctx context.Context, userID uint) error
+	// ListLoginHistory returns the last limit logins of a user, newest first.
+	ListLoginHistory(ctx context.Context, userID uint, limit int) ([]models.LoginAttempt, error)
+
+	// VerifyTwoFactor completes a login with the pre-auth token and a TOTP or recovery code.
+	VerifyTwoFactor(ctx context.Context, preAuthToken, code string, client types.ClientInfo) (*types.TokenPair, error)
+	GetTwoFactorStatus(ctx context.Context, userID uint) (*types.TwoFactorStatus, error)
+	// EnrollTwoFactor starts the enrollment of a TOTP second factor, returning its secret.
+	EnrollTwoFactor(ctx context.Context, userID uint) (*types.TwoFactorEnrollment, error)
+	// ActivateTwoFactor enables the enrolled secret once the user enters a code of it.
+	ActivateTwoFactor(ctx context.Context, userID uint, code string, completeLogin bool, client types.ClientInfo) (*types.TwoFactorActivation, error)
+	DisableTwoFactor(ctx context.Context, userID uint, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	// ResetTwoFactor removes the second factor of a user who lost it.
+	ResetTwoFactor(ctx context.Context, userID uint) (bool, error)
+}
+
+// authService implements the AuthService interface.
+type authService struct {
+	uow              repositories.UnitOfWork
+	userService      UserService
+	refreshTokenRepo repositories.RefreshTokenRepository
+	loginRepo        repositories.LoginRepository
+	twoFactorRepo    repositories.TwoFactorRepository
+	throttlePolicy   LoginThrottlePolicy
+	twoFactorPolicy  TwoFactorPolicy
+	refreshTTL       time.Duration
+}
+
+// NewAuthService creates a new instance of AuthService. Refresh tokens last refreshTTL,
+// DefaultRefreshTokenTTL when zero.
+func NewAuthService(uow repositories.UnitOfWork, userService UserService, refreshTokenRepo repositories.RefreshTokenRepository, loginRepo repositories.LoginRepository, twoFactorRepo repositories.TwoFactorRepository, throttlePolicy LoginThrottlePolicy, twoFactorPolicy TwoFactorPolicy, refreshTTL time.Duration) AuthService {
+	if refreshTTL <= 0 {
+		refreshTTL = DefaultRefreshTokenTTL
+	}
+	return &authService{
+		uow:              uow,
+		userService:      userService,
+		refreshTokenRepo: refreshTokenRepo,
+		loginRepo:        loginRepo,
+		twoFactorRepo:    twoFactorRepo,
+		throttlePolicy:   throttlePolicy,
+		twoFactorPolicy:  twoFactorPolicy,
+		refreshTTL:       refreshTTL,
+	}
+}
+
+// Login authenticates a user and issues their first token pair. Throttled attempts are refused
+// before the password is even checked. Users with a second factor, or whose role requires one,
+// get a pre-auth token instead, and the login is only recorded once they complete it.
+func (s *authService) Login(ctx context.Context, username, password string, client types.ClientInfo) (*types.LoginResult, error) {
+	err := s.checkLoginThrottle(ctx, username, client)
+	if errors.Is(err, ErrLoginThrottled) {
+		if err := s.recordFailedLogin(ctx, username, client, LoginFailureThrottled); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.AuthenticateUser(ctx, username, password)
+	if errors.Is(err, ErrInvalidCredentials) {
+		if err := s.recordLoginFailure(ctx, username, client); err != nil {
+			return nil, err
+		}
+		if err := s.recordFailedLogin(ctx, username, client, LoginFailureInvalidCredentials); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.LoginUser(ctx, user, client)
+}
+
+// LoginUser issues the token pair of a user, or their pre-auth token when a second factor is
+// needed, as Login does once the password is checked.
+func (s *authService) LoginUser(ctx context.Context, user *models.User, client types.ClientInfo) (*types.LoginResult, error) {
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.IsEnabled() || s.twoFactorPolicy.Required(user.Role) {
+		purpose := utils.TokenPurposeTwoFactor
+		if !twoFactor.IsEnabled() {
+			purpose = utils.TokenPurposeTwoFactorEnrollment
+		}
+		preAuthToken, err := utils.GeneratePurposeToken(user.ID, user.Role, purpose, s.twoFactorPolicy.PreAuthTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate token: %w", err)
+		}
+		return &types.LoginResult{
+			TwoFactorRequired:  true,
+			EnrollmentRequired: !twoFactor.IsEnabled(),
+			PreAuthToken:       preAuthToken,
+		}, nil
+	}
+
+	pair, err := s.completeLogin(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &types.LoginResult{TokenPair: pair}, nil
+}
+
+// completeLogin records the successful login of a user and starts their session. It forgets the
+// failures of the username, not those of the IP address.
+func (s *authService) completeLogin(ctx context.Context, user *models.User, client types.ClientInfo) (*types.TokenPair, error) {
+	if err := s.loginRepo.ResetLoginThrottle(ctx, userThrottleKey(user.Username)); err != nil {
+		return nil, err
+	}
+	if err := s.recordLoginAttempt(ctx, user.Username, user, client, ""); err != nil {
+		return nil, err
+	}
+	return s.IssueTokens(ctx, user, client)
+}
+
+// recordFailedLogin adds a failed login to the history of the user named username, if any.
+func (s *authService) recordFailedLogin(ctx context.Context, username string, client types.ClientInfo, reason string) error {
+	user, err := s.userService.GetUserByUsername(ctx, username)
+	if err != nil {
+		return err
+	}
+	return s.recordLoginAttempt(ctx, username, user, client, reason)
+}
+
+// UnlockUser forgets the failed logins of a user.
+func (s *authService) UnlockUser(ctx context.Context, userID uint) error {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	return s.loginRepo.ResetLoginThrottle(ctx, userThrottleKey(user.Username))
+}
+
+// ListLoginHistory retrieves the most recent logins of a user.
+func (s *authService) ListLoginHistory(ctx context.Context, userID uint, limit int) ([]models.LoginAttempt, error) {
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	return s.loginRepo.ListLoginAttempts(ctx, userID, limit)
+}
+
+// IssueTokens issues a token pair starting a new refresh token family.
+func (s *authService) IssueTokens(ctx context.Context, user *models.User, client types.ClientInfo) (*types.TokenPair, error) {
+	familyID, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	pair, refreshToken, err := s.newTokenPair(ctx, user, familyID, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.CreateRefreshToken(ctx, refreshToken); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// Refresh exchanges a refresh token for a new pair. Presenting a token that was already exchanged
+// means it leaked: every token of its family is revoked.
+func (s *authService) Refresh(ctx context.Context, refreshToken string, client types.ClientInfo) (*types.TokenPair, error) {
+	token, err := s.refreshTokenRepo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if token.RevokedAt != nil && token.ReplacedBy != nil {
+		slog.Warn("refresh token reused", "user_id", token.UserID, "ip", client.IPAddress)
+		if err := s.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if !token.IsActive(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// The role and companies are read again, so that changes apply from the next refresh.
+	user, err := s.userService.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	pair, next, err := s.newTokenPair(ctx, user, token.FamilyID, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.RotateRefreshToken(ctx, token, next); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRefreshToken, err)
+	}
+	return pair, nil
+}
+
+// Logout revokes the family of a refresh token. Unknown tokens are ignored.
+func (s *authService) Logout(ctx context.Context, refreshToken string) error {
+	token, err := s.refreshTokenRepo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil || token == nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, token.FamilyID)
+}
+
+// newTokenPair signs an access token for user and draws the refresh token paired with it.
+func (s *authService) newTokenPair(ctx context.Context, user *models.User, familyID string, client types.ClientInfo) (*types.TokenPair, *models.RefreshToken, error) {
+	companyIDs, err := s.userService.GetUserCompanyIDs(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	accessToken, err := utils.GenerateToken(user.ID, user.Role, companyIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair := &types.TokenPair{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(utils.AccessTokenTTL().Seconds()),
+	}
+	stored := &models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+		UserAgent: truncate(client.UserAgent, 255),
+		IPAddress: client.IPAddress,
+	}
+	return pair, stored, nil
+}
+
+// randomToken returns n random bytes encoded for URLs.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of a token, as stored in the database.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+// identityEpoch is the start of the mappings created for employees without a hire date,
+// early enough to cover every punch a terminal can record.
+var identityEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.Local)
+
+// ErrInvalidDeviceIdentity is returned when a mapping has an invalid scope, target or dates,
+// or overlaps another mapping.
+var ErrInvalidDeviceIdentity = errors.New("invalid device identity")
+
+// DeviceIdentityService defines the interface for the mapping of device user IDs to employees.
+type DeviceIdentityService interface {
+	CreateDeviceIdentity(ctx context.Context, identity *models.DeviceIdentity) error
+	GetDeviceIdentityByID(ctx context.Context, id uint) (*models.DeviceIdentity, error)
+	ListDeviceIdentities(ctx context.Context, filters map[string]interface{}) ([]models.DeviceIdentity, error)
+	UpdateDeviceIdentity(ctx context.Context, identity *models.DeviceIdentity) error
+	DeleteDeviceIdentity(ctx context.Context, id uint) error
+
+	// ResolveEmployee returns the employee enrolled under deviceUserID on device at t, or nil.
+	ResolveEmployee(ctx context.Context, device *models.Device, deviceUserID string, t time.Time) (*uint, error)
+	// SyncEmployee makes sure the registration number of an employee maps to them on the
+	// devices of their company, and links their punches.
+	SyncEmployee(ctx context.Context, employee *models.Employee) error
+	// SyncFromRegistrationNumbers runs SyncEmployee for every employee and links every punch
+	// that can be linked. It returns the number of punches whose employee changed.
+	SyncFromRegistrationNumbers(ctx context.Context) (int64, error)
+}
+
+// deviceIdentityService implements the DeviceIdentityService interface.
+type deviceIdentityService struct {
+	identityRepo repositories.DeviceIdentityRepository
+	employeeRepo repositories.EmployeeRepository
+	deviceRepo   repositories.DeviceRepository
+	companyRepo  repositories.CompanyRepository
+}
+
+// NewDeviceIdentityService creates a new instance of DeviceIdentityService.
+func NewDeviceIdentityService(identityRepo repositories.DeviceIdentityRepository,
+	employeeRepo repositories.EmployeeRepository,
+	deviceRepo repositories.DeviceRepository,
+	companyRepo repositories.CompanyRepository) DeviceIdentityService {
+	return &deviceIdentityService{
+		identityRepo: identityRepo,
+		employeeRepo: employeeRepo,
+		deviceRepo:   deviceRepo,
+		companyRepo:  companyRepo,
+	}
+}
+
+// CreateDeviceIdentity validates and stores a mapping, then links the punches it covers.
+func (s *deviceIdentityService) CreateDeviceIdentity(ctx context.Context, identity *models.DeviceIdentity) error {
+	if identity == nil {
+		return errors.New("device identity is nil")
+	}
+	identity.Source = models.DeviceIdentitySourceManual
+	if err := s.validate(ctx, identity); err != nil {
+		return err
+	}
+	if err := s.identityRepo.CreateDeviceIdentity(ctx, identity); err != nil {
+		return err
+	}
+	_, err := s.identityRepo.RelinkPunches(ctx, identity.DeviceUserID)
+	return err
+}
+
+// GetDeviceIdentityByID retrieves a mapping by its ID.
+func (s *deviceIdentityService) GetDeviceIdentityByID(ctx context.Context, id uint) (*models.DeviceIdentity, error) {
+	return s.identityRepo.GetDeviceIdentityByID(ctx, id)
+}
+
+// ListDeviceIdentities retrieves mappings matching the given filters.
+func (s *deviceIdentityService) ListDeviceIdentities(ctx context.Context, filters map[string]interface{}) ([]models.DeviceIdentity, error) {
+	return s.identityRepo.ListDeviceIdentities(ctx, filters)
+}
+
+// UpdateDeviceIdentity updates a mapping and relinks the punches of its old and new device user IDs.
+func (s *deviceIdentityService) UpdateDeviceIdentity(ctx context.Context, identity *models.DeviceIdentity) error {
+	existing, err := s.identityRepo.GetDeviceIdentityByID(ctx, identity.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("device identity not found")
+	}
+
+	identity.CreatedAt = existing.CreatedAt
+	identity.Source = models.DeviceIdentitySourceManual
+	if err := s.validate(ctx, identity); err != nil {
+		return err
+	}
+	if err := s.identityRepo.UpdateDeviceIdentity(ctx, identity); err != nil {
+		return err
+	}
+
+	if existing.DeviceUserID != identity.DeviceUserID {
+		if _, err := s.identityRepo.RelinkPunches(ctx, existing.DeviceUserID); err != nil {
+			return err
+		}
+	}
+	_, err = s.identityRepo.RelinkPunches(ctx, identity.DeviceUserID)
+	return err
+}
+
+// DeleteDeviceIdentity deletes a mapping and unlinks the punches only it covered.
+func (s *deviceIdentityService) DeleteDeviceIdentity(ctx context.Context, id uint) error {
+	existing, err := s.identityRepo.GetDeviceIdentityByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("device identity not found")
+	}
+	if err := s.identityRepo.DeleteDeviceIdentity(ctx, id); err != nil {
+		return err
+	}
+	_, err = s.identityRepo.RelinkPunches(ctx, existing.DeviceUserID)
+	return err
+}
+
+// ResolveEmployee applies the mappings of a device user ID to a punch.
+func (s *deviceIdentityService) ResolveEmployee(ctx context.Context, device *models.Device, deviceUserID string, t time.Time) (*uint, error) {
+	identities, err := s.identityRepo.GetIdentitiesByDeviceUserID(ctx, deviceUserID)
+	if err != nil {
+		return nil, err
+	}
+	identity := models.ResolveDeviceIdentity(identities, device, t)
+	if identity == nil {
+		return nil, nil
+	}
+	employeeID := identity.EmployeeID
+	return &employeeID, nil
+}
+
+// SyncEmployee creates the company mapping of the employee's registration number when missing,
+// and ends the automatic mappings of their previous registration numbers.
+func (s *deviceIdentityService) SyncEmployee(ctx context.Context, employee *models.Employee) error {
+	registration := strings.TrimSpace(employee.RegistrationNumber)
+	if registration == "" || employee.CompanyID == 0 {
+		return nil
+	}
+
+	existing, err := s.identityRepo.ListDeviceIdentities(ctx, map[string]interface{}{"employee_id": employee.ID})
+	if err != nil {
+		return err
+	}
+	found := false
+	relink := []string{registration}
+	yesterday := types.DateOnly(truncateToDay(time.Now()).AddDate(0, 0, -1))
+	for i := range existing {
+		identity := &existing[i]
+		if identity.DeviceUserID == registration && identity.DeviceID == nil &&
+			identity.CompanyID != nil && *identity.CompanyID == employee.CompanyID {
+			found = true
+			continue
+		}
+		// A registration number or company that changed no longer identifies the employee from now on.
+		if identity.Source == models.DeviceIdentitySourceRegistration && identity.EffectiveTo == nil {
+			if !identity.EffectiveFrom.ToTime().After(yesterday.ToTime()) {
+				identity.EffectiveTo = &yesterday
+				if err := s.identityRepo.UpdateDeviceIdentity(ctx, identity); err != nil {
+					return err
+				}
+			} else if err := s.identityRepo.DeleteDeviceIdentity(ctx, identity.ID); err != nil {
+				return err
+			}
+			relink = append(relink, identity.DeviceUserID)
+		}
+	}
+
+	if !found {
+		companyID := employee.CompanyID
+		from := types.DateOnly(identityEpoch)
+		if employee.HireDate != nil {
+			from = *employee.HireDate
+		}
+		identity := &models.DeviceIdentity{
+			CompanyID:     &companyID,
+			DeviceUserID:  registration,
+			EmployeeID:    employee.ID,
+			EffectiveFrom: from,
+			Source:        models.DeviceIdentitySourceRegistration,
+		}
+		conflicts, err := s.identityRepo.FindOverlappingIdentities(ctx, identity)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			log.Printf("device identity: %s is already mapped in company %d, not mapping it to employee %d",
+				registration, companyID, employee.ID)
+		} else if err := s.identityRepo.CreateDeviceIdentity(ctx, identity); err != nil {
+			return err
+		}
+	}
+
+	for _, deviceUserID := range relink {
+		if _, err := s.identityRepo.RelinkPunches(ctx, deviceUserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncFromRegistrationNumbers maps every employee's registration number and links all punches.
+func (s *deviceIdentityService) SyncFromRegistrationNumbers(ctx context.Context) (int64, error) {
+	if err := s.identityRepo.BackfillDeviceUserIDs(ctx); err != nil {
+		return 0, err
+	}
+	employees, err := s.employeeRepo.FetchEmployees(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, employee := range employees {
+		if err := s.SyncEmployee(ctx, employee); err != nil {
+			return 0, err
+		}
+	}
+
+	deviceUserIDs, err := s.identityRepo.ListUnlinkedDeviceUserIDs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var changed int64
+	for _, deviceUserID := range deviceUserIDs {
+		n, err := s.identityRepo.RelinkPunches(ctx, deviceUserID)
+		if err != nil {
+			return changed, err
+		}
+		changed += n
+	}
+	return changed, nil
+}
+
+// validate checks the scope, target and dates of a mapping, and that it overlaps no other mapping.
+func (s *deviceIdentityService) validate(ctx context.Context, identity *models.DeviceIdentity) error {
+	identity.DeviceUserID = strings.TrimSpace(identity.DeviceUserID)
+	if identity.DeviceUserID == "" {
+		return fmt.Errorf("%w: device user ID is required", ErrInvalidDeviceIdentity)
+	}
+	if (identity.DeviceID == nil) == (identity.CompanyID == nil) {
+		return fmt.Errorf("%w: exactly one of device_id and company_id is required", ErrInvalidDeviceIdentity)
+	}
+	if identity.DeviceID != nil {
+		device, err := s.deviceRepo.GetDeviceByID(ctx, *identity.DeviceID)
+		if err != nil {
+			return err
+		}
+		if device == nil {
+			return fmt.Errorf("%w: device not found", ErrInvalidDeviceIdentity)
+		}
+	} else {
+		company, err := s.companyRepo.GetCompanyByID(ctx, *identity.CompanyID)
+		if err != nil {
+			return err
+		}
+		if company == nil {
+			return fmt.Errorf("%w: company not found", ErrInvalidDeviceIdentity)
+		}
+	}
+
+	employee, err := s.employeeRepo.GetEmployeeByID(ctx, identity.EmployeeID)
+	if err != nil {
+		return err
+	}
+	if employee == nil {
+		return fmt.Errorf("%w: employee not found", ErrInvalidDeviceIdentity)
+	}
+
+	if identity.EffectiveFrom.ToTime().IsZero() {
+		identity.EffectiveFrom = types.DateOnly(identityEpoch)
+	}
+	if identity.EffectiveTo != nil && identity.EffectiveTo.ToTime().Before(identity.EffectiveFrom.ToTime()) {
+		return fmt.Errorf("%w: effective_to cannot be before effective_from", ErrInvalidDeviceIdentity)
+	}
+
+	conflicts, err := s.identityRepo.FindOverlappingIdentities(ctx, identity)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%w: device user ID %s is already mapped to employee %d over these dates (mapping %d)",
+			ErrInvalidDeviceIdentity, identity.DeviceUserID, conflicts[0].EmployeeID, conflicts[0].ID)
+	}
+	return nil
+}
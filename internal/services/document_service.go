@@ -0,0 +1,302 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/storage"
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidDocument is returned when an upload is rejected by content-type or size validation.
+var ErrInvalidDocument = errors.New("invalid document")
+
+// allowedDocumentTypes maps the accepted sniffed content types to the extension they are stored with.
+var allowedDocumentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+}
+
+// DocumentService defines the interface for justification documents.
+type DocumentService interface {
+	// UploadDocument validates and stores a file attached to a raw attendance, leave request,
+	// employee or punch correction request.
+	UploadDocument(ctx context.Context, document *models.Document, content io.Reader) error
+	GetDocumentByID(ctx context.Context, id uint) (*models.Document, error)
+	ListDocuments(ctx context.Context, filters map[string]interface{}) ([]models.Document, error)
+	// OpenDocument returns the stored content of a document. The caller must close it.
+	OpenDocument(ctx context.Context, document *models.Document) (io.ReadCloser, error)
+	DeleteDocument(ctx context.Context, id uint) error
+	// ReviewDocument approves or rejects a pending document. Approval justifies the absences it is attached to.
+	ReviewDocument(ctx context.Context, id uint, reviewerID uint, approve bool, comment string) (*models.Document, error)
+}
+
+// documentService implements the DocumentService interface.
+type documentService struct {
+	documentRepo      repositories.DocumentRepository
+	storage           storage.Storage
+	rawAttendanceRepo repositories.RawAttendanceRepository
+	leaveRequestRepo  repositories.LeaveRequestRepository
+	employeeRepo      repositories.EmployeeRepository
+	workDayRepo       repositories.WorkDayRepository
+	correctionRepo    repositories.PunchCorrectionRepository
+	periodService     PayrollPeriodService
+	maxSize           int64
+}
+
+// NewDocumentService creates a new instance of DocumentService accepting files up to maxSize bytes.
+func NewDocumentService(documentRepo repositories.DocumentRepository,
+	storage storage.Storage,
+	rawAttendanceRepo repositories.RawAttendanceRepository,
+	leaveRequestRepo repositories.LeaveRequestRepository,
+	employeeRepo repositories.EmployeeRepository,
+	workDayRepo repositories.WorkDayRepository,
+	correctionRepo repositories.PunchCorrectionRepository,
+	periodService PayrollPeriodService,
+	maxSize int64) DocumentService {
+	return &documentService{
+		documentRepo:      documentRepo,
+		storage:           storage,
+		rawAttendanceRepo: rawAttendanceRepo,
+		leaveRequestRepo:  leaveRequestRepo,
+		employeeRepo:      employeeRepo,
+		workDayRepo:       workDayRepo,
+		correctionRepo:    correctionRepo,
+		periodService:     periodService,
+		maxSize:           maxSize,
+	}
+}
+
+// UploadDocument checks the owner exists, sniffs the content type and stores the file.
+func (s *documentService) UploadDocument(ctx context.Context, document *models.Document, content io.Reader) error {
+	if document == nil {
+		return errors.New("document is nil")
+	}
+	if err := s.ensureOwnerExists(ctx, document.OwnerType, document.OwnerID); err != nil {
+		return err
+	}
+
+	// Read one byte past the limit to detect oversized files without trusting the client.
+	data, err := io.ReadAll(io.LimitReader(content, s.maxSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to read upload: %w", err)
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("%w: file is empty", ErrInvalidDocument)
+	}
+	if int64(len(data)) > s.maxSize {
+		return fmt.Errorf("%w: file exceeds the %d bytes limit", ErrInvalidDocument, s.maxSize)
+	}
+	contentType := http.DetectContentType(data)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	extension, ok := allowedDocumentTypes[contentType]
+	if !ok {
+		return fmt.Errorf("%w: content type %s is not allowed, upload a PDF or an image", ErrInvalidDocument, contentType)
+	}
+
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to generate storage key: %w", err)
+	}
+	document.StorageKey = fmt.Sprintf("%s/%d/%s%s", document.OwnerType, document.OwnerID, hex.EncodeToString(suffix), extension)
+	document.FileName = filepath.Base(document.FileName)
+	document.ContentType = contentType
+	document.Size = int64(len(data))
+	document.Status = models.DocumentStatusPending
+	document.ReviewedBy = nil
+	document.ReviewedAt = nil
+	document.ReviewComment = ""
+
+	if err := s.storage.Save(ctx, document.StorageKey, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	if err := s.documentRepo.CreateDocument(ctx, document); err != nil {
+		// Do not leave an orphan file behind
+		_ = s.storage.Delete(ctx, document.StorageKey)
+		return err
+	}
+	return nil
+}
+
+// GetDocumentByID retrieves a document by its ID.
+func (s *documentService) GetDocumentByID(ctx context.Context, id uint) (*models.Document, error) {
+	return s.documentRepo.GetDocumentByID(ctx, id)
+}
+
+// ListDocuments retrieves documents matching the given filters.
+func (s *documentService) ListDocuments(ctx context.Context, filters map[string]interface{}) ([]models.Document, error) {
+	return s.documentRepo.ListDocuments(ctx, filters)
+}
+
+// OpenDocument opens the stored file of a document.
+func (s *documentService) OpenDocument(ctx context.Context, document *models.Document) (io.ReadCloser, error) {
+	return s.storage.Open(ctx, document.StorageKey)
+}
+
+// DeleteDocument deletes a document and its file. Approved documents justify an absence and are kept.
+func (s *documentService) DeleteDocument(ctx context.Context, id uint) error {
+	document, err := s.documentRepo.GetDocumentByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if document == nil {
+		return errors.New("document not found")
+	}
+	if document.Status == models.DocumentStatusApproved {
+		return errors.New("an approved document cannot be deleted")
+	}
+	if err := s.documentRepo.DeleteDocument(ctx, id); err != nil {
+		return err
+	}
+	return s.storage.Delete(ctx, document.StorageKey)
+}
+
+// ReviewDocument records the review of a pending document and, on approval, marks the
+// raw attendances it covers as justified.
+func (s *documentService) ReviewDocument(ctx context.Context, id uint, reviewerID uint, approve bool, comment string) (*models.Document, error) {
+	document, err := s.documentRepo.GetDocumentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if document == nil {
+		return nil, errors.New("document not found")
+	}
+	if document.Status != models.DocumentStatusPending {
+		return nil, fmt.Errorf("document is already %s", document.Status)
+	}
+
+	if approve {
+		if err := s.justifyAbsences(ctx, document); err != nil {
+			return nil, err
+		}
+		document.Status = models.DocumentStatusApproved
+	} else {
+		document.Status = models.DocumentStatusRejected
+	}
+	now := time.Now()
+	if reviewerID != 0 {
+		document.ReviewedBy = &reviewerID
+	}
+	document.ReviewedAt = &now
+	document.ReviewComment = comment
+
+	if err := s.documentRepo.UpdateDocument(ctx, document); err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+// justifyAbsences marks the raw attendances linked to the document's owner as justified.
+// Documents kept on an employee's record justify nothing by themselves.
+func (s *documentService) justifyAbsences(ctx context.Context, document *models.Document) error {
+	ctx = types.WithRawAttendanceSource(ctx, types.RawAttendanceSourceJustification)
+	var rows []*models.RawAttendance
+	switch document.OwnerType {
+	case models.DocumentOwnerRawAttendance:
+		row, err := s.rawAttendanceRepo.GetRawAttendanceByID(ctx, document.OwnerID)
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			return errors.New("raw attendance not found")
+		}
+		rows = append(rows, row)
+	case models.DocumentOwnerLeaveRequest:
+		linked, err := s.rawAttendanceRepo.GetRawAttendancesByLeaveRequest(ctx, document.OwnerID)
+		if err != nil {
+			return err
+		}
+		rows = linked
+	}
+
+	for _, row := range rows {
+		if row.Justification == models.AbsenceJustified {
+			continue
+		}
+		if err := s.ensureRowEditable(ctx, row); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		if row.Justification == models.AbsenceJustified {
+			continue
+		}
+		row.Justification = models.AbsenceJustified
+		if err := s.rawAttendanceRepo.SaveRawAttendance(ctx, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureRowEditable fails when the workday of a row is locked or its date is in a closed payroll period.
+func (s *documentService) ensureRowEditable(ctx context.Context, row *models.RawAttendance) error {
+	workday, err := s.workDayRepo.GetWorkDayByID(ctx, row.WorkDayID)
+	if err != nil {
+		return err
+	}
+	if workday == nil {
+		return errors.New("workday not found")
+	}
+	if !workday.IsEditable() {
+		return fmt.Errorf("%w: workday %d is %s", ErrWorkDayLocked, workday.ID, workday.Status)
+	}
+	return s.periodService.EnsureDateOpen(ctx, row.CompanyID, workday.Date.ToTime())
+}
+
+// ensureOwnerExists validates the entity a document is attached to.
+func (s *documentService) ensureOwnerExists(ctx context.Context, ownerType string, ownerID uint) error {
+	if ownerID == 0 {
+		return fmt.Errorf("%w: owner ID is required", ErrInvalidDocument)
+	}
+	var found bool
+	switch ownerType {
+	case models.DocumentOwnerRawAttendance:
+		row, err := s.rawAttendanceRepo.GetRawAttendanceByID(ctx, ownerID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		found = row != nil
+	case models.DocumentOwnerLeaveRequest:
+		request, err := s.leaveRequestRepo.GetLeaveRequestByID(ctx, ownerID)
+		if err != nil {
+			return err
+		}
+		found = request != nil
+	case models.DocumentOwnerEmployee:
+		employee, err := s.employeeRepo.GetEmployeeByID(ctx, ownerID)
+		if err != nil {
+			return err
+		}
+		found = employee != nil
+	case models.DocumentOwnerPunchCorrection:
+		request, err := s.correctionRepo.GetPunchCorrectionByID(ctx, ownerID)
+		if err != nil {
+			return err
+		}
+		found = request != nil
+	default:
+		return fmt.Errorf("%w: owner type must be raw_attendance, leave_request, employee or punch_correction", ErrInvalidDocument)
+	}
+	if !found {
+		return fmt.Errorf("%w: %s %d not found", ErrInvalidDocument, ownerType, ownerID)
+	}
+	return nil
+}
@@ -0,0 +1,476 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+	"point-system-api/pkg/utils"
+)
+
+// ErrInvalidImport is returned when an import file or its options cannot be processed at all.
+// Problems with individual rows are reported in the import report instead.
+var ErrInvalidImport = errors.New("invalid import")
+
+// maxImportRows bounds the number of data rows accepted in one import.
+const maxImportRows = 5000
+
+// EmployeeImportService defines the interface for bulk employee imports.
+type EmployeeImportService interface {
+	// ImportEmployees validates every row of a CSV or XLSX file and, unless it is a dry run,
+	// creates the users and employees according to the import mode.
+	ImportEmployees(ctx context.Context, fileName string, data []byte, opts types.EmployeeImportOptions) (*types.EmployeeImportReport, error)
+}
+
+// employeeImportService implements the EmployeeImportService interface.
+type employeeImportService struct {
+	uow             repositories.UnitOfWork
+	employeeRepo    repositories.EmployeeRepository
+	userRepo        repositories.UserRepository
+	companyRepo     repositories.CompanyRepository
+	identityService DeviceIdentityService
+	policy          *PasswordPolicy
+}
+
+// NewEmployeeImportService creates a new instance of EmployeeImportService.
+func NewEmployeeImportService(uow repositories.UnitOfWork,
+	employeeRepo repositories.EmployeeRepository,
+	userRepo repositories.UserRepository,
+	companyRepo repositories.CompanyRepository,
+	identityService DeviceIdentityService,
+	policy *PasswordPolicy) EmployeeImportService {
+	return &employeeImportService{
+		uow:             uow,
+		employeeRepo:    employeeRepo,
+		userRepo:        userRepo,
+		companyRepo:     companyRepo,
+		identityService: identityService,
+		policy:          policy,
+	}
+}
+
+// importRecord is a validated row ready to be created.
+type importRecord struct {
+	report   *types.EmployeeImportRow
+	user     *models.User
+	employee *models.Employee
+}
+
+// ImportEmployees runs the import described by opts.
+func (s *employeeImportService) ImportEmployees(ctx context.Context, fileName string, data []byte, opts types.EmployeeImportOptions) (*types.EmployeeImportReport, error) {
+	if opts.Mode == "" {
+		opts.Mode = types.ImportModeAllOrNothing
+	}
+	if opts.Mode != types.ImportModeAllOrNothing && opts.Mode != types.ImportModeSkipInvalid {
+		return nil, fmt.Errorf("%w: mode must be %s or %s", ErrInvalidImport, types.ImportModeAllOrNothing, types.ImportModeSkipInvalid)
+	}
+
+	table, err := parseImportTable(fileName, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(table) < 2 {
+		return nil, fmt.Errorf("%w: the file needs a header row and at least one data row", ErrInvalidImport)
+	}
+	if len(table)-1 > maxImportRows {
+		return nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidImport, maxImportRows)
+	}
+	columns, err := mapImportColumns(table[0], opts.Mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &types.EmployeeImportReport{DryRun: opts.DryRun, Mode: opts.Mode}
+	records, err := s.validateRows(ctx, table[1:], columns, opts, report)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun || len(records) == 0 {
+		return report, nil
+	}
+	if opts.Mode == types.ImportModeAllOrNothing && report.InvalidRows > 0 {
+		return report, nil
+	}
+
+	for _, record := range records {
+		hashed, err := utils.HashPassword(record.user.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		record.user.Password = hashed
+	}
+
+	if opts.Mode == types.ImportModeAllOrNothing {
+		users := make([]*models.User, len(records))
+		employees := make([]*models.Employee, len(records))
+		for i, record := range records {
+			users[i], employees[i] = record.user, record.employee
+		}
+		err := s.uow.Do(ctx, func(ctx context.Context) error {
+			if err := s.employeeRepo.CreateEmployeesWithUsers(ctx, users, employees); err != nil {
+				return err
+			}
+			for _, employee := range employees {
+				if err := s.syncImportedEmployee(ctx, employee); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			record.report.Status = types.ImportRowCreated
+			record.report.EmployeeID = record.employee.ID
+		}
+		report.Created = len(records)
+	} else {
+		for _, record := range records {
+			err := s.uow.Do(ctx, func(ctx context.Context) error {
+				err := s.employeeRepo.CreateEmployeesWithUsers(ctx, []*models.User{record.user}, []*models.Employee{record.employee})
+				if err != nil {
+					return err
+				}
+				return s.syncImportedEmployee(ctx, record.employee)
+			})
+			if err != nil {
+				record.report.Status = types.ImportRowFailed
+				record.report.Errors = append(record.report.Errors, err.Error())
+				continue
+			}
+			record.report.Status = types.ImportRowCreated
+			record.report.EmployeeID = record.employee.ID
+			report.Created++
+		}
+		for i := range report.Rows {
+			if report.Rows[i].Status == types.ImportRowInvalid {
+				report.Rows[i].Status = types.ImportRowSkipped
+			}
+		}
+	}
+	report.Committed = report.Created > 0
+	return report, nil
+}
+
+// syncImportedEmployee links the punches recorded under the registration number of a new employee.
+func (s *employeeImportService) syncImportedEmployee(ctx context.Context, employee *models.Employee) error {
+	if err := s.identityService.SyncEmployee(ctx, employee); err != nil {
+		return fmt.Errorf("failed to map device identity: %w", err)
+	}
+	return nil
+}
+
+// validateRows checks every data row, filling the report, and returns the valid rows. Passwords
+// must meet the password policy, the default password being checked once for all the rows using it.
+func (s *employeeImportService) validateRows(ctx context.Context, rows [][]string, columns map[string]int,
+	opts types.EmployeeImportOptions, report *types.EmployeeImportReport) ([]*importRecord, error) {
+	companies := map[string]*models.Company{}
+	seenRegistrations := map[string]int{}
+	seenUsernames := map[string]int{}
+	defaultPasswordProblem := ""
+	if opts.DefaultPassword != "" {
+		if err := s.policy.Validate(opts.DefaultPassword, ""); err != nil {
+			defaultPasswordProblem = fmt.Sprintf("default password: %v", err)
+		}
+	}
+
+	report.Rows = make([]types.EmployeeImportRow, 0, len(rows))
+	rowRecords := make([]*importRecord, 0, len(rows))
+
+	for i, row := range rows {
+		value := func(field string) string {
+			index, ok := columns[field]
+			if !ok || index >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[index])
+		}
+		if isBlankRow(row) {
+			continue
+		}
+
+		line := i + 2
+		registration := value("registration_number")
+		username := value("username")
+		if username == "" {
+			username = registration
+		}
+		result := types.EmployeeImportRow{Row: line, RegistrationNumber: registration, Username: username}
+		var problems []string
+
+		firstName, lastName := value("first_name"), value("last_name")
+		if firstName == "" || lastName == "" {
+			problems = append(problems, "first name and last name are required")
+		}
+
+		if registration == "" {
+			problems = append(problems, "registration number is required")
+		} else if previous, ok := seenRegistrations[registration]; ok {
+			problems = append(problems, fmt.Sprintf("registration number %s is duplicated on row %d", registration, previous))
+		} else {
+			seenRegistrations[registration] = line
+			existing, err := s.employeeRepo.GetEmployeeByRegistrationNumber(ctx, registration)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				problems = append(problems, fmt.Sprintf("registration number %s already belongs to employee %d", registration, existing.ID))
+			}
+		}
+
+		if username != "" {
+			if previous, ok := seenUsernames[username]; ok {
+				problems = append(problems, fmt.Sprintf("username %s is duplicated on row %d", username, previous))
+			} else {
+				seenUsernames[username] = line
+				existing, err := s.userRepo.GetUserByUsername(ctx, username)
+				if err != nil {
+					return nil, err
+				}
+				if existing != nil {
+					problems = append(problems, fmt.Sprintf("username %s already exists", username))
+				}
+			}
+		}
+
+		password := value("password")
+		switch {
+		case password != "":
+			if err := s.policy.Validate(password, username); err != nil {
+				problems = append(problems, err.Error())
+			}
+		case opts.DefaultPassword == "":
+			problems = append(problems, "password is required (set a password column or a default password)")
+		case defaultPasswordProblem != "":
+			problems = append(problems, defaultPasswordProblem)
+		default:
+			password = opts.DefaultPassword
+		}
+
+		company, problem, err := s.resolveImportCompany(ctx, value("company"), opts.CompanyID, companies)
+		if err != nil {
+			return nil, err
+		}
+		if problem != "" {
+			problems = append(problems, problem)
+		}
+
+		startHour, endHour := value("start_hour"), value("end_hour")
+		for _, hour := range []string{startHour, endHour} {
+			if hour != "" && !isValidHour(hour) {
+				problems = append(problems, fmt.Sprintf("invalid hour %q, expected HH:MM", hour))
+			}
+		}
+
+		var hireDate *types.DateOnly
+		if raw := value("hire_date"); raw != "" {
+			parsed, err := parseImportDate(raw)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("invalid hire date %q, expected YYYY-MM-DD", raw))
+			} else {
+				date := types.DateOnly(parsed)
+				hireDate = &date
+			}
+		}
+
+		if len(problems) > 0 {
+			result.Status = types.ImportRowInvalid
+			result.Errors = problems
+			report.InvalidRows++
+			report.Rows = append(report.Rows, result)
+			rowRecords = append(rowRecords, nil)
+			continue
+		}
+
+		result.Status = types.ImportRowValid
+		report.ValidRows++
+		report.Rows = append(report.Rows, result)
+		rowRecords = append(rowRecords, &importRecord{
+			user: &models.User{
+				FirstName: firstName,
+				LastName:  lastName,
+				Username:  username,
+				Password:  password,
+				Role:      "employee",
+			},
+			employee: &models.Employee{
+				RegistrationNumber: registration,
+				Qualification:      value("qualification"),
+				CompanyID:          company.ID,
+				StartHour:          startHour,
+				EndHour:            endHour,
+				HireDate:           hireDate,
+			},
+		})
+	}
+	report.TotalRows = len(report.Rows)
+
+	// Link the records to their report rows once the slice no longer grows.
+	records := make([]*importRecord, 0, report.ValidRows)
+	for i, record := range rowRecords {
+		if record != nil {
+			record.report = &report.Rows[i]
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// resolveImportCompany finds the company of a row by ID or name, falling back to defaultID.
+// A non-empty problem is returned when the company is missing or unknown.
+func (s *employeeImportService) resolveImportCompany(ctx context.Context, raw string, defaultID uint,
+	cache map[string]*models.Company) (*models.Company, string, error) {
+	if raw == "" {
+		if defaultID == 0 {
+			return nil, "company is required", nil
+		}
+		raw = strconv.FormatUint(uint64(defaultID), 10)
+	}
+	if company, ok := cache[raw]; ok {
+		if company == nil {
+			return nil, fmt.Sprintf("unknown company %q", raw), nil
+		}
+		return company, "", nil
+	}
+
+	var company *models.Company
+	var err error
+	if id, parseErr := strconv.ParseUint(raw, 10, 64); parseErr == nil {
+		company, err = s.companyRepo.GetCompanyByID(ctx, uint(id))
+	} else {
+		company, err = s.companyRepo.GetCompanyByName(ctx, raw)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	cache[raw] = company
+	if company == nil {
+		return nil, fmt.Sprintf("unknown company %q", raw), nil
+	}
+	return company, "", nil
+}
+
+// parseImportTable reads the rows of a CSV file or of the first sheet of an XLSX workbook.
+func parseImportTable(fileName string, data []byte) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		// Spreadsheets exported with a comma decimal separator use semicolons.
+		firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+		if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+			reader.Comma = ';'
+		}
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%w: unreadable CSV: %v", ErrInvalidImport, err)
+		}
+		return rows, nil
+	case ".xlsx":
+		workbook, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: unreadable XLSX: %v", ErrInvalidImport, err)
+		}
+		defer workbook.Close()
+		sheets := workbook.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("%w: the workbook has no sheet", ErrInvalidImport)
+		}
+		rows, err := workbook.GetRows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: unreadable XLSX: %v", ErrInvalidImport, err)
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("%w: only .csv and .xlsx files are supported", ErrInvalidImport)
+	}
+}
+
+// mapImportColumns resolves the column index of each import field from the header row.
+// A mapped header that cannot be found is an error; unmapped fields are matched by name.
+func mapImportColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	known := map[string]bool{}
+	for _, field := range types.EmployeeImportFields {
+		known[field] = true
+	}
+	for field := range mapping {
+		if !known[field] {
+			return nil, fmt.Errorf("%w: unknown field %q in mapping", ErrInvalidImport, field)
+		}
+	}
+
+	index := map[string]int{}
+	for i, name := range header {
+		key := normalizeHeader(name)
+		if _, exists := index[key]; !exists {
+			index[key] = i
+		}
+	}
+
+	columns := map[string]int{}
+	for _, field := range types.EmployeeImportFields {
+		name, mapped := mapping[field]
+		if !mapped {
+			name = field
+		}
+		if i, ok := index[normalizeHeader(name)]; ok {
+			columns[field] = i
+		} else if mapped {
+			return nil, fmt.Errorf("%w: column %q mapped to %s is not in the header", ErrInvalidImport, name, field)
+		}
+	}
+	return columns, nil
+}
+
+// normalizeHeader makes header matching insensitive to case, spaces and separators.
+func normalizeHeader(name string) string {
+	name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(name)
+}
+
+// isBlankRow reports whether every cell of a row is empty.
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidHour accepts HH:MM and HH:MM:SS.
+func isValidHour(hour string) bool {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if _, err := time.Parse(layout, hour); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parseImportDate accepts ISO dates and the day-first dates spreadsheets commonly export.
+func parseImportDate(raw string) (time.Time, error) {
+	var lastErr error
+	for _, layout := range []string{"2006-01-02", "02/01/2006"} {
+		parsed, err := time.ParseInLocation(layout, raw, time.Local)
+		if err == nil {
+			return parsed, nil
+		}
+		lastErr = err
+	}
+	return time.Time{}, lastErr
+}
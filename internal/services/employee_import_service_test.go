@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+func TestParseImportTableCSVSemicolon(t *testing.T) {
+	data := []byte("\xef\xbb\xbfMatricule;Nom;Prénom\n1001;Doe;Jane\n")
+	rows, err := parseImportTable("staff.CSV", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0][0] != "Matricule" || rows[1][2] != "Jane" {
+		t.Errorf("unexpected rows %q", rows)
+	}
+}
+
+func TestParseImportTableRejectsUnknownExtension(t *testing.T) {
+	if _, err := parseImportTable("staff.txt", []byte("a,b")); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("got %v, want ErrInvalidImport", err)
+	}
+}
+
+func TestMapImportColumns(t *testing.T) {
+	header := []string{"Matricule", "First Name", "last_name", "Company"}
+	columns, err := mapImportColumns(header, map[string]string{"registration_number": "matricule"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"registration_number": 0, "first_name": 1, "last_name": 2, "company": 3}
+	for field, index := range want {
+		if columns[field] != index {
+			t.Errorf("%s mapped to %d, want %d", field, columns[field], index)
+		}
+	}
+	if _, ok := columns["password"]; ok {
+		t.Errorf("password should not be mapped")
+	}
+
+	if _, err := mapImportColumns(header, map[string]string{"first_name": "Given name"}); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("missing mapped column: got %v, want ErrInvalidImport", err)
+	}
+	if _, err := mapImportColumns(header, map[string]string{"salary": "Company"}); !errors.Is(err, ErrInvalidImport) {
+		t.Errorf("unknown field: got %v, want ErrInvalidImport", err)
+	}
+}
+
+// importFixture serves company 1 and no existing employee or user, and fails on any other call.
+type importFixture struct {
+	repositories.EmployeeRepository
+	repositories.UserRepository
+	repositories.CompanyRepository
+}
+
+func (importFixture) GetEmployeeByRegistrationNumber(ctx context.Context, registrationNumber string) (*models.Employee, error) {
+	return nil, nil
+}
+
+func (importFixture) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return nil, nil
+}
+
+func (importFixture) GetCompanyByID(ctx context.Context, id uint) (*models.Company, error) {
+	return &models.Company{Model: gorm.Model{ID: id}}, nil
+}
+
+func TestValidateRowsEnforcesThePasswordPolicy(t *testing.T) {
+	fixture := importFixture{}
+	service := &employeeImportService{employeeRepo: fixture, userRepo: fixture, companyRepo: fixture, policy: DefaultPasswordPolicy()}
+	columns := map[string]int{"registration_number": 0, "first_name": 1, "last_name": 2, "password": 3}
+	rows := [][]string{
+		{"1001", "Jane", "Doe", "Tr4ck-the-hours"},
+		{"1002", "John", "Doe", "password"},
+		{"1003", "Ana", "Lima", ""},
+	}
+
+	for _, tc := range []struct {
+		defaultPassword string
+		valid           []bool
+	}{
+		{"Welcome-2025!", []bool{true, false, true}},
+		{"welcome", []bool{true, false, false}},
+	} {
+		report := &types.EmployeeImportReport{}
+		opts := types.EmployeeImportOptions{CompanyID: 1, DefaultPassword: tc.defaultPassword}
+		if _, err := service.validateRows(context.Background(), rows, columns, opts, report); err != nil {
+			t.Fatal(err)
+		}
+		for i, row := range report.Rows {
+			if valid := row.Status == types.ImportRowValid; valid != tc.valid[i] {
+				t.Errorf("default %q, row %d: got %s %v, want valid=%v", tc.defaultPassword, row.Row, row.Status, row.Errors, tc.valid[i])
+			}
+		}
+		if tc.defaultPassword == "welcome" && !strings.HasPrefix(strings.Join(report.Rows[2].Errors, ""), "default password") {
+			t.Errorf("weak default password: got %v", report.Rows[2].Errors)
+		}
+	}
+}
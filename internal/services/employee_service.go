@@ -8,7 +8,6 @@ import (
 	"point-system-api/internal/models"
 	"point-system-api/internal/repositories"
 	"point-system-api/internal/types"
-	"point-system-api/pkg/utils"
 )
 
 // EmployeeService defines the interface for employee-related operations.
@@ -23,15 +22,21 @@ type EmployeeService interface {
 
 // employeeService implements the EmployeeService interface.
 type employeeService struct {
-	employeeRepo repositories.EmployeeRepository
-	userService  UserService
+	uow             repositories.UnitOfWork
+	employeeRepo    repositories.EmployeeRepository
+	employmentRepo  repositories.EmploymentRepository
+	userService     UserService
+	identityService DeviceIdentityService
 }
 
 // NewEmployeeService creates a new instance of EmployeeService.
-func NewEmployeeService(employeeRepo repositories.EmployeeRepository, userService UserService) EmployeeService {
+func NewEmployeeService(uow repositories.UnitOfWork, employeeRepo repositories.EmployeeRepository, employmentRepo repositories.EmploymentRepository, userService UserService, identityService DeviceIdentityService) EmployeeService {
 	return &employeeService{
-		employeeRepo: employeeRepo,
-		userService:  userService,
+		uow:             uow,
+		employeeRepo:    employeeRepo,
+		employmentRepo:  employmentRepo,
+		userService:     userService,
+		identityService: identityService,
 	}
 }
 
@@ -45,21 +50,30 @@ func (s *employeeService) CreateEmployee(ctx context.Context, employee models.Em
 	// Set the default role for the user
 	user.Role = "employee" // Default role for employees
 
-	// Create the user first
-	userID, err := s.userService.CreateUser(ctx, user)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
-	}
+	// The user, the employee and its device mapping are created together or not at all
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		// Create the user first
+		userID, err := s.userService.CreateUser(ctx, user)
+		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
 
-	// Set the UserID for the employee
-	employee.UserID = userID
+		// Set the UserID for the employee
+		employee.UserID = userID
 
-	// Create the employee in the database
-	err = s.employeeRepo.CreateEmployee(ctx, &employee)
+		// Create the employee in the database
+		if err := s.employeeRepo.CreateEmployee(ctx, &employee); err != nil {
+			return fmt.Errorf("failed to create employee: %w", err)
+		}
+
+		// Link the punches recorded under the employee's registration number
+		if err := s.identityService.SyncEmployee(ctx, &employee); err != nil {
+			return fmt.Errorf("failed to map device identity: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		// Rollback: Delete the user if employee creation fails
-		_, _ = s.userService.DeleteUser(ctx, userID)
-		return nil, fmt.Errorf("failed to create employee: %w", err)
+		return nil, err
 	}
 
 	return &employee, nil
@@ -85,19 +99,31 @@ func (s *employeeService) GetEmployeesByCompanyID(ctx context.Context, companyID
 
 // UpdateEmployee updates an existing employee and their associated user.
 func (s *employeeService) UpdateEmployee(ctx context.Context, employee models.Employee, userUpdates *models.User) (bool, error) {
+	// The employee, its history, its device mapping and its user are updated together or not at all
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		return s.updateEmployee(ctx, employee, userUpdates)
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// updateEmployee applies the changes of UpdateEmployee within its transaction.
+func (s *employeeService) updateEmployee(ctx context.Context, employee models.Employee, userUpdates *models.User) error {
 	// Validate that the employee ID is provided
 	if employee.ID == 0 {
-		return false, errors.New("employee ID is required")
+		return errors.New("employee ID is required")
 	}
 
 	// Retrieve the existing employee
 	existingEmployee, err := s.employeeRepo.GetEmployeeByID(ctx, employee.ID)
 	if err != nil {
-		return false, fmt.Errorf("failed to retrieve employee: %w", err)
+		return fmt.Errorf("failed to retrieve employee: %w", err)
 	}
 
 	if existingEmployee == nil {
-		return false, errors.New("employee not found")
+		return errors.New("employee not found")
 	}
 
 	// Update the employee details
@@ -121,10 +147,24 @@ func (s *employeeService) UpdateEmployee(ctx context.Context, employee models.Em
 		existingEmployee.EndHour = employee.EndHour
 	}
 
+	if employee.HireDate != nil {
+		existingEmployee.HireDate = employee.HireDate
+	}
+
 	// Save the updated employee
 	err = s.employeeRepo.UpdateEmployee(ctx, existingEmployee)
 	if err != nil {
-		return false, fmt.Errorf("failed to update employee: %w", err)
+		return fmt.Errorf("failed to update employee: %w", err)
+	}
+
+	// Editing the company or position corrects the current assignment; transfers keep history.
+	if err := s.employmentRepo.CorrectCurrentAssignment(ctx, existingEmployee); err != nil {
+		return fmt.Errorf("failed to update employment history: %w", err)
+	}
+
+	// Follow a new registration number or company on the devices
+	if err := s.identityService.SyncEmployee(ctx, existingEmployee); err != nil {
+		return fmt.Errorf("failed to map device identity: %w", err)
 	}
 
 	// Update the associated user (if userUpdates is provided)
@@ -132,10 +172,10 @@ func (s *employeeService) UpdateEmployee(ctx context.Context, employee models.Em
 		// Retrieve the existing user
 		existingUser, err := s.userService.GetUserByID(ctx, existingEmployee.UserID)
 		if err != nil {
-			return false, fmt.Errorf("failed to retrieve user: %w", err)
+			return fmt.Errorf("failed to retrieve user: %w", err)
 		}
 		if existingUser == nil {
-			return false, errors.New("user not found")
+			return errors.New("user not found")
 		}
 
 		// Update the user details
@@ -148,23 +188,22 @@ func (s *employeeService) UpdateEmployee(ctx context.Context, employee models.Em
 		if userUpdates.Username != "" {
 			existingUser.Username = userUpdates.Username
 		}
-		if userUpdates.Password != "" {
-			// Hash the new password before saving
-			hashedPassword, err := utils.HashPassword(userUpdates.Password)
-			if err != nil {
-				return false, fmt.Errorf("failed to hash password: %w", err)
-			}
-			existingUser.Password = hashedPassword
-		}
 
 		// Save the updated user
 		_, err = s.userService.UpdateUser(ctx, *existingUser)
 		if err != nil {
-			return false, fmt.Errorf("failed to update user: %w", err)
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		// A new password goes through the password policy
+		if userUpdates.Password != "" {
+			if err := s.userService.SetPassword(ctx, existingUser.ID, userUpdates.Password); err != nil {
+				return fmt.Errorf("failed to update password: %w", err)
+			}
 		}
 	}
 
-	return true, nil
+	return nil
 }
 
 // DeleteEmployee deletes an employee by their ID.
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+// ErrInvalidEmploymentChange is returned when a transfer or termination conflicts with the
+// employment history of the employee.
+var ErrInvalidEmploymentChange = errors.New("invalid employment change")
+
+// EmploymentHistory is the hire, termination and assignments of an employee.
+type EmploymentHistory struct {
+	EmployeeID        uint                          `json:"employee_id"`
+	HireDate          *types.DateOnly               `json:"hire_date"`
+	TerminationDate   *types.DateOnly               `json:"termination_date"`
+	TerminationReason string                        `json:"termination_reason"`
+	Assignments       []models.EmploymentAssignment `json:"assignments"`
+}
+
+// EmploymentService defines the interface for hires, transfers and terminations.
+type EmploymentService interface {
+	GetEmploymentHistory(ctx context.Context, employeeID uint) (*EmploymentHistory, error)
+	TransferEmployee(ctx context.Context, employeeID uint, request types.TransferRequest, actorID uint) (*models.EmploymentAssignment, error)
+	TerminateEmployee(ctx context.Context, employeeID uint, request types.TerminationRequest) (*models.Employee, error)
+	ReinstateEmployee(ctx context.Context, employeeID uint) (*models.Employee, error)
+	// SyncCurrentAssignments applies the transfers that became effective to the employees.
+	SyncCurrentAssignments(ctx context.Context) (int, error)
+}
+
+// employmentService implements the EmploymentService interface.
+type employmentService struct {
+	employmentRepo  repositories.EmploymentRepository
+	employeeRepo    repositories.EmployeeRepository
+	companyRepo     repositories.CompanyRepository
+	periodService   PayrollPeriodService
+	identityService DeviceIdentityService
+}
+
+// NewEmploymentService creates a new instance of EmploymentService.
+func NewEmploymentService(employmentRepo repositories.EmploymentRepository,
+	employeeRepo repositories.EmployeeRepository,
+	companyRepo repositories.CompanyRepository,
+	periodService PayrollPeriodService,
+	identityService DeviceIdentityService) EmploymentService {
+	return &employmentService{
+		employmentRepo:  employmentRepo,
+		employeeRepo:    employeeRepo,
+		companyRepo:     companyRepo,
+		periodService:   periodService,
+		identityService: identityService,
+	}
+}
+
+// GetEmploymentHistory retrieves the employment history of an employee.
+func (s *employmentService) GetEmploymentHistory(ctx context.Context, employeeID uint) (*EmploymentHistory, error) {
+	employee, err := s.getEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.employmentRepo.ListAssignments(ctx, employee.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &EmploymentHistory{
+		EmployeeID:        employee.ID,
+		HireDate:          employee.HireDate,
+		TerminationDate:   employee.TerminationDate,
+		TerminationReason: employee.TerminationReason,
+		Assignments:       assignments,
+	}, nil
+}
+
+// TransferEmployee ends the latest assignment of an employee the day before the effective date and
+// starts a new one with the requested company and position. Transfers can only be appended after
+// the latest change, and not into a closed payroll period of either company.
+func (s *employmentService) TransferEmployee(ctx context.Context, employeeID uint, request types.TransferRequest, actorID uint) (*models.EmploymentAssignment, error) {
+	employee, err := s.getEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	effective := request.EffectiveDate.ToTime()
+	if effective.IsZero() {
+		return nil, fmt.Errorf("%w: effective date is required", ErrInvalidEmploymentChange)
+	}
+
+	assignments, err := s.employmentRepo.ListAssignments(ctx, employee.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(assignments) == 0 {
+		return nil, fmt.Errorf("%w: employee has no employment history", ErrInvalidEmploymentChange)
+	}
+	current := assignments[len(assignments)-1]
+	if !effective.After(current.EffectiveFrom.ToTime()) {
+		return nil, fmt.Errorf("%w: the transfer must take effect after %s, the start of the current assignment",
+			ErrInvalidEmploymentChange, current.EffectiveFrom.ToTime().Format("2006-01-02"))
+	}
+	if current.EffectiveTo != nil && effective.After(current.EffectiveTo.ToTime()) {
+		return nil, fmt.Errorf("%w: the employment ends on %s", ErrInvalidEmploymentChange,
+			current.EffectiveTo.ToTime().Format("2006-01-02"))
+	}
+
+	companyID := request.CompanyID
+	if companyID == 0 {
+		companyID = current.CompanyID
+	}
+	qualification := strings.TrimSpace(request.Qualification)
+	if qualification == "" {
+		qualification = current.Qualification
+	}
+	if companyID == current.CompanyID && qualification == current.Qualification {
+		return nil, fmt.Errorf("%w: the company and position are unchanged", ErrInvalidEmploymentChange)
+	}
+	company, err := s.companyRepo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if company == nil {
+		return nil, fmt.Errorf("%w: company not found", ErrInvalidEmploymentChange)
+	}
+
+	for _, id := range []uint{current.CompanyID, companyID} {
+		if err := s.periodService.EnsureDateOpen(ctx, id, effective); err != nil {
+			return nil, err
+		}
+	}
+
+	next := &models.EmploymentAssignment{
+		EmployeeID:    employee.ID,
+		CompanyID:     companyID,
+		Qualification: qualification,
+		EffectiveFrom: types.DateOnly(truncateToDay(effective)),
+		EffectiveTo:   current.EffectiveTo,
+		Kind:          models.EmploymentKindTransfer,
+		Reason:        strings.TrimSpace(request.Reason),
+	}
+	if actorID != 0 {
+		next.CreatedBy = &actorID
+	}
+	dayBefore := types.DateOnly(truncateToDay(effective).AddDate(0, 0, -1))
+	current.EffectiveTo = &dayBefore
+	if err := s.employmentRepo.Transfer(ctx, &current, next); err != nil {
+		return nil, err
+	}
+
+	// Transfers effective today or earlier apply right away, later ones when they take effect.
+	if !effective.After(time.Now()) {
+		if _, err := s.SyncCurrentAssignments(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return next, nil
+}
+
+// TerminateEmployee records the last day of employment and its reason. The employee drops out of
+// the workdays after that date.
+func (s *employmentService) TerminateEmployee(ctx context.Context, employeeID uint, request types.TerminationRequest) (*models.Employee, error) {
+	employee, err := s.getEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if employee.TerminationDate != nil {
+		return nil, fmt.Errorf("%w: employee is already terminated, reinstate them first", ErrInvalidEmploymentChange)
+	}
+	date := request.Date.ToTime()
+	if date.IsZero() {
+		return nil, fmt.Errorf("%w: termination date is required", ErrInvalidEmploymentChange)
+	}
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: termination reason is required", ErrInvalidEmploymentChange)
+	}
+	if employee.HireDate != nil && date.Before(employee.HireDate.ToTime()) {
+		return nil, fmt.Errorf("%w: termination date is before the hire date", ErrInvalidEmploymentChange)
+	}
+
+	assignment, err := s.employmentRepo.GetAssignmentAt(ctx, employee.ID, date)
+	if err != nil {
+		return nil, err
+	}
+	if assignment == nil {
+		return nil, fmt.Errorf("%w: employee is not employed on %s", ErrInvalidEmploymentChange, date.Format("2006-01-02"))
+	}
+	if err := s.periodService.EnsureDateOpen(ctx, assignment.CompanyID, date); err != nil {
+		return nil, err
+	}
+
+	terminationDate := types.DateOnly(truncateToDay(date))
+	employee.TerminationDate = &terminationDate
+	employee.TerminationReason = reason
+	if err := s.employmentRepo.Terminate(ctx, employee); err != nil {
+		return nil, err
+	}
+	return employee, nil
+}
+
+// ReinstateEmployee cancels the termination of an employee.
+func (s *employmentService) ReinstateEmployee(ctx context.Context, employeeID uint) (*models.Employee, error) {
+	employee, err := s.getEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if employee.TerminationDate == nil {
+		return nil, fmt.Errorf("%w: employee is not terminated", ErrInvalidEmploymentChange)
+	}
+	if err := s.periodService.EnsureDateOpen(ctx, employee.CompanyID, employee.TerminationDate.ToTime()); err != nil {
+		return nil, err
+	}
+	if err := s.employmentRepo.Reinstate(ctx, employee); err != nil {
+		return nil, err
+	}
+	return employee, nil
+}
+
+// SyncCurrentAssignments copies today's assignment into every outdated employee and maps their
+// registration number in their new company.
+func (s *employmentService) SyncCurrentAssignments(ctx context.Context) (int, error) {
+	changed, err := s.employmentRepo.SyncCurrentAssignments(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	for _, employee := range changed {
+		if err := s.identityService.SyncEmployee(ctx, employee); err != nil {
+			return len(changed), err
+		}
+	}
+	return len(changed), nil
+}
+
+func (s *employmentService) getEmployee(ctx context.Context, employeeID uint) (*models.Employee, error) {
+	employee, err := s.employeeRepo.GetEmployeeByID(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		return nil, errors.New("employee not found")
+	}
+	return employee, nil
+}
+
+// StartEmploymentSyncJob creates the missing first assignments, then applies the transfers that
+// become effective every hour in the background.
+func StartEmploymentSyncJob(repo repositories.EmploymentRepository, service EmploymentService) {
+	if created, err := repo.EnsureInitialAssignments(context.Background()); err != nil {
+		log.Printf("employment history initialization failed: %v", err)
+	} else if created > 0 {
+		log.Printf("employment history initialized for %d employees", created)
+	}
+
+	go func() {
+		for {
+			changed, err := service.SyncCurrentAssignments(context.Background())
+			if err != nil {
+				log.Printf("employment assignment sync failed: %v", err)
+			} else if changed > 0 {
+				log.Printf("employment assignments applied to %d employees", changed)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+}
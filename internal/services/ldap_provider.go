@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/rbac"
+	"point-system-api/internal/repositories"
+)
+
+// LDAPConfig configures the authentication of users against an LDAP directory or Active Directory.
+type LDAPConfig struct {
+	URL                string // ldap://host:389 or ldaps://host:636
+	StartTLS           bool
+	InsecureSkipVerify bool
+	// BindDN and BindPassword authenticate the search of users; empty for an anonymous search.
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter finds the entry of a user, %s standing for the escaped username, such as
+	// (&(objectClass=person)(uid=%s)) or (sAMAccountName=%s) on Active Directory.
+	UserFilter         string
+	FirstNameAttribute string
+	LastNameAttribute  string
+	GroupAttribute     string // Attribute of user entries listing the DNs of their groups
+	// GroupRoles maps group DNs, in lower case, to the role of their members. Members of several
+	// groups get the most privileged role. Without mapping, users keep the role of their account.
+	GroupRoles  map[string]string
+	DefaultRole string // Role of users of no mapped group, refused when empty
+	CreateUsers bool   // Create the account of directory users on their first login
+	Timeout     time.Duration
+}
+
+// DefaultLDAPConfig returns the configuration completed by the environment.
+func DefaultLDAPConfig() LDAPConfig {
+	return LDAPConfig{
+		UserFilter:         "(&(objectClass=person)(uid=%s))",
+		FirstNameAttribute: "givenName",
+		LastNameAttribute:  "sn",
+		GroupAttribute:     "memberOf",
+		DefaultRole:        rbac.RoleEmployee,
+		CreateUsers:        true,
+		Timeout:            10 * time.Second,
+	}
+}
+
+// LoadLDAPConfigFromEnv reads LDAP_URL, LDAP_START_TLS, LDAP_INSECURE_SKIP_VERIFY, LDAP_BIND_DN,
+// LDAP_BIND_PASSWORD, LDAP_BASE_DN, LDAP_USER_FILTER, LDAP_FIRST_NAME_ATTRIBUTE,
+// LDAP_LAST_NAME_ATTRIBUTE, LDAP_GROUP_ATTRIBUTE, LDAP_GROUP_ROLES ("<group DN>:<role>;..."),
+// LDAP_DEFAULT_ROLE and LDAP_CREATE_USERS over the defaults. It returns nil without LDAP_URL.
+func LoadLDAPConfigFromEnv() (*LDAPConfig, error) {
+	config := DefaultLDAPConfig()
+	if config.URL = os.Getenv("LDAP_URL"); config.URL == "" {
+		return nil, nil
+	}
+	for key, target := range map[string]*string{
+		"LDAP_BIND_DN":              &config.BindDN,
+		"LDAP_BIND_PASSWORD":        &config.BindPassword,
+		"LDAP_BASE_DN":              &config.BaseDN,
+		"LDAP_USER_FILTER":          &config.UserFilter,
+		"LDAP_FIRST_NAME_ATTRIBUTE": &config.FirstNameAttribute,
+		"LDAP_LAST_NAME_ATTRIBUTE":  &config.LastNameAttribute,
+		"LDAP_GROUP_ATTRIBUTE":      &config.GroupAttribute,
+	} {
+		if value := os.Getenv(key); value != "" {
+			*target = value
+		}
+	}
+	for key, target := range map[string]*bool{
+		"LDAP_START_TLS":            &config.StartTLS,
+		"LDAP_INSECURE_SKIP_VERIFY": &config.InsecureSkipVerify,
+		"LDAP_CREATE_USERS":         &config.CreateUsers,
+	} {
+		if value := os.Getenv(key); value != "" {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %q", key, value)
+			}
+			*target = enabled
+		}
+	}
+	if value, ok := os.LookupEnv("LDAP_DEFAULT_ROLE"); ok {
+		config.DefaultRole = value
+	}
+	if value := os.Getenv("LDAP_GROUP_ROLES"); value != "" {
+		config.GroupRoles = map[string]string{}
+		for _, mapping := range strings.Split(value, ";") {
+			group, role, ok := cutLast(strings.TrimSpace(mapping), ":")
+			if !ok || group == "" {
+				return nil, fmt.Errorf("invalid LDAP_GROUP_ROLES entry %q", mapping)
+			}
+			config.GroupRoles[strings.ToLower(group)] = role
+		}
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// validate checks the filter and the roles of the configuration.
+func (c *LDAPConfig) validate() error {
+	if strings.Count(c.UserFilter, "%s") != 1 {
+		return fmt.Errorf("invalid LDAP user filter %q: it must contain %%s once", c.UserFilter)
+	}
+	roles := []string{c.DefaultRole}
+	for _, role := range c.GroupRoles {
+		roles = append(roles, role)
+	}
+	for _, role := range roles {
+		if role != "" && roleRank(role) == 0 {
+			return fmt.Errorf("invalid LDAP role mapping: unknown role %q", role)
+		}
+	}
+	return nil
+}
+
+// ldapAuthProvider authenticates users by binding to the directory with their entry and password.
+type ldapAuthProvider struct {
+	config   LDAPConfig
+	userRepo repositories.UserRepository
+}
+
+// NewLDAPAuthProvider creates the provider of the users of an LDAP directory.
+func NewLDAPAuthProvider(config LDAPConfig, userRepo repositories.UserRepository) (AuthProvider, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &ldapAuthProvider{config: config, userRepo: userRepo}, nil
+}
+
+// Name identifies the provider.
+func (p *ldapAuthProvider) Name() string { return models.AuthProviderLDAP }
+
+// Authenticate finds the entry of username, binds with it and password, and maps its groups to a
+// role. The account of the user is created on their first login, and their role follows their
+// groups afterwards.
+func (p *ldapAuthProvider) Authenticate(ctx context.Context, username, password string, user *models.User) (*models.User, error) {
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+	if user == nil && !p.config.CreateUsers {
+		return nil, ErrInvalidCredentials
+	}
+
+	entry, err := p.bind(username, password)
+	if err != nil {
+		return nil, err
+	}
+	role := p.role(entry)
+	if role == "" {
+		slog.Warn("directory user in no group granting a role", "username", username, "dn", entry.DN)
+		return nil, fmt.Errorf("%w: no role granted by the directory", ErrInvalidCredentials)
+	}
+
+	if user == nil {
+		created := models.User{
+			Username:     username,
+			FirstName:    entry.GetAttributeValue(p.config.FirstNameAttribute),
+			LastName:     entry.GetAttributeValue(p.config.LastNameAttribute),
+			Role:         role,
+			AuthProvider: models.AuthProviderLDAP,
+		}
+		// The account gets an unusable random password: the directory checks it.
+		if created.Password, err = randomToken(32); err != nil {
+			return nil, err
+		}
+		if _, err := p.userRepo.CreateUser(ctx, created); err != nil {
+			return nil, err
+		}
+		return p.userRepo.GetUserByUsername(ctx, username)
+	}
+
+	if len(p.config.GroupRoles) > 0 && user.Role != role {
+		if _, err := p.userRepo.UpdateUserRole(ctx, user.ID, role); err != nil {
+			return nil, err
+		}
+		user.Role = role
+	}
+	return user, nil
+}
+
+// bind returns the entry of username once the directory accepted password for it.
+func (p *ldapAuthProvider) bind(username, password string) (*ldap.Entry, error) {
+	conn, err := ldap.DialURL(p.config.URL, ldap.DialWithDialer(&net.Dialer{Timeout: p.config.Timeout}),
+		ldap.DialWithTLSConfig(&tls.Config{InsecureSkipVerify: p.config.InsecureSkipVerify}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the directory: %w", err)
+	}
+	defer conn.Close()
+	conn.SetTimeout(p.config.Timeout)
+
+	if p.config.StartTLS {
+		if err := conn.StartTLS(&tls.Config{InsecureSkipVerify: p.config.InsecureSkipVerify}); err != nil {
+			return nil, fmt.Errorf("failed to start TLS with the directory: %w", err)
+		}
+	}
+	if p.config.BindDN != "" {
+		if err := conn.Bind(p.config.BindDN, p.config.BindPassword); err != nil {
+			return nil, fmt.Errorf("failed to bind to the directory: %w", err)
+		}
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		p.config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(p.config.Timeout.Seconds()), false,
+		fmt.Sprintf(p.config.UserFilter, ldap.EscapeFilter(username)),
+		[]string{p.config.FirstNameAttribute, p.config.LastNameAttribute, p.config.GroupAttribute},
+		nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("failed to search the directory: %w", err)
+	}
+	if result == nil || len(result.Entries) != 1 {
+		// Unknown, or ambiguous: the filter must match one entry.
+		return nil, fmt.Errorf("%w: user not found in the directory", ErrInvalidCredentials)
+	}
+
+	entry := result.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to bind to the directory: %w", err)
+	}
+	return entry, nil
+}
+
+// role maps the groups of an entry to the most privileged role they grant, or the default role.
+func (p *ldapAuthProvider) role(entry *ldap.Entry) string {
+	role := ""
+	for _, group := range entry.GetAttributeValues(p.config.GroupAttribute) {
+		if mapped, ok := p.config.GroupRoles[strings.ToLower(group)]; ok && roleRank(mapped) > roleRank(role) {
+			role = mapped
+		}
+	}
+	if role == "" {
+		role = p.config.DefaultRole
+	}
+	return role
+}
+
+// roleRank orders the roles by privilege, 0 for unknown roles.
+func roleRank(role string) int {
+	switch role {
+	case rbac.RoleEmployee:
+		return 1
+	case rbac.RoleManager:
+		return 2
+	case rbac.RoleSuperAdmin:
+		return 3
+	}
+	return 0
+}
+
+// cutLast slices s around the last separator.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
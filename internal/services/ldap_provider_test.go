@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/rbac"
+	"point-system-api/internal/repositories"
+	"point-system-api/pkg/utils"
+)
+
+// testDirectory is an in-process LDAP server answering simple binds and searches with and, or,
+// not, equality and presence filters.
+type testDirectory struct {
+	entries map[string]testEntry // By DN
+}
+
+type testEntry struct {
+	password   string
+	attributes map[string][]string
+}
+
+// start serves the directory on a local port until the test ends and returns its URL.
+func (d *testDirectory) start(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(conn)
+		}
+	}()
+	return "ldap://" + listener.Addr().String()
+}
+
+func (d *testDirectory) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		request, err := ber.ReadPacket(conn)
+		if err != nil || len(request.Children) < 2 {
+			return
+		}
+		id, _ := request.Children[0].Value.(int64)
+		op := request.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			code := ldap.LDAPResultInvalidCredentials
+			if entry, ok := d.entries[op.Children[1].Data.String()]; ok && entry.password == op.Children[2].Data.String() {
+				code = ldap.LDAPResultSuccess
+			}
+			conn.Write(ldapMessage(id, ldapResult(ldap.ApplicationBindResponse, code)).Bytes())
+		case ldap.ApplicationSearchRequest:
+			base := strings.ToLower(op.Children[0].Data.String())
+			for dn, entry := range d.entries {
+				if strings.HasSuffix(strings.ToLower(dn), base) && entry.attributes != nil && matchesFilter(op.Children[6], entry.attributes) {
+					conn.Write(ldapMessage(id, searchEntry(dn, entry.attributes)).Bytes())
+				}
+			}
+			conn.Write(ldapMessage(id, ldapResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess)).Bytes())
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func ldapMessage(id int64, op *ber.Packet) *ber.Packet {
+	message := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	message.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "Message ID"))
+	message.AppendChild(op)
+	return message
+}
+
+func ldapResult(tag ber.Tag, code int) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "Result Code"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return result
+}
+
+func searchEntry(dn string, attributes map[string][]string) *ber.Packet {
+	entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Entry")
+	entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "DN"))
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+	for name, values := range attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		attribute.AppendChild(set)
+		list.AppendChild(attribute)
+	}
+	entry.AppendChild(list)
+	return entry
+}
+
+func matchesFilter(filter *ber.Packet, attributes map[string][]string) bool {
+	values := func(name string) []string {
+		for attribute, values := range attributes {
+			if strings.EqualFold(attribute, name) {
+				return values
+			}
+		}
+		return nil
+	}
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matchesFilter(child, attributes) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if matchesFilter(child, attributes) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return !matchesFilter(filter.Children[0], attributes)
+	case ldap.FilterEqualityMatch:
+		for _, value := range values(filter.Children[0].Data.String()) {
+			if strings.EqualFold(value, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterPresent:
+		return len(values(filter.Data.String())) > 0
+	}
+	return false
+}
+
+// memoryUserRepository keeps users in memory for the authentication tests.
+type memoryUserRepository struct {
+	repositories.UserRepository
+	users map[string]*models.User
+}
+
+func (r *memoryUserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	if user, ok := r.users[username]; ok {
+		copy := *user
+		return &copy, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	for _, user := range r.users {
+		if user.ID == id {
+			copy := *user
+			return &copy, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email != "" && strings.EqualFold(user.Email, email) {
+			copy := *user
+			return &copy, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryUserRepository) CreateUser(ctx context.Context, user models.User) (uint, error) {
+	user.ID = uint(len(r.users) + 1)
+	r.users[user.Username] = &user
+	return user.ID, nil
+}
+
+func (r *memoryUserRepository) UpdateUserRole(ctx context.Context, id uint, role string) (bool, error) {
+	for _, user := range r.users {
+		if user.ID == id {
+			user.Role = role
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestLDAPAuthentication(t *testing.T) {
+	const (
+		managers = "cn=Managers,ou=groups,dc=example,dc=com"
+		staff    = "cn=staff,ou=groups,dc=example,dc=com"
+	)
+	directory := &testDirectory{entries: map[string]testEntry{
+		"cn=search,dc=example,dc=com": {password: "search-secret"},
+		"uid=alice,ou=people,dc=example,dc=com": {password: "alice-secret", attributes: map[string][]string{
+			"objectClass": {"person"}, "uid": {"alice"}, "givenName": {"Alice"}, "sn": {"Martin"},
+			"memberOf": {staff, managers},
+		}},
+		"uid=bob,ou=people,dc=example,dc=com": {password: "bob-secret", attributes: map[string][]string{
+			"objectClass": {"person"}, "uid": {"bob"}, "givenName": {"Bob"}, "sn": {"Durand"},
+		}},
+	}}
+
+	config := DefaultLDAPConfig()
+	config.URL = directory.start(t)
+	config.BindDN, config.BindPassword = "cn=search,dc=example,dc=com", "search-secret"
+	config.BaseDN = "dc=example,dc=com"
+	config.GroupRoles = map[string]string{strings.ToLower(managers): rbac.RoleManager, staff: rbac.RoleEmployee}
+	config.DefaultRole = ""
+	config.Timeout = 5 * time.Second
+
+	repo := &memoryUserRepository{users: map[string]*models.User{}}
+	hash, err := utils.HashPassword("local-secret-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.users["carol"] = &models.User{ID: 100, Username: "carol", Password: hash, Role: rbac.RoleEmployee, AuthProvider: models.AuthProviderLocal}
+	provider, err := NewLDAPAuthProvider(config, repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := NewUserService(repo, nil, nil, nil, DefaultPasswordPolicy(), provider)
+	ctx := context.Background()
+
+	// The first login creates the account, with the most privileged role of the groups.
+	user, err := users.AuthenticateUser(ctx, "alice", "alice-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.AuthProvider != models.AuthProviderLDAP || user.Role != rbac.RoleManager || user.FirstName != "Alice" || user.LastName != "Martin" {
+		t.Errorf("created user: got %+v", user)
+	}
+	if user.HasLocalPassword() {
+		t.Error("directory user has a local password")
+	}
+
+	// The role follows the groups at every login.
+	directory.entries["uid=alice,ou=people,dc=example,dc=com"].attributes["memberOf"] = []string{staff}
+	if user, err = users.AuthenticateUser(ctx, "alice", "alice-secret"); err != nil || user.Role != rbac.RoleEmployee {
+		t.Errorf("after leaving the managers: got %+v, %v", user, err)
+	}
+
+	for _, check := range []struct{ username, password string }{
+		{"alice", "wrong"},
+		{"alice", ""},
+		{"bob", "bob-secret"}, // In no mapped group, and there is no default role
+		{"nobody", "secret"},
+		{"*", "alice-secret"}, // The username is escaped in the filter
+		{"carol", "alice-secret"},
+	} {
+		if _, err := users.AuthenticateUser(ctx, check.username, check.password); !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%s/%s: got %v, want invalid credentials", check.username, check.password, err)
+		}
+	}
+
+	// Local users keep logging in with their stored password.
+	if user, err := users.AuthenticateUser(ctx, "carol", "local-secret-1"); err != nil || user.ID != 100 {
+		t.Errorf("local user: got %+v, %v", user, err)
+	}
+}
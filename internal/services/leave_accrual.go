@@ -0,0 +1,188 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/types"
+)
+
+// ledgerKindOrder orders ledger lines falling on the same day: the year is closed before
+// anything else happens on January 1st, and days are earned before they are spent.
+var ledgerKindOrder = map[string]int{
+	types.LedgerRollover:       0,
+	types.LedgerExpiry:         1,
+	types.LedgerAccrual:        2,
+	types.LedgerSeniorityBonus: 3,
+	types.LedgerAdjustment:     4,
+	types.LedgerUsage:          5,
+}
+
+// buildLeaveLedger computes the movements of a leave balance from the hire date up to asOf.
+//
+// Accruals are earned on each monthly anniversary of the hire date, together with a twelfth of
+// the seniority bonus reached at that date. Approved leave is deducted on its start date. Stored
+// adjustments and rollovers are applied on their date, and the days carried by a rollover that
+// were not used before their expiry date are forfeited on that date.
+func buildLeaveLedger(policy *models.LeaveAccrualPolicy, hireDate, asOf time.Time,
+	approved []models.LeaveRequest, entries []models.LeaveBalanceEntry) []types.LeaveLedgerLine {
+	var lines []types.LeaveLedgerLine
+	asOf = truncateToDay(asOf)
+	hireDate = truncateToDay(hireDate)
+
+	if policy != nil {
+		for n := 1; ; n++ {
+			date := addMonthsClamped(hireDate, n)
+			if date.After(asOf) {
+				break
+			}
+			if policy.MonthlyAccrual != 0 {
+				lines = append(lines, types.LeaveLedgerLine{
+					Date: types.DateOnly(date),
+					Kind: types.LedgerAccrual,
+					Days: policy.MonthlyAccrual,
+				})
+			}
+			if bonus := seniorityBonusAt(policy.SeniorityBonuses, n/12); bonus != 0 {
+				lines = append(lines, types.LeaveLedgerLine{
+					Date: types.DateOnly(date),
+					Kind: types.LedgerSeniorityBonus,
+					Days: bonus / 12,
+				})
+			}
+		}
+	}
+
+	for _, request := range approved {
+		start := truncateToDay(request.StartDate.ToTime())
+		if start.After(asOf) {
+			continue
+		}
+		lines = append(lines, types.LeaveLedgerLine{
+			Date:           types.DateOnly(start),
+			Kind:           types.LedgerUsage,
+			Days:           -request.Days,
+			Reason:         request.Reason,
+			LeaveRequestID: request.ID,
+		})
+	}
+
+	for _, entry := range entries {
+		date := truncateToDay(entry.Date.ToTime())
+		if date.After(asOf) {
+			continue
+		}
+		kind := types.LedgerAdjustment
+		if entry.Kind == models.LeaveBalanceEntryRollover {
+			kind = types.LedgerRollover
+		}
+		lines = append(lines, types.LeaveLedgerLine{
+			Date:    types.DateOnly(date),
+			Kind:    kind,
+			Days:    entry.Days,
+			Reason:  entry.Reason,
+			EntryID: entry.ID,
+		})
+
+		if kind != types.LedgerRollover || entry.ExpiresOn == nil || entry.CarriedDays <= 0 {
+			continue
+		}
+		expiresOn := truncateToDay(entry.ExpiresOn.ToTime())
+		if expiresOn.After(asOf) {
+			continue
+		}
+		// Leave taken after the rollover consumes the carried days first.
+		remaining := entry.CarriedDays
+		for _, request := range approved {
+			start := truncateToDay(request.StartDate.ToTime())
+			if !start.Before(date) && start.Before(expiresOn) {
+				remaining -= request.Days
+			}
+		}
+		if remaining > 0 {
+			lines = append(lines, types.LeaveLedgerLine{
+				Date:    types.DateOnly(expiresOn),
+				Kind:    types.LedgerExpiry,
+				Days:    -remaining,
+				Reason:  "carried-over days expired",
+				EntryID: entry.ID,
+			})
+		}
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		di, dj := lines[i].Date.ToTime(), lines[j].Date.ToTime()
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return ledgerKindOrder[lines[i].Kind] < ledgerKindOrder[lines[j].Kind]
+	})
+
+	balance := 0.0
+	for i := range lines {
+		lines[i].Days = roundDays(lines[i].Days)
+		balance += lines[i].Days
+		lines[i].Balance = roundDays(balance)
+	}
+	return lines
+}
+
+// summarizeLeaveLedger totals the lines of a ledger into a balance.
+func summarizeLeaveLedger(lines []types.LeaveLedgerLine) types.LeaveBalance {
+	var summary types.LeaveBalance
+	for _, line := range lines {
+		switch line.Kind {
+		case types.LedgerAccrual, types.LedgerSeniorityBonus:
+			summary.Accrued += line.Days
+		case types.LedgerUsage:
+			summary.Used -= line.Days
+		case types.LedgerAdjustment:
+			summary.Adjusted += line.Days
+		case types.LedgerRollover, types.LedgerExpiry:
+			summary.Expired -= line.Days
+		}
+	}
+	summary.Accrued = roundDays(summary.Accrued)
+	summary.Used = roundDays(summary.Used)
+	summary.Adjusted = roundDays(summary.Adjusted)
+	summary.Expired = roundDays(summary.Expired)
+	if len(lines) > 0 {
+		summary.Balance = lines[len(lines)-1].Balance
+	}
+	return summary
+}
+
+// seniorityBonusAt returns the yearly bonus of the highest tier reached after the given years of service.
+func seniorityBonusAt(bonuses []models.LeaveSeniorityBonus, years int) float64 {
+	bonus, reached := 0.0, -1
+	for _, tier := range bonuses {
+		if tier.AfterYears <= years && tier.AfterYears > reached {
+			bonus, reached = tier.ExtraDaysPerYear, tier.AfterYears
+		}
+	}
+	return bonus
+}
+
+// addMonthsClamped adds months to a date, clamping the day to the end of shorter months
+// (an employee hired on January 31st accrues on February 28th).
+func addMonthsClamped(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location()).AddDate(0, months, 0)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := date.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, date.Location())
+}
+
+// truncateToDay drops the time of day of t.
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// roundDays rounds a number of days to two decimals.
+func roundDays(days float64) float64 {
+	return math.Round(days*100) / 100
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/types"
+)
+
+func mkDate(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestBuildLeaveLedgerAccrualAndUsage(t *testing.T) {
+	policy := &models.LeaveAccrualPolicy{MonthlyAccrual: 2}
+	approved := []models.LeaveRequest{
+		{StartDate: types.DateOnly(mkDate(2024, 3, 4)), Days: 3},
+		{StartDate: types.DateOnly(mkDate(2024, 9, 2)), Days: 5}, // after asOf, ignored
+	}
+	lines := buildLeaveLedger(policy, mkDate(2024, 1, 31), mkDate(2024, 5, 31), approved, nil)
+
+	// Accruals on Feb 29, Mar 31, Apr 30 and May 31, usage on Mar 4.
+	if len(lines) != 5 {
+		t.Fatalf("got %d lines, want 5: %+v", len(lines), lines)
+	}
+	if got := lines[0].Date.ToTime(); !got.Equal(mkDate(2024, 2, 29)) {
+		t.Errorf("first accrual on %v, want 2024-02-29", got)
+	}
+	if lines[1].Kind != types.LedgerUsage || lines[1].Balance != -1 {
+		t.Errorf("second line = %+v, want usage leaving -1", lines[1])
+	}
+	summary := summarizeLeaveLedger(lines)
+	if summary.Accrued != 8 || summary.Used != 3 || summary.Balance != 5 {
+		t.Errorf("summary = %+v, want accrued 8, used 3, balance 5", summary)
+	}
+}
+
+func TestBuildLeaveLedgerSeniorityBonus(t *testing.T) {
+	policy := &models.LeaveAccrualPolicy{
+		MonthlyAccrual: 1,
+		SeniorityBonuses: []models.LeaveSeniorityBonus{
+			{AfterYears: 1, ExtraDaysPerYear: 12},
+			{AfterYears: 5, ExtraDaysPerYear: 24},
+		},
+	}
+	lines := buildLeaveLedger(policy, mkDate(2020, 1, 1), mkDate(2021, 2, 1), nil, nil)
+
+	// 13 accruals, plus bonuses from the 12th month (1 year of service) onwards.
+	summary := summarizeLeaveLedger(lines)
+	if summary.Accrued != 15 {
+		t.Errorf("accrued = %v, want 15", summary.Accrued)
+	}
+}
+
+func TestBuildLeaveLedgerRolloverExpiry(t *testing.T) {
+	expires := types.DateOnly(mkDate(2025, 4, 1))
+	entries := []models.LeaveBalanceEntry{{
+		Date:        types.DateOnly(mkDate(2025, 1, 1)),
+		Kind:        models.LeaveBalanceEntryRollover,
+		Days:        -4,
+		CarriedDays: 5,
+		ExpiresOn:   &expires,
+		Year:        2024,
+	}}
+	approved := []models.LeaveRequest{{StartDate: types.DateOnly(mkDate(2025, 2, 10)), Days: 2}}
+	policy := &models.LeaveAccrualPolicy{MonthlyAccrual: 0.75}
+	lines := buildLeaveLedger(policy, mkDate(2024, 1, 1), mkDate(2025, 4, 30), approved, entries)
+
+	var expiry *types.LeaveLedgerLine
+	for i := range lines {
+		if lines[i].Kind == types.LedgerExpiry {
+			expiry = &lines[i]
+		}
+	}
+	if expiry == nil || expiry.Days != -3 {
+		t.Fatalf("expiry line = %+v, want -3 days", expiry)
+	}
+	// 15 monthly accruals (11.25 days) - 4 forfeited - 2 used - 3 expired.
+	if got := lines[len(lines)-1].Balance; got != 2.25 {
+		t.Errorf("final balance = %v, want 2.25", got)
+	}
+}
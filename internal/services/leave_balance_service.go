@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+// LeaveBalanceService defines the interface for leave accrual policies, balances and rollovers.
+type LeaveBalanceService interface {
+	GetAccrualPolicy(ctx context.Context, leaveTypeID uint) (*models.LeaveAccrualPolicy, error)
+	SaveAccrualPolicy(ctx context.Context, policy *models.LeaveAccrualPolicy) error
+
+	// GetBalances returns the balance of every leave type of the employee's company at asOf.
+	GetBalances(ctx context.Context, employeeID uint, asOf time.Time) ([]types.LeaveBalance, error)
+	// GetLedger returns every movement of an employee's balance of a leave type up to asOf.
+	GetLedger(ctx context.Context, employeeID, leaveTypeID uint, asOf time.Time) ([]types.LeaveLedgerLine, error)
+	// AdjustBalance records a manual change of a balance. A reason is mandatory.
+	AdjustBalance(ctx context.Context, employeeID, leaveTypeID uint, days float64, reason string, actorID uint) (*models.LeaveBalanceEntry, error)
+	// RunYearEndRollover closes the given year for every employee covered by an accrual policy,
+	// applying carry-over caps and expiry dates. Employees already rolled over are skipped, so the
+	// job can safely run more than once. The number of rollover entries created is returned.
+	RunYearEndRollover(ctx context.Context, year int) (int, error)
+}
+
+// leaveBalanceService implements the LeaveBalanceService interface.
+type leaveBalanceService struct {
+	balanceRepo      repositories.LeaveBalanceRepository
+	leaveTypeRepo    repositories.LeaveTypeRepository
+	leaveRequestRepo repositories.LeaveRequestRepository
+	employeeRepo     repositories.EmployeeRepository
+}
+
+// NewLeaveBalanceService creates a new instance of LeaveBalanceService.
+func NewLeaveBalanceService(balanceRepo repositories.LeaveBalanceRepository,
+	leaveTypeRepo repositories.LeaveTypeRepository,
+	leaveRequestRepo repositories.LeaveRequestRepository,
+	employeeRepo repositories.EmployeeRepository) LeaveBalanceService {
+	return &leaveBalanceService{
+		balanceRepo:      balanceRepo,
+		leaveTypeRepo:    leaveTypeRepo,
+		leaveRequestRepo: leaveRequestRepo,
+		employeeRepo:     employeeRepo,
+	}
+}
+
+// GetAccrualPolicy retrieves the accrual policy of a leave type.
+func (s *leaveBalanceService) GetAccrualPolicy(ctx context.Context, leaveTypeID uint) (*models.LeaveAccrualPolicy, error) {
+	return s.balanceRepo.GetAccrualPolicy(ctx, leaveTypeID)
+}
+
+// SaveAccrualPolicy validates and stores the accrual policy of a leave type.
+func (s *leaveBalanceService) SaveAccrualPolicy(ctx context.Context, policy *models.LeaveAccrualPolicy) error {
+	if policy == nil {
+		return errors.New("accrual policy is nil")
+	}
+	leaveType, err := s.leaveTypeRepo.GetLeaveTypeByID(ctx, policy.LeaveTypeID)
+	if err != nil {
+		return err
+	}
+	if leaveType == nil {
+		return errors.New("leave type not found")
+	}
+	if policy.MonthlyAccrual < 0 {
+		return errors.New("monthly accrual cannot be negative")
+	}
+	if policy.CarryOverCap != nil && *policy.CarryOverCap < 0 {
+		return errors.New("carry-over cap cannot be negative")
+	}
+	if policy.CarryOverExpiryMonths < 0 {
+		return errors.New("carry-over expiry cannot be negative")
+	}
+	seen := make(map[int]bool)
+	for _, bonus := range policy.SeniorityBonuses {
+		if bonus.AfterYears <= 0 || bonus.ExtraDaysPerYear < 0 {
+			return errors.New("seniority bonuses need a positive number of years and non-negative extra days")
+		}
+		if seen[bonus.AfterYears] {
+			return fmt.Errorf("duplicate seniority bonus after %d years", bonus.AfterYears)
+		}
+		seen[bonus.AfterYears] = true
+	}
+	return s.balanceRepo.SaveAccrualPolicy(ctx, policy)
+}
+
+// GetBalances computes the balances of an employee for each leave type of their company.
+func (s *leaveBalanceService) GetBalances(ctx context.Context, employeeID uint, asOf time.Time) ([]types.LeaveBalance, error) {
+	employee, err := s.getEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	leaveTypes, err := s.leaveTypeRepo.ListLeaveTypes(ctx, map[string]interface{}{"company_id": employee.CompanyID})
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]types.LeaveBalance, 0, len(leaveTypes))
+	for _, leaveType := range leaveTypes {
+		lines, err := s.ledgerOf(ctx, employee, leaveType.ID, asOf)
+		if err != nil {
+			return nil, err
+		}
+		pending, err := s.leaveRequestRepo.ListLeaveRequests(ctx, map[string]interface{}{
+			"employee_id":   employee.ID,
+			"leave_type_id": leaveType.ID,
+			"status":        models.LeaveStatusPending,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		balance := summarizeLeaveLedger(lines)
+		balance.LeaveTypeID = leaveType.ID
+		balance.LeaveTypeName = leaveType.Name
+		balance.LeaveTypeCode = leaveType.Code
+		for _, request := range pending {
+			balance.Pending += request.Days
+		}
+		balance.Pending = roundDays(balance.Pending)
+		balance.Available = roundDays(balance.Balance - balance.Pending)
+		balances = append(balances, balance)
+	}
+	return balances, nil
+}
+
+// GetLedger computes the ledger of an employee for a leave type.
+func (s *leaveBalanceService) GetLedger(ctx context.Context, employeeID, leaveTypeID uint, asOf time.Time) ([]types.LeaveLedgerLine, error) {
+	employee, err := s.getEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getLeaveTypeOf(ctx, employee, leaveTypeID); err != nil {
+		return nil, err
+	}
+	return s.ledgerOf(ctx, employee, leaveTypeID, asOf)
+}
+
+// AdjustBalance records a manual adjustment dated today.
+func (s *leaveBalanceService) AdjustBalance(ctx context.Context, employeeID, leaveTypeID uint, days float64, reason string, actorID uint) (*models.LeaveBalanceEntry, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("a reason is required to adjust a leave balance")
+	}
+	if days == 0 {
+		return nil, errors.New("adjustment days cannot be zero")
+	}
+	employee, err := s.getEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.getLeaveTypeOf(ctx, employee, leaveTypeID); err != nil {
+		return nil, err
+	}
+
+	entry := &models.LeaveBalanceEntry{
+		EmployeeID:  employeeID,
+		LeaveTypeID: leaveTypeID,
+		Date:        types.DateOnly(truncateToDay(time.Now())),
+		Kind:        models.LeaveBalanceEntryAdjustment,
+		Days:        days,
+		Reason:      reason,
+	}
+	if actorID != 0 {
+		entry.CreatedBy = &actorID
+	}
+	if err := s.balanceRepo.CreateBalanceEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// RunYearEndRollover closes a year for the employees of every leave type with an accrual policy.
+func (s *leaveBalanceService) RunYearEndRollover(ctx context.Context, year int) (int, error) {
+	yearEnd := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	newYear := yearEnd.AddDate(0, 0, 1)
+	if newYear.After(time.Now()) {
+		return 0, fmt.Errorf("year %d is not over yet", year)
+	}
+
+	policies, err := s.balanceRepo.ListAccrualPolicies(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for i := range policies {
+		policy := &policies[i]
+		leaveType, err := s.leaveTypeRepo.GetLeaveTypeByID(ctx, policy.LeaveTypeID)
+		if err != nil {
+			return created, err
+		}
+		if leaveType == nil {
+			continue
+		}
+		employees, err := s.employeeRepo.GetEmployeesByCompanyID(ctx, leaveType.CompanyID)
+		if err != nil {
+			return created, err
+		}
+
+		for _, employee := range employees {
+			if !hireDateOf(employee).Before(newYear) {
+				continue
+			}
+			existing, err := s.balanceRepo.GetRolloverEntry(ctx, employee.ID, leaveType.ID, year)
+			if err != nil {
+				return created, err
+			}
+			if existing != nil {
+				continue
+			}
+
+			lines, err := s.ledgerOf(ctx, employee, leaveType.ID, yearEnd)
+			if err != nil {
+				return created, err
+			}
+			entry := rolloverEntry(policy, summarizeLeaveLedger(lines).Balance, year, newYear)
+			entry.EmployeeID = employee.ID
+			entry.LeaveTypeID = leaveType.ID
+			if err := s.balanceRepo.CreateBalanceEntry(ctx, entry); err != nil {
+				return created, err
+			}
+			created++
+		}
+	}
+	return created, nil
+}
+
+// rolloverEntry builds the entry closing a year with the given balance: days above the
+// carry-over cap are forfeited and the carried days expire after the policy's delay.
+func rolloverEntry(policy *models.LeaveAccrualPolicy, balance float64, year int, newYear time.Time) *models.LeaveBalanceEntry {
+	carried := balance
+	if policy.CarryOverCap != nil && carried > *policy.CarryOverCap {
+		carried = *policy.CarryOverCap
+	}
+	if carried < 0 {
+		carried = 0
+	}
+	forfeited := 0.0
+	if balance > carried {
+		forfeited = roundDays(balance - carried)
+	}
+
+	entry := &models.LeaveBalanceEntry{
+		Date:        types.DateOnly(newYear),
+		Kind:        models.LeaveBalanceEntryRollover,
+		Days:        -forfeited,
+		CarriedDays: roundDays(carried),
+		Year:        year,
+		Reason:      fmt.Sprintf("year-end rollover %d: %.2f days carried, %.2f forfeited", year, carried, forfeited),
+	}
+	if policy.CarryOverExpiryMonths > 0 && carried > 0 {
+		expiresOn := types.DateOnly(newYear.AddDate(0, policy.CarryOverExpiryMonths, 0))
+		entry.ExpiresOn = &expiresOn
+	}
+	return entry
+}
+
+// ledgerOf loads everything a ledger is built from and builds it.
+func (s *leaveBalanceService) ledgerOf(ctx context.Context, employee *models.Employee, leaveTypeID uint, asOf time.Time) ([]types.LeaveLedgerLine, error) {
+	policy, err := s.balanceRepo.GetAccrualPolicy(ctx, leaveTypeID)
+	if err != nil {
+		return nil, err
+	}
+	approved, err := s.leaveRequestRepo.ListLeaveRequests(ctx, map[string]interface{}{
+		"employee_id":   employee.ID,
+		"leave_type_id": leaveTypeID,
+		"status":        models.LeaveStatusApproved,
+	})
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.balanceRepo.ListBalanceEntries(ctx, employee.ID, leaveTypeID)
+	if err != nil {
+		return nil, err
+	}
+	return buildLeaveLedger(policy, hireDateOf(employee), asOf, approved, entries), nil
+}
+
+// getEmployee retrieves an employee, failing when it does not exist.
+func (s *leaveBalanceService) getEmployee(ctx context.Context, employeeID uint) (*models.Employee, error) {
+	employee, err := s.employeeRepo.GetEmployeeByID(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		return nil, errors.New("employee not found")
+	}
+	return employee, nil
+}
+
+// getLeaveTypeOf retrieves a leave type, failing unless it belongs to the employee's company.
+func (s *leaveBalanceService) getLeaveTypeOf(ctx context.Context, employee *models.Employee, leaveTypeID uint) (*models.LeaveType, error) {
+	leaveType, err := s.leaveTypeRepo.GetLeaveTypeByID(ctx, leaveTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if leaveType == nil || leaveType.CompanyID != employee.CompanyID {
+		return nil, errors.New("leave type not found")
+	}
+	return leaveType, nil
+}
+
+// hireDateOf returns the hire date of an employee, falling back to the record's creation date.
+func hireDateOf(employee *models.Employee) time.Time {
+	if employee.HireDate != nil {
+		return employee.HireDate.ToTime()
+	}
+	return truncateToDay(employee.CreatedAt)
+}
+
+// StartLeaveRolloverJob runs the rollover of the previous year once a day in the background.
+// Rollovers are idempotent, so a missed or repeated run does no harm.
+func StartLeaveRolloverJob(service LeaveBalanceService) {
+	go func() {
+		for {
+			year := time.Now().Year() - 1
+			created, err := service.RunYearEndRollover(context.Background(), year)
+			if err != nil {
+				log.Printf("leave rollover for %d failed: %v", year, err)
+			} else if created > 0 {
+				log.Printf("leave rollover for %d: %d balances closed", year, created)
+			}
+			time.Sleep(24 * time.Hour)
+		}
+	}()
+}
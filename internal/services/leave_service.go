@@ -0,0 +1,525 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+// LeaveService defines the interface for leave type and leave request operations.
+type LeaveService interface {
+	CreateLeaveType(ctx context.Context, leaveType *models.LeaveType) error
+	GetLeaveTypeByID(ctx context.Context, id uint) (*models.LeaveType, error)
+	ListLeaveTypes(ctx context.Context, filters map[string]interface{}) ([]models.LeaveType, error)
+	UpdateLeaveType(ctx context.Context, leaveType *models.LeaveType) error
+	DeleteLeaveType(ctx context.Context, id uint) error
+
+	CreateLeaveRequest(ctx context.Context, request *models.LeaveRequest) error
+	GetLeaveRequestByID(ctx context.Context, id uint) (*models.LeaveRequest, error)
+	ListLeaveRequests(ctx context.Context, filters map[string]interface{}) ([]models.LeaveRequest, error)
+	ApproveLeaveRequest(ctx context.Context, id uint, reviewerID uint, comment string) (*models.LeaveRequest, error)
+	RejectLeaveRequest(ctx context.Context, id uint, reviewerID uint, comment string) (*models.LeaveRequest, error)
+	CancelLeaveRequest(ctx context.Context, id uint) (*models.LeaveRequest, error)
+
+	// ApplyApprovedLeave marks the raw attendances of a workday covered by approved leave, creating
+	// the rows of employees on leave who have none. inScope, when not nil, restricts the employees
+	// considered. The newly created rows are returned.
+	ApplyApprovedLeave(ctx context.Context, workday *models.WorkDay, inScope func(companyID, employeeID uint) bool) ([]*models.RawAttendance, error)
+}
+
+// leaveService implements the LeaveService interface.
+type leaveService struct {
+	uow               repositories.UnitOfWork
+	leaveTypeRepo     repositories.LeaveTypeRepository
+	leaveRequestRepo  repositories.LeaveRequestRepository
+	employeeRepo      repositories.EmployeeRepository
+	workDayRepo       repositories.WorkDayRepository
+	rawAttendanceRepo repositories.RawAttendanceRepository
+	employmentRepo    repositories.EmploymentRepository
+	periodService     PayrollPeriodService
+}
+
+// NewLeaveService creates a new instance of LeaveService.
+func NewLeaveService(uow repositories.UnitOfWork,
+	leaveTypeRepo repositories.LeaveTypeRepository,
+	leaveRequestRepo repositories.LeaveRequestRepository,
+	employeeRepo repositories.EmployeeRepository,
+	workDayRepo repositories.WorkDayRepository,
+	rawAttendanceRepo repositories.RawAttendanceRepository,
+	employmentRepo repositories.EmploymentRepository,
+	periodService PayrollPeriodService) LeaveService {
+	return &leaveService{
+		uow:               uow,
+		leaveTypeRepo:     leaveTypeRepo,
+		leaveRequestRepo:  leaveRequestRepo,
+		employeeRepo:      employeeRepo,
+		workDayRepo:       workDayRepo,
+		rawAttendanceRepo: rawAttendanceRepo,
+		employmentRepo:    employmentRepo,
+		periodService:     periodService,
+	}
+}
+
+// CreateLeaveType creates a new leave type for a company.
+func (s *leaveService) CreateLeaveType(ctx context.Context, leaveType *models.LeaveType) error {
+	if leaveType == nil {
+		return errors.New("leave type is nil")
+	}
+	if leaveType.CompanyID == 0 {
+		return errors.New("company ID is required")
+	}
+	if leaveType.Name == "" || leaveType.Code == "" {
+		return errors.New("name and code are required")
+	}
+
+	existing, err := s.leaveTypeRepo.GetLeaveTypeByCode(ctx, leaveType.CompanyID, leaveType.Code)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return errors.New("leave type code already exists for this company")
+	}
+
+	return s.leaveTypeRepo.CreateLeaveType(ctx, leaveType)
+}
+
+// GetLeaveTypeByID retrieves a leave type by its ID.
+func (s *leaveService) GetLeaveTypeByID(ctx context.Context, id uint) (*models.LeaveType, error) {
+	if id == 0 {
+		return nil, errors.New("invalid leave type ID")
+	}
+	return s.leaveTypeRepo.GetLeaveTypeByID(ctx, id)
+}
+
+// ListLeaveTypes retrieves leave types matching the given filters.
+func (s *leaveService) ListLeaveTypes(ctx context.Context, filters map[string]interface{}) ([]models.LeaveType, error) {
+	return s.leaveTypeRepo.ListLeaveTypes(ctx, filters)
+}
+
+// UpdateLeaveType updates the name, code and paid flag of a leave type.
+func (s *leaveService) UpdateLeaveType(ctx context.Context, leaveType *models.LeaveType) error {
+	if leaveType == nil || leaveType.ID == 0 {
+		return errors.New("invalid leave type data")
+	}
+
+	existing, err := s.leaveTypeRepo.GetLeaveTypeByID(ctx, leaveType.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("leave type not found")
+	}
+
+	if leaveType.Name != "" {
+		existing.Name = leaveType.Name
+	}
+	if leaveType.Code != "" && leaveType.Code != existing.Code {
+		duplicate, err := s.leaveTypeRepo.GetLeaveTypeByCode(ctx, existing.CompanyID, leaveType.Code)
+		if err != nil {
+			return err
+		}
+		if duplicate != nil {
+			return errors.New("leave type code already exists for this company")
+		}
+		existing.Code = leaveType.Code
+	}
+	existing.IsPaid = leaveType.IsPaid
+
+	if err := s.leaveTypeRepo.UpdateLeaveType(ctx, existing); err != nil {
+		return err
+	}
+
+	*leaveType = *existing
+	return nil
+}
+
+// DeleteLeaveType deletes a leave type by its ID.
+func (s *leaveService) DeleteLeaveType(ctx context.Context, id uint) error {
+	if id == 0 {
+		return errors.New("invalid leave type ID")
+	}
+	return s.leaveTypeRepo.DeleteLeaveType(ctx, id)
+}
+
+// CreateLeaveRequest validates and stores a new pending leave request.
+func (s *leaveService) CreateLeaveRequest(ctx context.Context, request *models.LeaveRequest) error {
+	if request == nil {
+		return errors.New("leave request is nil")
+	}
+
+	employee, err := s.employeeRepo.GetEmployeeByID(ctx, request.EmployeeID)
+	if err != nil {
+		return err
+	}
+	if employee == nil {
+		return errors.New("employee not found")
+	}
+
+	leaveType, err := s.leaveTypeRepo.GetLeaveTypeByID(ctx, request.LeaveTypeID)
+	if err != nil {
+		return err
+	}
+	if leaveType == nil || leaveType.CompanyID != employee.CompanyID {
+		return errors.New("leave type not found for the employee's company")
+	}
+
+	start, end := request.StartDate.ToTime(), request.EndDate.ToTime()
+	if start.IsZero() || end.IsZero() {
+		return errors.New("start date and end date are required")
+	}
+	if end.Before(start) {
+		return errors.New("end date must not be before start date")
+	}
+
+	request.HalfDay = strings.ToLower(strings.TrimSpace(request.HalfDay))
+	switch request.HalfDay {
+	case "":
+	case "morning", "afternoon":
+		if !start.Equal(end) {
+			return errors.New("half-day leave must start and end on the same date")
+		}
+	default:
+		return errors.New("half day must be morning or afternoon")
+	}
+
+	request.Days = countLeaveDays(start, end, request.HalfDay != "")
+	if request.Days == 0 {
+		return errors.New("leave request does not cover any working day")
+	}
+
+	overlapping, err := s.leaveRequestRepo.FindActiveLeaveRequests(ctx, request.EmployeeID, start, end, 0)
+	if err != nil {
+		return err
+	}
+	for _, other := range overlapping {
+		// Morning and afternoon leave of the same day may coexist.
+		if request.HalfDay != "" && other.HalfDay != "" && request.HalfDay != other.HalfDay {
+			continue
+		}
+		return fmt.Errorf("leave request overlaps %s request %d", other.Status, other.ID)
+	}
+
+	request.Status = models.LeaveStatusPending
+	request.ReviewedBy = nil
+	request.ReviewedAt = nil
+	request.ReviewComment = ""
+	return s.leaveRequestRepo.CreateLeaveRequest(ctx, request)
+}
+
+// GetLeaveRequestByID retrieves a leave request by its ID.
+func (s *leaveService) GetLeaveRequestByID(ctx context.Context, id uint) (*models.LeaveRequest, error) {
+	if id == 0 {
+		return nil, errors.New("invalid leave request ID")
+	}
+	return s.leaveRequestRepo.GetLeaveRequestByID(ctx, id)
+}
+
+// ListLeaveRequests retrieves leave requests matching the given filters.
+func (s *leaveService) ListLeaveRequests(ctx context.Context, filters map[string]interface{}) ([]models.LeaveRequest, error) {
+	return s.leaveRequestRepo.ListLeaveRequests(ctx, filters)
+}
+
+// ApproveLeaveRequest approves a pending request and marks the raw attendances of the existing
+// workdays it covers. A failure leaves both the request and the rows unchanged.
+func (s *leaveService) ApproveLeaveRequest(ctx context.Context, id uint, reviewerID uint, comment string) (*models.LeaveRequest, error) {
+	ctx = types.WithRawAttendanceSource(ctx, types.RawAttendanceSourceLeave)
+	var request *models.LeaveRequest
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		request, err = s.approveLeaveRequest(ctx, id, reviewerID, comment)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (s *leaveService) approveLeaveRequest(ctx context.Context, id uint, reviewerID uint, comment string) (*models.LeaveRequest, error) {
+	request, err := s.getPendingLeaveRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	employee, workdays, err := s.editableWorkDaysOf(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	request.Status = models.LeaveStatusApproved
+	request.ReviewedBy = &reviewerID
+	request.ReviewedAt = &now
+	request.ReviewComment = comment
+	if err := s.leaveRequestRepo.UpdateLeaveRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	for _, workday := range workdays {
+		assigned, err := s.employeeOnDate(ctx, employee, workday.Date.ToTime())
+		if err != nil {
+			return nil, err
+		}
+		if assigned == nil {
+			continue
+		}
+		if _, err := s.applyLeaveToRow(ctx, workday, request, assigned); err != nil {
+			return nil, err
+		}
+	}
+
+	return request, nil
+}
+
+// RejectLeaveRequest rejects a pending request.
+func (s *leaveService) RejectLeaveRequest(ctx context.Context, id uint, reviewerID uint, comment string) (*models.LeaveRequest, error) {
+	request, err := s.getPendingLeaveRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	request.Status = models.LeaveStatusRejected
+	request.ReviewedBy = &reviewerID
+	request.ReviewedAt = &now
+	request.ReviewComment = comment
+	if err := s.leaveRequestRepo.UpdateLeaveRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// CancelLeaveRequest cancels a pending or approved request. Cancelling approved leave restores the
+// attendance status of the rows it covered.
+func (s *leaveService) CancelLeaveRequest(ctx context.Context, id uint) (*models.LeaveRequest, error) {
+	ctx = types.WithRawAttendanceSource(ctx, types.RawAttendanceSourceLeave)
+	var request *models.LeaveRequest
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		request, err = s.cancelLeaveRequest(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (s *leaveService) cancelLeaveRequest(ctx context.Context, id uint) (*models.LeaveRequest, error) {
+	request, err := s.GetLeaveRequestByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, errors.New("leave request not found")
+	}
+	if request.Status != models.LeaveStatusPending && request.Status != models.LeaveStatusApproved {
+		return nil, fmt.Errorf("cannot cancel a %s leave request", request.Status)
+	}
+
+	if request.Status == models.LeaveStatusApproved {
+		_, workdays, err := s.editableWorkDaysOf(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		for _, workday := range workdays {
+			ra, err := s.rawAttendanceRepo.GetRawAttendanceByWorkDayAndUser(ctx, workday.ID, request.EmployeeID)
+			if err != nil {
+				return nil, err
+			}
+			if ra == nil || ra.LeaveRequestID == nil || *ra.LeaveRequestID != request.ID {
+				continue
+			}
+			ra.LeaveRequestID = nil
+			ra.Status = statusFromTimes(ra)
+			if err := s.rawAttendanceRepo.SaveRawAttendance(ctx, ra); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	request.Status = models.LeaveStatusCancelled
+	if err := s.leaveRequestRepo.UpdateLeaveRequest(ctx, request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// ApplyApprovedLeave marks the raw attendances of a workday covered by approved leave.
+func (s *leaveService) ApplyApprovedLeave(ctx context.Context, workday *models.WorkDay, inScope func(companyID, employeeID uint) bool) ([]*models.RawAttendance, error) {
+	requests, err := s.leaveRequestRepo.GetApprovedLeaveRequestsForDate(ctx, workday.Date.ToTime())
+	if err != nil {
+		return nil, err
+	}
+
+	created := []*models.RawAttendance{}
+	for i := range requests {
+		request := &requests[i]
+		employee, err := s.employeeRepo.GetEmployeeByIDWithUser(ctx, request.EmployeeID)
+		if err != nil {
+			return nil, err
+		}
+		if employee == nil || employee.ID == 0 {
+			continue
+		}
+		employee, err = s.employeeOnDate(ctx, employee, workday.Date.ToTime())
+		if err != nil {
+			return nil, err
+		}
+		if employee == nil {
+			continue
+		}
+		if inScope != nil && !inScope(employee.CompanyID, employee.ID) {
+			continue
+		}
+
+		ra, err := s.applyLeaveToRow(ctx, workday, request, employee)
+		if err != nil {
+			return nil, err
+		}
+		if ra != nil {
+			created = append(created, ra)
+		}
+	}
+
+	return created, nil
+}
+
+func (s *leaveService) getPendingLeaveRequest(ctx context.Context, id uint) (*models.LeaveRequest, error) {
+	request, err := s.GetLeaveRequestByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, errors.New("leave request not found")
+	}
+	if request.Status != models.LeaveStatusPending {
+		return nil, fmt.Errorf("leave request is already %s", request.Status)
+	}
+	return request, nil
+}
+
+// editableWorkDaysOf returns the existing workdays covered by a request, failing when one of them
+// can no longer be edited.
+func (s *leaveService) editableWorkDaysOf(ctx context.Context, request *models.LeaveRequest) (*types.EmployeeWithUser, []*models.WorkDay, error) {
+	employee, err := s.employeeRepo.GetEmployeeByIDWithUser(ctx, request.EmployeeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if employee == nil || employee.ID == 0 {
+		return nil, nil, errors.New("employee not found")
+	}
+
+	workdays, err := s.workDayRepo.ListWorkDaysBetween(ctx, request.StartDate.ToTime(), request.EndDate.ToTime())
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, workday := range workdays {
+		if !workday.IsEditable() {
+			return nil, nil, fmt.Errorf("%w: workday %d is %s", ErrWorkDayLocked, workday.ID, workday.Status)
+		}
+		if err := s.periodService.EnsureDateOpen(ctx, employee.CompanyID, workday.Date.ToTime()); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return employee, workdays, nil
+}
+
+// employeeOnDate returns a copy of the employee with the company and position of their assignment
+// on date, or nil when they were not employed on that date.
+func (s *leaveService) employeeOnDate(ctx context.Context, employee *types.EmployeeWithUser, date time.Time) (*types.EmployeeWithUser, error) {
+	assignment, err := s.employmentRepo.GetAssignmentAt(ctx, employee.ID, date)
+	if err != nil {
+		return nil, err
+	}
+	if assignment == nil {
+		return nil, nil
+	}
+	assigned := *employee
+	assigned.CompanyID = assignment.CompanyID
+	assigned.Qualification = assignment.Qualification
+	return &assigned, nil
+}
+
+// applyLeaveToRow sets the leave status on the employee's row for the workday, creating the row
+// when the employee has none. It returns the row only when it was created. Rows with punches keep
+// their status: half-day leave is linked to them, so that the report adds the half day to the hours
+// worked, and full-day leave leaves them untouched rather than counting the day twice.
+func (s *leaveService) applyLeaveToRow(ctx context.Context, workday *models.WorkDay, request *models.LeaveRequest, employee *types.EmployeeWithUser) (*models.RawAttendance, error) {
+	status := models.RawAttendanceStatusLeave
+	if request.HalfDay != "" {
+		status = models.RawAttendanceStatusHalfDayLeave
+	}
+
+	ra, err := s.rawAttendanceRepo.GetRawAttendanceByWorkDayAndUser(ctx, workday.ID, request.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ra != nil {
+		punched := ra.StartAt.Valid || ra.EndAt.Valid
+		linked := ra.LeaveRequestID != nil && *ra.LeaveRequestID == request.ID
+		switch {
+		case punched && request.HalfDay == "":
+			return nil, nil
+		case punched:
+			if linked {
+				return nil, nil
+			}
+		default:
+			if linked && ra.Status.String == status {
+				return nil, nil
+			}
+			ra.Status = sql.NullString{String: status, Valid: true}
+		}
+		ra.LeaveRequestID = &request.ID
+		return nil, s.rawAttendanceRepo.SaveRawAttendance(ctx, ra)
+	}
+
+	ra = &models.RawAttendance{
+		WorkDayID:          workday.ID,
+		CompanyID:          employee.CompanyID,
+		UserID:             employee.ID,
+		EmployeeName:       sql.NullString{String: employee.FirstName + " " + employee.LastName, Valid: true},
+		Position:           sql.NullString{String: employee.Qualification, Valid: employee.Qualification != ""},
+		Status:             sql.NullString{String: status, Valid: true},
+		CalculateLunchHour: true,
+		LeaveRequestID:     &request.ID,
+	}
+	if err := s.rawAttendanceRepo.CreateRawAttendance(ctx, ra); err != nil {
+		return nil, err
+	}
+	return ra, nil
+}
+
+// countLeaveDays counts the working days (Monday to Friday) in [start, end]; half-day requests count 0.5.
+func countLeaveDays(start, end time.Time, halfDay bool) float64 {
+	days := 0.0
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			days++
+		}
+	}
+	if halfDay && days > 0 {
+		return 0.5
+	}
+	return days
+}
+
+// statusFromTimes derives the attendance status of a row from its recorded times.
+func statusFromTimes(ra *models.RawAttendance) sql.NullString {
+	if ra.StartAt.Valid && ra.EndAt.Valid && ra.TotalHours.Valid && ra.TotalHours.Float64 > 0 {
+		return sql.NullString{String: "present", Valid: true}
+	}
+	return sql.NullString{String: "absent", Valid: true}
+}
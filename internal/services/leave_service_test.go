@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// leaveFixture serves the records the leave service reads for employee 7 of company 1, keeps the
+// rows it saves, and fails on any other call.
+type leaveFixture struct {
+	repositories.LeaveTypeRepository
+	repositories.LeaveRequestRepository
+	repositories.EmployeeRepository
+	repositories.WorkDayRepository
+	repositories.RawAttendanceRepository
+	repositories.EmploymentRepository
+	PayrollPeriodService
+	requests []models.LeaveRequest
+	workdays []*models.WorkDay
+	rows     map[uint]*models.RawAttendance // By workday
+}
+
+func (f *leaveFixture) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (f *leaveFixture) GetEmployeeByID(ctx context.Context, id uint) (*models.Employee, error) {
+	return &models.Employee{Model: gorm.Model{ID: id}, CompanyID: 1}, nil
+}
+
+func (f *leaveFixture) GetEmployeeByIDWithUser(ctx context.Context, id uint) (*types.EmployeeWithUser, error) {
+	return &types.EmployeeWithUser{ID: id, CompanyID: 1}, nil
+}
+
+func (f *leaveFixture) GetAssignmentAt(ctx context.Context, employeeID uint, date time.Time) (*models.EmploymentAssignment, error) {
+	return &models.EmploymentAssignment{EmployeeID: employeeID, CompanyID: 1}, nil
+}
+
+func (f *leaveFixture) GetLeaveTypeByID(ctx context.Context, id uint) (*models.LeaveType, error) {
+	return &models.LeaveType{Model: gorm.Model{ID: id}, CompanyID: 1}, nil
+}
+
+func (f *leaveFixture) CreateLeaveRequest(ctx context.Context, request *models.LeaveRequest) error {
+	request.ID = uint(len(f.requests) + 1)
+	f.requests = append(f.requests, *request)
+	return nil
+}
+
+func (f *leaveFixture) GetLeaveRequestByID(ctx context.Context, id uint) (*models.LeaveRequest, error) {
+	for _, request := range f.requests {
+		if request.ID == id {
+			return &request, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *leaveFixture) UpdateLeaveRequest(ctx context.Context, request *models.LeaveRequest) error {
+	f.requests[request.ID-1] = *request
+	return nil
+}
+
+func (f *leaveFixture) FindActiveLeaveRequests(ctx context.Context, employeeID uint, start, end time.Time, excludeID uint) ([]models.LeaveRequest, error) {
+	active := []models.LeaveRequest{}
+	for _, request := range f.requests {
+		if request.Status != models.LeaveStatusPending && request.Status != models.LeaveStatusApproved {
+			continue
+		}
+		if request.ID != excludeID && !request.StartDate.ToTime().After(end) && !request.EndDate.ToTime().Before(start) {
+			active = append(active, request)
+		}
+	}
+	return active, nil
+}
+
+func (f *leaveFixture) ListWorkDaysBetween(ctx context.Context, start, end time.Time) ([]*models.WorkDay, error) {
+	return f.workdays, nil
+}
+
+func (f *leaveFixture) EnsureDateOpen(ctx context.Context, companyID uint, date time.Time) error {
+	return nil
+}
+
+func (f *leaveFixture) GetRawAttendanceByWorkDayAndUser(ctx context.Context, workDayID uint, userID uint) (*models.RawAttendance, error) {
+	return f.rows[workDayID], nil
+}
+
+func (f *leaveFixture) SaveRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance) error {
+	f.rows[rawAttendance.WorkDayID] = rawAttendance
+	return nil
+}
+
+func (f *leaveFixture) CreateRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance) error {
+	f.rows[rawAttendance.WorkDayID] = rawAttendance
+	return nil
+}
+
+func leaveDate(value string) types.DateOnly {
+	parsed, _ := time.ParseInLocation("2006-01-02", value, time.Local)
+	return types.DateOnly(parsed)
+}
+
+func TestCreateLeaveRequestRefusesOverlaps(t *testing.T) {
+	fixture := &leaveFixture{}
+	service := NewLeaveService(fixture, fixture, fixture, fixture, fixture, fixture, fixture, fixture)
+	request := func(start, end, halfDay string) *models.LeaveRequest {
+		return &models.LeaveRequest{EmployeeID: 7, LeaveTypeID: 1, StartDate: leaveDate(start), EndDate: leaveDate(end), HalfDay: halfDay}
+	}
+
+	// Monday 4 to Friday 8 March 2024, then the morning of Monday 11.
+	for _, r := range []*models.LeaveRequest{request("2024-03-04", "2024-03-08", ""), request("2024-03-11", "2024-03-11", "morning")} {
+		if err := service.CreateLeaveRequest(context.Background(), r); err != nil {
+			t.Fatalf("CreateLeaveRequest %s: %v", r.StartDate.ToTime().Format("2006-01-02"), err)
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		request *models.LeaveRequest
+		overlap bool
+	}{
+		{"inside a week of leave", request("2024-03-06", "2024-03-06", ""), true},
+		{"across its end", request("2024-03-08", "2024-03-12", ""), true},
+		{"the same half day", request("2024-03-11", "2024-03-11", "morning"), true},
+		{"the other half day", request("2024-03-11", "2024-03-11", "afternoon"), false},
+		{"the next day", request("2024-03-12", "2024-03-12", ""), false},
+	} {
+		err := service.CreateLeaveRequest(context.Background(), tc.request)
+		if overlap := err != nil && strings.Contains(err.Error(), "overlaps"); overlap != tc.overlap {
+			t.Errorf("%s: got %v, want overlap=%v", tc.name, err, tc.overlap)
+		}
+	}
+}
+
+func TestApproveLeaveRequestKeepsPunchedRows(t *testing.T) {
+	text := func(value string) sql.NullString { return sql.NullString{String: value, Valid: true} }
+	punched := func(workDayID uint) *models.RawAttendance {
+		return &models.RawAttendance{
+			Model: gorm.Model{ID: workDayID}, WorkDayID: workDayID, UserID: 7, CompanyID: 1,
+			StartAt: text("08:00:00"), EndAt: text("12:00:00"), TotalHours: sql.NullFloat64{Float64: 4, Valid: true}, Status: text("present"),
+		}
+	}
+	fixture := &leaveFixture{
+		requests: []models.LeaveRequest{
+			{Model: gorm.Model{ID: 1}, EmployeeID: 7, StartDate: leaveDate("2024-03-04"), EndDate: leaveDate("2024-03-05"), Days: 2, Status: models.LeaveStatusPending},
+			{Model: gorm.Model{ID: 2}, EmployeeID: 7, StartDate: leaveDate("2024-03-06"), EndDate: leaveDate("2024-03-06"), HalfDay: "afternoon", Days: 0.5, Status: models.LeaveStatusPending},
+		},
+		rows: map[uint]*models.RawAttendance{2: punched(2), 3: punched(3)},
+	}
+	service := NewLeaveService(fixture, fixture, fixture, fixture, fixture, fixture, fixture, fixture)
+
+	// Full-day leave: workday 1 has no row, workday 2 has punches.
+	fixture.workdays = []*models.WorkDay{{Model: gorm.Model{ID: 1}, Date: leaveDate("2024-03-04")}, {Model: gorm.Model{ID: 2}, Date: leaveDate("2024-03-05")}}
+	if _, err := service.ApproveLeaveRequest(context.Background(), 1, 9, ""); err != nil {
+		t.Fatalf("ApproveLeaveRequest: %v", err)
+	}
+	if row := fixture.rows[1]; row == nil || row.Status.String != models.RawAttendanceStatusLeave || row.LeaveRequestID == nil {
+		t.Errorf("row without punches: got %+v, want a leave row", row)
+	}
+	if row := fixture.rows[2]; row.Status.String != "present" || row.LeaveRequestID != nil {
+		t.Errorf("punched row under full-day leave: got status %s and leave %v, want it untouched", row.Status.String, row.LeaveRequestID)
+	}
+
+	// Half-day leave is linked to the punched row, which keeps its status.
+	fixture.workdays = []*models.WorkDay{{Model: gorm.Model{ID: 3}, Date: leaveDate("2024-03-06")}}
+	if _, err := service.ApproveLeaveRequest(context.Background(), 2, 9, ""); err != nil {
+		t.Fatalf("ApproveLeaveRequest: %v", err)
+	}
+	if row := fixture.rows[3]; row.Status.String != "present" || row.LeaveRequestID == nil || *row.LeaveRequestID != 2 {
+		t.Errorf("punched row under half-day leave: got status %s and leave %v, want present linked to request 2", row.Status.String, row.LeaveRequestID)
+	}
+	if fixture.requests[1].Status != models.LeaveStatusApproved {
+		t.Errorf("half-day request is %s, want approved", fixture.requests[1].Status)
+	}
+}
+
+func TestCountLeaveDays(t *testing.T) {
+	for _, tc := range []struct {
+		start, end string
+		halfDay    bool
+		want       float64
+	}{
+		{"2024-03-04", "2024-03-08", false, 5}, // Monday to Friday
+		{"2024-03-08", "2024-03-11", false, 2}, // across a weekend
+		{"2024-03-09", "2024-03-10", false, 0}, // a weekend
+		{"2024-03-06", "2024-03-06", true, 0.5},
+		{"2024-03-09", "2024-03-09", true, 0},  // half a Saturday
+		{"2024-10-25", "2024-10-28", false, 2}, // across the end of summer time
+	} {
+		got := countLeaveDays(leaveDate(tc.start).ToTime(), leaveDate(tc.end).ToTime(), tc.halfDay)
+		if got != tc.want {
+			t.Errorf("%s to %s (half day %v): got %v, want %v", tc.start, tc.end, tc.halfDay, got, tc.want)
+		}
+	}
+}
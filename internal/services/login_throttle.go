@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/types"
+)
+
+// ErrLoginThrottled is returned when a login is refused because of the previous failures of the
+// username or the IP address.
+var ErrLoginThrottled = errors.New("too many failed login attempts")
+
+// LoginThrottledError tells when the next login attempt will be accepted.
+type LoginThrottledError struct {
+	RetryAt time.Time
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s, retry after %s", ErrLoginThrottled, e.RetryAt.Format(time.RFC3339))
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrLoginThrottled
+}
+
+// Reasons of failed logins in the login history.
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureThrottled          = "throttled"
+)
+
+// LoginThrottlePolicy slows down password guessing. Past FreeAttempts consecutive failures, each
+// attempt waits BaseDelay doubled at every further failure, up to MaxDelay. A username reaching
+// MaxUserFailures, or an IP address reaching MaxIPFailures, is locked out for LockoutDuration.
+type LoginThrottlePolicy struct {
+	FreeAttempts    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	MaxUserFailures int
+	MaxIPFailures   int
+	LockoutDuration time.Duration
+}
+
+// DefaultLoginThrottlePolicy returns the policy applied without configuration.
+func DefaultLoginThrottlePolicy() LoginThrottlePolicy {
+	return LoginThrottlePolicy{
+		FreeAttempts:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Minute,
+		MaxUserFailures: 5,
+		MaxIPFailures:   20,
+		LockoutDuration: 15 * time.Minute,
+	}
+}
+
+// LoadLoginThrottlePolicyFromEnv reads LOGIN_MAX_USER_FAILURES, LOGIN_MAX_IP_FAILURES and
+// LOGIN_LOCKOUT_DURATION over the default policy.
+func LoadLoginThrottlePolicyFromEnv() (LoginThrottlePolicy, error) {
+	policy := DefaultLoginThrottlePolicy()
+	for key, target := range map[string]*int{
+		"LOGIN_MAX_USER_FAILURES": &policy.MaxUserFailures,
+		"LOGIN_MAX_IP_FAILURES":   &policy.MaxIPFailures,
+	} {
+		if value := os.Getenv(key); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return policy, fmt.Errorf("invalid %s: %q", key, value)
+			}
+			*target = n
+		}
+	}
+	if value := os.Getenv("LOGIN_LOCKOUT_DURATION"); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return policy, fmt.Errorf("invalid LOGIN_LOCKOUT_DURATION: %w", err)
+		}
+		policy.LockoutDuration = duration
+	}
+	return policy, nil
+}
+
+// retryAt returns when the next attempt of a throttle is accepted, zero when it already is.
+func (p LoginThrottlePolicy) retryAt(throttle models.LoginThrottle) time.Time {
+	if throttle.LockedUntil != nil {
+		return *throttle.LockedUntil
+	}
+	if throttle.Failures < p.FreeAttempts || throttle.LastFailureAt == nil {
+		return time.Time{}
+	}
+	exponent := float64(throttle.Failures - p.FreeAttempts)
+	delay := time.Duration(math.Min(float64(p.BaseDelay)*math.Pow(2, exponent), float64(p.MaxDelay)))
+	return throttle.LastFailureAt.Add(delay)
+}
+
+// recordFailure counts a failure at now, locking the throttle out once it reaches maxFailures.
+// An expired lockout starts a new count.
+func (p LoginThrottlePolicy) recordFailure(throttle *models.LoginThrottle, maxFailures int, now time.Time) {
+	if throttle.LockedUntil != nil && !now.Before(*throttle.LockedUntil) {
+		throttle.Failures = 0
+		throttle.LockedUntil = nil
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = &now
+	if throttle.Failures >= maxFailures {
+		lockedUntil := now.Add(p.LockoutDuration)
+		throttle.LockedUntil = &lockedUntil
+	}
+}
+
+// userThrottleKey and ipThrottleKey name the throttles of a username and an IP address.
+func userThrottleKey(username string) string { return "user:" + strings.ToLower(username) }
+func ipThrottleKey(ip string) string         { return "ip:" + ip }
+
+// checkLoginThrottle refuses a login while the username or the IP address waits or is locked out.
+func (s *authService) checkLoginThrottle(ctx context.Context, username string, client types.ClientInfo) error {
+	throttles, err := s.loginRepo.GetLoginThrottles(ctx, []string{userThrottleKey(username), ipThrottleKey(client.IPAddress)})
+	if err != nil {
+		return err
+	}
+
+	retryAt := time.Time{}
+	for _, throttle := range throttles {
+		if at := s.throttlePolicy.retryAt(throttle); at.After(retryAt) {
+			retryAt = at
+		}
+	}
+	if time.Now().Before(retryAt) {
+		return &LoginThrottledError{RetryAt: retryAt}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login against the username and the IP address. Each
+// throttle is locked while its failure is counted, so that concurrent guesses all count, always
+// the username first so that they do not deadlock.
+func (s *authService) recordLoginFailure(ctx context.Context, username string, client types.ClientInfo) error {
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		for _, counted := range []struct {
+			key         string
+			maxFailures int
+		}{
+			{userThrottleKey(username), s.throttlePolicy.MaxUserFailures},
+			{ipThrottleKey(client.IPAddress), s.throttlePolicy.MaxIPFailures},
+		} {
+			throttle, err := s.loginRepo.LockLoginThrottle(ctx, counted.key)
+			if err != nil {
+				return err
+			}
+			s.throttlePolicy.recordFailure(throttle, counted.maxFailures, now)
+			if err := s.loginRepo.SaveLoginThrottle(ctx, throttle); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// recordLoginAttempt adds a login to the history of the user, if any.
+func (s *authService) recordLoginAttempt(ctx context.Context, username string, user *models.User, client types.ClientInfo, failure string) error {
+	attempt := &models.LoginAttempt{
+		Username:  truncate(username, 255),
+		IPAddress: client.IPAddress,
+		UserAgent: truncate(client.UserAgent, 255),
+		Success:   failure == "",
+		Reason:    failure,
+	}
+	if user != nil {
+		attempt.UserID = &user.ID
+	}
+	return s.loginRepo.CreateLoginAttempt(ctx, attempt)
+}
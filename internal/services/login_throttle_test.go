@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+func TestLoginThrottlePolicy(t *testing.T) {
+	policy := DefaultLoginThrottlePolicy()
+	start := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	throttle := models.LoginThrottle{Key: userThrottleKey("JDoe")}
+
+	// The free attempts are not delayed, then each failure doubles the wait.
+	wantDelays := []time.Duration{0, 0, time.Second, 2 * time.Second}
+	for i, want := range wantDelays {
+		now := start.Add(time.Duration(i) * time.Minute)
+		policy.recordFailure(&throttle, policy.MaxUserFailures, now)
+		got := time.Duration(0)
+		if at := policy.retryAt(throttle); !at.IsZero() {
+			got = at.Sub(now)
+		}
+		if got != want {
+			t.Errorf("after %d failures: got delay %v, want %v", i+1, got, want)
+		}
+	}
+
+	// The fifth failure locks the username out.
+	now := start.Add(10 * time.Minute)
+	policy.recordFailure(&throttle, policy.MaxUserFailures, now)
+	if at := policy.retryAt(throttle); !at.Equal(now.Add(policy.LockoutDuration)) {
+		t.Fatalf("lockout: got retry at %v, want %v", at, now.Add(policy.LockoutDuration))
+	}
+
+	// A failure after the lockout expired starts counting again.
+	policy.recordFailure(&throttle, policy.MaxUserFailures, now.Add(policy.LockoutDuration+time.Second))
+	if throttle.Failures != 1 || throttle.LockedUntil != nil {
+		t.Errorf("after the lockout: got %d failures, locked until %v", throttle.Failures, throttle.LockedUntil)
+	}
+
+	if throttle.Key != "user:jdoe" {
+		t.Errorf("usernames are throttled case-insensitively, got key %q", throttle.Key)
+	}
+}
+
+// throttleFixture keeps the throttles the auth service locks and saves, and the keys it locked
+// outside of a unit of work.
+type throttleFixture struct {
+	repositories.LoginRepository
+	throttles map[string]*models.LoginThrottle
+	inTx      bool
+	unlocked  []string
+}
+
+func (f *throttleFixture) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	f.inTx = true
+	defer func() { f.inTx = false }()
+	return fn(ctx)
+}
+
+func (f *throttleFixture) LockLoginThrottle(ctx context.Context, key string) (*models.LoginThrottle, error) {
+	if !f.inTx {
+		f.unlocked = append(f.unlocked, key)
+	}
+	if f.throttles[key] == nil {
+		f.throttles[key] = &models.LoginThrottle{Key: key}
+	}
+	throttle := *f.throttles[key]
+	return &throttle, nil
+}
+
+func (f *throttleFixture) SaveLoginThrottle(ctx context.Context, throttle *models.LoginThrottle) error {
+	f.throttles[throttle.Key] = throttle
+	return nil
+}
+
+func TestRecordLoginFailureCountsLockedThrottles(t *testing.T) {
+	fixture := &throttleFixture{throttles: map[string]*models.LoginThrottle{}}
+	service := &authService{uow: fixture, loginRepo: fixture, throttlePolicy: DefaultLoginThrottlePolicy()}
+	client := types.ClientInfo{IPAddress: "192.0.2.1"}
+
+	for i := 0; i < service.throttlePolicy.MaxUserFailures; i++ {
+		if err := service.recordLoginFailure(context.Background(), "JDoe", client); err != nil {
+			t.Fatalf("recordLoginFailure: %v", err)
+		}
+	}
+	if len(fixture.unlocked) != 0 {
+		t.Errorf("throttles %v counted outside of a unit of work", fixture.unlocked)
+	}
+	user, ip := fixture.throttles["user:jdoe"], fixture.throttles["ip:192.0.2.1"]
+	if user.Failures != 5 || user.LockedUntil == nil {
+		t.Errorf("username: got %d failures, locked until %v, want 5 and locked out", user.Failures, user.LockedUntil)
+	}
+	if ip.Failures != 5 || ip.LockedUntil != nil {
+		t.Errorf("IP address: got %d failures, locked until %v, want 5 and not locked out", ip.Failures, ip.LockedUntil)
+	}
+}
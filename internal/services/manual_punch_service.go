@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+// ErrInvalidPunch is returned when a punch entered or corrected by hand is incomplete or targets a
+// voided punch.
+var ErrInvalidPunch = errors.New("invalid punch")
+
+// ErrPunchNotFound is returned when the punch to correct does not exist.
+var ErrPunchNotFound = errors.New("attendance log not found")
+
+// PunchChange reports what entering or correcting a punch by hand did.
+type PunchChange struct {
+	Punch               *models.AttendanceLog  `json:"punch"`              // Punch added, edited, voided or deleted
+	Replaced            *models.AttendanceLog  `json:"replaced,omitempty"` // Punch voided by an adjustment
+	ReclassifiedPunches int                    `json:"reclassified_punches"`
+	WorkDays            []types.WorkDayRefresh `json:"work_days"`
+}
+
+// ManualPunchService defines the interface for the punches supervisors enter and correct by hand.
+// Every change requires a reason, reclassifies the punches of the employee and refreshes their raw
+// attendances. Device punches are never edited nor deleted: they are voided, or adjusted by
+// voiding them and entering the corrected punch.
+type ManualPunchService interface {
+	AddPunch(ctx context.Context, request types.ManualPunchRequest, actorID uint) (*PunchChange, error)
+	// UpdatePunch changes the timestamp of a punch entered by hand.
+	UpdatePunch(ctx context.Context, id uint, edit types.PunchEdit) (*PunchChange, error)
+	// AdjustPunch voids a punch and enters the corrected one, referencing it.
+	AdjustPunch(ctx context.Context, id uint, edit types.PunchEdit, actorID uint) (*PunchChange, error)
+	VoidPunch(ctx context.Context, id uint, void types.PunchVoid, actorID uint) (*PunchChange, error)
+	// DeletePunch deletes a punch entered by hand.
+	DeletePunch(ctx context.Context, id uint) (*PunchChange, error)
+}
+
+// manualPunchService implements the ManualPunchService interface.
+type manualPunchService struct {
+	unitOfWork        repositories.UnitOfWork
+	attendanceRepo    repositories.AttendanceRepository
+	employeeRepo      repositories.EmployeeRepository
+	periodService     PayrollPeriodService
+	attendanceService AttendanceService
+	workDayService    WorkDayService
+}
+
+// NewManualPunchService creates a new instance of ManualPunchService.
+func NewManualPunchService(unitOfWork repositories.UnitOfWork,
+	attendanceRepo repositories.AttendanceRepository,
+	employeeRepo repositories.EmployeeRepository,
+	periodService PayrollPeriodService,
+	attendanceService AttendanceService,
+	workDayService WorkDayService) ManualPunchService {
+	return &manualPunchService{
+		unitOfWork:        unitOfWork,
+		attendanceRepo:    attendanceRepo,
+		employeeRepo:      employeeRepo,
+		periodService:     periodService,
+		attendanceService: attendanceService,
+		workDayService:    workDayService,
+	}
+}
+
+// AddPunch enters a punch for an employee.
+func (s *manualPunchService) AddPunch(ctx context.Context, request types.ManualPunchRequest, actorID uint) (*PunchChange, error) {
+	source := strings.ToLower(strings.TrimSpace(request.Source))
+	if source == "" {
+		source = models.PunchSourceManual
+	}
+	if source != models.PunchSourceManual && source != models.PunchSourceImport && source != models.PunchSourceMobile {
+		return nil, fmt.Errorf("%w: source must be manual, import or mobile", ErrInvalidPunch)
+	}
+	reason, err := punchReason(request.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPunchTimestamp(request.Timestamp); err != nil {
+		return nil, err
+	}
+	employee, err := s.employeeRepo.GetEmployeeByID(ctx, request.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		return nil, fmt.Errorf("%w: employee %d not found", ErrInvalidPunch, request.EmployeeID)
+	}
+
+	change := &PunchChange{Punch: &models.AttendanceLog{
+		EmployeeID: &employee.ID,
+		Timestamp:  request.Timestamp,
+		Source:     source,
+		Reason:     reason,
+		CreatedBy:  actorRef(actorID),
+	}}
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := s.periodService.EnsureDateOpen(ctx, employee.CompanyID, request.Timestamp); err != nil {
+			return err
+		}
+		if err := s.attendanceRepo.CreateAttendanceLog(ctx, change.Punch); err != nil {
+			return fmt.Errorf("failed to create punch: %w", err)
+		}
+		return s.refresh(ctx, employee, change, request.Timestamp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// UpdatePunch moves a punch entered by hand to another time.
+func (s *manualPunchService) UpdatePunch(ctx context.Context, id uint, edit types.PunchEdit) (*PunchChange, error) {
+	reason, err := punchReason(edit.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPunchTimestamp(edit.Timestamp); err != nil {
+		return nil, err
+	}
+	punch, employee, err := s.getPunch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := punch.Timestamp
+	updated := *punch
+	updated.Timestamp = edit.Timestamp
+	updated.Reason = reason
+	change := &PunchChange{Punch: &updated}
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := s.attendanceService.UpdateAttendanceLog(ctx, &updated); err != nil {
+			return err
+		}
+		return s.refresh(ctx, employee, change, previous, edit.Timestamp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// AdjustPunch voids a punch and enters a manual punch at the corrected time in its place.
+func (s *manualPunchService) AdjustPunch(ctx context.Context, id uint, edit types.PunchEdit, actorID uint) (*PunchChange, error) {
+	reason, err := punchReason(edit.Reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPunchTimestamp(edit.Timestamp); err != nil {
+		return nil, err
+	}
+	punch, employee, err := s.getPunch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		return nil, fmt.Errorf("%w: punch %d is not linked to an employee", ErrInvalidPunch, id)
+	}
+
+	change := &PunchChange{
+		Punch: &models.AttendanceLog{
+			SerialNumber: punch.SerialNumber,
+			EmployeeID:   punch.EmployeeID,
+			Timestamp:    edit.Timestamp,
+			Source:       models.PunchSourceManual,
+			Reason:       reason,
+			CreatedBy:    actorRef(actorID),
+			CorrectsID:   &punch.ID,
+		},
+		Replaced: punch,
+	}
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := s.void(ctx, employee, punch, reason, actorID); err != nil {
+			return err
+		}
+		if err := s.periodService.EnsureDateOpen(ctx, employee.CompanyID, edit.Timestamp); err != nil {
+			return err
+		}
+		if err := s.attendanceRepo.CreateAttendanceLog(ctx, change.Punch); err != nil {
+			return fmt.Errorf("failed to create punch: %w", err)
+		}
+		return s.refresh(ctx, employee, change, punch.Timestamp, edit.Timestamp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// VoidPunch voids a punch, which stays stored but no longer counts.
+func (s *manualPunchService) VoidPunch(ctx context.Context, id uint, void types.PunchVoid, actorID uint) (*PunchChange, error) {
+	reason, err := punchReason(void.Reason)
+	if err != nil {
+		return nil, err
+	}
+	punch, employee, err := s.getPunch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &PunchChange{Punch: punch}
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := s.void(ctx, employee, punch, reason, actorID); err != nil {
+			return err
+		}
+		return s.refresh(ctx, employee, change, punch.Timestamp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// DeletePunch deletes a punch entered by hand.
+func (s *manualPunchService) DeletePunch(ctx context.Context, id uint) (*PunchChange, error) {
+	punch, employee, err := s.getPunch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &PunchChange{Punch: punch}
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := s.attendanceService.DeleteAttendanceLog(ctx, id); err != nil {
+			return err
+		}
+		return s.refresh(ctx, employee, change, punch.Timestamp)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// getPunch returns a punch that is not voided and its employee, nil for unmatched punches.
+func (s *manualPunchService) getPunch(ctx context.Context, id uint) (*models.AttendanceLog, *models.Employee, error) {
+	punch, err := s.attendanceRepo.GetAttendanceByID(ctx, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve attendance log: %w", err)
+	}
+	if punch == nil {
+		return nil, nil, ErrPunchNotFound
+	}
+	if punch.IsVoided() {
+		return nil, nil, fmt.Errorf("%w: attendance log %d is voided", ErrInvalidPunch, id)
+	}
+	if punch.EmployeeID == nil {
+		return punch, nil, nil
+	}
+	employee, err := s.employeeRepo.GetEmployeeByID(ctx, *punch.EmployeeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return punch, employee, nil
+}
+
+// void marks a punch voided by the actor, provided its date is open to changes.
+func (s *manualPunchService) void(ctx context.Context, employee *models.Employee, punch *models.AttendanceLog, reason string, actorID uint) error {
+	if employee != nil {
+		if err := s.periodService.EnsureDateOpen(ctx, employee.CompanyID, punch.Timestamp); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	punch.VoidedAt = &now
+	punch.VoidedBy = actorRef(actorID)
+	punch.VoidReason = reason
+	if err := s.attendanceRepo.UpdateAttendanceLog(ctx, punch); err != nil {
+		return fmt.Errorf("failed to void punch: %w", err)
+	}
+	return nil
+}
+
+// refresh reclassifies the punches of the employee from the earliest of the changed times, and
+// regenerates their raw attendances up to the last punch whose classification changed.
+func (s *manualPunchService) refresh(ctx context.Context, employee *models.Employee, change *PunchChange, times ...time.Time) error {
+	change.WorkDays = []types.WorkDayRefresh{}
+	if employee == nil {
+		return nil // Unmatched punches count for no one
+	}
+	from, to := times[0], times[0]
+	for _, t := range times[1:] {
+		if t.Before(from) {
+			from = t
+		}
+		if t.After(to) {
+			to = t
+		}
+	}
+
+	changed, err := s.attendanceService.ReclassifyPunches(ctx, employee.ID, from)
+	if err != nil {
+		return err
+	}
+	change.ReclassifiedPunches = len(changed)
+	for _, punch := range changed {
+		if punch.Timestamp.After(to) {
+			to = punch.Timestamp
+		}
+	}
+
+	// A night shift ending on the first day changes the row of the day before.
+	change.WorkDays, err = s.workDayService.RefreshEmployeeRawAttendances(ctx, employee, truncateToDay(from).AddDate(0, 0, -1), to)
+	return err
+}
+
+// punchReason returns the trimmed reason of a change, required and of at most 500 characters.
+func punchReason(reason string) (string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > 500 {
+		return "", fmt.Errorf("%w: a reason of at most 500 characters is required", ErrInvalidPunch)
+	}
+	return reason, nil
+}
+
+// checkPunchTimestamp rejects missing and future timestamps.
+func checkPunchTimestamp(timestamp time.Time) error {
+	if timestamp.IsZero() {
+		return fmt.Errorf("%w: the timestamp is required", ErrInvalidPunch)
+	}
+	if timestamp.After(time.Now()) {
+		return fmt.Errorf("%w: the timestamp is in the future", ErrInvalidPunch)
+	}
+	return nil
+}
+
+// actorRef returns a reference to the ID of the acting user, nil when unknown.
+func actorRef(actorID uint) *uint {
+	if actorID == 0 {
+		return nil
+	}
+	return &actorID
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManualPunchValidation(t *testing.T) {
+	if reason, err := punchReason("  forgot to punch in \n"); err != nil || reason != "forgot to punch in" {
+		t.Errorf("punchReason: got %q, %v", reason, err)
+	}
+	for _, reason := range []string{"", "   ", strings.Repeat("x", 501)} {
+		if _, err := punchReason(reason); !errors.Is(err, ErrInvalidPunch) {
+			t.Errorf("punchReason(%d chars): got %v, want ErrInvalidPunch", len(reason), err)
+		}
+	}
+
+	for _, tc := range []struct {
+		timestamp time.Time
+		valid     bool
+	}{
+		{time.Now().Add(-time.Hour), true},
+		{time.Time{}, false},               // missing
+		{time.Now().Add(time.Hour), false}, // in the future
+	} {
+		err := checkPunchTimestamp(tc.timestamp)
+		if tc.valid && err != nil {
+			t.Errorf("%v: unexpected error %v", tc.timestamp, err)
+		}
+		if !tc.valid && !errors.Is(err, ErrInvalidPunch) {
+			t.Errorf("%v: got %v, want ErrInvalidPunch", tc.timestamp, err)
+		}
+	}
+}
@@ -0,0 +1,664 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/rbac"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+// ErrInvalidOIDCLogin is returned when a single sign-on login cannot be completed: unknown or
+// expired state, code refused by the identity provider, invalid ID token or no account to log in.
+var ErrInvalidOIDCLogin = errors.New("invalid single sign-on login")
+
+// jwksRefreshInterval limits how often the keys of the identity provider are fetched again when
+// an ID token is signed by an unknown key.
+const jwksRefreshInterval = time.Minute
+
+// OIDCConfig configures the single sign-on of users with an OpenID Connect identity provider.
+type OIDCConfig struct {
+	IssuerURL    string // Discovered at <IssuerURL>/.well-known/openid-configuration
+	ClientID     string
+	ClientSecret string // Empty for a public client, which PKCE alone protects
+	// RedirectURL is the page the identity provider sends users back to, which posts the code and
+	// state it receives to /auth/oidc/callback.
+	RedirectURL   string
+	Scopes        []string
+	UsernameClaim string // Claim naming the accounts created, and matched against usernames when LinkByUsername
+	// RoleClaim names the claim, a string or a list of strings, mapped to roles by ClaimRoles.
+	// Dots reach nested claims, such as realm_access.roles.
+	RoleClaim string
+	// ClaimRoles maps values of RoleClaim, in lower case, to roles. Users with several values get
+	// the most privileged role. Without mapping, users keep the role of their account.
+	ClaimRoles  map[string]string
+	DefaultRole string // Role of users with no mapped value, refused when empty
+	LinkByEmail bool   // Link identities to the account having their verified email address
+	// LinkByUsername links identities to the ldap or oidc account having their username. Identity
+	// providers do not verify usernames, so only enable it when they cannot be chosen by users.
+	LinkByUsername bool
+	CreateUsers    bool // Create the account of unknown users on their first login
+	StateTTL       time.Duration
+	Timeout        time.Duration
+}
+
+// DefaultOIDCConfig returns the configuration completed by the environment.
+func DefaultOIDCConfig() OIDCConfig {
+	return OIDCConfig{
+		Scopes:        []string{"openid", "profile", "email"},
+		UsernameClaim: "preferred_username",
+		RoleClaim:     "groups",
+		DefaultRole:   rbac.RoleEmployee,
+		LinkByEmail:   true,
+		CreateUsers:   true,
+		StateTTL:      10 * time.Minute,
+		Timeout:       10 * time.Second,
+	}
+}
+
+// LoadOIDCConfigFromEnv reads OIDC_ISSUER_URL, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET,
+// OIDC_REDIRECT_URL, OIDC_SCOPES (space separated), OIDC_USERNAME_CLAIM, OIDC_ROLE_CLAIM,
+// OIDC_CLAIM_ROLES ("<value>:<role>;..."), OIDC_DEFAULT_ROLE, OIDC_LINK_BY_EMAIL,
+// OIDC_LINK_BY_USERNAME and OIDC_CREATE_USERS over the defaults. It returns nil without OIDC_ISSUER_URL.
+func LoadOIDCConfigFromEnv() (*OIDCConfig, error) {
+	config := DefaultOIDCConfig()
+	if config.IssuerURL = os.Getenv("OIDC_ISSUER_URL"); config.IssuerURL == "" {
+		return nil, nil
+	}
+	for key, target := range map[string]*string{
+		"OIDC_CLIENT_ID":      &config.ClientID,
+		"OIDC_CLIENT_SECRET":  &config.ClientSecret,
+		"OIDC_REDIRECT_URL":   &config.RedirectURL,
+		"OIDC_USERNAME_CLAIM": &config.UsernameClaim,
+		"OIDC_ROLE_CLAIM":     &config.RoleClaim,
+	} {
+		if value := os.Getenv(key); value != "" {
+			*target = value
+		}
+	}
+	for key, target := range map[string]*bool{
+		"OIDC_LINK_BY_EMAIL":    &config.LinkByEmail,
+		"OIDC_LINK_BY_USERNAME": &config.LinkByUsername,
+		"OIDC_CREATE_USERS":     &config.CreateUsers,
+	} {
+		if value := os.Getenv(key); value != "" {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %q", key, value)
+			}
+			*target = enabled
+		}
+	}
+	if value := os.Getenv("OIDC_SCOPES"); value != "" {
+		config.Scopes = strings.Fields(value)
+	}
+	if value, ok := os.LookupEnv("OIDC_DEFAULT_ROLE"); ok {
+		config.DefaultRole = value
+	}
+	if value := os.Getenv("OIDC_CLAIM_ROLES"); value != "" {
+		config.ClaimRoles = map[string]string{}
+		for _, mapping := range strings.Split(value, ";") {
+			claim, role, ok := cutLast(strings.TrimSpace(mapping), ":")
+			if !ok || claim == "" {
+				return nil, fmt.Errorf("invalid OIDC_CLAIM_ROLES entry %q", mapping)
+			}
+			config.ClaimRoles[strings.ToLower(claim)] = role
+		}
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// validate checks the client settings and the roles of the configuration.
+func (c *OIDCConfig) validate() error {
+	if c.IssuerURL == "" || c.ClientID == "" || c.RedirectURL == "" {
+		return errors.New("invalid OIDC configuration: the issuer URL, client ID and redirect URL are required")
+	}
+	roles := []string{c.DefaultRole}
+	for _, role := range c.ClaimRoles {
+		roles = append(roles, role)
+	}
+	for _, role := range roles {
+		if role != "" && roleRank(role) == 0 {
+			return fmt.Errorf("invalid OIDC role mapping: unknown role %q", role)
+		}
+	}
+	return nil
+}
+
+// OIDCService logs users in with the authorization code flow of an OpenID Connect identity
+// provider, protected by PKCE.
+type OIDCService interface {
+	// StartLogin returns the authorization URL of the identity provider to send the user to.
+	StartLogin(ctx context.Context) (*types.OIDCAuthorization, error)
+	// CompleteLogin exchanges the code the identity provider returned with state for the ID token
+	// of the user, and logs in the account it is linked to as AuthService.LoginUser does.
+	CompleteLogin(ctx context.Context, code, state string, client types.ClientInfo) (*types.LoginResult, error)
+}
+
+// oidcProviderMetadata is the part of the discovery document of the identity provider used here.
+type oidcProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcService implements the OIDCService interface.
+type oidcService struct {
+	config      OIDCConfig
+	authService AuthService
+	userRepo    repositories.UserRepository
+	oidcRepo    repositories.OIDCRepository
+	httpClient  *http.Client
+
+	mu            sync.Mutex
+	metadata      *oidcProviderMetadata // Discovered on first use
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewOIDCService creates a new instance of OIDCService. The identity provider is discovered on the
+// first login, so that it being unreachable does not prevent the service from starting.
+func NewOIDCService(config OIDCConfig, authService AuthService, userRepo repositories.UserRepository, oidcRepo repositories.OIDCRepository) (OIDCService, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &oidcService{
+		config:      config,
+		authService: authService,
+		userRepo:    userRepo,
+		oidcRepo:    oidcRepo,
+		httpClient:  &http.Client{Timeout: config.Timeout},
+	}, nil
+}
+
+// StartLogin draws the state, nonce and PKCE verifier of a login, stores them until the callback
+// and builds the authorization URL.
+func (s *oidcService) StartLogin(ctx context.Context) (*types.OIDCAuthorization, error) {
+	metadata, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	verifier, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	pending := &models.OIDCLoginState{
+		StateHash:    hashToken(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(s.config.StateTTL),
+	}
+	if err := s.oidcRepo.CreateLoginState(ctx, pending); err != nil {
+		return nil, err
+	}
+
+	authorizationURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	query := authorizationURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", s.config.ClientID)
+	query.Set("redirect_uri", s.config.RedirectURL)
+	query.Set("scope", strings.Join(s.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authorizationURL.RawQuery = query.Encode()
+
+	return &types.OIDCAuthorization{
+		AuthorizationURL: authorizationURL.String(),
+		ExpiresIn:        int64(s.config.StateTTL.Seconds()),
+	}, nil
+}
+
+// CompleteLogin consumes the state of the login, redeems the code with its PKCE verifier, checks
+// the ID token and logs in the account of its subject.
+func (s *oidcService) CompleteLogin(ctx context.Context, code, state string, client types.ClientInfo) (*types.LoginResult, error) {
+	if code == "" || state == "" {
+		return nil, fmt.Errorf("%w: missing code or state", ErrInvalidOIDCLogin)
+	}
+	pending, err := s.oidcRepo.ConsumeLoginState(ctx, hashToken(state))
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil || time.Now().After(pending.ExpiresAt) {
+		return nil, fmt.Errorf("%w: unknown or expired state", ErrInvalidOIDCLogin)
+	}
+	metadata, err := s.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, err := s.redeemCode(ctx, metadata, code, pending.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.verifyIDToken(ctx, metadata, rawIDToken, pending.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.linkedUser(ctx, metadata.Issuer, claims)
+	if err != nil {
+		return nil, err
+	}
+	return s.authService.LoginUser(ctx, user, client)
+}
+
+// discover fetches the discovery document of the identity provider once.
+func (s *oidcService) discover(ctx context.Context) (*oidcProviderMetadata, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.metadata != nil {
+		return s.metadata, nil
+	}
+
+	var metadata oidcProviderMetadata
+	discoveryURL := strings.TrimSuffix(s.config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := s.getJSON(ctx, discoveryURL, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to discover the identity provider: %w", err)
+	}
+	if metadata.Issuer != s.config.IssuerURL {
+		return nil, fmt.Errorf("failed to discover the identity provider: issuer %q does not match %q", metadata.Issuer, s.config.IssuerURL)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("failed to discover the identity provider: incomplete discovery document")
+	}
+	s.metadata = &metadata
+	return s.metadata, nil
+}
+
+// redeemCode exchanges an authorization code for the ID token of the user.
+func (s *oidcService) redeemCode(ctx context.Context, metadata *oidcProviderMetadata, code, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", s.config.RedirectURL)
+	form.Set("code_verifier", verifier)
+	if s.config.ClientSecret == "" {
+		form.Set("client_id", s.config.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if s.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to redeem authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("%w: code refused by the identity provider: %s %s", ErrInvalidOIDCLogin, body.Error, body.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to redeem authorization code: token endpoint answered %d", resp.StatusCode)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: no ID token returned", ErrInvalidOIDCLogin)
+	}
+	return body.IDToken, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, lifetime and nonce of an ID token and
+// returns its claims.
+func (s *oidcService) verifyIDToken(ctx context.Context, metadata *oidcProviderMetadata, rawIDToken, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.signingKey(ctx, metadata, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(s.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ID token: %v", ErrInvalidOIDCLogin, err)
+	}
+
+	tokenNonce, _ := claims["nonce"].(string)
+	if subtle.ConstantTimeCompare([]byte(tokenNonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: invalid ID token: nonce mismatch", ErrInvalidOIDCLogin)
+	}
+	if audience, _ := claims.GetAudience(); len(audience) > 1 {
+		if party, _ := claims["azp"].(string); party != s.config.ClientID {
+			return nil, fmt.Errorf("%w: invalid ID token: authorized party mismatch", ErrInvalidOIDCLogin)
+		}
+	}
+	if subject, _ := claims.GetSubject(); subject == "" {
+		return nil, fmt.Errorf("%w: invalid ID token: no subject", ErrInvalidOIDCLogin)
+	}
+	return claims, nil
+}
+
+// signingKey returns the key of the identity provider named kid, fetching the keys again when it
+// is unknown, as after a key rotation. Tokens without kid are accepted from providers with a
+// single key.
+func (s *oidcService) signingKey(ctx context.Context, metadata *oidcProviderMetadata, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lookup := func() crypto.PublicKey {
+		if kid == "" && len(s.keys) == 1 {
+			for _, key := range s.keys {
+				return key
+			}
+		}
+		return s.keys[kid]
+	}
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	if time.Since(s.keysFetchedAt) < jwksRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := s.fetchKeys(ctx, metadata.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	s.keys, s.keysFetchedAt = keys, time.Now()
+	if key := lookup(); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// fetchKeys reads the RSA and EC signing keys of a JSON Web Key Set, by key ID.
+func (s *oidcService) fetchKeys(ctx context.Context, jwksURI string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := s.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch the keys of the identity provider: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		switch jwk.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+			e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+			if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+				slog.Warn("skipping invalid key of the identity provider", "kid", jwk.Kid)
+				continue
+			}
+			keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+			curve, ok := curves[jwk.Crv]
+			x, errX := base64.RawURLEncoding.DecodeString(jwk.X)
+			y, errY := base64.RawURLEncoding.DecodeString(jwk.Y)
+			if !ok || errX != nil || errY != nil {
+				slog.Warn("skipping invalid key of the identity provider", "kid", jwk.Kid)
+				continue
+			}
+			keys[jwk.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	return keys, nil
+}
+
+// getJSON decodes the JSON document at rawURL into target.
+func (s *oidcService) getJSON(ctx context.Context, rawURL string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s answered %d", rawURL, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(target)
+}
+
+// linkedUser returns the account of the subject of claims. Subjects logging in for the first time
+// are linked to the account with their verified email address, or their username when enabled, or
+// get a new account.
+// The role of the account follows the role claim when it is mapped.
+func (s *oidcService) linkedUser(ctx context.Context, issuer string, claims jwt.MapClaims) (*models.User, error) {
+	subject, _ := claims.GetSubject()
+	username := claimString(claims, s.config.UsernameClaim)
+	email := claimString(claims, "email")
+	emailVerified, _ := claims["email_verified"].(bool)
+
+	role := s.role(claims)
+	if role == "" {
+		slog.Warn("single sign-on user granted no role", "issuer", issuer, "subject", subject)
+		return nil, fmt.Errorf("%w: no role granted by the identity provider", ErrInvalidOIDCLogin)
+	}
+
+	identity, err := s.oidcRepo.GetExternalIdentity(ctx, issuer, subject)
+	if err != nil {
+		return nil, err
+	}
+	var user *models.User
+	if identity != nil {
+		if user, err = s.userRepo.GetUserByID(ctx, identity.UserID); err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, fmt.Errorf("%w: the linked account no longer exists", ErrInvalidOIDCLogin)
+		}
+		if err := s.oidcRepo.TouchExternalIdentity(ctx, identity.ID, email, time.Now()); err != nil {
+			return nil, err
+		}
+	} else {
+		if user, err = s.accountOf(ctx, username, email, emailVerified); err != nil {
+			return nil, err
+		}
+		if user == nil {
+			if user, err = s.createUser(ctx, claims, username, email, role); err != nil {
+				return nil, err
+			}
+		}
+		now := time.Now()
+		identity = &models.ExternalIdentity{UserID: user.ID, Issuer: issuer, Subject: subject, Email: email, LastLoginAt: &now}
+		if err := s.oidcRepo.CreateExternalIdentity(ctx, identity); err != nil {
+			return nil, err
+		}
+		slog.Info("linked single sign-on identity", "user_id", user.ID, "issuer", issuer, "subject", subject)
+	}
+
+	if len(s.config.ClaimRoles) > 0 && user.Role != role {
+		if _, err := s.userRepo.UpdateUserRole(ctx, user.ID, role); err != nil {
+			return nil, err
+		}
+		user.Role = role
+	}
+	return user, nil
+}
+
+// accountOf finds the existing account of a subject logging in for the first time, by username
+// when enabled, then by email address when the identity provider verified it. Accounts whose role
+// is above employee are never linked this way, nor local accounts by their username.
+func (s *oidcService) accountOf(ctx context.Context, username, email string, emailVerified bool) (*models.User, error) {
+	if s.config.LinkByUsername && username != "" {
+		user, err := s.userRepo.GetUserByUsername(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil && user.HasLocalPassword() {
+			slog.Warn("single sign-on identity not linked to a local account", "user_id", user.ID)
+			return nil, fmt.Errorf("%w: the account %q logs in with its password", ErrInvalidOIDCLogin, username)
+		}
+		if user != nil {
+			return linkableAccount(user)
+		}
+	}
+	if s.config.LinkByEmail && email != "" && emailVerified {
+		user, err := s.userRepo.GetUserByEmail(ctx, email)
+		if err != nil || user == nil {
+			return nil, err
+		}
+		return linkableAccount(user)
+	}
+	return nil, nil
+}
+
+// linkableAccount refuses to link an identity to a privileged account, which an identity provider
+// could otherwise hand to anyone able to claim its username or email address.
+func linkableAccount(user *models.User) (*models.User, error) {
+	if roleRank(user.Role) > roleRank(rbac.RoleEmployee) {
+		slog.Warn("single sign-on identity not linked to a privileged account", "user_id", user.ID, "role", user.Role)
+		return nil, fmt.Errorf("%w: the account %q cannot be linked automatically", ErrInvalidOIDCLogin, user.Username)
+	}
+	return user, nil
+}
+
+// createUser creates the account of a subject, named by their username or email address, with an
+// unusable random password: they log in with single sign-on only.
+func (s *oidcService) createUser(ctx context.Context, claims jwt.MapClaims, username, email, role string) (*models.User, error) {
+	if !s.config.CreateUsers {
+		return nil, fmt.Errorf("%w: no account linked to this identity", ErrInvalidOIDCLogin)
+	}
+	if username == "" {
+		username = email
+	}
+	if username == "" {
+		return nil, fmt.Errorf("%w: the identity has neither username nor email address", ErrInvalidOIDCLogin)
+	}
+	if existing, err := s.userRepo.GetUserByUsername(ctx, username); err != nil || existing != nil {
+		if err == nil {
+			err = fmt.Errorf("%w: username %q is taken", ErrInvalidOIDCLogin, username)
+		}
+		return nil, err
+	}
+
+	user := models.User{
+		Username:     username,
+		Email:        email,
+		FirstName:    claimString(claims, "given_name"),
+		LastName:     claimString(claims, "family_name"),
+		Role:         role,
+		AuthProvider: models.AuthProviderOIDC,
+	}
+	var err error
+	if user.Password, err = randomToken(32); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetUserByUsername(ctx, username)
+}
+
+// role maps the values of the role claim to the most privileged role they grant, or the default
+// role.
+func (s *oidcService) role(claims jwt.MapClaims) string {
+	role := ""
+	for _, value := range claimStrings(claims, s.config.RoleClaim) {
+		if mapped, ok := s.config.ClaimRoles[strings.ToLower(value)]; ok && roleRank(mapped) > roleRank(role) {
+			role = mapped
+		}
+	}
+	if role == "" {
+		role = s.config.DefaultRole
+	}
+	return role
+}
+
+// claimValue returns the claim at a dotted path, nil when it is missing.
+func claimValue(claims jwt.MapClaims, path string) interface{} {
+	var value interface{} = map[string]interface{}(claims)
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+// claimString returns a string claim, empty when it is missing or not a string.
+func claimString(claims jwt.MapClaims, path string) string {
+	value, _ := claimValue(claims, path).(string)
+	return value
+}
+
+// claimStrings returns a claim holding a string or a list of strings.
+func claimStrings(claims jwt.MapClaims, path string) []string {
+	switch value := claimValue(claims, path).(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/rbac"
+	"point-system-api/internal/types"
+)
+
+// testIssuer is a local OpenID Connect identity provider. Its token endpoint redeems the code
+// "valid-code" once its PKCE verifier matches, with an ID token of the claims set by the test.
+type testIssuer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	claims    jwt.MapClaims
+	challenge string
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &testIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.URL,
+			"authorization_endpoint": issuer.URL + "/authorize",
+			"token_endpoint":         issuer.URL + "/token",
+			"jwks_uri":               issuer.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "key-1", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		id, secret, _ := r.BasicAuth()
+		if r.PostFormValue("code") != "valid-code" || id != "point-system" || secret != "client-secret" ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != issuer.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		issuer.challenge = "" // Codes are redeemed once
+		json.NewEncoder(w).Encode(map[string]string{"id_token": issuer.sign(t, issuer.claims, "key-1")})
+	})
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+func (i *testIssuer) sign(t *testing.T, claims jwt.MapClaims, kid string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// memoryOIDCRepository keeps the login states and identities in memory.
+type memoryOIDCRepository struct {
+	states     map[string]models.OIDCLoginState
+	identities []models.ExternalIdentity
+}
+
+func (r *memoryOIDCRepository) CreateLoginState(ctx context.Context, state *models.OIDCLoginState) error {
+	r.states[state.StateHash] = *state
+	return nil
+}
+
+func (r *memoryOIDCRepository) ConsumeLoginState(ctx context.Context, stateHash string) (*models.OIDCLoginState, error) {
+	state, ok := r.states[stateHash]
+	if !ok {
+		return nil, nil
+	}
+	delete(r.states, stateHash)
+	return &state, nil
+}
+
+func (r *memoryOIDCRepository) GetExternalIdentity(ctx context.Context, issuer, subject string) (*models.ExternalIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryOIDCRepository) CreateExternalIdentity(ctx context.Context, identity *models.ExternalIdentity) error {
+	identity.ID = uint(len(r.identities) + 1)
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *memoryOIDCRepository) TouchExternalIdentity(ctx context.Context, id uint, email string, at time.Time) error {
+	return nil
+}
+
+// loginRecorder stands for the AuthService and records the user it logs in.
+type loginRecorder struct {
+	AuthService
+	user *models.User
+}
+
+func (a *loginRecorder) LoginUser(ctx context.Context, user *models.User, client types.ClientInfo) (*types.LoginResult, error) {
+	a.user = user
+	return &types.LoginResult{TokenPair: &types.TokenPair{Token: user.Username}}, nil
+}
+
+func TestOIDCLogin(t *testing.T) {
+	issuer := newTestIssuer(t)
+	config := DefaultOIDCConfig()
+	config.IssuerURL = issuer.URL
+	config.ClientID, config.ClientSecret = "point-system", "client-secret"
+	config.RedirectURL = "https://app.example.com/sso/callback"
+	config.ClaimRoles = map[string]string{"hr-managers": rbac.RoleManager}
+
+	users := &memoryUserRepository{users: map[string]*models.User{
+		"jdoe":  {ID: 100, Username: "jdoe", Email: "john.doe@example.com", Role: rbac.RoleEmployee, AuthProvider: models.AuthProviderLocal},
+		"admin": {ID: 1, Username: "admin", Email: "it@example.com", Role: rbac.RoleSuperAdmin, AuthProvider: models.AuthProviderLocal},
+		"bwong": {ID: 101, Username: "bwong", Role: rbac.RoleEmployee, AuthProvider: models.AuthProviderLDAP},
+	}}
+	identities := &memoryOIDCRepository{states: map[string]models.OIDCLoginState{}}
+	auth := &loginRecorder{}
+	service, err := NewOIDCService(config, auth, users, identities)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// login goes through the flow, the ID token holding claims, with the nonce of the login unless
+	// set, and returns the state for replays.
+	login := func(claims jwt.MapClaims) (*models.User, string, error) {
+		t.Helper()
+		authorization, err := service.StartLogin(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authorizationURL, err := url.Parse(authorization.AuthorizationURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		query := authorizationURL.Query()
+		if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != "point-system" || query.Get("redirect_uri") != config.RedirectURL {
+			t.Fatalf("authorization URL: got %s", authorization.AuthorizationURL)
+		}
+		issuer.challenge = query.Get("code_challenge")
+		issuer.claims = jwt.MapClaims{
+			"iss": issuer.URL, "aud": "point-system", "nonce": query.Get("nonce"),
+			"iat": time.Now().Unix(), "exp": time.Now().Add(5 * time.Minute).Unix(),
+		}
+		for name, value := range claims {
+			issuer.claims[name] = value
+		}
+		auth.user = nil
+		_, err = service.CompleteLogin(ctx, "valid-code", query.Get("state"), types.ClientInfo{})
+		return auth.user, query.Get("state"), err
+	}
+
+	// An unknown subject gets an account, with the role of its mapped group.
+	user, state, err := login(jwt.MapClaims{
+		"sub": "subject-1", "preferred_username": "asmith", "email": "alice.smith@example.com",
+		"given_name": "Alice", "family_name": "Smith", "groups": []string{"staff", "HR-Managers"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Username != "asmith" || user.AuthProvider != models.AuthProviderOIDC || user.Role != rbac.RoleManager || user.FirstName != "Alice" {
+		t.Errorf("created user: got %+v", user)
+	}
+	createdID := user.ID
+
+	// A state completes one login.
+	if _, err := service.CompleteLogin(ctx, "valid-code", state, types.ClientInfo{}); !errors.Is(err, ErrInvalidOIDCLogin) {
+		t.Errorf("replayed state: got %v", err)
+	}
+
+	// The subject stays linked to its account whatever its username, and its role follows its groups.
+	if user, _, err = login(jwt.MapClaims{"sub": "subject-1", "preferred_username": "alice", "groups": "staff"}); err != nil || user.ID != createdID || user.Role != rbac.RoleEmployee {
+		t.Errorf("second login: got %+v, %v", user, err)
+	}
+
+	// Existing accounts are linked by verified email address.
+	if user, _, err = login(jwt.MapClaims{"sub": "subject-2", "preferred_username": "john", "email": "John.Doe@example.com", "email_verified": true}); err != nil || user.ID != 100 {
+		t.Errorf("linking by email: got %+v, %v", user, err)
+	}
+
+	// Usernames are not verified: they link nothing unless enabled, and then only ldap or oidc
+	// accounts below manager.
+	if user, _, err = login(jwt.MapClaims{"sub": "subject-4", "preferred_username": "admin"}); !errors.Is(err, ErrInvalidOIDCLogin) || user != nil {
+		t.Errorf("username of the administrator: got %+v, %v, want invalid login", user, err)
+	}
+	service.(*oidcService).config.LinkByUsername = true
+	for _, username := range []string{"admin", "jdoe"} {
+		if user, _, err = login(jwt.MapClaims{"sub": "subject-4", "preferred_username": username}); !errors.Is(err, ErrInvalidOIDCLogin) || user != nil {
+			t.Errorf("username of local account %s: got %+v, %v, want invalid login", username, user, err)
+		}
+	}
+	if user, _, err = login(jwt.MapClaims{"sub": "subject-4", "preferred_username": "bwong"}); err != nil || user.ID != 101 {
+		t.Errorf("linking by username: got %+v, %v", user, err)
+	}
+	service.(*oidcService).config.LinkByUsername = false
+
+	// Privileged accounts are not linked by email address either.
+	if user, _, err = login(jwt.MapClaims{"sub": "subject-5", "preferred_username": "it", "email": "it@example.com", "email_verified": true}); !errors.Is(err, ErrInvalidOIDCLogin) || user != nil {
+		t.Errorf("email address of the administrator: got %+v, %v, want invalid login", user, err)
+	}
+
+	for name, claims := range map[string]jwt.MapClaims{
+		"wrong nonce":    {"sub": "subject-1", "nonce": "other"},
+		"wrong audience": {"sub": "subject-1", "aud": "other-client"},
+		"wrong issuer":   {"sub": "subject-1", "iss": "https://evil.example.com"},
+		"expired":        {"sub": "subject-1", "exp": time.Now().Add(-time.Hour).Unix()},
+		"no subject":     {"preferred_username": "asmith"},
+	} {
+		if _, _, err := login(claims); !errors.Is(err, ErrInvalidOIDCLogin) {
+			t.Errorf("%s: got %v, want invalid login", name, err)
+		}
+	}
+
+	// Unverified addresses link nothing, and accounts are not created when disabled.
+	service.(*oidcService).config.CreateUsers = false
+	if _, _, err := login(jwt.MapClaims{"sub": "subject-3", "preferred_username": "jd", "email": "john.doe@example.com"}); !errors.Is(err, ErrInvalidOIDCLogin) {
+		t.Errorf("unverified email: got %v, want invalid login", err)
+	}
+
+	// ID tokens signed by another key are refused.
+	issuer.key, _ = rsa.GenerateKey(rand.Reader, 2048)
+	if _, _, err := login(jwt.MapClaims{"sub": "subject-1"}); !errors.Is(err, ErrInvalidOIDCLogin) {
+		t.Errorf("forged token: got %v, want invalid login", err)
+	}
+}
@@ -0,0 +1,501 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+// ErrInvalidOrganization is returned when a department, team or assignment is invalid.
+var ErrInvalidOrganization = errors.New("invalid organization")
+
+// ReportingLine is a person of the reporting-line tree with the people reporting to them.
+type ReportingLine struct {
+	UserID       uint             `json:"user_id"`
+	EmployeeID   *uint            `json:"employee_id"` // Nil for managers who are not assigned to a department
+	Name         string           `json:"name"`
+	DepartmentID *uint            `json:"department_id"` // Department the person is assigned to
+	TeamID       *uint            `json:"team_id"`
+	Manages      []string         `json:"manages"` // Departments and teams managed by the person
+	Reports      []*ReportingLine `json:"reports"`
+}
+
+// OrganizationService defines the interface for departments, teams and the manager hierarchy.
+type OrganizationService interface {
+	CreateDepartment(ctx context.Context, department *models.Department) error
+	GetDepartmentByID(ctx context.Context, id uint) (*models.Department, error)
+	ListDepartments(ctx context.Context, filters map[string]interface{}) ([]models.Department, error)
+	UpdateDepartment(ctx context.Context, department *models.Department) error
+	DeleteDepartment(ctx context.Context, id uint) error
+
+	CreateTeam(ctx context.Context, team *models.Team) error
+	GetTeamByID(ctx context.Context, id uint) (*models.Team, error)
+	ListTeams(ctx context.Context, filters map[string]interface{}) ([]models.Team, error)
+	UpdateTeam(ctx context.Context, team *models.Team) error
+	DeleteTeam(ctx context.Context, id uint) error
+
+	ListOrgAssignments(ctx context.Context, employeeID uint) ([]models.OrgAssignment, error)
+	// AssignEmployee moves an employee to a department and team from a date onwards.
+	AssignEmployee(ctx context.Context, employeeID, departmentID uint, teamID *uint, from time.Time) (*models.OrgAssignment, error)
+	// EndOrgAssignment removes an employee from their department after date.
+	EndOrgAssignment(ctx context.Context, employeeID uint, date time.Time) (*models.OrgAssignment, error)
+
+	// GetReportingTree builds the reporting lines of a company (all companies when zero) on date,
+	// limited to the subtree of managerID when it is not zero.
+	GetReportingTree(ctx context.Context, companyID, managerID uint, date time.Time) ([]*ReportingLine, error)
+	// ManagerScope returns the departments and teams managed by a user.
+	ManagerScope(ctx context.Context, userID uint) (*types.OrgScope, error)
+}
+
+// organizationService implements the OrganizationService interface.
+type organizationService struct {
+	orgRepo      repositories.OrganizationRepository
+	employeeRepo repositories.EmployeeRepository
+	companyRepo  repositories.CompanyRepository
+	userRepo     repositories.UserRepository
+}
+
+// NewOrganizationService creates a new instance of OrganizationService.
+func NewOrganizationService(orgRepo repositories.OrganizationRepository,
+	employeeRepo repositories.EmployeeRepository,
+	companyRepo repositories.CompanyRepository,
+	userRepo repositories.UserRepository) OrganizationService {
+	return &organizationService{
+		orgRepo:      orgRepo,
+		employeeRepo: employeeRepo,
+		companyRepo:  companyRepo,
+		userRepo:     userRepo,
+	}
+}
+
+// CreateDepartment validates and stores a department.
+func (s *organizationService) CreateDepartment(ctx context.Context, department *models.Department) error {
+	if err := s.validateDepartment(ctx, department); err != nil {
+		return err
+	}
+	return s.orgRepo.CreateDepartment(ctx, department)
+}
+
+// GetDepartmentByID retrieves a department by its ID.
+func (s *organizationService) GetDepartmentByID(ctx context.Context, id uint) (*models.Department, error) {
+	return s.orgRepo.GetDepartmentByID(ctx, id)
+}
+
+// ListDepartments retrieves departments matching the given filters.
+func (s *organizationService) ListDepartments(ctx context.Context, filters map[string]interface{}) ([]models.Department, error) {
+	return s.orgRepo.ListDepartments(ctx, filters)
+}
+
+// UpdateDepartment updates the name and manager of a department. Departments never change company.
+func (s *organizationService) UpdateDepartment(ctx context.Context, department *models.Department) error {
+	existing, err := s.orgRepo.GetDepartmentByID(ctx, department.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("department not found")
+	}
+	existing.Name = department.Name
+	existing.ManagerID = department.ManagerID
+	if err := s.validateDepartment(ctx, existing); err != nil {
+		return err
+	}
+	if err := s.orgRepo.UpdateDepartment(ctx, existing); err != nil {
+		return err
+	}
+	*department = *existing
+	return nil
+}
+
+// DeleteDepartment deletes a department without teams nor current members.
+func (s *organizationService) DeleteDepartment(ctx context.Context, id uint) error {
+	teams, err := s.orgRepo.ListTeams(ctx, map[string]interface{}{"department_id": id})
+	if err != nil {
+		return err
+	}
+	if len(teams) > 0 {
+		return fmt.Errorf("%w: the department still has teams", ErrInvalidOrganization)
+	}
+	members, err := s.orgRepo.CountActiveOrgAssignments(ctx, "department_id", id, time.Now())
+	if err != nil {
+		return err
+	}
+	if members > 0 {
+		return fmt.Errorf("%w: the department still has members", ErrInvalidOrganization)
+	}
+	return s.orgRepo.DeleteDepartment(ctx, id)
+}
+
+// CreateTeam validates and stores a team.
+func (s *organizationService) CreateTeam(ctx context.Context, team *models.Team) error {
+	if err := s.validateTeam(ctx, team); err != nil {
+		return err
+	}
+	return s.orgRepo.CreateTeam(ctx, team)
+}
+
+// GetTeamByID retrieves a team by its ID.
+func (s *organizationService) GetTeamByID(ctx context.Context, id uint) (*models.Team, error) {
+	return s.orgRepo.GetTeamByID(ctx, id)
+}
+
+// ListTeams retrieves teams matching the given filters.
+func (s *organizationService) ListTeams(ctx context.Context, filters map[string]interface{}) ([]models.Team, error) {
+	return s.orgRepo.ListTeams(ctx, filters)
+}
+
+// UpdateTeam updates the name and manager of a team. Teams never change department.
+func (s *organizationService) UpdateTeam(ctx context.Context, team *models.Team) error {
+	existing, err := s.orgRepo.GetTeamByID(ctx, team.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.New("team not found")
+	}
+	existing.Name = team.Name
+	existing.ManagerID = team.ManagerID
+	if err := s.validateTeam(ctx, existing); err != nil {
+		return err
+	}
+	if err := s.orgRepo.UpdateTeam(ctx, existing); err != nil {
+		return err
+	}
+	*team = *existing
+	return nil
+}
+
+// DeleteTeam deletes a team without current members.
+func (s *organizationService) DeleteTeam(ctx context.Context, id uint) error {
+	members, err := s.orgRepo.CountActiveOrgAssignments(ctx, "team_id", id, time.Now())
+	if err != nil {
+		return err
+	}
+	if members > 0 {
+		return fmt.Errorf("%w: the team still has members", ErrInvalidOrganization)
+	}
+	return s.orgRepo.DeleteTeam(ctx, id)
+}
+
+// ListOrgAssignments retrieves the department and team history of an employee.
+func (s *organizationService) ListOrgAssignments(ctx context.Context, employeeID uint) ([]models.OrgAssignment, error) {
+	return s.orgRepo.ListOrgAssignments(ctx, employeeID)
+}
+
+// AssignEmployee ends the current assignment of the employee the day before from and starts the
+// new one. Assignments can only be appended after the start of the latest one.
+func (s *organizationService) AssignEmployee(ctx context.Context, employeeID, departmentID uint, teamID *uint, from time.Time) (*models.OrgAssignment, error) {
+	employee, err := s.employeeRepo.GetEmployeeByID(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		return nil, errors.New("employee not found")
+	}
+	department, err := s.orgRepo.GetDepartmentByID(ctx, departmentID)
+	if err != nil {
+		return nil, err
+	}
+	if department == nil {
+		return nil, fmt.Errorf("%w: department not found", ErrInvalidOrganization)
+	}
+	if department.CompanyID != employee.CompanyID {
+		return nil, fmt.Errorf("%w: the department belongs to another company", ErrInvalidOrganization)
+	}
+	if teamID != nil {
+		team, err := s.orgRepo.GetTeamByID(ctx, *teamID)
+		if err != nil {
+			return nil, err
+		}
+		if team == nil || team.DepartmentID != department.ID {
+			return nil, fmt.Errorf("%w: team not found in the department", ErrInvalidOrganization)
+		}
+	}
+	if from.IsZero() {
+		from = time.Now()
+	}
+	from = truncateToDay(from)
+
+	assignments, err := s.orgRepo.ListOrgAssignments(ctx, employee.ID)
+	if err != nil {
+		return nil, err
+	}
+	var current *models.OrgAssignment
+	if len(assignments) > 0 {
+		latest := assignments[len(assignments)-1]
+		if !from.After(latest.EffectiveFrom.ToTime()) {
+			return nil, fmt.Errorf("%w: the assignment must take effect after %s, the start of the current one",
+				ErrInvalidOrganization, latest.EffectiveFrom.ToTime().Format("2006-01-02"))
+		}
+		if latest.EffectiveTo == nil || !latest.EffectiveTo.ToTime().Before(from) {
+			dayBefore := types.DateOnly(from.AddDate(0, 0, -1))
+			latest.EffectiveTo = &dayBefore
+			current = &latest
+		}
+	}
+
+	next := &models.OrgAssignment{
+		EmployeeID:    employee.ID,
+		DepartmentID:  department.ID,
+		TeamID:        teamID,
+		EffectiveFrom: types.DateOnly(from),
+	}
+	if err := s.orgRepo.ReplaceOrgAssignment(ctx, current, next); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// EndOrgAssignment ends the open assignment of an employee on date.
+func (s *organizationService) EndOrgAssignment(ctx context.Context, employeeID uint, date time.Time) (*models.OrgAssignment, error) {
+	assignments, err := s.orgRepo.ListOrgAssignments(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if len(assignments) == 0 || assignments[len(assignments)-1].EffectiveTo != nil {
+		return nil, fmt.Errorf("%w: the employee has no open assignment", ErrInvalidOrganization)
+	}
+	latest := assignments[len(assignments)-1]
+	if date.IsZero() {
+		date = time.Now()
+	}
+	if truncateToDay(date).Before(latest.EffectiveFrom.ToTime()) {
+		return nil, fmt.Errorf("%w: the assignment starts on %s", ErrInvalidOrganization,
+			latest.EffectiveFrom.ToTime().Format("2006-01-02"))
+	}
+	end := types.DateOnly(truncateToDay(date))
+	latest.EffectiveTo = &end
+	if err := s.orgRepo.UpdateOrgAssignment(ctx, &latest); err != nil {
+		return nil, err
+	}
+	return &latest, nil
+}
+
+// GetReportingTree builds the reporting lines of the departments of a company on date.
+func (s *organizationService) GetReportingTree(ctx context.Context, companyID, managerID uint, date time.Time) ([]*ReportingLine, error) {
+	departmentFilters := map[string]interface{}{}
+	if companyID != 0 {
+		departmentFilters["company_id"] = companyID
+	}
+	departments, err := s.orgRepo.ListDepartments(ctx, departmentFilters)
+	if err != nil {
+		return nil, err
+	}
+	teams, err := s.orgRepo.ListTeams(ctx, map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.orgRepo.ListOrgMembersAt(ctx, companyID, date)
+	if err != nil {
+		return nil, err
+	}
+	nodes, parent := buildReportingLines(departments, teams, members)
+
+	// Managers who are not members only have their user to name them.
+	var unnamed []uint
+	for userID, n := range nodes {
+		if n.Name == "" {
+			unnamed = append(unnamed, userID)
+		}
+	}
+	users, err := s.orgRepo.ListUsersByIDs(ctx, unnamed)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		nodes[user.ID].Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+
+	return assembleReportingTree(nodes, parent, managerID), nil
+}
+
+// buildReportingLines returns the people of the given departments keyed by user ID, and the
+// manager each of them reports to: team managers report to the manager of their department, and
+// members to the manager of their team, or of their department when the team has none. A person
+// reporting to several managers is placed under the first one found, and edges closing a cycle
+// are dropped.
+func buildReportingLines(departments []models.Department, teams []models.Team, members []repositories.OrgMember) (map[uint]*ReportingLine, map[uint]uint) {
+	departmentByID := make(map[uint]*models.Department, len(departments))
+	for i := range departments {
+		departmentByID[departments[i].ID] = &departments[i]
+	}
+	teamByID := map[uint]*models.Team{}
+	var teamIDs []uint
+	for i := range teams {
+		if departmentByID[teams[i].DepartmentID] != nil {
+			teamByID[teams[i].ID] = &teams[i]
+			teamIDs = append(teamIDs, teams[i].ID)
+		}
+	}
+	sort.Slice(teamIDs, func(i, j int) bool { return teamIDs[i] < teamIDs[j] })
+
+	nodes := map[uint]*ReportingLine{}
+	node := func(userID uint) *ReportingLine {
+		if n, ok := nodes[userID]; ok {
+			return n
+		}
+		n := &ReportingLine{UserID: userID, Manages: []string{}, Reports: []*ReportingLine{}}
+		nodes[userID] = n
+		return n
+	}
+	parent := map[uint]uint{}
+	link := func(child, manager uint) {
+		if child == manager {
+			return
+		}
+		if _, ok := parent[child]; ok {
+			return
+		}
+		for up, ok := manager, true; ok; up, ok = parent[up] {
+			if up == child {
+				return
+			}
+		}
+		parent[child] = manager
+	}
+
+	for _, department := range departments {
+		if department.ManagerID != nil {
+			n := node(*department.ManagerID)
+			n.Manages = append(n.Manages, department.Name)
+		}
+	}
+	for _, id := range teamIDs {
+		team := teamByID[id]
+		if team.ManagerID == nil {
+			continue
+		}
+		department := departmentByID[team.DepartmentID]
+		n := node(*team.ManagerID)
+		n.Manages = append(n.Manages, department.Name+" / "+team.Name)
+		if department.ManagerID != nil {
+			link(*team.ManagerID, *department.ManagerID)
+		}
+	}
+	for _, member := range members {
+		employeeID, departmentID := member.EmployeeID, member.DepartmentID
+		n := node(member.UserID)
+		n.EmployeeID = &employeeID
+		n.DepartmentID = &departmentID
+		n.TeamID = member.TeamID
+		n.Name = strings.TrimSpace(member.FirstName + " " + member.LastName)
+
+		if member.TeamID != nil {
+			if team := teamByID[*member.TeamID]; team != nil && team.ManagerID != nil {
+				link(member.UserID, *team.ManagerID)
+				continue
+			}
+		}
+		if department := departmentByID[member.DepartmentID]; department != nil && department.ManagerID != nil {
+			link(member.UserID, *department.ManagerID)
+		}
+	}
+	return nodes, parent
+}
+
+// assembleReportingTree links the people to their manager and returns the roots sorted by name,
+// or only the subtree of managerID when it is not zero.
+func assembleReportingTree(nodes map[uint]*ReportingLine, parent map[uint]uint, managerID uint) []*ReportingLine {
+	roots := []*ReportingLine{}
+	for userID, n := range nodes {
+		if manager, ok := parent[userID]; ok {
+			nodes[manager].Reports = append(nodes[manager].Reports, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+	for _, n := range nodes {
+		sortReportingLines(n.Reports)
+	}
+	sortReportingLines(roots)
+
+	if managerID != 0 {
+		if n, ok := nodes[managerID]; ok {
+			return []*ReportingLine{n}
+		}
+		return []*ReportingLine{}
+	}
+	return roots
+}
+
+// ManagerScope returns the departments and teams a user manages. The scope is empty, not nil,
+// for users who manage nothing.
+func (s *organizationService) ManagerScope(ctx context.Context, userID uint) (*types.OrgScope, error) {
+	scope := &types.OrgScope{DepartmentIDs: []uint{}, TeamIDs: []uint{}}
+	departments, err := s.orgRepo.ListDepartments(ctx, map[string]interface{}{"manager_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	for _, department := range departments {
+		scope.DepartmentIDs = append(scope.DepartmentIDs, department.ID)
+	}
+	teams, err := s.orgRepo.ListTeams(ctx, map[string]interface{}{"manager_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	for _, team := range teams {
+		scope.TeamIDs = append(scope.TeamIDs, team.ID)
+	}
+	return scope, nil
+}
+
+func (s *organizationService) validateDepartment(ctx context.Context, department *models.Department) error {
+	department.Name = strings.TrimSpace(department.Name)
+	if department.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidOrganization)
+	}
+	company, err := s.companyRepo.GetCompanyByID(ctx, department.CompanyID)
+	if err != nil {
+		return err
+	}
+	if company == nil {
+		return fmt.Errorf("%w: company not found", ErrInvalidOrganization)
+	}
+	return s.validateManager(ctx, department.ManagerID)
+}
+
+func (s *organizationService) validateTeam(ctx context.Context, team *models.Team) error {
+	team.Name = strings.TrimSpace(team.Name)
+	if team.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidOrganization)
+	}
+	department, err := s.orgRepo.GetDepartmentByID(ctx, team.DepartmentID)
+	if err != nil {
+		return err
+	}
+	if department == nil {
+		return fmt.Errorf("%w: department not found", ErrInvalidOrganization)
+	}
+	return s.validateManager(ctx, team.ManagerID)
+}
+
+func (s *organizationService) validateManager(ctx context.Context, managerID *uint) error {
+	if managerID == nil {
+		return nil
+	}
+	user, err := s.userRepo.GetUserByID(ctx, *managerID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("%w: manager not found", ErrInvalidOrganization)
+	}
+	return nil
+}
+
+// sortReportingLines orders reporting lines by name, then user ID.
+func sortReportingLines(lines []*ReportingLine) {
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].Name != lines[j].Name {
+			return lines[i].Name < lines[j].Name
+		}
+		return lines[i].UserID < lines[j].UserID
+	})
+}
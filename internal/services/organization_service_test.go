@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+func TestBuildReportingTree(t *testing.T) {
+	id := func(v uint) *uint { return &v }
+	departments := []models.Department{
+		{Model: gorm.Model{ID: 1}, Name: "Operations", ManagerID: id(10)},
+	}
+	teams := []models.Team{
+		{Model: gorm.Model{ID: 1}, DepartmentID: 1, Name: "Night", ManagerID: id(20)},
+		{Model: gorm.Model{ID: 2}, DepartmentID: 1, Name: "Day"}, // no manager
+		{Model: gorm.Model{ID: 3}, DepartmentID: 2, Name: "Other", ManagerID: id(99)},
+	}
+	members := []repositories.OrgMember{
+		{EmployeeID: 1, UserID: 30, FirstName: "Bea", DepartmentID: 1, TeamID: id(1)},
+		{EmployeeID: 2, UserID: 31, FirstName: "Al", DepartmentID: 1, TeamID: id(2)},
+		{EmployeeID: 3, UserID: 20, FirstName: "Team", LastName: "Lead", DepartmentID: 1, TeamID: id(1)},
+		// The department manager is a member of a team they manage through its lead: no cycle.
+		{EmployeeID: 4, UserID: 10, FirstName: "Head", DepartmentID: 1, TeamID: id(1)},
+	}
+
+	nodes, parent := buildReportingLines(departments, teams, members)
+	if _, ok := nodes[99]; ok {
+		t.Errorf("manager of a team outside the departments is in the tree")
+	}
+	tree := assembleReportingTree(nodes, parent, 0)
+
+	if len(tree) != 1 || tree[0].UserID != 10 {
+		t.Fatalf("got %d roots, want the department manager only", len(tree))
+	}
+	reports := tree[0].Reports
+	if len(reports) != 2 || reports[0].Name != "Al" || reports[1].Name != "Team Lead" {
+		t.Fatalf("department manager reports: got %+v", reports)
+	}
+	if lead := reports[1]; len(lead.Reports) != 1 || lead.Reports[0].UserID != 30 {
+		t.Errorf("team lead reports: got %+v", lead.Reports)
+	}
+	if len(tree[0].Manages) != 1 || len(reports[1].Manages) != 1 || reports[1].Manages[0] != "Operations / Night" {
+		t.Errorf("manages: got %v and %v", tree[0].Manages, reports[1].Manages)
+	}
+
+	nodes, parent = buildReportingLines(departments, teams, members)
+	sub := assembleReportingTree(nodes, parent, 20)
+	if len(sub) != 1 || sub[0].UserID != 20 || len(sub[0].Reports) != 1 {
+		t.Errorf("subtree of the team lead: got %+v", sub)
+	}
+}
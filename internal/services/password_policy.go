@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrWeakPassword is returned when a new password does not meet the password policy.
+var ErrWeakPassword = errors.New("password does not meet the policy")
+
+// PasswordPolicy is enforced on every new password.
+type PasswordPolicy struct {
+	MinLength     int           // Minimum number of characters
+	MinClasses    int           // Minimum number of character classes: lower, upper, digit, symbol
+	HistorySize   int           // Number of previous passwords that cannot be reused
+	ResetTokenTTL time.Duration // Lifetime of password reset tokens
+	breached      map[string]bool
+}
+
+// DefaultPasswordPolicy returns the policy applied without configuration.
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{MinLength: 10, MinClasses: 3, HistorySize: 5, ResetTokenTTL: time.Hour}
+}
+
+// LoadPasswordPolicyFromEnv reads PASSWORD_MIN_LENGTH, PASSWORD_MIN_CLASSES, PASSWORD_HISTORY,
+// PASSWORD_RESET_TTL and PASSWORD_BREACHED_LIST over the default policy.
+func LoadPasswordPolicyFromEnv() (*PasswordPolicy, error) {
+	policy := DefaultPasswordPolicy()
+	for key, target := range map[string]*int{
+		"PASSWORD_MIN_LENGTH":  &policy.MinLength,
+		"PASSWORD_MIN_CLASSES": &policy.MinClasses,
+		"PASSWORD_HISTORY":     &policy.HistorySize,
+	} {
+		if value := os.Getenv(key); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s: %q", key, value)
+			}
+			*target = n
+		}
+	}
+	if value := os.Getenv("PASSWORD_RESET_TTL"); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid PASSWORD_RESET_TTL: %w", err)
+		}
+		policy.ResetTokenTTL = ttl
+	}
+	if path := os.Getenv("PASSWORD_BREACHED_LIST"); path != "" {
+		if err := policy.LoadBreachedList(path); err != nil {
+			return nil, err
+		}
+	}
+	return policy, nil
+}
+
+// LoadBreachedList reads the passwords known to be breached from a file, one per line, either in
+// clear or as the hex SHA-1 of the password optionally followed by ":count" (the format of the
+// Have I Been Pwned downloads).
+func (p *PasswordPolicy) LoadBreachedList(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer file.Close()
+
+	breached := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if hash, _, _ := strings.Cut(line, ":"); len(hash) == 40 && isHex(hash) {
+			breached[strings.ToUpper(hash)] = true
+			continue
+		}
+		breached[sha1Hex(line)] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	p.breached = breached
+	return nil
+}
+
+// Validate checks a new password of username against the policy. The reuse of previous passwords
+// is checked by the user service, which knows the history.
+func (p *PasswordPolicy) Validate(password, username string) error {
+	var problems []string
+	if len([]rune(password)) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("at least %d characters are required", p.MinLength))
+	}
+	if classes := characterClasses(password); classes < p.MinClasses {
+		problems = append(problems, fmt.Sprintf("at least %d of lowercase letters, uppercase letters, digits and symbols are required", p.MinClasses))
+	}
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		problems = append(problems, "the password must not contain the username")
+	}
+	if p.breached[sha1Hex(password)] {
+		problems = append(problems, "the password appears in a list of breached passwords")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrWeakPassword, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// characterClasses counts the classes of characters used by password.
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	count := 0
+	for _, used := range []bool{lower, upper, digit, symbol} {
+		if used {
+			count++
+		}
+	}
+	return count
+}
+
+// sha1Hex returns the uppercase hex SHA-1 of s.
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// isHex reports whether s only holds hexadecimal digits.
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "breached.txt")
+	content := "Password123!\n" + sha1Hex("Summer2025!x") + ":4211\n"
+	if err := os.WriteFile(list, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policy := DefaultPasswordPolicy()
+	if err := policy.LoadBreachedList(list); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		password string
+		valid    bool
+	}{
+		{"correct-Horse-7", true},
+		{"Short1!", false},             // too short
+		{"alllowercaseletters", false}, // one class only
+		{"Password123!", false},        // breached, in clear
+		{"Summer2025!x", false},        // breached, by hash
+		{"my-jdoe-Password1", false},   // contains the username
+	} {
+		err := policy.Validate(tc.password, "JDoe")
+		if tc.valid && err != nil {
+			t.Errorf("%q: unexpected error %v", tc.password, err)
+		}
+		if !tc.valid && !errors.Is(err, ErrWeakPassword) {
+			t.Errorf("%q: got %v, want ErrWeakPassword", tc.password, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+)
+
+// ErrPeriodClosed is returned when an edit targets data inside a closed payroll period.
+var ErrPeriodClosed = errors.New("payroll period is closed")
+
+// ErrWorkDayLocked is returned when an edit targets a workday that is approved or locked.
+var ErrWorkDayLocked = errors.New("workday is not editable")
+
+// PayrollPeriodService defines the interface for payroll period-related operations.
+type PayrollPeriodService interface {
+	CreatePayrollPeriod(ctx context.Context, period *models.PayrollPeriod, actorID uint) error
+	GetPayrollPeriodByID(ctx context.Context, id uint) (*models.PayrollPeriod, error)
+	ListPayrollPeriods(ctx context.Context, filters map[string]interface{}) ([]models.PayrollPeriod, error)
+	ClosePayrollPeriod(ctx context.Context, id uint, actorID uint) (*models.PayrollPeriod, error)
+	ReopenPayrollPeriod(ctx context.Context, id uint, actorID uint, reason string) (*models.PayrollPeriod, error)
+	ListPayrollPeriodEvents(ctx context.Context, id uint) ([]models.PayrollPeriodEvent, error)
+	// EnsureDateOpen fails with ErrPeriodClosed when the date lies in a closed period of the company.
+	// A zero companyID checks the periods of every company.
+	EnsureDateOpen(ctx context.Context, companyID uint, date time.Time) error
+}
+
+// payrollPeriodService implements the PayrollPeriodService interface.
+type payrollPeriodService struct {
+	periodRepo repositories.PayrollPeriodRepository
+}
+
+// NewPayrollPeriodService creates a new instance of PayrollPeriodService.
+func NewPayrollPeriodService(periodRepo repositories.PayrollPeriodRepository) PayrollPeriodService {
+	return &payrollPeriodService{
+		periodRepo: periodRepo,
+	}
+}
+
+// CreatePayrollPeriod creates a new open payroll period after checking it does not overlap another one.
+func (s *payrollPeriodService) CreatePayrollPeriod(ctx context.Context, period *models.PayrollPeriod, actorID uint) error {
+	if period == nil {
+		return errors.New("payroll period is nil")
+	}
+	if period.CompanyID == 0 {
+		return errors.New("company ID is required")
+	}
+	start, end := period.StartDate.ToTime(), period.EndDate.ToTime()
+	if start.IsZero() || end.IsZero() {
+		return errors.New("start date and end date are required")
+	}
+	if end.Before(start) {
+		return errors.New("end date must not be before start date")
+	}
+
+	overlapping, err := s.periodRepo.FindOverlappingPeriod(ctx, period.CompanyID, start, end, 0)
+	if err != nil {
+		return err
+	}
+	if overlapping != nil {
+		return fmt.Errorf("payroll period overlaps existing period %d", overlapping.ID)
+	}
+
+	period.Status = models.PayrollPeriodStatusOpen
+	if err := s.periodRepo.CreatePayrollPeriod(ctx, period); err != nil {
+		return err
+	}
+
+	return s.recordEvent(ctx, period.ID, "created", "", actorID)
+}
+
+// GetPayrollPeriodByID retrieves a payroll period by its ID.
+func (s *payrollPeriodService) GetPayrollPeriodByID(ctx context.Context, id uint) (*models.PayrollPeriod, error) {
+	if id == 0 {
+		return nil, errors.New("invalid payroll period ID")
+	}
+	return s.periodRepo.GetPayrollPeriodByID(ctx, id)
+}
+
+// ListPayrollPeriods retrieves payroll periods matching the given filters.
+func (s *payrollPeriodService) ListPayrollPeriods(ctx context.Context, filters map[string]interface{}) ([]models.PayrollPeriod, error) {
+	return s.periodRepo.ListPayrollPeriods(ctx, filters)
+}
+
+// ClosePayrollPeriod closes a period, freezing the attendance data it covers.
+func (s *payrollPeriodService) ClosePayrollPeriod(ctx context.Context, id uint, actorID uint) (*models.PayrollPeriod, error) {
+	period, err := s.GetPayrollPeriodByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if period == nil {
+		return nil, errors.New("payroll period not found")
+	}
+	if period.Status == models.PayrollPeriodStatusClosed {
+		return nil, errors.New("payroll period is already closed")
+	}
+
+	now := time.Now()
+	period.Status = models.PayrollPeriodStatusClosed
+	period.ClosedAt = &now
+	period.ClosedBy = &actorID
+	if err := s.periodRepo.UpdatePayrollPeriod(ctx, period); err != nil {
+		return nil, err
+	}
+
+	return period, s.recordEvent(ctx, period.ID, "closed", "", actorID)
+}
+
+// ReopenPayrollPeriod reopens a closed period. The reason is mandatory and kept in the audit trail.
+func (s *payrollPeriodService) ReopenPayrollPeriod(ctx context.Context, id uint, actorID uint, reason string) (*models.PayrollPeriod, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("a reason is required to reopen a payroll period")
+	}
+
+	period, err := s.GetPayrollPeriodByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if period == nil {
+		return nil, errors.New("payroll period not found")
+	}
+	if period.Status != models.PayrollPeriodStatusClosed {
+		return nil, errors.New("payroll period is not closed")
+	}
+
+	period.Status = models.PayrollPeriodStatusOpen
+	period.ClosedAt = nil
+	period.ClosedBy = nil
+	if err := s.periodRepo.UpdatePayrollPeriod(ctx, period); err != nil {
+		return nil, err
+	}
+
+	return period, s.recordEvent(ctx, period.ID, "reopened", reason, actorID)
+}
+
+// ListPayrollPeriodEvents retrieves the audit trail of a payroll period.
+func (s *payrollPeriodService) ListPayrollPeriodEvents(ctx context.Context, id uint) ([]models.PayrollPeriodEvent, error) {
+	if id == 0 {
+		return nil, errors.New("invalid payroll period ID")
+	}
+	return s.periodRepo.ListPayrollPeriodEvents(ctx, id)
+}
+
+// EnsureDateOpen fails with ErrPeriodClosed when the date lies in a closed period of the company.
+func (s *payrollPeriodService) EnsureDateOpen(ctx context.Context, companyID uint, date time.Time) error {
+	period, err := s.periodRepo.FindClosedPeriod(ctx, companyID, date)
+	if err != nil {
+		return err
+	}
+	if period != nil {
+		return fmt.Errorf("%w: period %s to %s of company %d no longer accepts changes",
+			ErrPeriodClosed,
+			period.StartDate.ToTime().Format("2006-01-02"),
+			period.EndDate.ToTime().Format("2006-01-02"),
+			period.CompanyID)
+	}
+	return nil
+}
+
+func (s *payrollPeriodService) recordEvent(ctx context.Context, periodID uint, action, reason string, actorID uint) error {
+	event := &models.PayrollPeriodEvent{
+		PayrollPeriodID: periodID,
+		Action:          action,
+		Reason:          reason,
+	}
+	if actorID != 0 {
+		event.UserID = &actorID
+	}
+	return s.periodRepo.CreatePayrollPeriodEvent(ctx, event)
+}
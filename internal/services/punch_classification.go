@@ -0,0 +1,52 @@
+package services
+
+import (
+	"time"
+
+	"point-system-api/internal/models"
+)
+
+// maxShiftHours is the longest time after the first IN of a day for which a punch following
+// an IN is still classified as OUT.
+const maxShiftHours = 12
+
+// classifyPunches recomputes the system punch of the punches at or after from, the way ingestion
+// classifies them one by one: a punch is an OUT when the previous punch is an IN of a shift
+// started at most maxShiftHours earlier, and an IN otherwise. punches must be the punches of a
+// single employee sorted by timestamp; the punches before from only seed the classification.
+// It returns the punches whose system punch changed.
+func classifyPunches(punches []models.AttendanceLog, from time.Time) []*models.AttendanceLog {
+	var changed []*models.AttendanceLog
+	for i := range punches {
+		punch := &punches[i]
+		if punch.Timestamp.Before(from) {
+			continue
+		}
+
+		systemPunch := "IN"
+		if i > 0 && punches[i-1].SystemPunch == "IN" {
+			previous := punches[i-1]
+			if firstIn := firstInOfDay(punches[:i], previous.Timestamp); firstIn != nil &&
+				punch.Timestamp.Sub(firstIn.Timestamp).Hours() <= maxShiftHours {
+				systemPunch = "OUT"
+			}
+		}
+
+		if punch.SystemPunch != systemPunch {
+			punch.SystemPunch = systemPunch
+			changed = append(changed, punch)
+		}
+	}
+	return changed
+}
+
+// firstInOfDay returns the earliest IN among punches on the calendar day of day, or nil.
+func firstInOfDay(punches []models.AttendanceLog, day time.Time) *models.AttendanceLog {
+	date := day.Format("2006-01-02")
+	for i := range punches {
+		if punches[i].SystemPunch == "IN" && punches[i].Timestamp.Format("2006-01-02") == date {
+			return &punches[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"point-system-api/internal/models"
+)
+
+func TestClassifyPunches(t *testing.T) {
+	at := func(d, h, m int) time.Time { return time.Date(2024, 5, d, h, m, 0, 0, time.UTC) }
+	punches := []models.AttendanceLog{
+		{Timestamp: at(1, 8, 0), SystemPunch: "IN"}, // seed, kept as is
+		{Timestamp: at(1, 17, 0), SystemPunch: "OUT"},
+		{Timestamp: at(2, 8, 0)},
+		{Timestamp: at(2, 12, 0)},
+		{Timestamp: at(2, 13, 0), SystemPunch: "OUT"},
+		{Timestamp: at(2, 17, 0), SystemPunch: "OUT"},
+		{Timestamp: at(3, 22, 0)},
+		{Timestamp: at(4, 6, 0)},  // night shift, 8h after the IN of the previous day
+		{Timestamp: at(4, 21, 0)}, // IN
+		{Timestamp: at(5, 10, 0)}, // 13h after the IN: a new shift
+	}
+
+	changed := classifyPunches(punches, at(2, 0, 0))
+
+	want := []string{"IN", "OUT", "IN", "OUT", "IN", "OUT", "IN", "OUT", "IN", "IN"}
+	for i, punch := range punches {
+		if punch.SystemPunch != want[i] {
+			t.Errorf("punch %d at %s: got %q, want %q", i, punch.Timestamp.Format(time.DateTime), punch.SystemPunch, want[i])
+		}
+	}
+	if len(changed) != 7 {
+		t.Errorf("got %d changed punches, want 7", len(changed))
+	}
+}
@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+// ErrInvalidPunchCorrection is returned when a punch correction request is incomplete, targets a
+// punch of another employee or duplicates a pending request.
+var ErrInvalidPunchCorrection = errors.New("invalid punch correction request")
+
+// ErrPunchCorrectionNotFound is returned when a punch correction request does not exist.
+var ErrPunchCorrectionNotFound = errors.New("punch correction request not found")
+
+// PunchCorrectionApproval is an approved punch correction request and what it did to the punches.
+type PunchCorrectionApproval struct {
+	Request *models.PunchCorrectionRequest `json:"request"`
+	Change  *PunchChange                   `json:"change"`
+}
+
+// PunchCorrectionService defines the interface for the requests of employees to fix their punches.
+type PunchCorrectionService interface {
+	// SubmitPunchCorrection files a pending request of an employee to add, adjust or void a punch.
+	SubmitPunchCorrection(ctx context.Context, employeeID uint, submission types.PunchCorrectionSubmission) (*models.PunchCorrectionRequest, error)
+	GetPunchCorrectionByID(ctx context.Context, id uint) (*models.PunchCorrectionRequest, error)
+	ListPunchCorrections(ctx context.Context, filters map[string]interface{}) ([]models.PunchCorrectionRequest, error)
+	// ApprovePunchCorrection approves a pending request and applies it as a manual punch entered,
+	// adjusted or voided on behalf of the reviewer, linked to the request. A non-nil scope restricts
+	// the requests the reviewer may approve to those listed to them.
+	ApprovePunchCorrection(ctx context.Context, id uint, reviewerID uint, comment string, scope *types.OrgScope) (*PunchCorrectionApproval, error)
+	RejectPunchCorrection(ctx context.Context, id uint, reviewerID uint, comment string, scope *types.OrgScope) (*models.PunchCorrectionRequest, error)
+}
+
+// punchCorrectionService implements the PunchCorrectionService interface.
+type punchCorrectionService struct {
+	unitOfWork         repositories.UnitOfWork
+	correctionRepo     repositories.PunchCorrectionRepository
+	employeeRepo       repositories.EmployeeRepository
+	attendanceRepo     repositories.AttendanceRepository
+	periodService      PayrollPeriodService
+	manualPunchService ManualPunchService
+}
+
+// NewPunchCorrectionService creates a new instance of PunchCorrectionService.
+func NewPunchCorrectionService(unitOfWork repositories.UnitOfWork,
+	correctionRepo repositories.PunchCorrectionRepository,
+	employeeRepo repositories.EmployeeRepository,
+	attendanceRepo repositories.AttendanceRepository,
+	periodService PayrollPeriodService,
+	manualPunchService ManualPunchService) PunchCorrectionService {
+	return &punchCorrectionService{
+		unitOfWork:         unitOfWork,
+		correctionRepo:     correctionRepo,
+		employeeRepo:       employeeRepo,
+		attendanceRepo:     attendanceRepo,
+		periodService:      periodService,
+		manualPunchService: manualPunchService,
+	}
+}
+
+// SubmitPunchCorrection validates a correction against the punches of the employee and the payroll
+// periods of their company, and stores it pending review. A punch has one pending request at most.
+func (s *punchCorrectionService) SubmitPunchCorrection(ctx context.Context, employeeID uint, submission types.PunchCorrectionSubmission) (*models.PunchCorrectionRequest, error) {
+	employee, err := s.employeeRepo.GetEmployeeByID(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		return nil, errors.New("employee not found")
+	}
+
+	request := &models.PunchCorrectionRequest{
+		EmployeeID:      employee.ID,
+		CompanyID:       employee.CompanyID,
+		Kind:            strings.ToLower(strings.TrimSpace(submission.Kind)),
+		AttendanceLogID: submission.AttendanceLogID,
+		Timestamp:       submission.Timestamp,
+		Reason:          strings.TrimSpace(submission.Reason),
+		Status:          models.PunchCorrectionPending,
+	}
+	if request.Reason == "" || len(request.Reason) > 500 {
+		return nil, fmt.Errorf("%w: a reason of at most 500 characters is required", ErrInvalidPunchCorrection)
+	}
+	switch request.Kind {
+	case models.PunchCorrectionAdd:
+		if request.Timestamp == nil || request.AttendanceLogID != nil {
+			return nil, fmt.Errorf("%w: adding a punch takes its timestamp and no attendance log", ErrInvalidPunchCorrection)
+		}
+	case models.PunchCorrectionAdjust:
+		if request.Timestamp == nil || request.AttendanceLogID == nil {
+			return nil, fmt.Errorf("%w: adjusting a punch takes its attendance log and the corrected timestamp", ErrInvalidPunchCorrection)
+		}
+	case models.PunchCorrectionVoid:
+		if request.AttendanceLogID == nil {
+			return nil, fmt.Errorf("%w: voiding a punch takes its attendance log", ErrInvalidPunchCorrection)
+		}
+		request.Timestamp = nil
+	default:
+		return nil, fmt.Errorf("%w: kind must be add, adjust or void", ErrInvalidPunchCorrection)
+	}
+
+	// The days the correction changes must still be open to changes.
+	var dates []time.Time
+	if request.Timestamp != nil {
+		if request.Timestamp.After(time.Now()) {
+			return nil, fmt.Errorf("%w: the timestamp is in the future", ErrInvalidPunchCorrection)
+		}
+		dates = append(dates, *request.Timestamp)
+	}
+	if request.AttendanceLogID != nil {
+		punch, err := s.attendanceRepo.GetAttendanceByID(ctx, *request.AttendanceLogID)
+		if err != nil {
+			return nil, err
+		}
+		if punch == nil || punch.EmployeeID == nil || *punch.EmployeeID != employee.ID {
+			return nil, fmt.Errorf("%w: attendance log %d is not a punch of the employee", ErrInvalidPunchCorrection, *request.AttendanceLogID)
+		}
+		if punch.IsVoided() {
+			return nil, fmt.Errorf("%w: attendance log %d is voided", ErrInvalidPunchCorrection, punch.ID)
+		}
+		dates = append(dates, punch.Timestamp)
+
+		pending, err := s.correctionRepo.ListPunchCorrections(ctx, map[string]interface{}{
+			"employee_id": employee.ID,
+			"status":      models.PunchCorrectionPending,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range pending {
+			if other.AttendanceLogID != nil && *other.AttendanceLogID == *request.AttendanceLogID {
+				return nil, fmt.Errorf("%w: request %d already corrects this punch", ErrInvalidPunchCorrection, other.ID)
+			}
+		}
+	}
+	for _, date := range dates {
+		if err := s.periodService.EnsureDateOpen(ctx, employee.CompanyID, date); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.correctionRepo.CreatePunchCorrection(ctx, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// GetPunchCorrectionByID retrieves a punch correction request by its ID.
+func (s *punchCorrectionService) GetPunchCorrectionByID(ctx context.Context, id uint) (*models.PunchCorrectionRequest, error) {
+	return s.correctionRepo.GetPunchCorrectionByID(ctx, id)
+}
+
+// ListPunchCorrections retrieves punch correction requests filtered by employee_id, company_id,
+// status and org_scope.
+func (s *punchCorrectionService) ListPunchCorrections(ctx context.Context, filters map[string]interface{}) ([]models.PunchCorrectionRequest, error) {
+	return s.correctionRepo.ListPunchCorrections(ctx, filters)
+}
+
+// ApprovePunchCorrection applies a pending request through the manual punches, which reclassifies
+// the punches of the employee and refreshes the raw attendances of the days it changes. The punch
+// and the request reference each other.
+func (s *punchCorrectionService) ApprovePunchCorrection(ctx context.Context, id uint, reviewerID uint, comment string, scope *types.OrgScope) (*PunchCorrectionApproval, error) {
+	request, err := s.getPendingPunchCorrection(ctx, id, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	approval := &PunchCorrectionApproval{Request: request}
+	err = s.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		var err error
+		switch request.Kind {
+		case models.PunchCorrectionAdd:
+			approval.Change, err = s.manualPunchService.AddPunch(ctx, types.ManualPunchRequest{
+				EmployeeID: request.EmployeeID,
+				Timestamp:  *request.Timestamp,
+				Source:     models.PunchSourceManual,
+				Reason:     request.Reason,
+			}, reviewerID)
+		case models.PunchCorrectionAdjust:
+			approval.Change, err = s.manualPunchService.AdjustPunch(ctx, *request.AttendanceLogID,
+				types.PunchEdit{Timestamp: *request.Timestamp, Reason: request.Reason}, reviewerID)
+		case models.PunchCorrectionVoid:
+			approval.Change, err = s.manualPunchService.VoidPunch(ctx, *request.AttendanceLogID,
+				types.PunchVoid{Reason: request.Reason}, reviewerID)
+		default:
+			return fmt.Errorf("%w: unknown kind %s", ErrInvalidPunchCorrection, request.Kind)
+		}
+		if err != nil {
+			return err
+		}
+
+		punch := approval.Change.Punch
+		punch.PunchCorrectionID = &request.ID
+		if err := s.attendanceRepo.UpdateAttendanceLog(ctx, punch); err != nil {
+			return fmt.Errorf("failed to link punch to its correction request: %w", err)
+		}
+
+		now := time.Now()
+		request.Status = models.PunchCorrectionApproved
+		request.ReviewedBy = &reviewerID
+		request.ReviewedAt = &now
+		request.ReviewComment = comment
+		request.ResultAttendanceLogID = &punch.ID
+		return s.correctionRepo.UpdatePunchCorrection(ctx, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+// RejectPunchCorrection rejects a pending request.
+func (s *punchCorrectionService) RejectPunchCorrection(ctx context.Context, id uint, reviewerID uint, comment string, scope *types.OrgScope) (*models.PunchCorrectionRequest, error) {
+	request, err := s.getPendingPunchCorrection(ctx, id, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	request.Status = models.PunchCorrectionRejected
+	request.ReviewedBy = &reviewerID
+	request.ReviewedAt = &now
+	request.ReviewComment = comment
+	if err := s.correctionRepo.UpdatePunchCorrection(ctx, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// getPendingPunchCorrection loads a pending request. Requests outside a non-nil scope are not
+// found, as in the review queue.
+func (s *punchCorrectionService) getPendingPunchCorrection(ctx context.Context, id uint, scope *types.OrgScope) (*models.PunchCorrectionRequest, error) {
+	request, err := s.correctionRepo.GetPunchCorrectionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, ErrPunchCorrectionNotFound
+	}
+	if scope != nil {
+		inScope, err := s.correctionRepo.ListPunchCorrections(ctx, map[string]interface{}{
+			"employee_id": request.EmployeeID,
+			"org_scope":   scope,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(inScope, func(other models.PunchCorrectionRequest) bool { return other.ID == request.ID }) {
+			return nil, ErrPunchCorrectionNotFound
+		}
+	}
+	if request.Status != models.PunchCorrectionPending {
+		return nil, fmt.Errorf("%w: request is already %s", ErrInvalidPunchCorrection, request.Status)
+	}
+	return request, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// correctionFixture serves the pending requests 1 of employee 7, of department 1, and 2 of
+// employee 8, of department 2, and fails on any other call.
+type correctionFixture struct {
+	repositories.PunchCorrectionRepository
+	requests map[uint]*models.PunchCorrectionRequest
+}
+
+func (f *correctionFixture) GetPunchCorrectionByID(ctx context.Context, id uint) (*models.PunchCorrectionRequest, error) {
+	return f.requests[id], nil
+}
+
+func (f *correctionFixture) ListPunchCorrections(ctx context.Context, filters map[string]interface{}) ([]models.PunchCorrectionRequest, error) {
+	departments := map[uint]uint{7: 1, 8: 2}
+	listed := []models.PunchCorrectionRequest{}
+	for _, request := range f.requests {
+		if request.EmployeeID != filters["employee_id"] {
+			continue
+		}
+		if scope, ok := filters["org_scope"].(*types.OrgScope); ok && !slices.Contains(scope.DepartmentIDs, departments[request.EmployeeID]) {
+			continue
+		}
+		listed = append(listed, *request)
+	}
+	return listed, nil
+}
+
+func (f *correctionFixture) UpdatePunchCorrection(ctx context.Context, request *models.PunchCorrectionRequest) error {
+	f.requests[request.ID] = request
+	return nil
+}
+
+func TestReviewPunchCorrectionWithinScope(t *testing.T) {
+	fixture := &correctionFixture{requests: map[uint]*models.PunchCorrectionRequest{
+		1: {Model: gorm.Model{ID: 1}, EmployeeID: 7, Status: models.PunchCorrectionPending},
+		2: {Model: gorm.Model{ID: 2}, EmployeeID: 8, Status: models.PunchCorrectionPending},
+	}}
+	service := NewPunchCorrectionService(nil, fixture, nil, nil, nil, nil)
+	scope := &types.OrgScope{DepartmentIDs: []uint{1}, TeamIDs: []uint{}}
+
+	if _, err := service.ApprovePunchCorrection(context.Background(), 2, 9, "", scope); !errors.Is(err, ErrPunchCorrectionNotFound) {
+		t.Errorf("approving a request of another department: got %v, want ErrPunchCorrectionNotFound", err)
+	}
+	if _, err := service.RejectPunchCorrection(context.Background(), 2, 9, "", scope); !errors.Is(err, ErrPunchCorrectionNotFound) {
+		t.Errorf("rejecting a request of another department: got %v, want ErrPunchCorrectionNotFound", err)
+	}
+	if fixture.requests[2].Status != models.PunchCorrectionPending {
+		t.Fatalf("request of another department is %s", fixture.requests[2].Status)
+	}
+
+	if _, err := service.RejectPunchCorrection(context.Background(), 1, 9, "", scope); err != nil {
+		t.Errorf("rejecting a request of the department: %v", err)
+	}
+	// Without a scope, every request of the company may be reviewed.
+	if _, err := service.RejectPunchCorrection(context.Background(), 2, 9, "", nil); err != nil {
+		t.Errorf("rejecting without a scope: %v", err)
+	}
+}
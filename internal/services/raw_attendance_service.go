@@ -4,34 +4,83 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"maps"
 	"point-system-api/internal/models"
 	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+	"reflect"
+	"slices"
 	"time"
 )
 
+// ErrRawAttendanceVersionNotFound is returned when a version of a raw attendance does not exist.
+var ErrRawAttendanceVersionNotFound = errors.New("raw attendance version not found")
+
+// revertedColumns are the columns a revert restores. The workday, company and employee of a row
+// never change.
+var revertedColumns = []string{
+	"employee_name", "position", "start_at", "end_at", "total_hours", "total_hour_out", "status", "notes",
+	"calculate_over_time", "calculate_lunch_hour", "manually_edited", "leave_request_id", "justification",
+}
+
 type RawAttendanceService interface {
 	CreateRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance) error
 	GetRawAttendanceByID(ctx context.Context, id uint) (*models.RawAttendance, error)
-	GetRawAttendancesByCompanyIDAndWorkDay(ctx context.Context, companyID uint, workDayID uint) ([]*models.RawAttendance, error)
+	GetRawAttendancesByCompanyIDAndWorkDay(ctx context.Context, companyID uint, workDayID uint, scope *types.OrgScope) ([]*models.RawAttendance, error)
 	UpdateRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance, id uint) error
 	DeleteRawAttendance(ctx context.Context, id uint) error
-	ListRawAttendances(ctx context.Context) ([]*models.RawAttendance, error)
+	ListRawAttendances(ctx context.Context, scope *types.OrgScope) ([]*models.RawAttendance, error)
+	// GetCurrentVersion returns the number of the latest version of a raw attendance.
+	GetCurrentVersion(ctx context.Context, id uint) (uint, error)
+	// ListVersions returns the versions of a raw attendance, oldest first.
+	ListVersions(ctx context.Context, id uint) ([]models.RawAttendanceVersion, error)
+	// DiffVersions returns the columns that differ between two versions of a raw attendance.
+	DiffVersions(ctx context.Context, id uint, from, to uint) (*types.RawAttendanceVersionDiff, error)
+	// RevertToVersion restores the values of a version of a raw attendance, recorded as a new version.
+	RevertToVersion(ctx context.Context, id uint, version uint) (*models.RawAttendance, error)
 }
 
 type rawAttendanceService struct {
 	rawAttendanceRepo repositories.RawAttendanceRepository
+	versionRepo       repositories.RawAttendanceVersionRepository
+	workDayRepo       repositories.WorkDayRepository
+	periodService     PayrollPeriodService
 }
 
-func NewRawAttendanceService(rawAttendanceRepo repositories.RawAttendanceRepository) RawAttendanceService {
+func NewRawAttendanceService(rawAttendanceRepo repositories.RawAttendanceRepository, versionRepo repositories.RawAttendanceVersionRepository, workDayRepo repositories.WorkDayRepository, periodService PayrollPeriodService) RawAttendanceService {
 	return &rawAttendanceService{
 		rawAttendanceRepo: rawAttendanceRepo,
+		versionRepo:       versionRepo,
+		workDayRepo:       workDayRepo,
+		periodService:     periodService,
+	}
+}
+
+// ensureEditable rejects changes to rows of a workday that is approved or locked,
+// or that falls inside a closed payroll period of the row's company.
+func (s *rawAttendanceService) ensureEditable(ctx context.Context, workDayID, companyID uint) error {
+	workday, err := s.workDayRepo.GetWorkDayByID(ctx, workDayID)
+	if err != nil {
+		return err
+	}
+	if workday == nil {
+		return errors.New("workday not found")
 	}
+	if !workday.IsEditable() {
+		return fmt.Errorf("%w: workday %d is %s", ErrWorkDayLocked, workday.ID, workday.Status)
+	}
+	return s.periodService.EnsureDateOpen(ctx, companyID, workday.Date.ToTime())
 }
 
 func (s *rawAttendanceService) CreateRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance) error {
 	if rawAttendance == nil {
 		return errors.New("raw attendance is nil")
 	}
+	if err := s.ensureEditable(ctx, rawAttendance.WorkDayID, rawAttendance.CompanyID); err != nil {
+		return err
+	}
+	ctx = types.WithRawAttendanceSource(ctx, types.RawAttendanceSourceManual)
 	return s.rawAttendanceRepo.CreateRawAttendance(ctx, rawAttendance)
 }
 
@@ -39,8 +88,8 @@ func (s *rawAttendanceService) GetRawAttendanceByID(ctx context.Context, id uint
 	return s.rawAttendanceRepo.GetRawAttendanceByID(ctx, id)
 }
 
-func (s *rawAttendanceService) GetRawAttendancesByCompanyIDAndWorkDay(ctx context.Context, companyID uint, workDayID uint) ([]*models.RawAttendance, error) {
-	return s.rawAttendanceRepo.GetRawAttendancesByCompanyIDAndWorkDay(ctx, companyID, workDayID)
+func (s *rawAttendanceService) GetRawAttendancesByCompanyIDAndWorkDay(ctx context.Context, companyID uint, workDayID uint, scope *types.OrgScope) ([]*models.RawAttendance, error) {
+	return s.rawAttendanceRepo.GetRawAttendancesByCompanyIDAndWorkDay(ctx, companyID, workDayID, scope)
 }
 
 func (s *rawAttendanceService) UpdateRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance, id uint) error {
@@ -48,6 +97,14 @@ func (s *rawAttendanceService) UpdateRawAttendance(ctx context.Context, rawAtten
 		return errors.New("raw attendance is nil")
 	}
 
+	existing, err := s.rawAttendanceRepo.GetRawAttendanceByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureEditable(ctx, existing.WorkDayID, existing.CompanyID); err != nil {
+		return err
+	}
+
 	if rawAttendance.StartAt.String != "" && rawAttendance.EndAt.String != "" {
 		const layoutWithoutSeconds = "15:04"
 		// Use only first 5 characters to ignore seconds
@@ -69,13 +126,146 @@ func (s *rawAttendanceService) UpdateRawAttendance(ctx context.Context, rawAtten
 		}
 	}
 
+	rawAttendance.Justification = absenceJustification(rawAttendance.Status, existing.Justification)
+	// Workday regeneration preserves corrected times and status, not rows whose notes were edited.
+	rawAttendance.ManuallyEdited = existing.ManuallyEdited || correctsPunches(existing, rawAttendance)
+
+	ctx = types.WithRawAttendanceSource(ctx, types.RawAttendanceSourceManual)
 	return s.rawAttendanceRepo.UpdateRawAttendance(ctx, rawAttendance, id)
 }
 
+// correctsPunches reports whether an update changes the times or the status of a row. Times are
+// compared to the minute, as they are entered without seconds.
+func correctsPunches(existing, updated *models.RawAttendance) bool {
+	sameTime := func(a, b sql.NullString) bool {
+		if a.Valid != b.Valid {
+			return false
+		}
+		if len(a.String) >= 5 && len(b.String) >= 5 {
+			return a.String[:5] == b.String[:5]
+		}
+		return a.String == b.String
+	}
+	return !sameTime(existing.StartAt, updated.StartAt) ||
+		!sameTime(existing.EndAt, updated.EndAt) ||
+		existing.Status != updated.Status
+}
+
 func (s *rawAttendanceService) DeleteRawAttendance(ctx context.Context, id uint) error {
+	existing, err := s.rawAttendanceRepo.GetRawAttendanceByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureEditable(ctx, existing.WorkDayID, existing.CompanyID); err != nil {
+		return err
+	}
+	ctx = types.WithRawAttendanceSource(ctx, types.RawAttendanceSourceManual)
 	return s.rawAttendanceRepo.DeleteRawAttendance(ctx, id)
 }
 
-func (s *rawAttendanceService) ListRawAttendances(ctx context.Context) ([]*models.RawAttendance, error) {
-	return s.rawAttendanceRepo.ListRawAttendances(ctx)
+func (s *rawAttendanceService) ListRawAttendances(ctx context.Context, scope *types.OrgScope) ([]*models.RawAttendance, error) {
+	return s.rawAttendanceRepo.ListRawAttendances(ctx, scope)
+}
+
+// GetCurrentVersion retrieves the number of the latest version of a raw attendance, 0 for rows
+// not changed since versions are recorded.
+func (s *rawAttendanceService) GetCurrentVersion(ctx context.Context, id uint) (uint, error) {
+	return s.versionRepo.GetCurrentVersion(ctx, id)
+}
+
+// ListVersions retrieves the timeline of a raw attendance: its generation, manual edits,
+// regenerations, ...
+func (s *rawAttendanceService) ListVersions(ctx context.Context, id uint) ([]models.RawAttendanceVersion, error) {
+	if _, err := s.getRawAttendance(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.versionRepo.ListRawAttendanceVersions(ctx, id)
+}
+
+// DiffVersions compares two versions of a raw attendance, in either order.
+func (s *rawAttendanceService) DiffVersions(ctx context.Context, id uint, from, to uint) (*types.RawAttendanceVersionDiff, error) {
+	fromVersion, err := s.getVersion(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := s.getVersion(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+	return &types.RawAttendanceVersionDiff{
+		RawAttendanceID: id,
+		From:            from,
+		To:              to,
+		Changes:         diffRowValues(fromVersion.Values, toVersion.Values),
+	}, nil
+}
+
+// RevertToVersion restores the values of a version, provided the workday of the row is editable
+// and its date is in an open payroll period. Restoring the manually edited flag of the version,
+// a row reverted to a generated version follows its punches again on regeneration.
+func (s *rawAttendanceService) RevertToVersion(ctx context.Context, id uint, version uint) (*models.RawAttendance, error) {
+	existing, err := s.getRawAttendance(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureEditable(ctx, existing.WorkDayID, existing.CompanyID); err != nil {
+		return nil, err
+	}
+	target, err := s.getVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(revertedColumns))
+	for _, column := range revertedColumns {
+		if value, ok := target.Values[column]; ok {
+			values[column] = value
+		}
+	}
+	ctx = types.WithRawAttendanceSource(ctx, types.RawAttendanceSourceRevert)
+	if err := s.rawAttendanceRepo.RestoreRawAttendance(ctx, id, values); err != nil {
+		return nil, err
+	}
+	return s.getRawAttendance(ctx, id)
+}
+
+func (s *rawAttendanceService) getRawAttendance(ctx context.Context, id uint) (*models.RawAttendance, error) {
+	rawAttendance, err := s.rawAttendanceRepo.GetRawAttendanceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rawAttendance == nil {
+		return nil, errors.New("raw attendance not found")
+	}
+	return rawAttendance, nil
+}
+
+func (s *rawAttendanceService) getVersion(ctx context.Context, id uint, version uint) (*models.RawAttendanceVersion, error) {
+	found, err := s.versionRepo.GetRawAttendanceVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: version %d of raw attendance %d", ErrRawAttendanceVersionNotFound, version, id)
+	}
+	return found, nil
+}
+
+// diffRowValues returns the columns whose values differ between two versions of a row, by name.
+func diffRowValues(from, to types.RowValues) []types.FieldChange {
+	columns := map[string]bool{}
+	for column := range from {
+		columns[column] = true
+	}
+	for column := range to {
+		columns[column] = true
+	}
+
+	changes := []types.FieldChange{}
+	for _, column := range slices.Sorted(maps.Keys(columns)) {
+		if !reflect.DeepEqual(from[column], to[column]) {
+			changes = append(changes, types.FieldChange{Field: column, Old: from[column], New: to[column]})
+		}
+	}
+	return changes
 }
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+func TestCorrectsPunches(t *testing.T) {
+	text := func(value string) sql.NullString { return sql.NullString{String: value, Valid: true} }
+	existing := &models.RawAttendance{StartAt: text("08:00:00"), EndAt: text("17:00:00"), Status: text("present"), Notes: text("")}
+
+	for _, tc := range []struct {
+		name    string
+		updated models.RawAttendance
+		want    bool
+	}{
+		{"notes only", models.RawAttendance{StartAt: text("08:00"), EndAt: text("17:00"), Status: text("present"), Notes: text("left for the dentist")}, false},
+		{"start time", models.RawAttendance{StartAt: text("07:45"), EndAt: text("17:00"), Status: text("present")}, true},
+		{"end time cleared", models.RawAttendance{StartAt: text("08:00"), Status: text("present")}, true},
+		{"status", models.RawAttendance{StartAt: text("08:00"), EndAt: text("17:00"), Status: text("absent")}, true},
+	} {
+		if got := correctsPunches(existing, &tc.updated); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestDiffRowValues(t *testing.T) {
+	from := types.RowValues{"start_at": "08:00:00", "status": "present", "notes": nil, "total_hours": 9.0}
+	to := types.RowValues{"start_at": "07:30:00", "status": "present", "total_hours": 9.5, "leave_request_id": 4.0}
+
+	changes := diffRowValues(from, to)
+	want := []types.FieldChange{
+		{Field: "leave_request_id", Old: nil, New: 4.0},
+		{Field: "start_at", Old: "08:00:00", New: "07:30:00"},
+		{Field: "total_hours", Old: 9.0, New: 9.5},
+	}
+	if fmt.Sprint(changes) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", changes, want)
+	}
+	if changes := diffRowValues(to, to); len(changes) != 0 {
+		t.Errorf("identical versions: got %v", changes)
+	}
+}
+
+// revertFixture serves raw attendance 5 of company 1 on workday 2, its version 1, and records the
+// values restored. It fails on any other call.
+type revertFixture struct {
+	repositories.RawAttendanceRepository
+	repositories.RawAttendanceVersionRepository
+	repositories.WorkDayRepository
+	PayrollPeriodService
+	workday  *models.WorkDay
+	closed   bool
+	restored map[string]interface{}
+}
+
+func (f *revertFixture) GetRawAttendanceByID(ctx context.Context, id uint) (*models.RawAttendance, error) {
+	return &models.RawAttendance{Model: gorm.Model{ID: id}, WorkDayID: 2, CompanyID: 1}, nil
+}
+
+func (f *revertFixture) GetWorkDayByID(ctx context.Context, id uint) (*models.WorkDay, error) {
+	return f.workday, nil
+}
+
+func (f *revertFixture) EnsureDateOpen(ctx context.Context, companyID uint, date time.Time) error {
+	if f.closed {
+		return fmt.Errorf("%w: period of company %d", ErrPeriodClosed, companyID)
+	}
+	return nil
+}
+
+func (f *revertFixture) GetRawAttendanceVersion(ctx context.Context, rawAttendanceID uint, version uint) (*models.RawAttendanceVersion, error) {
+	if version != 1 {
+		return nil, nil
+	}
+	return &models.RawAttendanceVersion{RawAttendanceID: rawAttendanceID, Version: 1, Values: types.RowValues{
+		"work_day_id": 2.0, "start_at": "08:00:00", "status": "present", "manually_edited": false,
+	}}, nil
+}
+
+func (f *revertFixture) RestoreRawAttendance(ctx context.Context, id uint, values map[string]interface{}) error {
+	f.restored = values
+	return nil
+}
+
+func TestRevertToVersionRequiresAnEditableRow(t *testing.T) {
+	fixture := &revertFixture{workday: &models.WorkDay{Model: gorm.Model{ID: 2}, Status: models.WorkDayStatusApproved}}
+	service := NewRawAttendanceService(fixture, fixture, fixture, fixture)
+
+	if _, err := service.RevertToVersion(context.Background(), 5, 1); !errors.Is(err, ErrWorkDayLocked) {
+		t.Errorf("approved workday: got %v, want ErrWorkDayLocked", err)
+	}
+	fixture.workday.Status = models.WorkDayStatusDraft
+	fixture.closed = true
+	if _, err := service.RevertToVersion(context.Background(), 5, 1); !errors.Is(err, ErrPeriodClosed) {
+		t.Errorf("closed payroll period: got %v, want ErrPeriodClosed", err)
+	}
+	if fixture.restored != nil {
+		t.Fatalf("a refused revert restored %v", fixture.restored)
+	}
+
+	fixture.closed = false
+	if _, err := service.RevertToVersion(context.Background(), 5, 2); !errors.Is(err, ErrRawAttendanceVersionNotFound) {
+		t.Errorf("unknown version: got %v, want ErrRawAttendanceVersionNotFound", err)
+	}
+	if _, err := service.RevertToVersion(context.Background(), 5, 1); err != nil {
+		t.Fatalf("RevertToVersion: %v", err)
+	}
+	want := map[string]interface{}{"start_at": "08:00:00", "status": "present", "manually_edited": false}
+	if fmt.Sprint(fixture.restored) != fmt.Sprint(want) {
+		t.Errorf("restored %v, want %v", fixture.restored, want)
+	}
+}
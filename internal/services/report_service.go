@@ -2,13 +2,17 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+
 	"gorm.io/gorm"
 )
 
 type ReportService interface {
-	GenerateReport(ctx context.Context, companyID uint, startDate, endDate time.Time) ([]ReportResult, error)
+	GenerateReport(ctx context.Context, companyID uint, startDate, endDate time.Time, scope *types.OrgScope) ([]ReportResult, error)
 }
 
 type reportService struct {
@@ -16,16 +20,18 @@ type reportService struct {
 }
 
 type ReportResult struct {
-	UserID       uint
-	EmployeeName string
-	WorkDays     float64
+	UserID          uint
+	EmployeeName    string
+	WorkDays        float64 // Worked days plus paid leave days
+	PaidLeaveDays   float64
+	UnpaidLeaveDays float64
 }
 
 func NewReportService(db *gorm.DB) ReportService {
 	return &reportService{db: db}
 }
 
-func (s *reportService) GenerateReport(ctx context.Context, companyID uint, startDate, endDate time.Time) ([]ReportResult, error) {
+func (s *reportService) GenerateReport(ctx context.Context, companyID uint, startDate, endDate time.Time, scope *types.OrgScope) ([]ReportResult, error) {
 	var results []ReportResult
 
 	query := `
@@ -40,30 +46,57 @@ func (s *reportService) GenerateReport(ctx context.Context, companyID uint, star
         MIN(raw_attendances.employee_name) AS employee_name, 
         ROUND(
             SUM(
-                IF(
-                    ((raw_attendances.total_hours - raw_attendances.total_hour_out - 
-                      IF(raw_attendances.calculate_lunch_hour, 1, 0))) > 9 
-                    AND raw_attendances.calculate_over_time, 
-                    (raw_attendances.total_hours - raw_attendances.total_hour_out - 
-                     IF(raw_attendances.calculate_lunch_hour, 1, 0)), 
+                IFNULL(
                     IF(
                         ((raw_attendances.total_hours - raw_attendances.total_hour_out - 
-                          IF(raw_attendances.calculate_lunch_hour, 1, 0))) > 9, 
-                        9, 
+                          IF(raw_attendances.calculate_lunch_hour, 1, 0))) > 9 
+                        AND raw_attendances.calculate_over_time, 
                         (raw_attendances.total_hours - raw_attendances.total_hour_out - 
-                         IF(raw_attendances.calculate_lunch_hour, 1, 0))
-                    )
-                ) / 9
+                         IF(raw_attendances.calculate_lunch_hour, 1, 0)), 
+                        IF(
+                            ((raw_attendances.total_hours - raw_attendances.total_hour_out - 
+                              IF(raw_attendances.calculate_lunch_hour, 1, 0))) > 9, 
+                            9, 
+                            (raw_attendances.total_hours - raw_attendances.total_hour_out - 
+                             IF(raw_attendances.calculate_lunch_hour, 1, 0))
+                        )
+                    ) / 9,
+                    0
+                )
+                + IF(leave_types.is_paid, IF(leave_requests.half_day <> '', 0.5, 1), 0)
             ) * 2 
-        ) / 2 AS work_days
+        ) / 2 AS work_days,
+        SUM(IF(leave_requests.id IS NOT NULL AND leave_types.is_paid, IF(leave_requests.half_day <> '', 0.5, 1), 0)) AS paid_leave_days,
+        SUM(IF(leave_requests.id IS NOT NULL AND NOT leave_types.is_paid, IF(leave_requests.half_day <> '', 0.5, 1), 0)) AS unpaid_leave_days
     FROM uniqueWorkDay
     INNER JOIN raw_attendances 
         ON raw_attendances.work_day_id = uniqueWorkDay.id
+    LEFT JOIN leave_requests 
+        ON leave_requests.id = raw_attendances.leave_request_id
+    LEFT JOIN leave_types 
+        ON leave_types.id = leave_requests.leave_type_id
+    LEFT JOIN employees 
+        ON employees.id = raw_attendances.user_id
     WHERE raw_attendances.company_id = ?
+        AND (employees.termination_date IS NULL OR uniqueWorkDay.date <= employees.termination_date)
+        %s
     GROUP BY raw_attendances.user_id;
     `
 
-	err := s.db.WithContext(ctx).Raw(query, startDate, endDate, companyID).Scan(&results).Error
+	if tenant := types.TenantScopeFrom(ctx); tenant != nil && !tenant.Allows(companyID) {
+		return nil, fmt.Errorf("%w: company %d", types.ErrOutsideTenant, companyID)
+	}
+
+	// Restrict the report to the employees of the requested departments and teams.
+	args := []interface{}{startDate, endDate, companyID}
+	scopeCondition, scopeArgs := repositories.OrgScopeCondition("raw_attendances.user_id", "uniqueWorkDay.date", scope)
+	if scopeCondition != "" {
+		scopeCondition = "AND " + scopeCondition
+		args = append(args, scopeArgs...)
+	}
+	query = fmt.Sprintf(query, scopeCondition)
+
+	err := s.db.WithContext(ctx).Raw(query, args...).Scan(&results).Error
 	if err != nil {
 		return nil, err
 	}
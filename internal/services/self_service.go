@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+// ErrNoEmployeeRecord is returned by the self-service of users who are not employees.
+var ErrNoEmployeeRecord = errors.New("no employee record is linked to this account")
+
+// SelfService gives employees access to their own data. Every method takes the ID of the
+// authenticated user and works on the employee record of their account, never on an ID supplied
+// by the client.
+type SelfService interface {
+	GetProfile(ctx context.Context, userID uint) (*types.SelfProfile, error)
+	// ListPunches returns the punches of the employee on the days from from to to.
+	ListPunches(ctx context.Context, userID uint, from, to time.Time) ([]models.AttendanceLog, error)
+	// GetAttendance returns the daily raw attendances of the employee from from to to, and their totals.
+	GetAttendance(ctx context.Context, userID uint, from, to time.Time) (*types.SelfAttendance, error)
+	// GetSchedule returns, for each day from from to to, whether it is worked, the hours of the
+	// employee and their leave.
+	GetSchedule(ctx context.Context, userID uint, from, to time.Time) ([]types.ScheduleDay, error)
+	GetLeaveBalances(ctx context.Context, userID uint, asOf time.Time) ([]types.LeaveBalance, error)
+	ListLeaveRequests(ctx context.Context, userID uint) ([]models.LeaveRequest, error)
+	// SubmitLeaveRequest files a leave request for the employee.
+	SubmitLeaveRequest(ctx context.Context, userID uint, request *models.LeaveRequest) error
+	ListPunchCorrections(ctx context.Context, userID uint) ([]models.PunchCorrectionRequest, error)
+	SubmitPunchCorrection(ctx context.Context, userID uint, submission types.PunchCorrectionSubmission) (*models.PunchCorrectionRequest, error)
+	// AttachPunchCorrectionDocument uploads a document backing a pending punch correction request
+	// of the employee.
+	AttachPunchCorrectionDocument(ctx context.Context, userID uint, correctionID uint, document *models.Document, content io.Reader) error
+}
+
+// selfService implements the SelfService interface.
+type selfService struct {
+	employeeRepo           repositories.EmployeeRepository
+	userRepo               repositories.UserRepository
+	companyRepo            repositories.CompanyRepository
+	attendanceRepo         repositories.AttendanceRepository
+	rawAttendanceRepo      repositories.RawAttendanceRepository
+	workDayRepo            repositories.WorkDayRepository
+	leaveRequestRepo       repositories.LeaveRequestRepository
+	leaveService           LeaveService
+	leaveBalanceService    LeaveBalanceService
+	punchCorrectionService PunchCorrectionService
+	documentService        DocumentService
+}
+
+// NewSelfService creates a new instance of SelfService.
+func NewSelfService(employeeRepo repositories.EmployeeRepository, userRepo repositories.UserRepository, companyRepo repositories.CompanyRepository, attendanceRepo repositories.AttendanceRepository, rawAttendanceRepo repositories.RawAttendanceRepository, workDayRepo repositories.WorkDayRepository, leaveRequestRepo repositories.LeaveRequestRepository, leaveService LeaveService, leaveBalanceService LeaveBalanceService, punchCorrectionService PunchCorrectionService, documentService DocumentService) SelfService {
+	return &selfService{
+		employeeRepo:           employeeRepo,
+		userRepo:               userRepo,
+		companyRepo:            companyRepo,
+		attendanceRepo:         attendanceRepo,
+		rawAttendanceRepo:      rawAttendanceRepo,
+		workDayRepo:            workDayRepo,
+		leaveRequestRepo:       leaveRequestRepo,
+		leaveService:           leaveService,
+		leaveBalanceService:    leaveBalanceService,
+		punchCorrectionService: punchCorrectionService,
+		documentService:        documentService,
+	}
+}
+
+// ownEmployee returns the employee record of a user. The records of the employee are reached
+// whatever their company, such as rows of a company they were transferred from.
+func (s *selfService) ownEmployee(ctx context.Context, userID uint) (context.Context, *models.Employee, error) {
+	ctx = types.WithoutTenantScope(ctx)
+	employee, err := s.employeeRepo.GetEmployeeByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if employee == nil {
+		return nil, nil, ErrNoEmployeeRecord
+	}
+	return ctx, employee, nil
+}
+
+// GetProfile retrieves the account, employee record and company of the user.
+func (s *selfService) GetProfile(ctx context.Context, userID uint) (*types.SelfProfile, error) {
+	ctx, employee, err := s.ownEmployee(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	employeeWithUser, err := s.employeeRepo.GetEmployeeByIDWithUser(ctx, employee.ID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	company, err := s.companyRepo.GetCompanyByID(ctx, employee.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &types.SelfProfile{EmployeeWithUser: *employeeWithUser}
+	if user != nil {
+		profile.Email = user.Email
+	}
+	if company != nil {
+		profile.CompanyName = company.CompanyName
+	}
+	return profile, nil
+}
+
+// ListPunches retrieves the punches of the employee, oldest first.
+func (s *selfService) ListPunches(ctx context.Context, userID uint, from, to time.Time) ([]models.AttendanceLog, error) {
+	ctx, employee, err := s.ownEmployee(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.attendanceRepo.ListEmployeePunches(ctx, employee.ID, from, to.AddDate(0, 0, 1))
+}
+
+// GetAttendance retrieves the raw attendances of the employee and sums their hours and statuses.
+func (s *selfService) GetAttendance(ctx context.Context, userID uint, from, to time.Time) (*types.SelfAttendance, error) {
+	ctx, employee, err := s.ownEmployee(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.rawAttendanceRepo.ListEmployeeDailyAttendances(ctx, employee.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	attendance := &types.SelfAttendance{
+		From:   types.DateOnly(from),
+		To:     types.DateOnly(to),
+		Days:   days,
+		Totals: types.AttendanceTotals{Days: len(days), ByStatus: map[string]int{}},
+	}
+	for _, day := range days {
+		if day.Status != nil {
+			attendance.Totals.ByStatus[*day.Status]++
+		}
+		if day.TotalHours != nil {
+			attendance.Totals.TotalHours += *day.TotalHours
+		}
+		if day.TotalHourOut != nil {
+			attendance.Totals.TotalHourOut += *day.TotalHourOut
+		}
+	}
+	return attendance, nil
+}
+
+// GetSchedule lists the days of the range. Days with a workday take its type; the others are
+// workdays from Monday to Friday, as leave is counted. Days outside the employment of the
+// employee are left out.
+func (s *selfService) GetSchedule(ctx context.Context, userID uint, from, to time.Time) ([]types.ScheduleDay, error) {
+	ctx, employee, err := s.ownEmployee(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	workdays, err := s.workDayRepo.ListWorkDaysBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	dayTypes := map[string]string{}
+	for _, workday := range workdays {
+		dayTypes[workday.Date.ToTime().Format("2006-01-02")] = workday.DayType
+	}
+	leave, err := s.leaveRequestRepo.FindActiveLeaveRequests(ctx, employee.ID, from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := []types.ScheduleDay{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if employee.HireDate != nil && day.Before(employee.HireDate.ToTime()) ||
+			employee.TerminationDate != nil && day.After(employee.TerminationDate.ToTime()) {
+			continue
+		}
+
+		scheduled := types.ScheduleDay{Date: types.DateOnly(day), DayType: "workday"}
+		if dayType, ok := dayTypes[day.Format("2006-01-02")]; ok {
+			scheduled.DayType = dayType
+		} else if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			scheduled.DayType = "free"
+		}
+		if scheduled.DayType == "workday" {
+			scheduled.StartHour, scheduled.EndHour = employee.StartHour, employee.EndHour
+		}
+		for _, request := range leave {
+			if !day.Before(request.StartDate.ToTime()) && !day.After(request.EndDate.ToTime()) {
+				scheduled.Leave = &types.ScheduledLeave{
+					LeaveRequestID: request.ID,
+					LeaveTypeID:    request.LeaveTypeID,
+					Status:         request.Status,
+					HalfDay:        request.HalfDay,
+				}
+				break
+			}
+		}
+		schedule = append(schedule, scheduled)
+	}
+	return schedule, nil
+}
+
+// GetLeaveBalances retrieves the leave balances of the employee at asOf.
+func (s *selfService) GetLeaveBalances(ctx context.Context, userID uint, asOf time.Time) ([]types.LeaveBalance, error) {
+	ctx, employee, err := s.ownEmployee(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.leaveBalanceService.GetBalances(ctx, employee.ID, asOf)
+}
+
+// ListLeaveRequests retrieves the leave requests of the employee, latest first.
+func (s *selfService) ListLeaveRequests(ctx context.Context, userID uint) ([]models.LeaveRequest, error) {
+	ctx, employee, err := s.ownEmployee(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.leaveService.ListLeaveRequests(ctx, map[string]interface{}{"employee_id": employee.ID})
+}
+
+// SubmitLeaveRequest files a leave request for the employee, whatever employee it names.
+func (s *selfService) SubmitLeaveRequest(ctx context.Context, userID uint, request *models.LeaveRequest) error {
+	ctx, employee, err := s.ownEmployee(ctx, userID)
+	if err != nil {
+		return err
+	}
+	request.EmployeeID = employee.ID
+	return s.leaveService.CreateLeaveRequest(ctx, request)
+}
+
+// ListPunchCorrections retrieves the punch correction requests of the employee, latest first.
+func (s *selfService) ListPunchCorrections(ctx context.Context, userID uint) ([]models.PunchCorrectionRequest, error) {
+	ctx, employee, err := s.ownEmployee(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.punchCorrectionService.ListPunchCorrections(ctx, map[string]interface{}{"employee_id": employee.ID})
+}
+
+// SubmitPunchCorrection files a punch correction request for the employee.
+func (s *selfService) SubmitPunchCorrection(ctx context.Context, userID uint, submission types.PunchCorrectionSubmission) (*models.PunchCorrectionRequest, error) {
+	ctx, employee, err := s.ownEmployee(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.punchCorrectionService.SubmitPunchCorrection(ctx, employee.ID, submission)
+}
+
+// AttachPunchCorrectionDocument attaches a document to a pending punch correction request of the
+// employee, such as a screenshot of the terminal being down.
+func (s *selfService) AttachPunchCorrectionDocument(ctx context.Context, userID uint, correctionID uint, document *models.Document, content io.Reader) error {
+	ctx, employee, err := s.ownEmployee(ctx, userID)
+	if err != nil {
+		return err
+	}
+	request, err := s.punchCorrectionService.GetPunchCorrectionByID(ctx, correctionID)
+	if err != nil {
+		return err
+	}
+	if request == nil || request.EmployeeID != employee.ID {
+		return ErrPunchCorrectionNotFound
+	}
+	if request.Status != models.PunchCorrectionPending {
+		return fmt.Errorf("%w: request is already %s", ErrInvalidPunchCorrection, request.Status)
+	}
+
+	document.OwnerType = models.DocumentOwnerPunchCorrection
+	document.OwnerID = request.ID
+	document.UploadedBy = &userID
+	return s.documentService.UploadDocument(ctx, document, content)
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// scheduleFixture serves the records GetSchedule reads, and fails on any other call.
+type scheduleFixture struct {
+	repositories.EmployeeRepository
+	repositories.WorkDayRepository
+	repositories.LeaveRequestRepository
+	employee *models.Employee
+	workdays []*models.WorkDay
+	leave    []models.LeaveRequest
+}
+
+func (f *scheduleFixture) GetEmployeeByUserID(ctx context.Context, userID uint) (*models.Employee, error) {
+	if f.employee == nil || f.employee.UserID != userID {
+		return nil, nil
+	}
+	return f.employee, nil
+}
+
+func (f *scheduleFixture) ListWorkDaysBetween(ctx context.Context, start, end time.Time) ([]*models.WorkDay, error) {
+	return f.workdays, nil
+}
+
+func (f *scheduleFixture) FindActiveLeaveRequests(ctx context.Context, employeeID uint, start, end time.Time, excludeID uint) ([]models.LeaveRequest, error) {
+	return f.leave, nil
+}
+
+func TestSelfSchedule(t *testing.T) {
+	date := func(value string) time.Time {
+		parsed, _ := time.ParseInLocation("2006-01-02", value, time.Local)
+		return parsed
+	}
+	hired := types.DateOnly(date("2024-03-05"))
+	fixture := &scheduleFixture{
+		employee: &models.Employee{UserID: 7, StartHour: "08:00", EndHour: "17:00", HireDate: &hired},
+		workdays: []*models.WorkDay{
+			{Date: types.DateOnly(date("2024-03-08")), DayType: "holiday"},
+			{Date: types.DateOnly(date("2024-03-09")), DayType: "workday"}, // a worked Saturday
+		},
+		leave: []models.LeaveRequest{
+			{Model: gorm.Model{ID: 3}, LeaveTypeID: 1, StartDate: types.DateOnly(date("2024-03-06")), EndDate: types.DateOnly(date("2024-03-06")), Status: models.LeaveStatusApproved},
+		},
+	}
+	service := &selfService{employeeRepo: fixture, workDayRepo: fixture, leaveRequestRepo: fixture}
+
+	// From Monday to Sunday, the employee being hired on Tuesday.
+	schedule, err := service.GetSchedule(context.Background(), 7, date("2024-03-04"), date("2024-03-10"))
+	if err != nil {
+		t.Fatalf("GetSchedule: %v", err)
+	}
+	want := []struct {
+		date, dayType, startHour string
+		leave                    uint
+	}{
+		{"2024-03-05", "workday", "08:00", 0},
+		{"2024-03-06", "workday", "08:00", 3},
+		{"2024-03-07", "workday", "08:00", 0},
+		{"2024-03-08", "holiday", "", 0},
+		{"2024-03-09", "workday", "08:00", 0},
+		{"2024-03-10", "free", "", 0},
+	}
+	if len(schedule) != len(want) {
+		t.Fatalf("got %d days, want %d", len(schedule), len(want))
+	}
+	for i, day := range schedule {
+		got := day.Date.ToTime().Format("2006-01-02")
+		var leave uint
+		if day.Leave != nil {
+			leave = day.Leave.LeaveRequestID
+		}
+		if got != want[i].date || day.DayType != want[i].dayType || day.StartHour != want[i].startHour || leave != want[i].leave {
+			t.Errorf("day %d = %s %s %q leave %d, want %+v", i, got, day.DayType, day.StartHour, leave, want[i])
+		}
+	}
+
+	if _, err := service.GetSchedule(context.Background(), 8, date("2024-03-04"), date("2024-03-10")); !errors.Is(err, ErrNoEmployeeRecord) {
+		t.Errorf("schedule of a user without employee record: got %v, want ErrNoEmployeeRecord", err)
+	}
+}
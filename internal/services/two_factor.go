@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/rbac"
+	"point-system-api/internal/types"
+	"point-system-api/pkg/utils"
+)
+
+var (
+	// ErrInvalidTwoFactorCode is returned when a TOTP or recovery code is wrong, expired or already used.
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidPreAuthToken is returned when a pre-auth token is invalid or expired.
+	ErrInvalidPreAuthToken = errors.New("invalid or expired pre-auth token")
+	// ErrTwoFactorConflict is returned when the second factor of a user is not in the state an
+	// operation expects, or must stay enabled.
+	ErrTwoFactorConflict = errors.New("two-factor authentication conflict")
+)
+
+// LoginFailureInvalidTwoFactor is the reason of logins failed on the second factor.
+const LoginFailureInvalidTwoFactor = "invalid_two_factor"
+
+// TwoFactorPolicy configures TOTP second factors.
+type TwoFactorPolicy struct {
+	RequiredRoles []string      // Roles that cannot log in without a second factor
+	Issuer        string        // Name of the service in authenticator apps
+	PreAuthTTL    time.Duration // Time given to enter the code after the password
+	RecoveryCodes int           // Number of recovery codes issued at once
+}
+
+// DefaultTwoFactorPolicy returns the policy applied without configuration: second factors are
+// optional for every role.
+func DefaultTwoFactorPolicy() TwoFactorPolicy {
+	return TwoFactorPolicy{Issuer: "Point System", PreAuthTTL: 5 * time.Minute, RecoveryCodes: 10}
+}
+
+// LoadTwoFactorPolicyFromEnv reads TWO_FACTOR_REQUIRED_ROLES, a comma-separated list of roles, and
+// TWO_FACTOR_ISSUER over the default policy.
+func LoadTwoFactorPolicyFromEnv() (TwoFactorPolicy, error) {
+	policy := DefaultTwoFactorPolicy()
+	for _, role := range strings.Split(os.Getenv("TWO_FACTOR_REQUIRED_ROLES"), ",") {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+		if role != rbac.RoleSuperAdmin && role != rbac.RoleManager && role != rbac.RoleEmployee {
+			return policy, fmt.Errorf("invalid TWO_FACTOR_REQUIRED_ROLES: unknown role %q", role)
+		}
+		policy.RequiredRoles = append(policy.RequiredRoles, role)
+	}
+	if issuer := os.Getenv("TWO_FACTOR_ISSUER"); issuer != "" {
+		policy.Issuer = issuer
+	}
+	return policy, nil
+}
+
+// Required reports whether users of role must log in with a second factor.
+func (p TwoFactorPolicy) Required(role string) bool {
+	return slices.Contains(p.RequiredRoles, role)
+}
+
+// recoveryCodeEncoding spells recovery codes without padding nor ambiguous case.
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// recoveryCodeBytes is the randomness of a recovery code: 80 bits, spelled in 16 characters.
+const recoveryCodeBytes = 10
+
+// newRecoveryCodes draws n recovery codes, returning them and their bcrypt hashes.
+func newRecoveryCodes(n int) (codes, hashes []string, err error) {
+	for i := 0; i < n; i++ {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		hash, err := utils.HashPassword(code)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+		codes = append(codes, code[:4]+"-"+code[4:8]+"-"+code[8:12]+"-"+code[12:])
+		hashes = append(hashes, hash)
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode drops the separators and case of a recovery code as typed by a user.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// GetTwoFactorStatus tells whether a user has a second factor and must have one.
+func (s *authService) GetTwoFactorStatus(ctx context.Context, userID uint) (*types.TwoFactorStatus, error) {
+	user, twoFactor, err := s.ownTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &types.TwoFactorStatus{Enabled: twoFactor.IsEnabled(), Required: s.twoFactorPolicy.Required(user.Role)}
+	if status.Enabled {
+		if status.RecoveryCodesRemaining, err = s.twoFactorRepo.CountUnusedRecoveryCodes(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// EnrollTwoFactor draws a new pending TOTP secret for a user, replacing any pending one. A user
+// with an enabled second factor disables it first.
+func (s *authService) EnrollTwoFactor(ctx context.Context, userID uint) (*types.TwoFactorEnrollment, error) {
+	user, twoFactor, err := s.ownTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.IsEnabled() {
+		return nil, fmt.Errorf("%w: two-factor authentication is already enabled", ErrTwoFactorConflict)
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.SaveTwoFactor(ctx, &models.TwoFactor{UserID: userID, Secret: secret}); err != nil {
+		return nil, err
+	}
+	return &types.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(s.twoFactorPolicy.Issuer, user.Username, secret),
+	}, nil
+}
+
+// ActivateTwoFactor enables the pending secret of a user once they enter a code of it, and
+// issues their recovery codes. When completeLogin is set, the activation ends a login that
+// required the enrollment and the session is started.
+func (s *authService) ActivateTwoFactor(ctx context.Context, userID uint, code string, completeLogin bool, client types.ClientInfo) (*types.TwoFactorActivation, error) {
+	user, twoFactor, err := s.ownTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor == nil || twoFactor.IsEnabled() {
+		return nil, fmt.Errorf("%w: no pending two-factor enrollment", ErrTwoFactorConflict)
+	}
+	step, ok := utils.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes(s.twoFactorPolicy.RecoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	twoFactor.EnabledAt = &now
+	twoFactor.LastUsedStep = step
+	if err := s.twoFactorRepo.EnableTwoFactor(ctx, twoFactor, hashes); err != nil {
+		return nil, err
+	}
+
+	activation := &types.TwoFactorActivation{RecoveryCodes: codes}
+	if completeLogin {
+		if activation.TokenPair, err = s.completeLogin(types.WithoutTenantScope(ctx), user, client); err != nil {
+			return nil, err
+		}
+	}
+	return activation, nil
+}
+
+// VerifyTwoFactor completes a login with a TOTP or recovery code. Wrong codes count as failed
+// logins, so that guessing them is throttled like guessing passwords.
+func (s *authService) VerifyTwoFactor(ctx context.Context, preAuthToken, code string, client types.ClientInfo) (*types.TokenPair, error) {
+	claims, err := utils.ValidateToken(preAuthToken)
+	if err != nil || claims.Purpose != utils.TokenPurposeTwoFactor {
+		return nil, ErrInvalidPreAuthToken
+	}
+	user, err := s.userService.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidPreAuthToken
+	}
+
+	err = s.checkLoginThrottle(ctx, user.Username, client)
+	if errors.Is(err, ErrLoginThrottled) {
+		if err := s.recordLoginAttempt(ctx, user.Username, user, client, LoginFailureThrottled); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkSecondFactor(ctx, user.ID, code, true)
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		if err := s.recordLoginFailure(ctx, user.Username, client); err != nil {
+			return nil, err
+		}
+		if err := s.recordLoginAttempt(ctx, user.Username, user, client, LoginFailureInvalidTwoFactor); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.completeLogin(ctx, user, client)
+}
+
+// DisableTwoFactor removes the second factor of a user who proves they hold it. Users whose role
+// requires a second factor cannot disable it.
+func (s *authService) DisableTwoFactor(ctx context.Context, userID uint, code string) error {
+	user, twoFactor, err := s.ownTwoFactor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.IsEnabled() {
+		return fmt.Errorf("%w: two-factor authentication is not enabled", ErrTwoFactorConflict)
+	}
+	if s.twoFactorPolicy.Required(user.Role) {
+		return fmt.Errorf("%w: two-factor authentication is required for the %s role", ErrTwoFactorConflict, user.Role)
+	}
+	if err := s.checkSecondFactor(ctx, userID, code, true); err != nil {
+		return err
+	}
+	return s.twoFactorRepo.DeleteTwoFactor(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user who enters a TOTP code.
+func (s *authService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	_, twoFactor, err := s.ownTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !twoFactor.IsEnabled() {
+		return nil, fmt.Errorf("%w: two-factor authentication is not enabled", ErrTwoFactorConflict)
+	}
+	if err := s.checkSecondFactor(ctx, userID, code, false); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes(s.twoFactorPolicy.RecoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// ResetTwoFactor removes the second factor of a user who lost it. Their sessions end; a role
+// requiring a second factor makes them enroll a new one on their next login.
+func (s *authService) ResetTwoFactor(ctx context.Context, userID uint) (bool, error) {
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, nil
+	}
+	if err := s.twoFactorRepo.DeleteTwoFactor(ctx, userID); err != nil {
+		return false, err
+	}
+	return true, s.refreshTokenRepo.RevokeUserRefreshTokens(ctx, userID)
+}
+
+// ownTwoFactor retrieves a user acting on their own second factor, and that factor, nil when they
+// have none. Users always reach their own account, whatever their companies.
+func (s *authService) ownTwoFactor(ctx context.Context, userID uint) (*models.User, *models.TwoFactor, error) {
+	ctx = types.WithoutTenantScope(ctx)
+	user, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, errors.New("user not found")
+	}
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, twoFactor, nil
+}
+
+// checkSecondFactor checks a code of the enabled second factor of a user: a TOTP code, or, when
+// allowRecovery is set, an unused recovery code, which is then used up.
+func (s *authService) checkSecondFactor(ctx context.Context, userID uint, code string, allowRecovery bool) error {
+	twoFactor, err := s.twoFactorRepo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !twoFactor.IsEnabled() {
+		return fmt.Errorf("%w: two-factor authentication is not enabled", ErrTwoFactorConflict)
+	}
+
+	code = strings.TrimSpace(code)
+	if step, ok := utils.ValidateTOTP(twoFactor.Secret, code, time.Now()); ok {
+		fresh, err := s.twoFactorRepo.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return fmt.Errorf("%w: code already used", ErrInvalidTwoFactorCode)
+		}
+		return nil
+	}
+	if allowRecovery {
+		used, err := s.useRecoveryCode(ctx, userID, normalizeRecoveryCode(code))
+		if err != nil {
+			return err
+		}
+		if used {
+			return nil
+		}
+	}
+	return ErrInvalidTwoFactorCode
+}
+
+// useRecoveryCode uses up the unused recovery code of a user matching code, reporting false when
+// none does.
+func (s *authService) useRecoveryCode(ctx context.Context, userID uint, code string) (bool, error) {
+	if len(code) != recoveryCodeEncoding.EncodedLen(recoveryCodeBytes) {
+		return false, nil
+	}
+	recoveryCodes, err := s.twoFactorRepo.ListUnusedRecoveryCodes(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, recoveryCode := range recoveryCodes {
+		if utils.CheckPassword(recoveryCode.CodeHash, code) {
+			return s.twoFactorRepo.UseRecoveryCode(ctx, recoveryCode.ID)
+		}
+	}
+	return false, nil
+}
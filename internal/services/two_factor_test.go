@@ -0,0 +1,44 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"point-system-api/internal/rbac"
+	"point-system-api/pkg/utils"
+)
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for i, code := range codes {
+		if seen[code] {
+			t.Errorf("duplicate recovery code %s", code)
+		}
+		seen[code] = true
+		// Codes are accepted however the user types them.
+		typed := strings.ToUpper(strings.ReplaceAll(code, "-", " "))
+		if len(code) != len("xxxx-xxxx-xxxx-xxxx") || !utils.CheckPassword(hashes[i], normalizeRecoveryCode(typed)) {
+			t.Errorf("recovery code %s typed as %q does not match its hash", code, typed)
+		}
+	}
+}
+
+func TestTwoFactorPolicyFromEnv(t *testing.T) {
+	t.Setenv("TWO_FACTOR_REQUIRED_ROLES", "super-admin, manager")
+	policy, err := LoadTwoFactorPolicyFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !policy.Required(rbac.RoleSuperAdmin) || !policy.Required(rbac.RoleManager) || policy.Required(rbac.RoleEmployee) {
+		t.Errorf("required roles: got %v", policy.RequiredRoles)
+	}
+
+	t.Setenv("TWO_FACTOR_REQUIRED_ROLES", "admin")
+	if _, err := LoadTwoFactorPolicyFromEnv(); err == nil {
+		t.Error("unknown role accepted")
+	}
+}
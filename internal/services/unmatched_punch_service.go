@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+)
+
+// ErrUnmatchedGroupNotFound is returned when a device has no unmatched punch under a device user ID.
+var ErrUnmatchedGroupNotFound = errors.New("unmatched punch group not found")
+
+// ErrInvalidReconciliation is returned when a group of unmatched punches cannot be linked as requested.
+var ErrInvalidReconciliation = errors.New("invalid reconciliation")
+
+// UnmatchedPunchService defines the interface for reconciling punches not linked to any employee.
+type UnmatchedPunchService interface {
+	// ListUnmatchedPunches groups the unmatched punches by device and device user ID.
+	ListUnmatchedPunches(ctx context.Context, filters map[string]interface{}) ([]types.UnmatchedPunchGroup, error)
+	// AssignToEmployee links a group to an existing employee.
+	AssignToEmployee(ctx context.Context, serialNumber, deviceUserID string, employeeID uint) (*types.PunchReconciliation, error)
+	// CreateEmployeeFromGroup creates an employee and links a group to them.
+	CreateEmployeeFromGroup(ctx context.Context, serialNumber, deviceUserID string, employee models.Employee, user models.User) (*types.PunchReconciliation, error)
+}
+
+// unmatchedPunchService implements the UnmatchedPunchService interface.
+type unmatchedPunchService struct {
+	attendanceRepo    repositories.AttendanceRepository
+	employeeRepo      repositories.EmployeeRepository
+	identityService   DeviceIdentityService
+	employeeService   EmployeeService
+	attendanceService AttendanceService
+	workDayService    WorkDayService
+}
+
+// NewUnmatchedPunchService creates a new instance of UnmatchedPunchService.
+func NewUnmatchedPunchService(attendanceRepo repositories.AttendanceRepository,
+	employeeRepo repositories.EmployeeRepository,
+	identityService DeviceIdentityService,
+	employeeService EmployeeService,
+	attendanceService AttendanceService,
+	workDayService WorkDayService) UnmatchedPunchService {
+	return &unmatchedPunchService{
+		attendanceRepo:    attendanceRepo,
+		employeeRepo:      employeeRepo,
+		identityService:   identityService,
+		employeeService:   employeeService,
+		attendanceService: attendanceService,
+		workDayService:    workDayService,
+	}
+}
+
+// ListUnmatchedPunches retrieves the unmatched punch groups matching the given filters.
+func (s *unmatchedPunchService) ListUnmatchedPunches(ctx context.Context, filters map[string]interface{}) ([]types.UnmatchedPunchGroup, error) {
+	groups, err := s.attendanceRepo.GetUnmatchedPunchGroups(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	if groups == nil {
+		groups = []types.UnmatchedPunchGroup{}
+	}
+	return groups, nil
+}
+
+// AssignToEmployee maps the device user ID of a group to an existing employee on its device.
+func (s *unmatchedPunchService) AssignToEmployee(ctx context.Context, serialNumber, deviceUserID string, employeeID uint) (*types.PunchReconciliation, error) {
+	group, err := s.findGroup(ctx, serialNumber, deviceUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	employee, err := s.employeeRepo.GetEmployeeByID(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if employee == nil {
+		return nil, fmt.Errorf("%w: employee not found", ErrInvalidReconciliation)
+	}
+
+	return s.linkGroup(ctx, group, employee)
+}
+
+// CreateEmployeeFromGroup creates an employee, by default registered under the device user ID in the
+// company of the device, and links the group to them.
+func (s *unmatchedPunchService) CreateEmployeeFromGroup(ctx context.Context, serialNumber, deviceUserID string, employee models.Employee, user models.User) (*types.PunchReconciliation, error) {
+	group, err := s.findGroup(ctx, serialNumber, deviceUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(employee.RegistrationNumber) == "" {
+		employee.RegistrationNumber = group.DeviceUserID
+	}
+	if employee.CompanyID == 0 {
+		employee.CompanyID = group.CompanyID
+	}
+	if employee.CompanyID == 0 {
+		return nil, fmt.Errorf("%w: the device has no company, a company is required", ErrInvalidReconciliation)
+	}
+
+	created, err := s.employeeService.CreateEmployee(ctx, employee, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.linkGroup(ctx, group, created)
+}
+
+// findGroup retrieves the unmatched punch group of a device user ID on a device.
+func (s *unmatchedPunchService) findGroup(ctx context.Context, serialNumber, deviceUserID string) (*types.UnmatchedPunchGroup, error) {
+	groups, err := s.attendanceRepo.GetUnmatchedPunchGroups(ctx, map[string]interface{}{
+		"serial_number":  serialNumber,
+		"device_user_id": deviceUserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, ErrUnmatchedGroupNotFound
+	}
+	return &groups[0], nil
+}
+
+// linkGroup maps the group to the employee on its device unless existing mappings already link it,
+// then reclassifies the employee's punches and regenerates the affected raw attendances.
+func (s *unmatchedPunchService) linkGroup(ctx context.Context, group *types.UnmatchedPunchGroup, employee *models.Employee) (*types.PunchReconciliation, error) {
+	reconciliation := &types.PunchReconciliation{EmployeeID: employee.ID}
+
+	remaining, err := s.remainingPunches(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	if remaining > 0 {
+		if group.DeviceID == nil {
+			return nil, fmt.Errorf("%w: device %s is not registered", ErrInvalidReconciliation, group.SerialNumber)
+		}
+		identity := &models.DeviceIdentity{
+			DeviceID:      group.DeviceID,
+			DeviceUserID:  group.DeviceUserID,
+			EmployeeID:    employee.ID,
+			EffectiveFrom: types.DateOnly(truncateToDay(group.FirstPunch)),
+			Source:        models.DeviceIdentitySourceManual,
+		}
+		if err := s.identityService.CreateDeviceIdentity(ctx, identity); err != nil {
+			return nil, err
+		}
+		reconciliation.DeviceIdentityID = identity.ID
+
+		if remaining, err = s.remainingPunches(ctx, group); err != nil {
+			return nil, err
+		}
+	}
+	reconciliation.LinkedPunches = group.PunchCount - remaining
+
+	changed, err := s.attendanceService.ReclassifyPunches(ctx, employee.ID, group.FirstPunch)
+	if err != nil {
+		return nil, err
+	}
+	reconciliation.ReclassifiedPunches = len(changed)
+
+	// A night shift ending on the first day of the group changes the row of the day before.
+	from := truncateToDay(group.FirstPunch).AddDate(0, 0, -1)
+	to := group.LastPunch
+	for _, punch := range changed {
+		if punch.Timestamp.After(to) {
+			to = punch.Timestamp
+		}
+	}
+	reconciliation.WorkDays, err = s.workDayService.RefreshEmployeeRawAttendances(ctx, employee, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return reconciliation, nil
+}
+
+// remainingPunches returns the number of punches of the group still not linked to an employee.
+func (s *unmatchedPunchService) remainingPunches(ctx context.Context, group *types.UnmatchedPunchGroup) (int64, error) {
+	groups, err := s.attendanceRepo.GetUnmatchedPunchGroups(ctx, map[string]interface{}{
+		"serial_number":  group.SerialNumber,
+		"device_user_id": group.DeviceUserID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	var remaining int64
+	for _, g := range groups {
+		remaining += g.PunchCount
+	}
+	return remaining, nil
+}
@@ -4,33 +4,78 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"point-system-api/internal/models"
+	"point-system-api/internal/notify"
+	"point-system-api/internal/rbac"
 	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
 	"point-system-api/pkg/utils"
 )
 
+var (
+	// ErrInvalidCredentials is returned when a username or password is wrong.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrInvalidResetToken is returned when a password reset token is unknown, expired or used.
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	// ErrInvalidRole is returned when a role does not exist.
+	ErrInvalidRole = errors.New("invalid role")
+)
+
 // UserService defines the interface for user-related operations.
 type UserService interface {
 	CreateUser(ctx context.Context, user models.User) (uint, error)
 	GetUserByID(ctx context.Context, id uint) (*models.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	// UpdateUser updates the username and names of a user. Roles and passwords have their own flows.
 	UpdateUser(ctx context.Context, user models.User) (bool, error)
+	ChangeUserRole(ctx context.Context, userID uint, role string) (bool, error)
 	DeleteUser(ctx context.Context, id uint) (bool, error)
 	AuthenticateUser(ctx context.Context, username, password string) (*models.User, error)
 	ListUsersForSelect(ctx context.Context) ([]map[string]interface{}, error)
 	ListUsers(ctx context.Context, page, limit int, filters map[string]interface{}) ([]models.User, int64, error)
+	GetUserCompanyIDs(ctx context.Context, userID uint) ([]uint, error)
+	SetUserCompanies(ctx context.Context, userID uint, companyIDs []uint) error
+
+	// ChangePassword sets a new password for a user who proves they know the current one.
+	ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error
+	// SetPassword sets a new password for a user, enforcing the policy, and ends their sessions.
+	SetPassword(ctx context.Context, userID uint, newPassword string) error
+	// RequestPasswordReset sends a reset token to the user with username, if any.
+	RequestPasswordReset(ctx context.Context, username string) error
+	// SendPasswordReset sends a reset token to a user on behalf of an administrator.
+	SendPasswordReset(ctx context.Context, userID uint) error
+	// ResetPassword sets a new password with a reset token, which can only be used once.
+	ResetPassword(ctx context.Context, token, newPassword string) error
 }
 
 // userService implements the UserService interface.
 type userService struct {
-	userRepo repositories.UserRepository
+	userRepo         repositories.UserRepository
+	passwordRepo     repositories.PasswordRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	notifier         notify.Notifier
+	policy           *PasswordPolicy
+	providers        map[string]AuthProvider
+	external         []AuthProvider // Providers tried, in order, for usernames without an account
 }
 
-// NewUserService creates a new instance of UserService.
-func NewUserService(userRepo repositories.UserRepository) UserService {
+// NewUserService creates a new instance of UserService. Users are authenticated by the local
+// provider, checking their stored password, or by one of the external providers.
+func NewUserService(userRepo repositories.UserRepository, passwordRepo repositories.PasswordRepository, refreshTokenRepo repositories.RefreshTokenRepository, notifier notify.Notifier, policy *PasswordPolicy, external ...AuthProvider) UserService {
+	providers := map[string]AuthProvider{models.AuthProviderLocal: localAuthProvider{}}
+	for _, provider := range external {
+		providers[provider.Name()] = provider
+	}
 	return &userService{
-		userRepo: userRepo,
+		userRepo:         userRepo,
+		passwordRepo:     passwordRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		notifier:         notifier,
+		policy:           policy,
+		providers:        providers,
+		external:         external,
 	}
 }
 
@@ -45,14 +90,22 @@ func (s *userService) CreateUser(ctx context.Context, user models.User) (uint, e
 		return 0, errors.New("username already exists")
 	}
 
-	// Hash the user's password before saving
-	hashedPassword, err := utils.HashPassword(user.Password)
-	if err != nil {
-		return 0, fmt.Errorf("failed to hash password: %w", err)
+	// Users of an external provider get an unusable random password.
+	if user.AuthProvider == "" {
+		user.AuthProvider = models.AuthProviderLocal
+	}
+	if _, ok := s.providers[user.AuthProvider]; !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAuthProvider, user.AuthProvider)
+	}
+	if user.HasLocalPassword() {
+		if err := s.policy.Validate(user.Password, user.Username); err != nil {
+			return 0, err
+		}
+	} else if user.Password, err = randomToken(32); err != nil {
+		return 0, err
 	}
-	user.Password = hashedPassword
 
-	// Create the user in the database
+	// Create the user in the database, which hashes the password
 	userID, err := s.userRepo.CreateUser(ctx, user)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create user: %w", err)
@@ -85,18 +138,13 @@ func (s *userService) UpdateUser(ctx context.Context, user models.User) (bool, e
 	if err != nil {
 		return false, fmt.Errorf("failed to check existing user: %w", err)
 	}
+	if existingUser == nil {
+		return false, nil
+	}
 	existingUser.Username = user.Username
+	existingUser.Email = user.Email
 	existingUser.FirstName = user.FirstName
 	existingUser.LastName = user.LastName
-	existingUser.Role = user.Role
-	// Hash the user's password if it's being updated
-	if user.Password != "" {
-		hashedPassword, err := utils.HashPassword(user.Password)
-		if err != nil {
-			return false, fmt.Errorf("failed to hash password: %w", err)
-		}
-		existingUser.Password = hashedPassword
-	}
 
 	// Update the user in the database
 	success, err := s.userRepo.UpdateUser(ctx, *existingUser)
@@ -116,23 +164,35 @@ func (s *userService) DeleteUser(ctx context.Context, id uint) (bool, error) {
 	return success, nil
 }
 
-// AuthenticateUser authenticates a user by their username and password.
+// AuthenticateUser authenticates a user by their username and password, with the provider of
+// their account. Usernames without an account are tried against the external providers, which
+// create the account of the users they know.
 func (s *userService) AuthenticateUser(ctx context.Context, username, password string) (*models.User, error) {
 	// Retrieve the user by username
 	user, err := s.userRepo.GetUserByUsername(ctx, username)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve user: %w", err)
 	}
-	if user == nil {
-		return nil, errors.New("user not found")
+	if user != nil {
+		name := user.AuthProvider
+		if user.HasLocalPassword() {
+			name = models.AuthProviderLocal
+		}
+		provider, ok := s.providers[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: authentication provider %q is not configured", ErrInvalidCredentials, name)
+		}
+		return provider.Authenticate(ctx, username, password, user)
 	}
 
-	// Check if the provided password matches the stored hashed password
-	if !utils.CheckPassword(user.Password, password) {
-		return nil, errors.New("invalid credentials")
+	for _, provider := range s.external {
+		user, err := provider.Authenticate(ctx, username, password, nil)
+		if errors.Is(err, ErrInvalidCredentials) {
+			continue
+		}
+		return user, err
 	}
-
-	return user, nil
+	return nil, fmt.Errorf("%w: user not found", ErrInvalidCredentials)
 }
 
 // ListUsersForSelect retrieves all users for use in select options.
@@ -173,3 +233,207 @@ func (s *userService) ListUsers(ctx context.Context, page, limit int, filters ma
 
 	return users, total, nil
 }
+
+// GetUserCompanyIDs retrieves the companies whose data a user may reach.
+func (s *userService) GetUserCompanyIDs(ctx context.Context, userID uint) ([]uint, error) {
+	companyIDs, err := s.userRepo.ListUserCompanyIDs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve companies of user: %w", err)
+	}
+	return companyIDs, nil
+}
+
+// SetUserCompanies links a user to the given companies, replacing the previous links. The company
+// employing the user stays reachable regardless.
+func (s *userService) SetUserCompanies(ctx context.Context, userID uint, companyIDs []uint) error {
+	seen := map[uint]bool{}
+	unique := make([]uint, 0, len(companyIDs))
+	for _, id := range companyIDs {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if err := s.userRepo.SetUserCompanies(ctx, userID, unique); err != nil {
+		return fmt.Errorf("failed to set companies of user: %w", err)
+	}
+	return nil
+}
+
+// ChangeUserRole changes the role of a user.
+func (s *userService) ChangeUserRole(ctx context.Context, userID uint, role string) (bool, error) {
+	if role != rbac.RoleSuperAdmin && role != rbac.RoleManager && role != rbac.RoleEmployee {
+		return false, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check existing user: %w", err)
+	}
+	if user == nil {
+		return false, nil
+	}
+	return s.userRepo.UpdateUserRole(ctx, userID, role)
+}
+
+// ChangePassword checks the current password of a user before setting the new one.
+func (s *userService) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error {
+	// Users always reach their own account, whatever their companies.
+	ctx = types.WithoutTenantScope(ctx)
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve user: %w", err)
+	}
+	if user != nil && !user.HasLocalPassword() {
+		return ErrExternalPassword
+	}
+	if user == nil || !utils.CheckPassword(user.Password, currentPassword) {
+		return ErrInvalidCredentials
+	}
+	return s.setPassword(ctx, user, newPassword)
+}
+
+// SetPassword sets a new password for a user.
+func (s *userService) SetPassword(ctx context.Context, userID uint, newPassword string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve user: %w", err)
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	return s.setPassword(ctx, user, newPassword)
+}
+
+// RequestPasswordReset sends a reset token to the user with username. Unknown usernames are
+// ignored, so that the answer does not disclose which users exist.
+func (s *userService) RequestPasswordReset(ctx context.Context, username string) error {
+	user, err := s.userRepo.GetUserByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve user: %w", err)
+	}
+	if user == nil || !user.HasLocalPassword() {
+		return nil
+	}
+	return s.sendPasswordReset(ctx, user)
+}
+
+// SendPasswordReset sends a reset token to a user.
+func (s *userService) SendPasswordReset(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve user: %w", err)
+	}
+	if user == nil {
+		return errors.New("user not found")
+	}
+	return s.sendPasswordReset(ctx, user)
+}
+
+// ResetPassword consumes a reset token and sets the new password of its user.
+func (s *userService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	resetToken, err := s.passwordRepo.GetPasswordResetTokenByHash(ctx, hashToken(token))
+	if err != nil {
+		return err
+	}
+	if resetToken == nil || resetToken.UsedAt != nil || !time.Now().Before(resetToken.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+	user, err := s.userRepo.GetUserByID(ctx, resetToken.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve user: %w", err)
+	}
+	if user == nil {
+		return ErrInvalidResetToken
+	}
+
+	// The policy is checked before the token is used, so that a rejected password can be retried.
+	if err := s.checkNewPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+	used, err := s.passwordRepo.UsePasswordResetToken(ctx, resetToken.ID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidResetToken
+	}
+	return s.setPassword(ctx, user, newPassword)
+}
+
+// sendPasswordReset issues a reset token, replacing the previous ones, and notifies the user.
+func (s *userService) sendPasswordReset(ctx context.Context, user *models.User) error {
+	if !user.HasLocalPassword() {
+		return ErrExternalPassword
+	}
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	if err := s.passwordRepo.InvalidatePasswordResetTokens(ctx, user.ID); err != nil {
+		return err
+	}
+	resetToken := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.policy.ResetTokenTTL),
+	}
+	if err := s.passwordRepo.CreatePasswordResetToken(ctx, resetToken); err != nil {
+		return err
+	}
+
+	message := notify.Message{
+		UserID:   user.ID,
+		Username: user.Username,
+		Subject:  "Password reset",
+		Body: fmt.Sprintf("Use this code to choose a new password: %s\nIt expires at %s and can only be used once.",
+			token, resetToken.ExpiresAt.Format(time.RFC3339)),
+	}
+	if err := s.notifier.Notify(ctx, message); err != nil {
+		return fmt.Errorf("failed to send password reset: %w", err)
+	}
+	return nil
+}
+
+// checkNewPassword enforces the policy and the reuse history on a new password of user.
+func (s *userService) checkNewPassword(ctx context.Context, user *models.User, password string) error {
+	if !user.HasLocalPassword() {
+		return ErrExternalPassword
+	}
+	if err := s.policy.Validate(password, user.Username); err != nil {
+		return err
+	}
+	if utils.CheckPassword(user.Password, password) {
+		return fmt.Errorf("%w: the password must differ from the current one", ErrWeakPassword)
+	}
+	if s.policy.HistorySize > 0 {
+		history, err := s.passwordRepo.ListPasswordHistory(ctx, user.ID, s.policy.HistorySize)
+		if err != nil {
+			return err
+		}
+		for _, hash := range history {
+			if utils.CheckPassword(hash, password) {
+				return fmt.Errorf("%w: the last %d passwords cannot be reused", ErrWeakPassword, s.policy.HistorySize)
+			}
+		}
+	}
+	return nil
+}
+
+// setPassword stores a new password once checked, then ends the sessions and pending resets of
+// the user.
+func (s *userService) setPassword(ctx context.Context, user *models.User, password string) error {
+	if err := s.checkNewPassword(ctx, user, password); err != nil {
+		return err
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	if err := s.passwordRepo.SetPassword(ctx, user.ID, hash); err != nil {
+		return err
+	}
+	if err := s.passwordRepo.InvalidatePasswordResetTokens(ctx, user.ID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeUserRefreshTokens(ctx, user.ID)
+}
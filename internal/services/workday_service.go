@@ -4,12 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
 	"point-system-api/internal/models"
 	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
 )
 
+// ErrWorkDayNotFound is returned when the workday to change does not exist.
+var ErrWorkDayNotFound = errors.New("workday not found")
+
 // WorkDayService defines the interface for workday-related operations.
 type WorkDayService interface {
 	CreateWorkDay(ctx context.Context, workday *models.WorkDay) error
@@ -17,21 +22,38 @@ type WorkDayService interface {
 	ListWorkDays(ctx context.Context) ([]*models.WorkDay, error)
 	UpdateWorkDay(ctx context.Context, workday *models.WorkDay) error
 	DeleteWorkDay(ctx context.Context, id uint) error
+	RegenerateWorkDay(ctx context.Context, id uint, opts types.RegenerateWorkDayOptions) ([]types.RawAttendanceDiff, error)
+	TransitionWorkDay(ctx context.Context, id uint, status string) (*models.WorkDay, error)
+	RefreshEmployeeRawAttendances(ctx context.Context, employee *models.Employee, from, to time.Time) ([]types.WorkDayRefresh, error)
+}
+
+// workDayTransitions lists the lifecycle states a workday may move to from each state.
+var workDayTransitions = map[string][]string{
+	models.WorkDayStatusDraft:       {models.WorkDayStatusUnderReview},
+	models.WorkDayStatusUnderReview: {models.WorkDayStatusDraft, models.WorkDayStatusApproved},
+	models.WorkDayStatusApproved:    {models.WorkDayStatusUnderReview, models.WorkDayStatusLocked},
+	models.WorkDayStatusLocked:      {models.WorkDayStatusApproved},
 }
 
 // workDayService implements the WorkDayService interface.
 type workDayService struct {
+	uow               repositories.UnitOfWork
 	workDayRepo       repositories.WorkDayRepository
 	rawAttendanceRepo repositories.RawAttendanceRepository
 	attendanceRepo    repositories.AttendanceRepository
+	periodService     PayrollPeriodService
+	leaveService      LeaveService
 }
 
 // NewWorkDayService creates a new instance of WorkDayService.
-func NewWorkDayService(workDayRepo repositories.WorkDayRepository, rawAttendanceRepo repositories.RawAttendanceRepository, attendanceRepo repositories.AttendanceRepository) *workDayService {
+func NewWorkDayService(uow repositories.UnitOfWork, workDayRepo repositories.WorkDayRepository, rawAttendanceRepo repositories.RawAttendanceRepository, attendanceRepo repositories.AttendanceRepository, periodService PayrollPeriodService, leaveService LeaveService) *workDayService {
 	return &workDayService{
+		uow:               uow,
 		workDayRepo:       workDayRepo,
 		rawAttendanceRepo: rawAttendanceRepo,
 		attendanceRepo:    attendanceRepo,
+		periodService:     periodService,
+		leaveService:      leaveService,
 	}
 }
 
@@ -55,74 +77,112 @@ func (s *workDayService) CreateWorkDay(ctx context.Context, workday *models.Work
 		return errors.New("cannot create workday for the current day or future dates")
 	}
 
-	if err := s.workDayRepo.CreateWorkDay(ctx, workday); err != nil {
-		return err
-	}
+	// The workday and its raw attendances are created together or not at all. Workdays are shared
+	// by every company, so the rows of all of them are generated whatever the tenant of the request,
+	// except for the companies whose payroll period is closed.
+	workday.Status = models.WorkDayStatusDraft
+	ctx = types.WithRawAttendanceSource(types.WithoutTenantScope(ctx), types.RawAttendanceSourceGeneration)
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.workDayRepo.CreateWorkDay(ctx, workday); err != nil {
+			return err
+		}
 
-	// create raw attendance for all employees that existing in this workday existing view
-	employeeAttendances, err := s.workDayRepo.GetEmployeesWithAttendance(ctx, workday.Date.ToTime())
-	if err != nil {
-		return err
-	}
+		// create raw attendance for all employees that existing in this workday existing view
+		employeeAttendances, err := s.workDayRepo.GetEmployeesWithAttendance(ctx, workday.Date.ToTime())
+		if err != nil {
+			return err
+		}
 
-	for _, ea := range employeeAttendances {
-		status := determineAttendanceStatus(
-			ea.Checkin,
-			ea.Checkout)
-
-		rawAttendance := models.RawAttendance{
-			WorkDayID: workday.ID,
-			CompanyID: ea.CompanyID,
-			UserID:    ea.UserID,
-			EmployeeName: sql.NullString{
-				String: ea.FirstName + " " + ea.LastName,
-				Valid:  true,
-			},
-			Position: sql.NullString{
-				String: ea.Qualification,
-				Valid:  ea.Qualification != "",
-			},
-			StartAt: sql.NullString{
-				String: ea.Checkin.Time.Format("15:04:05"),
-				Valid:  !ea.Checkin.Time.IsZero(),
-			},
-			EndAt: sql.NullString{
-				String: ea.Checkout.Time.Format("15:04:05"),
-				Valid:  !ea.Checkout.Time.IsZero(),
-			},
-			TotalHours: sql.NullFloat64{
-				Float64: calculateTotalHours(ea.Checkin.Time, ea.Checkout.Time),
-				Valid:   !ea.Checkin.Time.IsZero() && !ea.Checkout.Time.IsZero(),
-			},
-			Status: status,
-			Notes: sql.NullString{
-				String: "",
-				Valid:  false,
-			},
-			CalculateOverTime:  false,
-			CalculateLunchHour: true,
-		}
-
-		// Calculate TotalHourOut based on attendance logs between checkin and checkout if both are not zero
-		if !ea.Checkin.Time.IsZero() && !ea.Checkout.Time.IsZero() {
-			totalHourOut, err := s.attendanceRepo.GetTotalHourOutByUserAndTimeRange(ctx, ea.RegisterNumber, ea.Checkin.Time, ea.Checkout.Time)
+		for _, ea := range employeeAttendances {
+			open, err := s.periodOpen(ctx, ea.CompanyID, workday.Date.ToTime())
 			if err != nil {
 				return err
 			}
-			rawAttendance.TotalHourOut = sql.NullFloat64{
-				Float64: totalHourOut,
-				Valid:   true,
+			if !open {
+				continue
+			}
+
+			rawAttendance, err := s.buildRawAttendance(ctx, workday.ID, ea)
+			if err != nil {
+				return err
+			}
+
+			if err := s.rawAttendanceRepo.CreateRawAttendance(ctx, rawAttendance); err != nil {
+				return err
 			}
-		} else {
-			rawAttendance.TotalHourOut = sql.NullFloat64{Valid: false}
 		}
 
-		if err := s.rawAttendanceRepo.CreateRawAttendance(ctx, &rawAttendance); err != nil {
+		// Employees on approved leave get their row even without punches
+		var periodErr error
+		inScope := func(companyID, employeeID uint) bool {
+			open, err := s.periodOpen(ctx, companyID, workday.Date.ToTime())
+			if err != nil && periodErr == nil {
+				periodErr = err
+			}
+			return open
+		}
+		if _, err := s.leaveService.ApplyApprovedLeave(ctx, workday, inScope); err != nil {
 			return err
 		}
+		return periodErr
+	})
+}
+
+// buildRawAttendance computes the raw attendance row of an employee for a workday from their punches.
+func (s *workDayService) buildRawAttendance(ctx context.Context, workDayID uint, ea types.EmployeeAttendance) (*models.RawAttendance, error) {
+	status := determineAttendanceStatus(
+		ea.Checkin,
+		ea.Checkout)
+
+	rawAttendance := models.RawAttendance{
+		WorkDayID: workDayID,
+		CompanyID: ea.CompanyID,
+		UserID:    ea.UserID,
+		EmployeeName: sql.NullString{
+			String: ea.FirstName + " " + ea.LastName,
+			Valid:  true,
+		},
+		Position: sql.NullString{
+			String: ea.Qualification,
+			Valid:  ea.Qualification != "",
+		},
+		StartAt: sql.NullString{
+			String: ea.Checkin.Time.Format("15:04:05"),
+			Valid:  !ea.Checkin.Time.IsZero(),
+		},
+		EndAt: sql.NullString{
+			String: ea.Checkout.Time.Format("15:04:05"),
+			Valid:  !ea.Checkout.Time.IsZero(),
+		},
+		TotalHours: sql.NullFloat64{
+			Float64: calculateTotalHours(ea.Checkin.Time, ea.Checkout.Time),
+			Valid:   !ea.Checkin.Time.IsZero() && !ea.Checkout.Time.IsZero(),
+		},
+		Status: status,
+		Notes: sql.NullString{
+			String: "",
+			Valid:  false,
+		},
+		CalculateOverTime:  false,
+		CalculateLunchHour: true,
+		Justification:      absenceJustification(status, ""),
 	}
 
-	return nil
+	// Calculate TotalHourOut based on attendance logs between checkin and checkout if both are not zero
+	if !ea.Checkin.Time.IsZero() && !ea.Checkout.Time.IsZero() {
+		totalHourOut, err := s.attendanceRepo.GetTotalHourOutByEmployeeAndTimeRange(ctx, ea.UserID, ea.Checkin.Time, ea.Checkout.Time)
+		if err != nil {
+			return nil, err
+		}
+		rawAttendance.TotalHourOut = sql.NullFloat64{
+			Float64: totalHourOut,
+			Valid:   true,
+		}
+	} else {
+		rawAttendance.TotalHourOut = sql.NullFloat64{Valid: false}
+	}
+
+	return &rawAttendance, nil
 }
 
 // Add the following helper function in the same file
@@ -172,13 +232,28 @@ func (s *workDayService) ListWorkDays(ctx context.Context) ([]*models.WorkDay, e
 	return s.workDayRepo.ListWorkDays(ctx)
 }
 
-// UpdateWorkDay updates an existing workday in the database.
+// UpdateWorkDay updates the date and day type of an editable workday.
 func (s *workDayService) UpdateWorkDay(ctx context.Context, workday *models.WorkDay) error {
 	if workday == nil || workday.ID == 0 {
 		return errors.New("invalid workday data")
 	}
 
-	return s.workDayRepo.UpdateWorkDay(ctx, workday)
+	existing, err := s.getEditableWorkDay(ctx, workday.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.ensureRowsOpen(ctx, existing, workday.Date.ToTime()); err != nil {
+		return err
+	}
+
+	existing.Date = workday.Date
+	existing.DayType = workday.DayType
+	if err := s.workDayRepo.UpdateWorkDay(ctx, existing); err != nil {
+		return err
+	}
+
+	*workday = *existing
+	return nil
 }
 
 // DeleteWorkDay deletes a workday by its ID.
@@ -187,5 +262,424 @@ func (s *workDayService) DeleteWorkDay(ctx context.Context, id uint) error {
 		return errors.New("invalid workday ID")
 	}
 
+	if _, err := s.getEditableWorkDay(ctx, id); err != nil {
+		return err
+	}
+
 	return s.workDayRepo.DeleteWorkDay(ctx, id)
 }
+
+// TransitionWorkDay moves a workday to another lifecycle state.
+func (s *workDayService) TransitionWorkDay(ctx context.Context, id uint, status string) (*models.WorkDay, error) {
+	if id == 0 {
+		return nil, errors.New("invalid workday ID")
+	}
+
+	workday, err := s.workDayRepo.GetWorkDayByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if workday == nil {
+		return nil, ErrWorkDayNotFound
+	}
+
+	current := workday.Status
+	if current == "" {
+		current = models.WorkDayStatusDraft
+	}
+
+	allowed := false
+	for _, next := range workDayTransitions[current] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("cannot move workday from %s to %s", current, status)
+	}
+
+	// Unlocking a workday is not possible while the payroll period of one of its rows is closed.
+	if current == models.WorkDayStatusLocked {
+		if err := s.ensureRowsOpen(ctx, workday, workday.Date.ToTime()); err != nil {
+			return nil, err
+		}
+	}
+
+	workday.Status = status
+	if err := s.workDayRepo.UpdateWorkDay(ctx, workday); err != nil {
+		return nil, err
+	}
+
+	return workday, nil
+}
+
+// getEditableWorkDay loads a workday and checks that neither its state nor a closed payroll
+// period of the companies of its rows forbids changes.
+func (s *workDayService) getEditableWorkDay(ctx context.Context, id uint) (*models.WorkDay, error) {
+	workday, err := s.getUnlockedWorkDay(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureRowsOpen(ctx, workday, workday.Date.ToTime()); err != nil {
+		return nil, err
+	}
+	return workday, nil
+}
+
+// ensureRowsOpen checks that the payroll period of every company with a raw attendance on the
+// workday is open on date. Rows of every company count, whatever the tenant of the request.
+func (s *workDayService) ensureRowsOpen(ctx context.Context, workday *models.WorkDay, date time.Time) error {
+	rows, err := s.rawAttendanceRepo.GetRawAttendancesByWorkDay(types.WithoutTenantScope(ctx), workday.ID)
+	if err != nil {
+		return err
+	}
+	checked := map[uint]bool{}
+	for _, row := range rows {
+		if checked[row.CompanyID] {
+			continue
+		}
+		checked[row.CompanyID] = true
+		if err := s.periodService.EnsureDateOpen(ctx, row.CompanyID, date); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// periodOpen reports whether the payroll period of a company still accepts changes on date.
+func (s *workDayService) periodOpen(ctx context.Context, companyID uint, date time.Time) (bool, error) {
+	err := s.periodService.EnsureDateOpen(ctx, companyID, date)
+	if errors.Is(err, ErrPeriodClosed) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// getUnlockedWorkDay loads a workday and checks that its lifecycle state allows changes.
+func (s *workDayService) getUnlockedWorkDay(ctx context.Context, id uint) (*models.WorkDay, error) {
+	workday, err := s.workDayRepo.GetWorkDayByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if workday == nil {
+		return nil, ErrWorkDayNotFound
+	}
+	if !workday.IsEditable() {
+		return nil, fmt.Errorf("%w: workday %d is %s", ErrWorkDayLocked, workday.ID, workday.Status)
+	}
+	return workday, nil
+}
+
+// RegenerateWorkDay recomputes the raw attendances of an existing workday from the current punches.
+// Rows that were corrected by hand keep their times, hours and status unless opts.Force is set;
+// notes and the overtime/lunch flags are only reset when forcing.
+func (s *workDayService) RegenerateWorkDay(ctx context.Context, id uint, opts types.RegenerateWorkDayOptions) ([]types.RawAttendanceDiff, error) {
+	if id == 0 {
+		return nil, errors.New("invalid workday ID")
+	}
+
+	// Closed payroll periods are checked per company while regenerating: their rows are
+	// reported as skipped and the rows of the other companies are still rebuilt.
+	workday, err := s.getUnlockedWorkDay(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failure halfway leaves the raw attendances as they were.
+	var diffs []types.RawAttendanceDiff
+	err = s.uow.Do(ctx, func(ctx context.Context) error {
+		var err error
+		diffs, err = s.regenerateWorkDay(ctx, workday, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return diffs, nil
+}
+
+// regenerateWorkDay rebuilds the raw attendances of an editable workday and returns the changes.
+func (s *workDayService) regenerateWorkDay(ctx context.Context, workday *models.WorkDay, opts types.RegenerateWorkDayOptions) ([]types.RawAttendanceDiff, error) {
+	ctx = types.WithRawAttendanceSource(ctx, types.RawAttendanceSourceRegeneration)
+	employeeFilter := make(map[uint]bool, len(opts.EmployeeIDs))
+	for _, employeeID := range opts.EmployeeIDs {
+		employeeFilter[employeeID] = true
+	}
+	tenant := types.TenantScopeFrom(ctx)
+	inScope := func(companyID, employeeID uint) bool {
+		if opts.CompanyID != 0 && companyID != opts.CompanyID {
+			return false
+		}
+		if tenant != nil && !tenant.Allows(companyID) {
+			return false
+		}
+		if len(employeeFilter) > 0 && !employeeFilter[employeeID] {
+			return false
+		}
+		return true
+	}
+
+	existingRows, err := s.rawAttendanceRepo.GetRawAttendancesByWorkDay(ctx, workday.ID)
+	if err != nil {
+		return nil, err
+	}
+	existingByUser := make(map[uint]*models.RawAttendance, len(existingRows))
+	for _, ra := range existingRows {
+		existingByUser[ra.UserID] = ra
+	}
+
+	employeeAttendances, err := s.workDayRepo.GetEmployeesWithAttendance(ctx, workday.Date.ToTime())
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := []types.RawAttendanceDiff{}
+	seen := make(map[uint]bool, len(employeeAttendances))
+	for _, ea := range employeeAttendances {
+		if !inScope(ea.CompanyID, ea.UserID) {
+			continue
+		}
+		seen[ea.UserID] = true
+
+		// Rows of companies whose payroll period is closed are reported but never touched.
+		if err := s.periodService.EnsureDateOpen(ctx, ea.CompanyID, workday.Date.ToTime()); err != nil {
+			if !errors.Is(err, ErrPeriodClosed) {
+				return nil, err
+			}
+			diff := types.RawAttendanceDiff{
+				UserID:       ea.UserID,
+				CompanyID:    ea.CompanyID,
+				EmployeeName: ea.FirstName + " " + ea.LastName,
+				Action:       "skipped",
+				Changes:      []types.FieldChange{},
+				Preserved:    []string{},
+				Reason:       err.Error(),
+			}
+			if existing, ok := existingByUser[ea.UserID]; ok {
+				diff.RawAttendanceID = existing.ID
+			}
+			diffs = append(diffs, diff)
+			continue
+		}
+
+		fresh, err := s.buildRawAttendance(ctx, workday.ID, ea)
+		if err != nil {
+			return nil, err
+		}
+
+		existing, ok := existingByUser[ea.UserID]
+		if !ok {
+			if err := s.rawAttendanceRepo.CreateRawAttendance(ctx, fresh); err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, types.RawAttendanceDiff{
+				RawAttendanceID: fresh.ID,
+				UserID:          fresh.UserID,
+				CompanyID:       fresh.CompanyID,
+				EmployeeName:    fresh.EmployeeName.String,
+				Action:          "created",
+				Changes:         diffRawAttendance(&models.RawAttendance{}, fresh),
+				Preserved:       []string{},
+			})
+			continue
+		}
+
+		diff := mergeRegeneratedRawAttendance(existing, fresh, opts.Force)
+		if len(diff.Changes) > 0 {
+			if err := s.rawAttendanceRepo.SaveRawAttendance(ctx, existing); err != nil {
+				return nil, err
+			}
+		}
+		diffs = append(diffs, diff)
+	}
+
+	// Approved leave is re-applied, creating the rows of employees on leave without punches.
+	leaveRows, err := s.leaveService.ApplyApprovedLeave(ctx, workday, inScope)
+	if err != nil {
+		return nil, err
+	}
+	for _, ra := range leaveRows {
+		seen[ra.UserID] = true
+		diffs = append(diffs, types.RawAttendanceDiff{
+			RawAttendanceID: ra.ID,
+			UserID:          ra.UserID,
+			CompanyID:       ra.CompanyID,
+			EmployeeName:    ra.EmployeeName.String,
+			Action:          "created",
+			Changes:         diffRawAttendance(&models.RawAttendance{}, ra),
+			Preserved:       []string{},
+			Reason:          "approved leave",
+		})
+	}
+
+	// Rows whose punches have disappeared are left untouched but reported.
+	for _, ra := range existingRows {
+		if seen[ra.UserID] || !inScope(ra.CompanyID, ra.UserID) {
+			continue
+		}
+		if ra.LeaveRequestID != nil {
+			diffs = append(diffs, types.RawAttendanceDiff{
+				RawAttendanceID: ra.ID,
+				UserID:          ra.UserID,
+				CompanyID:       ra.CompanyID,
+				EmployeeName:    ra.EmployeeName.String,
+				Action:          "unchanged",
+				Changes:         []types.FieldChange{},
+				Preserved:       []string{},
+				Reason:          "approved leave",
+			})
+			continue
+		}
+		diffs = append(diffs, types.RawAttendanceDiff{
+			RawAttendanceID: ra.ID,
+			UserID:          ra.UserID,
+			CompanyID:       ra.CompanyID,
+			EmployeeName:    ra.EmployeeName.String,
+			Action:          "skipped",
+			Changes:         []types.FieldChange{},
+			Preserved:       []string{},
+			Reason:          "no punches found for this workday",
+		})
+	}
+
+	return diffs, nil
+}
+
+// RefreshEmployeeRawAttendances regenerates the raw attendance of an employee on every existing
+// workday between from and to after their punches changed. Workdays that are locked or fall in a
+// closed payroll period are reported as skipped.
+func (s *workDayService) RefreshEmployeeRawAttendances(ctx context.Context, employee *models.Employee, from, to time.Time) ([]types.WorkDayRefresh, error) {
+	workdays, err := s.workDayRepo.ListWorkDaysBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshes := make([]types.WorkDayRefresh, 0, len(workdays))
+	for _, workday := range workdays {
+		refresh := types.WorkDayRefresh{WorkDayID: workday.ID, Date: workday.Date, Diffs: []types.RawAttendanceDiff{}}
+		err := s.periodService.EnsureDateOpen(ctx, employee.CompanyID, workday.Date.ToTime())
+		var diffs []types.RawAttendanceDiff
+		if err == nil {
+			diffs, err = s.RegenerateWorkDay(ctx, workday.ID, types.RegenerateWorkDayOptions{
+				CompanyID:   employee.CompanyID,
+				EmployeeIDs: []uint{employee.ID},
+			})
+		}
+		if err != nil {
+			if !errors.Is(err, ErrWorkDayLocked) && !errors.Is(err, ErrPeriodClosed) {
+				return nil, err
+			}
+			refresh.Skipped = err.Error()
+		} else {
+			refresh.Diffs = diffs
+		}
+		refreshes = append(refreshes, refresh)
+	}
+	return refreshes, nil
+}
+
+// absenceJustification returns the justification of a row with the given status: absences
+// start unjustified, and a justification granted from a document is kept whatever the status.
+func absenceJustification(status sql.NullString, current string) string {
+	absent := status.Valid && status.String == "absent"
+	switch {
+	case absent && current == "":
+		return models.AbsenceUnjustified
+	case !absent && current == models.AbsenceUnjustified:
+		return ""
+	}
+	return current
+}
+
+// mergeRegeneratedRawAttendance applies the freshly computed values onto an existing row
+// and reports the changed and preserved fields.
+func mergeRegeneratedRawAttendance(existing, fresh *models.RawAttendance, force bool) types.RawAttendanceDiff {
+	before := *existing
+	preserved := []string{}
+
+	existing.CompanyID = fresh.CompanyID
+	existing.EmployeeName = fresh.EmployeeName
+	existing.Position = fresh.Position
+
+	if existing.ManuallyEdited && !force {
+		preserved = append(preserved, "start_at", "end_at", "total_hours", "total_hour_out", "status")
+	} else {
+		existing.StartAt = fresh.StartAt
+		existing.EndAt = fresh.EndAt
+		existing.TotalHours = fresh.TotalHours
+		existing.TotalHourOut = fresh.TotalHourOut
+		existing.Status = fresh.Status
+	}
+
+	// The status of a row covered by approved leave is owned by the leave request.
+	if existing.LeaveRequestID != nil && existing.Status != before.Status {
+		existing.Status = before.Status
+		preserved = append(preserved, "status")
+	}
+
+	existing.Justification = absenceJustification(existing.Status, existing.Justification)
+
+	if force {
+		existing.Notes = fresh.Notes
+		existing.CalculateOverTime = fresh.CalculateOverTime
+		existing.CalculateLunchHour = fresh.CalculateLunchHour
+		existing.ManuallyEdited = false
+	} else {
+		preserved = append(preserved, "notes", "calculate_over_time", "calculate_lunch_hour")
+	}
+
+	changes := diffRawAttendance(&before, existing)
+	action := "updated"
+	if len(changes) == 0 {
+		action = "unchanged"
+	}
+
+	return types.RawAttendanceDiff{
+		RawAttendanceID: existing.ID,
+		UserID:          existing.UserID,
+		CompanyID:       existing.CompanyID,
+		EmployeeName:    existing.EmployeeName.String,
+		Action:          action,
+		Changes:         changes,
+		Preserved:       preserved,
+	}
+}
+
+// diffRawAttendance lists the fields that differ between two raw attendance rows.
+func diffRawAttendance(before, after *models.RawAttendance) []types.FieldChange {
+	changes := []types.FieldChange{}
+	add := func(field string, old, new interface{}) {
+		if old != new {
+			changes = append(changes, types.FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	add("company_id", before.CompanyID, after.CompanyID)
+	add("employee_name", nullStringValue(before.EmployeeName), nullStringValue(after.EmployeeName))
+	add("position", nullStringValue(before.Position), nullStringValue(after.Position))
+	add("start_at", nullStringValue(before.StartAt), nullStringValue(after.StartAt))
+	add("end_at", nullStringValue(before.EndAt), nullStringValue(after.EndAt))
+	add("total_hours", nullFloatValue(before.TotalHours), nullFloatValue(after.TotalHours))
+	add("total_hour_out", nullFloatValue(before.TotalHourOut), nullFloatValue(after.TotalHourOut))
+	add("status", nullStringValue(before.Status), nullStringValue(after.Status))
+	add("notes", nullStringValue(before.Notes), nullStringValue(after.Notes))
+	add("calculate_over_time", before.CalculateOverTime, after.CalculateOverTime)
+	add("calculate_lunch_hour", before.CalculateLunchHour, after.CalculateLunchHour)
+
+	return changes
+}
+
+func nullStringValue(v sql.NullString) interface{} {
+	if !v.Valid {
+		return nil
+	}
+	return v.String
+}
+
+func nullFloatValue(v sql.NullFloat64) interface{} {
+	if !v.Valid {
+		return nil
+	}
+	return v.Float64
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"point-system-api/internal/models"
+	"point-system-api/internal/repositories"
+	"point-system-api/internal/types"
+
+	"gorm.io/gorm"
+)
+
+// regenerationFixture serves the records the workday service reads, closes the payroll period of
+// closedCompany, and fails on any other call.
+type regenerationFixture struct {
+	repositories.WorkDayRepository
+	repositories.RawAttendanceRepository
+	PayrollPeriodService
+	LeaveService
+	workday       *models.WorkDay
+	attendances   []types.EmployeeAttendance
+	closedCompany uint
+	rows          []*models.RawAttendance
+	created       []*models.RawAttendance
+	deleted       bool
+}
+
+func (f *regenerationFixture) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (f *regenerationFixture) GetWorkDayByID(ctx context.Context, id uint) (*models.WorkDay, error) {
+	if f.workday.ID != id {
+		return nil, nil
+	}
+	return f.workday, nil
+}
+
+func (f *regenerationFixture) GetEmployeesWithAttendance(ctx context.Context, date time.Time) ([]types.EmployeeAttendance, error) {
+	return f.attendances, nil
+}
+
+func (f *regenerationFixture) CreateWorkDay(ctx context.Context, workday *models.WorkDay) error {
+	workday.ID = 2
+	return nil
+}
+
+func (f *regenerationFixture) DeleteWorkDay(ctx context.Context, id uint) error {
+	f.deleted = true
+	return nil
+}
+
+func (f *regenerationFixture) GetRawAttendancesByWorkDay(ctx context.Context, workDayID uint) ([]*models.RawAttendance, error) {
+	return f.rows, nil
+}
+
+func (f *regenerationFixture) CreateRawAttendance(ctx context.Context, rawAttendance *models.RawAttendance) error {
+	f.created = append(f.created, rawAttendance)
+	return nil
+}
+
+func (f *regenerationFixture) EnsureDateOpen(ctx context.Context, companyID uint, date time.Time) error {
+	// Without a company, any closed period counts.
+	if companyID == 0 || companyID == f.closedCompany {
+		return fmt.Errorf("%w: period of company %d", ErrPeriodClosed, f.closedCompany)
+	}
+	return nil
+}
+
+func (f *regenerationFixture) ApplyApprovedLeave(ctx context.Context, workday *models.WorkDay, inScope func(companyID, employeeID uint) bool) ([]*models.RawAttendance, error) {
+	return nil, nil
+}
+
+func TestRegenerateWorkDaySkipsCompaniesWithAClosedPeriod(t *testing.T) {
+	checkin := time.Date(2024, 3, 4, 8, 0, 0, 0, time.Local)
+	fixture := &regenerationFixture{
+		workday: &models.WorkDay{Model: gorm.Model{ID: 1}, Date: types.DateOnly(checkin), Status: models.WorkDayStatusDraft},
+		attendances: []types.EmployeeAttendance{
+			{UserID: 10, CompanyID: 1, FirstName: "Ana", LastName: "Lima", Checkin: sql.NullTime{Time: checkin, Valid: true}},
+			{UserID: 20, CompanyID: 2, FirstName: "Rui", LastName: "Sousa", Checkin: sql.NullTime{Time: checkin, Valid: true}},
+		},
+		closedCompany: 2,
+	}
+	service := NewWorkDayService(fixture, fixture, fixture, nil, fixture, fixture)
+
+	diffs, err := service.RegenerateWorkDay(context.Background(), 1, types.RegenerateWorkDayOptions{})
+	if err != nil {
+		t.Fatalf("RegenerateWorkDay: %v", err)
+	}
+	actions := map[uint]string{}
+	for _, diff := range diffs {
+		actions[diff.UserID] = diff.Action
+	}
+	if len(diffs) != 2 || actions[10] != "created" || actions[20] != "skipped" {
+		t.Errorf("got actions %v, want the row of company 1 created and the one of company 2 skipped", actions)
+	}
+	if len(fixture.created) != 1 || fixture.created[0].CompanyID != 1 {
+		t.Errorf("got %d created rows, want the row of company 1 only", len(fixture.created))
+	}
+
+	if _, err := service.RegenerateWorkDay(context.Background(), 3, types.RegenerateWorkDayOptions{}); !errors.Is(err, ErrWorkDayNotFound) {
+		t.Errorf("regenerating a missing workday: got %v, want ErrWorkDayNotFound", err)
+	}
+
+	// A workday past review is refused before any row is looked at.
+	fixture.workday.Status = models.WorkDayStatusApproved
+	if _, err := service.RegenerateWorkDay(context.Background(), 1, types.RegenerateWorkDayOptions{}); !errors.Is(err, ErrWorkDayLocked) {
+		t.Errorf("regenerating an approved workday: got %v, want ErrWorkDayLocked", err)
+	}
+}
+
+func TestClosedPeriodsOnlyLockTheirCompany(t *testing.T) {
+	checkin := time.Date(2024, 3, 4, 8, 0, 0, 0, time.Local)
+	fixture := &regenerationFixture{
+		workday: &models.WorkDay{Model: gorm.Model{ID: 1}, Date: types.DateOnly(checkin), Status: models.WorkDayStatusDraft},
+		attendances: []types.EmployeeAttendance{
+			{UserID: 10, CompanyID: 1, FirstName: "Ana", LastName: "Lima", Checkin: sql.NullTime{Time: checkin, Valid: true}},
+			{UserID: 20, CompanyID: 2, FirstName: "Rui", LastName: "Sousa", Checkin: sql.NullTime{Time: checkin, Valid: true}},
+		},
+		closedCompany: 2,
+	}
+	service := NewWorkDayService(fixture, fixture, fixture, nil, fixture, fixture)
+
+	// A new workday gets the rows of the open companies only.
+	if err := service.CreateWorkDay(context.Background(), &models.WorkDay{Date: types.DateOnly(checkin), DayType: "workday"}); err != nil {
+		t.Fatalf("CreateWorkDay: %v", err)
+	}
+	if len(fixture.created) != 1 || fixture.created[0].CompanyID != 1 {
+		t.Errorf("got %d created rows, want the row of company 1 only", len(fixture.created))
+	}
+
+	// A workday is locked by the companies of its rows.
+	fixture.rows = []*models.RawAttendance{{UserID: 10, CompanyID: 1}}
+	if err := service.DeleteWorkDay(context.Background(), 1); err != nil || !fixture.deleted {
+		t.Errorf("deleting a workday of company 1: got %v, deleted %v", err, fixture.deleted)
+	}
+	fixture.rows = append(fixture.rows, &models.RawAttendance{UserID: 20, CompanyID: 2})
+	if err := service.DeleteWorkDay(context.Background(), 1); !errors.Is(err, ErrPeriodClosed) {
+		t.Errorf("deleting a workday of company 2: got %v, want ErrPeriodClosed", err)
+	}
+}
+
+func TestMergeRegeneratedRawAttendance(t *testing.T) {
+	text := func(value string) sql.NullString { return sql.NullString{String: value, Valid: true} }
+	hours := func(value float64) sql.NullFloat64 { return sql.NullFloat64{Float64: value, Valid: true} }
+	leaveID := uint(4)
+	fresh := func() *models.RawAttendance {
+		return &models.RawAttendance{
+			CompanyID: 1, EmployeeName: text("Ana Lima"),
+			StartAt: text("08:00:00"), EndAt: text("17:00:00"), TotalHours: hours(9), Status: text("present"),
+			CalculateLunchHour: true,
+		}
+	}
+	corrected := func() *models.RawAttendance {
+		return &models.RawAttendance{
+			Model: gorm.Model{ID: 3}, CompanyID: 1, EmployeeName: text("Ana Lima"),
+			StartAt: text("07:30:00"), EndAt: text("17:00:00"), TotalHours: hours(9.5), Status: text("present"),
+			Notes: text("came in early"), CalculateLunchHour: true, ManuallyEdited: true,
+		}
+	}
+
+	for _, tc := range []struct {
+		name      string
+		existing  *models.RawAttendance
+		force     bool
+		action    string
+		changed   []string
+		startAt   string
+		preserved int
+	}{
+		{"unchanged row", fresh(), false, "unchanged", nil, "08:00:00", 3},
+		{"hand correction kept", corrected(), false, "unchanged", nil, "07:30:00", 8},
+		{"hand correction forced", corrected(), true, "updated", []string{"start_at", "total_hours", "notes"}, "08:00:00", 0},
+		{"leave keeps its status", &models.RawAttendance{Status: text("leave"), LeaveRequestID: &leaveID, CompanyID: 1, EmployeeName: text("Ana Lima"), CalculateLunchHour: true},
+			false, "updated", []string{"start_at", "end_at", "total_hours"}, "08:00:00", 4},
+	} {
+		diff := mergeRegeneratedRawAttendance(tc.existing, fresh(), tc.force)
+		fields := []string{}
+		for _, change := range diff.Changes {
+			fields = append(fields, change.Field)
+		}
+		if diff.Action != tc.action || fmt.Sprint(fields) != fmt.Sprint(append([]string{}, tc.changed...)) {
+			t.Errorf("%s: got %s %v, want %s %v", tc.name, diff.Action, fields, tc.action, tc.changed)
+		}
+		if tc.existing.StartAt.String != tc.startAt || len(diff.Preserved) != tc.preserved {
+			t.Errorf("%s: start %s with %d preserved fields, want %s with %d", tc.name, tc.existing.StartAt.String, len(diff.Preserved), tc.startAt, tc.preserved)
+		}
+		if tc.force && tc.existing.ManuallyEdited {
+			t.Errorf("%s: forcing keeps the row marked as edited", tc.name)
+		}
+	}
+}
+
+func TestDiffRawAttendance(t *testing.T) {
+	before := &models.RawAttendance{StartAt: sql.NullString{String: "08:00:00", Valid: true}, CalculateLunchHour: true}
+	after := &models.RawAttendance{StartAt: sql.NullString{String: "08:00:00", Valid: true}, TotalHours: sql.NullFloat64{Float64: 8, Valid: true}}
+
+	changes := diffRawAttendance(before, after)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2: %+v", len(changes), changes)
+	}
+	if changes[0].Field != "total_hours" || changes[0].Old != nil || changes[0].New != 8.0 {
+		t.Errorf("got %+v, want total_hours from null to 8", changes[0])
+	}
+	if changes[1].Field != "calculate_lunch_hour" || changes[1].Old != true || changes[1].New != false {
+		t.Errorf("got %+v, want calculate_lunch_hour from true to false", changes[1])
+	}
+	if changes := diffRawAttendance(after, after); len(changes) != 0 {
+		t.Errorf("identical rows: got %+v", changes)
+	}
+}
@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no object is stored under a key.
+var ErrNotFound = errors.New("stored object not found")
+
+// Storage persists uploaded files under opaque keys.
+type Storage interface {
+	// Save stores the content read from r under key, replacing any previous object.
+	Save(ctx context.Context, key string, r io.Reader) error
+	// Open returns a reader over the object stored under key. The caller must close it.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
@@ -10,7 +10,7 @@ type EmployeeAttendance struct {
 	CompanyID      uint
 	FirstName      string
 	LastName       string
-	RegisterNumber uint
+	RegisterNumber string
 	Qualification  string
 	Date           time.Time
 	Checkin        sql.NullTime
@@ -0,0 +1,65 @@
+package types
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// AuditActor is who performs the changes of a request, recorded by the audit trail. Changes made
+// without an actor, such as by scheduled jobs, are recorded as made by the system.
+type AuditActor struct {
+	UserID           *uint
+	ServiceAccountID *uint
+	IPAddress        string
+	UserAgent        string
+}
+
+// auditActorKey is the context key of the audit actor.
+type auditActorKey struct{}
+
+// WithAuditActor returns a copy of ctx whose changes are recorded as made by actor.
+func WithAuditActor(ctx context.Context, actor *AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActorFrom returns the audit actor of ctx, or nil for changes made by the system.
+func AuditActorFrom(ctx context.Context) *AuditActor {
+	if ctx == nil {
+		return nil
+	}
+	actor, _ := ctx.Value(auditActorKey{}).(*AuditActor)
+	return actor
+}
+
+// AuditChanges lists the columns a change of a row set, stored as JSON. Old is nil for created
+// rows and New is nil for deleted rows.
+type AuditChanges []FieldChange
+
+// Value converts AuditChanges to a SQL-compatible value
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal([]FieldChange(c))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan assigns a value from a database driver
+func (c *AuditChanges) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, (*[]FieldChange)(c))
+	case string:
+		return json.Unmarshal([]byte(v), (*[]FieldChange)(c))
+	default:
+		return fmt.Errorf("unsupported data type: %T", value)
+	}
+}
@@ -0,0 +1,105 @@
+package types
+
+// TokenPair is returned on login and refresh. Token is a short-lived access token sent as a Bearer
+// token; RefreshToken obtains the next pair from /auth/refresh.
+type TokenPair struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // Lifetime of the access token, in seconds
+}
+
+// RefreshTokenRequest carries the refresh token to exchange or revoke.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// ClientInfo describes the client of an authentication request.
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+}
+
+// ChangePasswordRequest changes the password of the authenticated user.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ForgotPasswordRequest asks for a password reset token to be sent to a user.
+type ForgotPasswordRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// ResetPasswordRequest sets a new password with a reset token.
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// LoginResult is returned on login: the token pair of the session, or, when the user has a second
+// factor, a short-lived pre-auth token exchanged for the pair at /auth/2fa/verify. Users required
+// to use a second factor who have none must enroll one with the pre-auth token first.
+type LoginResult struct {
+	*TokenPair
+	TwoFactorRequired  bool   `json:"two_factor_required,omitempty"`
+	EnrollmentRequired bool   `json:"enrollment_required,omitempty"`
+	PreAuthToken       string `json:"pre_auth_token,omitempty"`
+}
+
+// TwoFactorStatus describes the second factor of a user.
+type TwoFactorStatus struct {
+	Enabled                bool  `json:"enabled"`
+	Required               bool  `json:"required"` // Required by the role of the user
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorEnrollment is the pending TOTP secret of a user, to add to an authenticator app.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorActivation returns the recovery codes of a newly enabled second factor, shown only once.
+// When the activation completes a login, it also carries the token pair of the session.
+type TwoFactorActivation struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	*TokenPair
+}
+
+// TwoFactorCodeRequest carries a TOTP code or, where accepted, a recovery code.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TwoFactorVerifyRequest completes a login with a TOTP code or a recovery code.
+type TwoFactorVerifyRequest struct {
+	PreAuthToken string `json:"pre_auth_token" binding:"required"`
+	Code         string `json:"code" binding:"required"`
+}
+
+// APIKeyPrefix starts every API key, telling them apart from access tokens.
+const APIKeyPrefix = "psk_"
+
+// APIKeyPrincipal is the identity of a request authenticated by the API key of a service account.
+type APIKeyPrincipal struct {
+	APIKeyID         uint
+	ServiceAccountID uint
+	Permissions      []string
+	CompanyIDs       []uint // Empty when the account reaches every company
+}
+
+// OIDCAuthorization starts a single sign-on login: the front end sends the user to
+// AuthorizationURL, and the identity provider sends them back to the redirect URL with the code and
+// state to post to /auth/oidc/callback.
+type OIDCAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	ExpiresIn        int64  `json:"expires_in"` // Seconds left to complete the login
+}
+
+// OIDCCallbackRequest completes a single sign-on login with the parameters the identity provider
+// added to the redirect URL.
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
@@ -0,0 +1,59 @@
+package types
+
+// Employee import modes.
+const (
+	// ImportModeAllOrNothing creates every row in a single transaction, or nothing if any row is invalid.
+	ImportModeAllOrNothing = "all_or_nothing"
+	// ImportModeSkipInvalid creates the valid rows and reports the others.
+	ImportModeSkipInvalid = "skip_invalid"
+)
+
+// Employee import row statuses.
+const (
+	ImportRowValid   = "valid"
+	ImportRowInvalid = "invalid"
+	ImportRowCreated = "created"
+	ImportRowSkipped = "skipped"
+	ImportRowFailed  = "failed"
+)
+
+// EmployeeImportFields lists the fields an import column can be mapped to.
+var EmployeeImportFields = []string{
+	"registration_number", "first_name", "last_name", "username", "password",
+	"qualification", "company", "start_hour", "end_hour", "hire_date",
+}
+
+// EmployeeImportOptions controls how an employee import file is read and committed.
+type EmployeeImportOptions struct {
+	// Mapping maps an import field to the header of the column holding it. Fields left out are
+	// looked up by their own name.
+	Mapping map[string]string `json:"mapping"`
+	Mode    string            `json:"mode"`    // all_or_nothing (default) or skip_invalid
+	DryRun  bool              `json:"dry_run"` // Validate and report without creating anything
+	// CompanyID is used for rows without a company column.
+	CompanyID uint `json:"company_id"`
+	// DefaultPassword is used for rows without a password column.
+	DefaultPassword string `json:"-"`
+}
+
+// EmployeeImportRow reports the outcome of one data row of an import file.
+type EmployeeImportRow struct {
+	Row                int      `json:"row"` // Line number in the file, the header being line 1
+	RegistrationNumber string   `json:"registration_number"`
+	Username           string   `json:"username"`
+	Status             string   `json:"status"`
+	Errors             []string `json:"errors,omitempty"`
+	EmployeeID         uint     `json:"employee_id,omitempty"`
+}
+
+// EmployeeImportReport is the row-by-row result of an employee import.
+type EmployeeImportReport struct {
+	DryRun      bool                `json:"dry_run"`
+	Mode        string              `json:"mode"`
+	TotalRows   int                 `json:"total_rows"`
+	ValidRows   int                 `json:"valid_rows"`
+	InvalidRows int                 `json:"invalid_rows"`
+	Created     int                 `json:"created"`
+	Committed   bool                `json:"committed"` // Whether anything was written to the database
+	Rows        []EmployeeImportRow `json:"rows"`
+}
@@ -0,0 +1,15 @@
+package types
+
+// TransferRequest moves an employee to another company and/or position from EffectiveDate onwards.
+type TransferRequest struct {
+	CompanyID     uint     `json:"company_id"`    // Defaults to the current company
+	Qualification string   `json:"qualification"` // Defaults to the current position
+	EffectiveDate DateOnly `json:"effective_date"`
+	Reason        string   `json:"reason"`
+}
+
+// TerminationRequest ends the employment of an employee after Date.
+type TerminationRequest struct {
+	Date   DateOnly `json:"date"` // Last day of employment
+	Reason string   `json:"reason" binding:"required"`
+}
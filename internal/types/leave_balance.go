@@ -0,0 +1,36 @@
+package types
+
+// Leave ledger line kinds.
+const (
+	LedgerAccrual        = "accrual"
+	LedgerSeniorityBonus = "seniority_bonus"
+	LedgerUsage          = "usage"
+	LedgerAdjustment     = "adjustment"
+	LedgerRollover       = "rollover"
+	LedgerExpiry         = "expiry"
+)
+
+// LeaveLedgerLine is one movement of an employee's leave balance with the running balance after it.
+type LeaveLedgerLine struct {
+	Date           DateOnly `json:"date"`
+	Kind           string   `json:"kind"`
+	Days           float64  `json:"days"`
+	Balance        float64  `json:"balance"`
+	Reason         string   `json:"reason,omitempty"`
+	LeaveRequestID uint     `json:"leave_request_id,omitempty"`
+	EntryID        uint     `json:"entry_id,omitempty"`
+}
+
+// LeaveBalance summarises an employee's balance of one leave type at a date.
+type LeaveBalance struct {
+	LeaveTypeID   uint    `json:"leave_type_id"`
+	LeaveTypeName string  `json:"leave_type_name"`
+	LeaveTypeCode string  `json:"leave_type_code"`
+	Accrued       float64 `json:"accrued"`
+	Used          float64 `json:"used"`
+	Adjusted      float64 `json:"adjusted"`
+	Expired       float64 `json:"expired"`
+	Pending       float64 `json:"pending"` // Days of pending requests, not yet deducted
+	Balance       float64 `json:"balance"`
+	Available     float64 `json:"available"` // Balance minus pending days
+}
@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// ManualPunchRequest enters a punch by hand for an employee.
+type ManualPunchRequest struct {
+	EmployeeID uint      `json:"employee_id" binding:"required"`
+	Timestamp  time.Time `json:"timestamp" binding:"required"`
+	Source     string    `json:"source"` // manual (default), import or mobile
+	Reason     string    `json:"reason" binding:"required"`
+}
+
+// PunchEdit changes the timestamp of a punch entered by hand, or replaces a punch by a corrected
+// one when adjusting it.
+type PunchEdit struct {
+	Timestamp time.Time `json:"timestamp" binding:"required"`
+	Reason    string    `json:"reason" binding:"required"`
+}
+
+// PunchVoid voids a punch.
+type PunchVoid struct {
+	Reason string `json:"reason" binding:"required"`
+}
@@ -0,0 +1,22 @@
+package types
+
+// OrgScope restricts a listing to the employees assigned, on the date of each record, to one of
+// the departments (any of their teams) or teams. A nil scope restricts nothing, an empty one
+// matches nothing.
+type OrgScope struct {
+	DepartmentIDs []uint
+	TeamIDs       []uint
+}
+
+// OrgAssignmentRequest moves an employee to a department, and optionally one of its teams, from
+// EffectiveFrom onwards.
+type OrgAssignmentRequest struct {
+	DepartmentID  uint     `json:"department_id" binding:"required"`
+	TeamID        *uint    `json:"team_id"`
+	EffectiveFrom DateOnly `json:"effective_from"` // Defaults to today
+}
+
+// EndOrgAssignmentRequest removes an employee from their department after Date.
+type EndOrgAssignmentRequest struct {
+	Date DateOnly `json:"date"` // Last day in the department, defaults to today
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Sources of the versions of a raw attendance: what changed the row.
+const (
+	RawAttendanceSourceGeneration    = "generation"    // Created with its workday
+	RawAttendanceSourceRegeneration  = "regeneration"  // Rebuilt from the punches
+	RawAttendanceSourceManual        = "manual"        // Corrected by a user
+	RawAttendanceSourceLeave         = "leave"         // Leave approved or cancelled
+	RawAttendanceSourceJustification = "justification" // Absence justified by a document
+	RawAttendanceSourceRevert        = "revert"        // Reverted to an earlier version
+	RawAttendanceSourceSystem        = "system"        // Anything else
+	RawAttendanceSourceBaseline      = "baseline"      // Values from before the row was first versioned
+)
+
+// rawAttendanceSourceKey is the context key of the source of raw attendance changes.
+type rawAttendanceSourceKey struct{}
+
+// WithRawAttendanceSource returns a copy of ctx whose changes to raw attendances are versioned as
+// made by source.
+func WithRawAttendanceSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, rawAttendanceSourceKey{}, source)
+}
+
+// RawAttendanceSourceFrom returns the source of the raw attendance changes of ctx, system when
+// none was set.
+func RawAttendanceSourceFrom(ctx context.Context) string {
+	if ctx != nil {
+		if source, ok := ctx.Value(rawAttendanceSourceKey{}).(string); ok {
+			return source
+		}
+	}
+	return RawAttendanceSourceSystem
+}
+
+// RowValues are the values of the columns of a row by name, stored as JSON.
+type RowValues map[string]interface{}
+
+// Value converts RowValues to a SQL-compatible value
+func (v RowValues) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(map[string]interface{}(v))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan assigns a value from a database driver
+func (v *RowValues) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(data, (*map[string]interface{})(v))
+	case string:
+		return json.Unmarshal([]byte(data), (*map[string]interface{})(v))
+	default:
+		return fmt.Errorf("unsupported data type: %T", value)
+	}
+}
+
+// RawAttendanceVersionDiff lists the columns that differ between two versions of a raw attendance.
+type RawAttendanceVersionDiff struct {
+	RawAttendanceID uint          `json:"raw_attendance_id"`
+	From            uint          `json:"from"`
+	To              uint          `json:"to"`
+	Changes         []FieldChange `json:"changes"`
+}
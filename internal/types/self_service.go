@@ -0,0 +1,69 @@
+package types
+
+import "time"
+
+// SelfProfile is the account and employee record of the authenticated employee.
+type SelfProfile struct {
+	EmployeeWithUser
+	Email       string `json:"email"`
+	CompanyName string `json:"company_name"`
+}
+
+// DailyAttendance is the raw attendance of an employee for one workday.
+type DailyAttendance struct {
+	ID             uint     `json:"id"`
+	WorkDayID      uint     `json:"work_day_id"`
+	Date           DateOnly `json:"date"`
+	DayType        string   `json:"day_type"`
+	StartAt        *string  `json:"start_at"`
+	EndAt          *string  `json:"end_at"`
+	TotalHours     *float64 `json:"total_hours"`
+	TotalHourOut   *float64 `json:"total_hour_out"`
+	Status         *string  `json:"status"`
+	Justification  string   `json:"justification"`
+	Notes          *string  `json:"notes"`
+	LeaveRequestID *uint    `json:"leave_request_id"`
+}
+
+// AttendanceTotals sums the daily attendances of a date range.
+type AttendanceTotals struct {
+	Days         int            `json:"days"`
+	ByStatus     map[string]int `json:"by_status"` // Number of days of each status: present, absent, leave, ...
+	TotalHours   float64        `json:"total_hours"`
+	TotalHourOut float64        `json:"total_hour_out"`
+}
+
+// SelfAttendance is the daily attendance of the authenticated employee over a date range.
+type SelfAttendance struct {
+	From   DateOnly          `json:"from"`
+	To     DateOnly          `json:"to"`
+	Days   []DailyAttendance `json:"days"`
+	Totals AttendanceTotals  `json:"totals"`
+}
+
+// ScheduleDay is what is planned for an employee on a date: whether it is worked, their hours and
+// the leave covering it.
+type ScheduleDay struct {
+	Date      DateOnly `json:"date"`
+	DayType   string   `json:"day_type"` // workday, free or holiday; weekends are free unless a workday says otherwise
+	StartHour string   `json:"start_hour,omitempty"`
+	EndHour   string   `json:"end_hour,omitempty"`
+	// Leave is the pending or approved leave request covering the date, if any.
+	Leave *ScheduledLeave `json:"leave,omitempty"`
+}
+
+// ScheduledLeave is a leave request covering a scheduled day.
+type ScheduledLeave struct {
+	LeaveRequestID uint   `json:"leave_request_id"`
+	LeaveTypeID    uint   `json:"leave_type_id"`
+	Status         string `json:"status"`
+	HalfDay        string `json:"half_day,omitempty"`
+}
+
+// PunchCorrectionSubmission asks for a punch to be added, moved to another time or voided.
+type PunchCorrectionSubmission struct {
+	Kind            string     `json:"kind" binding:"required"` // add, adjust or void
+	AttendanceLogID *uint      `json:"attendance_log_id"`       // Punch to adjust or void
+	Timestamp       *time.Time `json:"timestamp"`               // Time of the punch to add, or the corrected time
+	Reason          string     `json:"reason" binding:"required"`
+}
@@ -0,0 +1,49 @@
+package types
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrOutsideTenant is returned when a request reaches data of a company outside its tenant scope.
+// It reads "not found" so that the existence of the data is not disclosed.
+var ErrOutsideTenant = errors.New("not found")
+
+// TenantScope restricts the data reachable by a user to the companies they are linked to.
+type TenantScope struct {
+	UserID     uint
+	CompanyIDs []uint
+}
+
+// Allows reports whether the company is part of the scope.
+func (s *TenantScope) Allows(companyID uint) bool {
+	for _, id := range s.CompanyIDs {
+		if id == companyID {
+			return true
+		}
+	}
+	return false
+}
+
+// tenantScopeKey is the context key of the tenant scope.
+type tenantScopeKey struct{}
+
+// WithTenantScope returns a copy of ctx restricted to scope.
+func WithTenantScope(ctx context.Context, scope *TenantScope) context.Context {
+	return context.WithValue(ctx, tenantScopeKey{}, scope)
+}
+
+// WithoutTenantScope returns a copy of ctx lifting any tenant restriction, for the integrity
+// checks that must see every company.
+func WithoutTenantScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, tenantScopeKey{}, (*TenantScope)(nil))
+}
+
+// TenantScopeFrom returns the tenant scope of ctx, or nil when ctx is not restricted.
+func TenantScopeFrom(ctx context.Context) *TenantScope {
+	if ctx == nil {
+		return nil
+	}
+	scope, _ := ctx.Value(tenantScopeKey{}).(*TenantScope)
+	return scope
+}
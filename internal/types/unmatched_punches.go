@@ -0,0 +1,33 @@
+package types
+
+import "time"
+
+// UnmatchedPunchGroup summarizes the punches of a device user ID on a device that are not
+// linked to any employee.
+type UnmatchedPunchGroup struct {
+	SerialNumber string    `json:"serial_number"`
+	DeviceID     *uint     `json:"device_id"` // Nil when the device is not registered
+	DeviceName   string    `json:"device_name"`
+	CompanyID    uint      `json:"company_id"` // Company of the device, 0 when unassigned
+	DeviceUserID string    `json:"device_user_id"`
+	PunchCount   int64     `json:"punch_count"`
+	FirstPunch   time.Time `json:"first_punch"`
+	LastPunch    time.Time `json:"last_punch"`
+}
+
+// WorkDayRefresh reports the regeneration of one workday after punches were reconciled.
+type WorkDayRefresh struct {
+	WorkDayID uint                `json:"work_day_id"`
+	Date      DateOnly            `json:"date"`
+	Diffs     []RawAttendanceDiff `json:"diffs"`
+	Skipped   string              `json:"skipped,omitempty"` // Why the workday could not be regenerated
+}
+
+// PunchReconciliation reports what linking a group of unmatched punches to an employee did.
+type PunchReconciliation struct {
+	EmployeeID          uint             `json:"employee_id"`
+	DeviceIdentityID    uint             `json:"device_identity_id,omitempty"` // Mapping created for the group, if any
+	LinkedPunches       int64            `json:"linked_punches"`
+	ReclassifiedPunches int              `json:"reclassified_punches"`
+	WorkDays            []WorkDayRefresh `json:"work_days"`
+}
@@ -0,0 +1,27 @@
+package types
+
+// RegenerateWorkDayOptions limits and controls the regeneration of a workday's raw attendances.
+type RegenerateWorkDayOptions struct {
+	CompanyID   uint   `json:"company_id"`   // Only regenerate rows of this company (0 = all companies)
+	EmployeeIDs []uint `json:"employee_ids"` // Only regenerate rows of these employees (empty = all employees)
+	Force       bool   `json:"force"`        // Overwrite manual edits with the values computed from punches
+}
+
+// FieldChange describes the old and new value of a single raw attendance field.
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// RawAttendanceDiff reports what a regeneration did to one raw attendance row.
+type RawAttendanceDiff struct {
+	RawAttendanceID uint          `json:"raw_attendance_id"`
+	UserID          uint          `json:"user_id"`
+	CompanyID       uint          `json:"company_id"`
+	EmployeeName    string        `json:"employee_name"`
+	Action          string        `json:"action"`    // created, updated, unchanged, skipped
+	Changes         []FieldChange `json:"changes"`   // Fields whose value changed
+	Preserved       []string      `json:"preserved"` // Fields kept because they were edited manually
+	Reason          string        `json:"reason,omitempty"`
+}
@@ -1,41 +1,134 @@
 package utils
 
 import (
+	"crypto/rand"
 	"errors"
+	"fmt"
+	"log"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Purposes of the short-lived tokens issued between the password and the second factor of a login.
+// They are refused wherever an access token is expected.
+const (
+	TokenPurposeTwoFactor           = "2fa"        // The user enters a TOTP or recovery code
+	TokenPurposeTwoFactorEnrollment = "2fa-enroll" // The user must enroll a second factor first
+)
+
+// DefaultAccessTokenTTL is the lifetime of access tokens when none is configured. Sessions last
+// longer through refresh tokens.
+const DefaultAccessTokenTTL = 15 * time.Minute
+
 // JWTClaims represents the claims in a JWT token.
 type JWTClaims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	UserID     uint   `json:"user_id"`
+	Role       string `json:"role"`
+	CompanyIDs []uint `json:"company_ids,omitempty"` // Companies the user may reach
+	Purpose    string `json:"purpose,omitempty"`     // Set on the tokens of a login step, empty on access tokens
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a new JWT token for a user.
-func GenerateToken(userID uint, role string) (string, error) {
-	// Define the expiration time for the token
-	expirationTime := time.Now().Add(24 * time.Hour) // Token expires in 24 hours
-
-	// Create the JWT claims
-	claims := &JWTClaims{
-		UserID: userID,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			Issuer:    "point-system-api",
-		},
+// JWTKey is a key signing or verifying tokens, named by the kid header of the tokens it signs.
+type JWTKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	Sign   interface{} // HMAC secret or private key, nil for keys only verifying tokens
+	Verify interface{} // HMAC secret or public key
+}
+
+// jwtKeySet holds the key signing new tokens and every key accepted when validating them.
+type jwtKeySet struct {
+	active    *JWTKey
+	keys      map[string]*JWTKey
+	accessTTL time.Duration
+}
+
+var (
+	jwtKeysMu sync.RWMutex
+	jwtKeys   *jwtKeySet
+)
+
+// ConfigureJWT sets the key signing new tokens and the retired keys still accepted for the tokens
+// they signed, so that keys can be rotated without logging everyone out.
+func ConfigureJWT(active *JWTKey, retired []*JWTKey, accessTTL time.Duration) error {
+	if active == nil || active.Sign == nil {
+		return errors.New("the active JWT key must be able to sign")
+	}
+	if accessTTL <= 0 {
+		accessTTL = DefaultAccessTokenTTL
+	}
+	set := &jwtKeySet{active: active, keys: map[string]*JWTKey{active.ID: active}, accessTTL: accessTTL}
+	for _, key := range retired {
+		if _, ok := set.keys[key.ID]; ok {
+			return fmt.Errorf("duplicate JWT key ID %q", key.ID)
+		}
+		set.keys[key.ID] = key
 	}
 
-	// Create the token with the claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	jwtKeysMu.Lock()
+	jwtKeys = set
+	jwtKeysMu.Unlock()
+	return nil
+}
+
+// currentJWTKeys returns the configured keys. Without configuration a random HS256 key is
+// generated, so tokens do not survive a restart.
+func currentJWTKeys() *jwtKeySet {
+	jwtKeysMu.RLock()
+	set := jwtKeys
+	jwtKeysMu.RUnlock()
+	if set != nil {
+		return set
+	}
 
-	// Sign the token with a secret key
-	secretKey := []byte("point-system-2025$$") // Replace with a secure secret key
-	tokenString, err := token.SignedString(secretKey)
+	jwtKeysMu.Lock()
+	defer jwtKeysMu.Unlock()
+	if jwtKeys == nil {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(fmt.Sprintf("failed to generate JWT secret: %v", err))
+		}
+		log.Println("No JWT key configured, tokens are signed with a random key lost on restart")
+		key := &JWTKey{ID: "ephemeral", Method: jwt.SigningMethodHS256, Sign: secret, Verify: secret}
+		jwtKeys = &jwtKeySet{active: key, keys: map[string]*JWTKey{key.ID: key}, accessTTL: DefaultAccessTokenTTL}
+	}
+	return jwtKeys
+}
+
+// AccessTokenTTL returns the lifetime of the access tokens.
+func AccessTokenTTL() time.Duration {
+	return currentJWTKeys().accessTTL
+}
+
+// GenerateToken generates a new access token for a user linked to companyIDs, signed with the
+// active key.
+func GenerateToken(userID uint, role string, companyIDs []uint) (string, error) {
+	return signToken(&JWTClaims{UserID: userID, Role: role, CompanyIDs: companyIDs}, currentJWTKeys().accessTTL)
+}
+
+// GeneratePurposeToken generates a token of user for a single login step, valid for ttl.
+func GeneratePurposeToken(userID uint, role, purpose string, ttl time.Duration) (string, error) {
+	return signToken(&JWTClaims{UserID: userID, Role: role, Purpose: purpose}, ttl)
+}
+
+// signToken signs claims with the active key, valid for ttl from now.
+func signToken(claims *JWTClaims, ttl time.Duration) (string, error) {
+	keys := currentJWTKeys()
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		Issuer:    "point-system-api",
+	}
+
+	// Create the token with the claims, naming its key
+	token := jwt.NewWithClaims(keys.active.Method, claims)
+	token.Header["kid"] = keys.active.ID
+
+	tokenString, err := token.SignedString(keys.active.Sign)
 	if err != nil {
 		return "", err
 	}
@@ -43,18 +136,25 @@ func GenerateToken(userID uint, role string) (string, error) {
 	return tokenString, nil
 }
 
-// ValidateToken validates a JWT token and returns its claims.
+// ValidateToken validates a JWT token against the key named by its kid header and returns its claims.
 func ValidateToken(tokenString string) (*JWTClaims, error) {
+	keys := currentJWTKeys()
+
 	// Parse the token
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys.keys[kid]
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+
 		// Validate the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, errors.New("invalid signing method")
 		}
 
-		// Return the secret key
-		return []byte("point-system-2025$$"), nil // Replace with the same secret key used in GenerateToken
-	})
+		return key.Verify, nil
+	}, jwt.WithIssuer("point-system-api"))
 	if err != nil {
 		return nil, err
 	}
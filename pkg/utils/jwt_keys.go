@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// LoadJWTKey builds a key for algorithm (HS256, RS256 or EdDSA). For HS256, material is the
+// secret itself; otherwise it is the path of a PEM file holding either a private key, which signs
+// and verifies, or a public key, which only verifies.
+func LoadJWTKey(id, algorithm, material string) (*JWTKey, error) {
+	if material == "" {
+		return nil, fmt.Errorf("JWT key %q has no key material", id)
+	}
+
+	switch strings.ToUpper(algorithm) {
+	case "", "HS256":
+		return &JWTKey{ID: id, Method: jwt.SigningMethodHS256, Sign: []byte(material), Verify: []byte(material)}, nil
+	case "RS256":
+		data, err := os.ReadFile(material)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT key %q: %w", id, err)
+		}
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			return &JWTKey{ID: id, Method: jwt.SigningMethodRS256, Sign: private, Verify: &private.PublicKey}, nil
+		}
+		public, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("JWT key %q is not an RSA key: %w", id, err)
+		}
+		return &JWTKey{ID: id, Method: jwt.SigningMethodRS256, Verify: public}, nil
+	case "EDDSA":
+		data, err := os.ReadFile(material)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT key %q: %w", id, err)
+		}
+		if private, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+			return &JWTKey{ID: id, Method: jwt.SigningMethodEdDSA, Sign: private, Verify: private.(ed25519.PrivateKey).Public()}, nil
+		}
+		public, err := jwt.ParseEdPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("JWT key %q is not an Ed25519 key: %w", id, err)
+		}
+		return &JWTKey{ID: id, Method: jwt.SigningMethodEdDSA, Verify: public}, nil
+	}
+	return nil, fmt.Errorf("unsupported JWT algorithm %q", algorithm)
+}
+
+// ConfigureJWTFromEnv configures the JWT keys from the environment:
+//   - JWT_KEY_ID, JWT_ALGORITHM (HS256, RS256 or EdDSA) and JWT_SECRET (HS256) or
+//     JWT_PRIVATE_KEY_FILE (RS256, EdDSA) set the active key;
+//   - JWT_RETIRED_KEYS lists the keys still accepted after a rotation, as comma separated
+//     "kid:algorithm:secret-or-pem-file" entries;
+//   - JWT_ACCESS_TTL sets the lifetime of access tokens (e.g. "15m").
+//
+// Without JWT_SECRET nor JWT_PRIVATE_KEY_FILE it leaves the random fallback key in place.
+func ConfigureJWTFromEnv() error {
+	algorithm := os.Getenv("JWT_ALGORITHM")
+	material := os.Getenv("JWT_SECRET")
+	if !strings.EqualFold(algorithm, "HS256") && algorithm != "" {
+		material = os.Getenv("JWT_PRIVATE_KEY_FILE")
+	}
+	if material == "" {
+		return nil
+	}
+
+	active, err := LoadJWTKey(os.Getenv("JWT_KEY_ID"), algorithm, material)
+	if err != nil {
+		return err
+	}
+	if active.Sign == nil {
+		return fmt.Errorf("JWT key %q must be a private key", active.ID)
+	}
+
+	var retired []*JWTKey
+	for _, entry := range strings.Split(os.Getenv("JWT_RETIRED_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return fmt.Errorf("invalid retired JWT key %q, want kid:algorithm:key", entry)
+		}
+		key, err := LoadJWTKey(parts[0], parts[1], parts[2])
+		if err != nil {
+			return err
+		}
+		key.Sign = nil // Retired keys never sign
+		retired = append(retired, key)
+	}
+
+	var accessTTL time.Duration
+	if value := os.Getenv("JWT_ACCESS_TTL"); value != "" {
+		if accessTTL, err = time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid JWT_ACCESS_TTL: %w", err)
+		}
+	}
+
+	return ConfigureJWT(active, retired, accessTTL)
+}
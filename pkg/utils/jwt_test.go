@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writeEdKey(t *testing.T, dir, name string) string {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	oldKey, err := LoadJWTKey("2025-01", "EdDSA", writeEdKey(t, dir, "old.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := LoadJWTKey("2025-06", "EdDSA", writeEdKey(t, dir, "new.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ConfigureJWT(oldKey, nil, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	issuedBefore, err := GenerateToken(7, "manager", []uint{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// After the rotation, tokens of the retired key stay valid while new ones use the new key.
+	retired := *oldKey
+	retired.Sign = nil
+	if err := ConfigureJWT(newKey, []*JWTKey{&retired}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateToken(issuedBefore)
+	if err != nil {
+		t.Fatalf("token of the retired key: %v", err)
+	}
+	if claims.UserID != 7 || claims.Role != "manager" || len(claims.CompanyIDs) != 2 {
+		t.Errorf("claims: got %+v", claims)
+	}
+	issuedAfter, err := GenerateToken(7, "manager", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(issuedAfter); err != nil {
+		t.Errorf("token of the new key: %v", err)
+	}
+
+	// Once the retired key is dropped, its tokens are refused.
+	if err := ConfigureJWT(newKey, nil, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(issuedBefore); err == nil {
+		t.Error("token of a dropped key accepted")
+	}
+
+	// A token signed with an HMAC key named like an asymmetric one is refused.
+	forged := &JWTKey{ID: newKey.ID, Method: jwt.SigningMethodHS256, Sign: []byte("secret"), Verify: []byte("secret")}
+	if err := ConfigureJWT(forged, nil, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	token, err := GenerateToken(1, "super-admin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigureJWT(newKey, nil, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateToken(token); err == nil {
+		t.Error("token signed with the wrong algorithm accepted")
+	}
+}
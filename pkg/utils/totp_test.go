@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 test vectors for SHA-1, truncated to 6 digits.
+	secret := totpEncoding.EncodeToString([]byte("12345678901234567890"))
+	for _, tc := range []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{20000000000, "353130"},
+	} {
+		code, err := TOTPCode(secret, time.Unix(tc.unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tc.code {
+			t.Errorf("at %d: got %s, want %s", tc.unix, code, tc.code)
+		}
+	}
+
+	// The previous step is accepted for clock drift, older ones are not.
+	now := time.Unix(1111111109, 0)
+	if step, ok := ValidateTOTP(secret, "081804", now.Add(30*time.Second)); !ok || step != 1111111109/30 {
+		t.Errorf("previous step: got %d, %v", step, ok)
+	}
+	if _, ok := ValidateTOTP(secret, "081804", now.Add(90*time.Second)); ok {
+		t.Error("code two steps old accepted")
+	}
+
+	uri := TOTPProvisioningURI("Point System", "jdoe", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/Point%20System:jdoe?") || !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("provisioning URI: got %s", uri)
+	}
+}